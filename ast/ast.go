@@ -40,3 +40,13 @@ type Expression interface {
 	// ExpressionNode signals that this Node is an expression.
 	ExpressionNode()
 }
+
+// Pattern describes the shape of a value in a case of a match expression.
+// Patterns are not expressions and may only appear in a match case.
+type Pattern interface {
+	// Node is embedded here to indicate that all patterns are AST nodes.
+	Node
+
+	// PatternNode signals that this Node is a pattern.
+	PatternNode()
+}
@@ -234,6 +234,60 @@ func (c *Call) String() string {
 	return out.String()
 }
 
+// Spread is an expression node that unpacks a value into the arguments of a
+// call. A list spread ("...items") supplies positional arguments, while a map
+// spread ("**opts") supplies keyword arguments.
+type Spread struct {
+	token token.Token // the "..." or "**" token
+	value Expression  // the value being unpacked
+}
+
+// NewSpread creates a new Spread node.
+func NewSpread(token token.Token, value Expression) *Spread {
+	return &Spread{token: token, value: value}
+}
+
+func (s *Spread) ExpressionNode() {}
+
+func (s *Spread) IsExpression() bool { return true }
+
+func (s *Spread) Token() token.Token { return s.token }
+
+func (s *Spread) Literal() string { return s.token.Literal }
+
+func (s *Spread) Value() Expression { return s.value }
+
+// IsMap returns true if the spread unpacks a map of keyword arguments.
+func (s *Spread) IsMap() bool { return s.token.Type == token.POW }
+
+func (s *Spread) String() string { return s.token.Literal + s.value.String() }
+
+// KeywordArg is an expression node that passes a call argument by the name
+// of the parameter it is bound to, as in "fetch(url, timeout=5)".
+type KeywordArg struct {
+	name  *Ident     // the parameter name
+	value Expression // the argument value
+}
+
+// NewKeywordArg creates a new KeywordArg node.
+func NewKeywordArg(name *Ident, value Expression) *KeywordArg {
+	return &KeywordArg{name: name, value: value}
+}
+
+func (k *KeywordArg) ExpressionNode() {}
+
+func (k *KeywordArg) IsExpression() bool { return true }
+
+func (k *KeywordArg) Token() token.Token { return k.name.Token() }
+
+func (k *KeywordArg) Literal() string { return k.name.Literal() }
+
+func (k *KeywordArg) Name() *Ident { return k.name }
+
+func (k *KeywordArg) Value() Expression { return k.value }
+
+func (k *KeywordArg) String() string { return k.name.Literal() + "=" + k.value.String() }
+
 // GetAttr is an expression node that describes the access of an attribute on
 // an object.
 type GetAttr struct {
@@ -244,6 +298,10 @@ type GetAttr struct {
 
 	// The attribute itself
 	attribute *Ident
+
+	// optional is true for "?." accesses, which evaluate to nil when the
+	// object is nil
+	optional bool
 }
 
 // NewGetAttr creates a new GetAttr node.
@@ -251,6 +309,11 @@ func NewGetAttr(token token.Token, object Expression, attribute *Ident) *GetAttr
 	return &GetAttr{token: token, object: object, attribute: attribute}
 }
 
+// NewOptionalGetAttr creates a new GetAttr node for an "object?.name" access.
+func NewOptionalGetAttr(token token.Token, object Expression, attribute *Ident) *GetAttr {
+	return &GetAttr{token: token, object: object, attribute: attribute, optional: true}
+}
+
 func (e *GetAttr) ExpressionNode() {}
 
 func (e *GetAttr) IsExpression() bool { return true }
@@ -263,14 +326,47 @@ func (e *GetAttr) Object() Expression { return e.object }
 
 func (e *GetAttr) Name() string { return e.attribute.value }
 
+func (e *GetAttr) IsOptional() bool { return e.optional }
+
 func (e *GetAttr) String() string {
 	var out bytes.Buffer
 	out.WriteString(e.object.String())
-	out.WriteString(".")
+	if e.optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
 	out.WriteString(e.attribute.value)
 	return out.String()
 }
 
+// OptionalChain is an expression node that wraps a chain of attribute
+// accesses, index operations and calls containing at least one "?." access,
+// as in "resp?.data.items[0]". When the object of a "?." access is nil, the
+// rest of the chain is skipped and the whole chain evaluates to nil.
+type OptionalChain struct {
+	token token.Token // the first "?." token
+	expr  Expression
+}
+
+// NewOptionalChain creates a new OptionalChain node.
+func NewOptionalChain(token token.Token, expr Expression) *OptionalChain {
+	return &OptionalChain{token: token, expr: expr}
+}
+
+func (c *OptionalChain) ExpressionNode() {}
+
+func (c *OptionalChain) IsExpression() bool { return true }
+
+func (c *OptionalChain) Token() token.Token { return c.token }
+
+func (c *OptionalChain) Literal() string { return c.token.Literal }
+
+// Expression returns the outermost expression of the chain.
+func (c *OptionalChain) Expression() Expression { return c.expr }
+
+func (c *OptionalChain) String() string { return c.expr.String() }
+
 // Pipe is an expression node that describes a sequence of transformations
 // applied to an initial value.
 type Pipe struct {
@@ -310,9 +406,10 @@ func (p *Pipe) String() string {
 // ObjectCall is an expression node that describes the invocation of a method
 // on an object.
 type ObjectCall struct {
-	token  token.Token
-	object Expression
-	call   Expression
+	token    token.Token
+	object   Expression
+	call     Expression
+	optional bool
 }
 
 // NewObjectCall creates a new ObjectCall node.
@@ -320,6 +417,12 @@ func NewObjectCall(token token.Token, object Expression, call Expression) *Objec
 	return &ObjectCall{token: token, object: object, call: call}
 }
 
+// NewOptionalObjectCall creates a new ObjectCall node for an
+// "object?.method()" call, which evaluates to nil when the object is nil.
+func NewOptionalObjectCall(token token.Token, object Expression, call Expression) *ObjectCall {
+	return &ObjectCall{token: token, object: object, call: call, optional: true}
+}
+
 func (c *ObjectCall) ExpressionNode() {}
 
 func (c *ObjectCall) IsExpression() bool { return true }
@@ -332,10 +435,16 @@ func (c *ObjectCall) Object() Expression { return c.object }
 
 func (c *ObjectCall) Call() Expression { return c.call }
 
+func (c *ObjectCall) IsOptional() bool { return c.optional }
+
 func (c *ObjectCall) String() string {
 	var out bytes.Buffer
 	out.WriteString(c.object.String())
-	out.WriteString(".")
+	if c.optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
 	out.WriteString(c.call.String())
 	return out.String()
 }
@@ -349,6 +458,10 @@ type Index struct {
 
 	// index is the value used to index the container.
 	index Expression
+
+	// optional is true for "?.[]" indexing, which evaluates to nil when the
+	// container is nil or is a map that is missing the key
+	optional bool
 }
 
 // NewIndex creates a new Index node.
@@ -356,6 +469,11 @@ func NewIndex(token token.Token, left Expression, index Expression) *Index {
 	return &Index{token: token, left: left, index: index}
 }
 
+// NewOptionalIndex creates a new Index node for "left?.[index]".
+func NewOptionalIndex(token token.Token, left Expression, index Expression) *Index {
+	return &Index{token: token, left: left, index: index, optional: true}
+}
+
 func (i *Index) ExpressionNode() {}
 
 func (i *Index) IsExpression() bool { return true }
@@ -368,10 +486,15 @@ func (i *Index) Left() Expression { return i.left }
 
 func (i *Index) Index() Expression { return i.index }
 
+func (i *Index) IsOptional() bool { return i.optional }
+
 func (i *Index) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(i.left.String())
+	if i.optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(i.index.String())
 	out.WriteString("])")
@@ -390,6 +513,10 @@ type Slice struct {
 
 	// Optional "to" index for [from:to] style expressions
 	toIndex Expression
+
+	// optional is true for "?.[from:to]" slicing, which evaluates to nil
+	// when the container is nil
+	optional bool
 }
 
 // NewSlice creates a new Slice node.
@@ -397,6 +524,11 @@ func NewSlice(token token.Token, left Expression, fromIndex Expression, toIndex
 	return &Slice{token: token, left: left, fromIndex: fromIndex, toIndex: toIndex}
 }
 
+// NewOptionalSlice creates a new Slice node for "left?.[from:to]".
+func NewOptionalSlice(token token.Token, left Expression, fromIndex Expression, toIndex Expression) *Slice {
+	return &Slice{token: token, left: left, fromIndex: fromIndex, toIndex: toIndex, optional: true}
+}
+
 func (s *Slice) ExpressionNode() {}
 
 func (s *Slice) IsExpression() bool { return true }
@@ -411,10 +543,15 @@ func (s *Slice) FromIndex() Expression { return s.fromIndex }
 
 func (s *Slice) ToIndex() Expression { return s.toIndex }
 
+func (s *Slice) IsOptional() bool { return s.optional }
+
 func (s *Slice) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(s.left.String())
+	if s.optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	if s.fromIndex != nil {
 		out.WriteString(s.fromIndex.String())
@@ -533,6 +670,231 @@ func (s *Switch) String() string {
 	return out.String()
 }
 
+// MatchCase is one case within a match expression. The case matches when
+// any one of its patterns matches and its guard, if any, is truthy.
+type MatchCase struct {
+	token token.Token
+
+	// Default branch?
+	isDefault bool
+
+	// Alternative patterns to match against
+	patterns []Pattern
+
+	// Optional condition that must hold for the case to match
+	guard Expression
+
+	// The code to execute if there is a match
+	block *Block
+}
+
+// NewMatchCase creates a new MatchCase node.
+func NewMatchCase(token token.Token, patterns []Pattern, guard Expression, block *Block) *MatchCase {
+	return &MatchCase{token: token, patterns: patterns, guard: guard, block: block}
+}
+
+// NewDefaultMatchCase represents the default case within a match expression.
+func NewDefaultMatchCase(token token.Token, block *Block) *MatchCase {
+	return &MatchCase{token: token, isDefault: true, block: block}
+}
+
+func (c *MatchCase) ExpressionNode() {}
+
+func (c *MatchCase) IsExpression() bool { return true }
+
+func (c *MatchCase) Token() token.Token { return c.token }
+
+func (c *MatchCase) Literal() string { return c.token.Literal }
+
+func (c *MatchCase) IsDefault() bool { return c.isDefault }
+
+func (c *MatchCase) Patterns() []Pattern { return c.patterns }
+
+func (c *MatchCase) Guard() Expression { return c.guard }
+
+func (c *MatchCase) Block() *Block { return c.block }
+
+func (c *MatchCase) String() string {
+	var out bytes.Buffer
+	if c.isDefault {
+		out.WriteString("default")
+	} else {
+		out.WriteString("case ")
+		tmp := []string{}
+		for _, pattern := range c.patterns {
+			tmp = append(tmp, pattern.String())
+		}
+		out.WriteString(strings.Join(tmp, ", "))
+		if c.guard != nil {
+			out.WriteString(" if ")
+			out.WriteString(c.guard.String())
+		}
+	}
+	out.WriteString(":\n")
+	if c.block != nil {
+		for i, exp := range c.block.statements {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString("\t" + exp.String())
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Match is an expression node that compares a value against the patterns of
+// each of its cases in turn, and evaluates to the block of the first case
+// that matches.
+type Match struct {
+	// token containing "match"
+	token token.Token
+
+	// the expression to match against
+	value Expression
+
+	// match cases
+	cases []*MatchCase
+}
+
+// NewMatch creates a new Match node.
+func NewMatch(token token.Token, value Expression, cases []*MatchCase) *Match {
+	return &Match{token: token, value: value, cases: cases}
+}
+
+func (m *Match) ExpressionNode() {}
+
+func (m *Match) IsExpression() bool { return true }
+
+func (m *Match) Token() token.Token { return m.token }
+
+func (m *Match) Literal() string { return m.token.Literal }
+
+func (m *Match) Value() Expression { return m.value }
+
+func (m *Match) Cases() []*MatchCase { return m.cases }
+
+func (m *Match) String() string {
+	var out bytes.Buffer
+	out.WriteString("match ")
+	out.WriteString(m.value.String())
+	out.WriteString(" {\n")
+	for _, c := range m.cases {
+		out.WriteString(c.String())
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// SelectCase is one case within a select expression. A case either receives
+// from a channel, optionally binding the received value and a flag that
+// reports whether the channel was open, or sends a value on a channel.
+type SelectCase struct {
+	token token.Token
+
+	// Default branch?
+	isDefault bool
+
+	// Names bound to the received value and, optionally, the open flag
+	names []*Ident
+
+	// The *Receive or *Send operation of the case
+	comm Node
+
+	// The code to execute if this case proceeds
+	block *Block
+}
+
+// NewSelectCase creates a new SelectCase node.
+func NewSelectCase(token token.Token, names []*Ident, comm Node, block *Block) *SelectCase {
+	return &SelectCase{token: token, names: names, comm: comm, block: block}
+}
+
+// NewDefaultSelectCase represents the default case within a select expression.
+func NewDefaultSelectCase(token token.Token, block *Block) *SelectCase {
+	return &SelectCase{token: token, isDefault: true, block: block}
+}
+
+func (c *SelectCase) ExpressionNode() {}
+
+func (c *SelectCase) IsExpression() bool { return true }
+
+func (c *SelectCase) Token() token.Token { return c.token }
+
+func (c *SelectCase) Literal() string { return c.token.Literal }
+
+func (c *SelectCase) IsDefault() bool { return c.isDefault }
+
+func (c *SelectCase) Names() []*Ident { return c.names }
+
+func (c *SelectCase) Comm() Node { return c.comm }
+
+func (c *SelectCase) Block() *Block { return c.block }
+
+func (c *SelectCase) String() string {
+	var out bytes.Buffer
+	if c.isDefault {
+		out.WriteString("default")
+	} else {
+		out.WriteString("case ")
+		if len(c.names) > 0 {
+			tmp := []string{}
+			for _, name := range c.names {
+				tmp = append(tmp, name.String())
+			}
+			out.WriteString(strings.Join(tmp, ", "))
+			out.WriteString(" := ")
+		}
+		out.WriteString(c.comm.String())
+	}
+	out.WriteString(":\n")
+	if c.block != nil {
+		for i, exp := range c.block.statements {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString("\t" + exp.String())
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Select is an expression node that waits until one of its cases can send
+// or receive on a channel, and evaluates to the block of that case.
+type Select struct {
+	// token containing "select"
+	token token.Token
+
+	// select cases
+	cases []*SelectCase
+}
+
+// NewSelect creates a new Select node.
+func NewSelect(token token.Token, cases []*SelectCase) *Select {
+	return &Select{token: token, cases: cases}
+}
+
+func (s *Select) ExpressionNode() {}
+
+func (s *Select) IsExpression() bool { return true }
+
+func (s *Select) Token() token.Token { return s.token }
+
+func (s *Select) Literal() string { return s.token.Literal }
+
+func (s *Select) Cases() []*SelectCase { return s.cases }
+
+func (s *Select) String() string {
+	var out bytes.Buffer
+	out.WriteString("select {\n")
+	for _, c := range s.cases {
+		out.WriteString(c.String())
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
 // In is an expression node that checks whether a value is present in a container.
 type In struct {
 	token token.Token
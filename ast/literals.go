@@ -110,6 +110,10 @@ type Func struct {
 	// parameters is the list of parameters the function receives.
 	parameters []*Ident
 
+	// restParameter is the optional final "...name" parameter, which receives
+	// any additional arguments as a list.
+	restParameter *Ident
+
 	// defaults holds any default values for arguments which aren't specified.
 	defaults map[string]Expression
 
@@ -128,6 +132,25 @@ func NewFunc(token token.Token, name *Ident, parameters []*Ident, defaults map[s
 	}
 }
 
+// NewVariadicFunc creates a new Func node that has a rest parameter.
+func NewVariadicFunc(
+	token token.Token,
+	name *Ident,
+	parameters []*Ident,
+	restParameter *Ident,
+	defaults map[string]Expression,
+	body *Block,
+) *Func {
+	return &Func{
+		token:         token,
+		name:          name,
+		parameters:    parameters,
+		restParameter: restParameter,
+		defaults:      defaults,
+		body:          body,
+	}
+}
+
 func (f *Func) ExpressionNode() {}
 
 func (f *Func) IsExpression() bool { return f.name == nil }
@@ -148,6 +171,10 @@ func (f *Func) ParameterNames() []string {
 	return names
 }
 
+// RestParameter returns the "...name" parameter of the function, or nil if
+// the function does not have one.
+func (f *Func) RestParameter() *Ident { return f.restParameter }
+
 func (f *Func) Defaults() map[string]Expression { return f.defaults }
 
 func (f *Func) Body() *Block { return f.body }
@@ -158,6 +185,9 @@ func (f *Func) String() string {
 	for _, p := range f.parameters {
 		params = append(params, p.value)
 	}
+	if f.restParameter != nil {
+		params = append(params, "..."+f.restParameter.value)
+	}
 	out.WriteString(f.Literal())
 	if f.name != nil {
 		out.WriteString(" " + f.name.value)
@@ -311,3 +341,155 @@ func (s *Set) String() string {
 	out.WriteString("}")
 	return out.String()
 }
+
+// ForClause is a "for" clause of a comprehension, such as "for k, v in m if
+// v > 0". Each clause may be followed by any number of "if" conditions, which
+// filter the items of the clause.
+type ForClause struct {
+	token token.Token // the "for" token
+
+	// the names the items of the iterable are bound to
+	names []*Ident
+
+	// the value being iterated over
+	iterable Expression
+
+	// the conditions an item must satisfy
+	conditions []Expression
+}
+
+// NewForClause creates a new ForClause node.
+func NewForClause(token token.Token, names []*Ident, iterable Expression, conditions []Expression) *ForClause {
+	return &ForClause{token: token, names: names, iterable: iterable, conditions: conditions}
+}
+
+func (f *ForClause) IsExpression() bool { return false }
+
+func (f *ForClause) Token() token.Token { return f.token }
+
+func (f *ForClause) Literal() string { return f.token.Literal }
+
+func (f *ForClause) Names() []*Ident { return f.names }
+
+func (f *ForClause) Iterable() Expression { return f.iterable }
+
+func (f *ForClause) Conditions() []Expression { return f.conditions }
+
+func (f *ForClause) String() string {
+	var out bytes.Buffer
+	names := make([]string, 0, len(f.names))
+	for _, name := range f.names {
+		names = append(names, name.Literal())
+	}
+	out.WriteString("for ")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString(" in ")
+	out.WriteString(f.iterable.String())
+	for _, cond := range f.conditions {
+		out.WriteString(" if ")
+		out.WriteString(cond.String())
+	}
+	return out.String()
+}
+
+func clausesString(clauses []*ForClause) string {
+	items := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		items = append(items, clause.String())
+	}
+	return strings.Join(items, " ")
+}
+
+// ListComprehension is an expression node that builds a list from the values
+// of an expression, evaluated for each item of its for clauses, as in
+// "[x * 2 for x in items if x > 0]".
+type ListComprehension struct {
+	token   token.Token // the '[' token
+	element Expression
+	clauses []*ForClause
+}
+
+// NewListComprehension creates a new ListComprehension node.
+func NewListComprehension(token token.Token, element Expression, clauses []*ForClause) *ListComprehension {
+	return &ListComprehension{token: token, element: element, clauses: clauses}
+}
+
+func (l *ListComprehension) ExpressionNode() {}
+
+func (l *ListComprehension) IsExpression() bool { return true }
+
+func (l *ListComprehension) Token() token.Token { return l.token }
+
+func (l *ListComprehension) Literal() string { return l.token.Literal }
+
+func (l *ListComprehension) Element() Expression { return l.element }
+
+func (l *ListComprehension) Clauses() []*ForClause { return l.clauses }
+
+func (l *ListComprehension) String() string {
+	return "[" + l.element.String() + " " + clausesString(l.clauses) + "]"
+}
+
+// MapComprehension is an expression node that builds a map from the keys and
+// values of two expressions, evaluated for each item of its for clauses, as
+// in "{k: v for k, v in m}". Unlike in a map literal, a key that is a name is
+// evaluated as a variable.
+type MapComprehension struct {
+	token   token.Token // the '{' token
+	key     Expression
+	value   Expression
+	clauses []*ForClause
+}
+
+// NewMapComprehension creates a new MapComprehension node.
+func NewMapComprehension(token token.Token, key, value Expression, clauses []*ForClause) *MapComprehension {
+	return &MapComprehension{token: token, key: key, value: value, clauses: clauses}
+}
+
+func (m *MapComprehension) ExpressionNode() {}
+
+func (m *MapComprehension) IsExpression() bool { return true }
+
+func (m *MapComprehension) Token() token.Token { return m.token }
+
+func (m *MapComprehension) Literal() string { return m.token.Literal }
+
+func (m *MapComprehension) Key() Expression { return m.key }
+
+func (m *MapComprehension) Value() Expression { return m.value }
+
+func (m *MapComprehension) Clauses() []*ForClause { return m.clauses }
+
+func (m *MapComprehension) String() string {
+	return "{" + m.key.String() + ": " + m.value.String() + " " + clausesString(m.clauses) + "}"
+}
+
+// SetComprehension is an expression node that builds a set from the values
+// of an expression, evaluated for each item of its for clauses, as in
+// "{x for x in items}".
+type SetComprehension struct {
+	token   token.Token // the '{' token
+	element Expression
+	clauses []*ForClause
+}
+
+// NewSetComprehension creates a new SetComprehension node.
+func NewSetComprehension(token token.Token, element Expression, clauses []*ForClause) *SetComprehension {
+	return &SetComprehension{token: token, element: element, clauses: clauses}
+}
+
+func (s *SetComprehension) ExpressionNode() {}
+
+func (s *SetComprehension) IsExpression() bool { return true }
+
+func (s *SetComprehension) Token() token.Token { return s.token }
+
+func (s *SetComprehension) Literal() string { return s.token.Literal }
+
+func (s *SetComprehension) Element() Expression { return s.element }
+
+func (s *SetComprehension) Clauses() []*ForClause { return s.clauses }
+
+func (s *SetComprehension) String() string {
+	return "{" + s.element.String() + " " + clausesString(s.clauses) + "}"
+}
@@ -0,0 +1,220 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/risor-io/risor/token"
+)
+
+// WildcardPattern is a pattern that matches any value, written as "_".
+type WildcardPattern struct {
+	token token.Token
+}
+
+// NewWildcardPattern creates a new WildcardPattern node.
+func NewWildcardPattern(token token.Token) *WildcardPattern {
+	return &WildcardPattern{token: token}
+}
+
+func (p *WildcardPattern) PatternNode() {}
+
+func (p *WildcardPattern) IsExpression() bool { return false }
+
+func (p *WildcardPattern) Token() token.Token { return p.token }
+
+func (p *WildcardPattern) Literal() string { return p.token.Literal }
+
+func (p *WildcardPattern) String() string { return "_" }
+
+// CapturePattern is a pattern that matches any value and binds it to a name.
+type CapturePattern struct {
+	name *Ident
+}
+
+// NewCapturePattern creates a new CapturePattern node.
+func NewCapturePattern(name *Ident) *CapturePattern {
+	return &CapturePattern{name: name}
+}
+
+func (p *CapturePattern) PatternNode() {}
+
+func (p *CapturePattern) IsExpression() bool { return false }
+
+func (p *CapturePattern) Token() token.Token { return p.name.Token() }
+
+func (p *CapturePattern) Literal() string { return p.name.Literal() }
+
+func (p *CapturePattern) Name() *Ident { return p.name }
+
+func (p *CapturePattern) String() string { return p.name.Literal() }
+
+// ValuePattern is a pattern that matches values equal to a literal or to a
+// dotted name, such as 42, "pod", nil, or http.StatusOK.
+type ValuePattern struct {
+	value Expression
+}
+
+// NewValuePattern creates a new ValuePattern node.
+func NewValuePattern(value Expression) *ValuePattern {
+	return &ValuePattern{value: value}
+}
+
+func (p *ValuePattern) PatternNode() {}
+
+func (p *ValuePattern) IsExpression() bool { return false }
+
+func (p *ValuePattern) Token() token.Token { return p.value.Token() }
+
+func (p *ValuePattern) Literal() string { return p.value.Literal() }
+
+func (p *ValuePattern) Value() Expression { return p.value }
+
+func (p *ValuePattern) String() string { return p.value.String() }
+
+// RestPattern appears within a ListPattern and matches the items of the list
+// that are not matched by the other patterns, as in "[first, ...rest]".
+type RestPattern struct {
+	token token.Token
+
+	// the name the remaining items are bound to, or nil for "..."
+	name *Ident
+}
+
+// NewRestPattern creates a new RestPattern node.
+func NewRestPattern(token token.Token, name *Ident) *RestPattern {
+	return &RestPattern{token: token, name: name}
+}
+
+func (p *RestPattern) PatternNode() {}
+
+func (p *RestPattern) IsExpression() bool { return false }
+
+func (p *RestPattern) Token() token.Token { return p.token }
+
+func (p *RestPattern) Literal() string { return p.token.Literal }
+
+// Name returns the name the remaining items are bound to, if any.
+func (p *RestPattern) Name() *Ident { return p.name }
+
+func (p *RestPattern) String() string {
+	if p.name == nil {
+		return "..."
+	}
+	return "..." + p.name.Literal()
+}
+
+// ListPattern is a pattern that matches a list with a matching item for each
+// of its patterns. Without a RestPattern, the list length must match exactly.
+type ListPattern struct {
+	token token.Token
+	items []Pattern
+}
+
+// NewListPattern creates a new ListPattern node.
+func NewListPattern(token token.Token, items []Pattern) *ListPattern {
+	return &ListPattern{token: token, items: items}
+}
+
+func (p *ListPattern) PatternNode() {}
+
+func (p *ListPattern) IsExpression() bool { return false }
+
+func (p *ListPattern) Token() token.Token { return p.token }
+
+func (p *ListPattern) Literal() string { return p.token.Literal }
+
+func (p *ListPattern) Items() []Pattern { return p.items }
+
+func (p *ListPattern) String() string {
+	items := make([]string, 0, len(p.items))
+	for _, item := range p.items {
+		items = append(items, item.String())
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// MapPattern is a pattern that matches a map containing each of its keys,
+// with values that match the corresponding patterns. Other keys are ignored.
+type MapPattern struct {
+	token  token.Token
+	keys   []Expression
+	values []Pattern
+}
+
+// NewMapPattern creates a new MapPattern node. Each key is a String or an
+// Ident, which is used as a string key as in a map literal.
+func NewMapPattern(token token.Token, keys []Expression, values []Pattern) *MapPattern {
+	return &MapPattern{token: token, keys: keys, values: values}
+}
+
+func (p *MapPattern) PatternNode() {}
+
+func (p *MapPattern) IsExpression() bool { return false }
+
+func (p *MapPattern) Token() token.Token { return p.token }
+
+func (p *MapPattern) Literal() string { return p.token.Literal }
+
+func (p *MapPattern) Keys() []Expression { return p.keys }
+
+func (p *MapPattern) Values() []Pattern { return p.values }
+
+func (p *MapPattern) String() string {
+	items := make([]string, 0, len(p.keys))
+	for i, key := range p.keys {
+		items = append(items, key.String()+": "+p.values[i].String())
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+// TypePattern is a pattern that matches values of the named type, as in
+// "int(n)" or "Point(x=0, y=y)". The optional value pattern is matched
+// against the value itself, and each attribute pattern against the named
+// attribute of the value. The name may be that of a struct.
+type TypePattern struct {
+	name      *Ident
+	value     Pattern
+	attrNames []*Ident
+	attrs     []Pattern
+}
+
+// NewTypePattern creates a new TypePattern node.
+func NewTypePattern(name *Ident, value Pattern, attrNames []*Ident, attrs []Pattern) *TypePattern {
+	return &TypePattern{name: name, value: value, attrNames: attrNames, attrs: attrs}
+}
+
+func (p *TypePattern) PatternNode() {}
+
+func (p *TypePattern) IsExpression() bool { return false }
+
+func (p *TypePattern) Token() token.Token { return p.name.Token() }
+
+func (p *TypePattern) Literal() string { return p.name.Literal() }
+
+func (p *TypePattern) Name() *Ident { return p.name }
+
+// Value returns the pattern matched against the value itself, if any.
+func (p *TypePattern) Value() Pattern { return p.value }
+
+// AttrNames returns the names of the attributes matched by the pattern.
+func (p *TypePattern) AttrNames() []*Ident { return p.attrNames }
+
+// Attrs returns the patterns matched against the named attributes.
+func (p *TypePattern) Attrs() []Pattern { return p.attrs }
+
+func (p *TypePattern) String() string {
+	var out bytes.Buffer
+	var args []string
+	if p.value != nil {
+		args = append(args, p.value.String())
+	}
+	for i, name := range p.attrNames {
+		args = append(args, name.Literal()+"="+p.attrs[i].String())
+	}
+	out.WriteString(p.name.Literal())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/risor-io/risor/token"
 )
@@ -11,12 +12,21 @@ import (
 type Program struct {
 	// The list of statements which comprise the program.
 	statements []Node
+
+	// The comments in the program source, in order.
+	comments []*Comment
 }
 
 func NewProgram(statements []Node) *Program {
 	return &Program{statements: statements}
 }
 
+// NewProgramWithComments creates a Program that retains the comments found in
+// its source.
+func NewProgramWithComments(statements []Node, comments []*Comment) *Program {
+	return &Program{statements: statements, comments: comments}
+}
+
 func (p *Program) Token() token.Token {
 	if len(p.statements) > 0 {
 		return p.statements[0].Token()
@@ -30,6 +40,11 @@ func (p *Program) Literal() string { return p.Token().Literal }
 
 func (p *Program) Statements() []Node { return p.statements }
 
+// Comments returns the comments in the program source, in order. Comments are
+// not part of the statement tree; tools that need them, such as the formatter,
+// place them using their positions.
+func (p *Program) Comments() []*Comment { return p.comments }
+
 func (p *Program) First() Node {
 	if len(p.statements) > 0 {
 		return p.statements[0]
@@ -48,3 +63,27 @@ func (p *Program) String() string {
 	}
 	return out.String()
 }
+
+// Comment holds a "#", "//", or "/* */" comment from the source.
+type Comment struct {
+	token token.Token
+}
+
+// NewComment creates a new Comment node from a comment token.
+func NewComment(token token.Token) *Comment {
+	return &Comment{token: token}
+}
+
+func (c *Comment) Token() token.Token { return c.token }
+
+func (c *Comment) IsExpression() bool { return false }
+
+func (c *Comment) Literal() string { return c.token.Literal }
+
+// Text returns the comment text, including its delimiters.
+func (c *Comment) Text() string { return c.token.Literal }
+
+// IsBlock returns true if this is a "/* */" comment.
+func (c *Comment) IsBlock() bool { return strings.HasPrefix(c.token.Literal, "/*") }
+
+func (c *Comment) String() string { return c.token.Literal }
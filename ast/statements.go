@@ -43,6 +43,9 @@ func (s *Var) Literal() string { return s.token.Literal }
 
 func (s *Var) Value() (string, Expression) { return s.name.value, s.value }
 
+// Ident returns the identifier of the variable being assigned.
+func (s *Var) Ident() *Ident { return s.name }
+
 func (s *Var) IsWalrus() bool { return s.isWalrus }
 
 func (s *Var) String() string {
@@ -91,6 +94,9 @@ func (s *MultiVar) Value() ([]string, Expression) {
 	return names, s.value
 }
 
+// Idents returns the identifiers of the variables being assigned.
+func (s *MultiVar) Idents() []*Ident { return s.names }
+
 func (s *MultiVar) IsWalrus() bool { return s.isWalrus }
 
 func (s *MultiVar) String() string {
@@ -136,6 +142,9 @@ func (c *Const) Literal() string { return c.token.Literal }
 
 func (c *Const) Value() (string, Expression) { return c.name.value, c.value }
 
+// Ident returns the identifier of the constant.
+func (c *Const) Ident() *Ident { return c.name }
+
 func (c *Const) String() string {
 	var out bytes.Buffer
 	out.WriteString(c.Literal() + " ")
@@ -154,6 +163,9 @@ type Control struct {
 
 	// optional value, for return statements
 	value Expression
+
+	// optional label of the loop to break out of or continue
+	label *Ident
 }
 
 // NewControl creates a new Control node.
@@ -161,6 +173,12 @@ func NewControl(token token.Token, value Expression) *Control {
 	return &Control{token: token, value: value}
 }
 
+// NewLabeledControl creates a new Control node that targets the loop with
+// the given label, rather than the innermost loop.
+func NewLabeledControl(token token.Token, label *Ident) *Control {
+	return &Control{token: token, label: label}
+}
+
 func (c *Control) StatementNode() {}
 
 func (c *Control) IsExpression() bool { return false }
@@ -171,6 +189,8 @@ func (c *Control) Literal() string { return c.token.Literal }
 
 func (c *Control) Value() Expression { return c.value }
 
+func (c *Control) Label() *Ident { return c.label }
+
 func (c *Control) IsReturn() bool {
 	return c.token.Type == token.RETURN
 }
@@ -181,6 +201,9 @@ func (c *Control) String() string {
 	if c.value != nil {
 		out.WriteString(" " + c.value.String())
 	}
+	if c.label != nil {
+		out.WriteString(" " + c.label.String())
+	}
 	return out.String()
 }
 
@@ -217,6 +240,40 @@ func (r *Return) String() string {
 	return out.String()
 }
 
+// Yield is a statement node that suspends a generator function, producing
+// the next value of the generator.
+type Yield struct {
+	// "yield"
+	token token.Token
+
+	// optional value
+	value Expression
+}
+
+// NewYield creates a new Yield node.
+func NewYield(token token.Token, value Expression) *Yield {
+	return &Yield{token: token, value: value}
+}
+
+func (y *Yield) StatementNode() {}
+
+func (y *Yield) IsExpression() bool { return false }
+
+func (y *Yield) Token() token.Token { return y.token }
+
+func (y *Yield) Literal() string { return y.token.Literal }
+
+func (y *Yield) Value() Expression { return y.value }
+
+func (y *Yield) String() string {
+	var out bytes.Buffer
+	out.WriteString(y.Literal())
+	if y.value != nil {
+		out.WriteString(" " + y.value.String())
+	}
+	return out.String()
+}
+
 // Block is a node that holds a sequence of statements. This is used to
 // represent the body of a function, loop, or a conditional.
 type Block struct {
@@ -355,6 +412,77 @@ func (f *For) String() string {
 	return out.String()
 }
 
+// Labeled is a statement node that names a for loop, so that break and
+// continue statements within nested loops can target it.
+type Labeled struct {
+	// the label identifier
+	label *Ident
+
+	// the loop being labeled
+	loop *For
+}
+
+// NewLabeled creates a new Labeled node.
+func NewLabeled(label *Ident, loop *For) *Labeled {
+	return &Labeled{label: label, loop: loop}
+}
+
+func (l *Labeled) StatementNode() {}
+
+func (l *Labeled) IsExpression() bool { return false }
+
+func (l *Labeled) Token() token.Token { return l.label.Token() }
+
+func (l *Labeled) Literal() string { return l.label.Literal() }
+
+func (l *Labeled) Label() *Ident { return l.label }
+
+func (l *Labeled) Loop() *For { return l.loop }
+
+func (l *Labeled) String() string {
+	return l.label.String() + ": " + l.loop.String()
+}
+
+// Decorated is a statement node that declares a named function and rebinds
+// its name to the result of passing the function through each decorator, from
+// the innermost decorator outward.
+type Decorated struct {
+	// the "@" token of the first decorator
+	token token.Token
+
+	// the decorator expressions, in source order
+	decorators []Expression
+
+	// the function being decorated
+	fn *Func
+}
+
+// NewDecorated creates a new Decorated node.
+func NewDecorated(token token.Token, decorators []Expression, fn *Func) *Decorated {
+	return &Decorated{token: token, decorators: decorators, fn: fn}
+}
+
+func (d *Decorated) StatementNode() {}
+
+func (d *Decorated) IsExpression() bool { return false }
+
+func (d *Decorated) Token() token.Token { return d.token }
+
+func (d *Decorated) Literal() string { return d.token.Literal }
+
+func (d *Decorated) Decorators() []Expression { return d.decorators }
+
+func (d *Decorated) Func() *Func { return d.fn }
+
+func (d *Decorated) String() string {
+	var out bytes.Buffer
+	for _, decorator := range d.decorators {
+		out.WriteString("@" + decorator.String() + "\n")
+	}
+	out.WriteString(d.fn.String())
+	return out.String()
+}
+
 // Assign is a statement node used to describe a variable assignment.
 type Assign struct {
 	token    token.Token
@@ -384,6 +512,10 @@ func (a *Assign) Literal() string { return a.token.Literal }
 
 func (a *Assign) Name() string { return a.name.value }
 
+// Ident returns the identifier being assigned, which is nil for an index
+// assignment.
+func (a *Assign) Ident() *Ident { return a.name }
+
 func (a *Assign) Index() *Index { return a.index }
 
 func (a *Assign) Operator() string { return a.operator }
@@ -471,6 +603,9 @@ func (i *FromImport) Parents() []*Ident { return i.parents }
 
 func (i *FromImport) Imports() []*Import { return i.imports }
 
+// IsGrouped returns true if the imports are enclosed in parentheses.
+func (i *FromImport) IsGrouped() bool { return i.isGrouped }
+
 func (i *FromImport) String() string {
 	var out bytes.Buffer
 	out.WriteString(i.Literal() + " ")
@@ -650,3 +785,206 @@ func (s *Send) Value() Expression { return s.value }
 func (s *Send) String() string {
 	return fmt.Sprintf("%s <- %s", s.channel.String(), s.value.String())
 }
+
+// Struct is a statement that declares a user-defined record type with a
+// set of named fields and optional methods.
+type Struct struct {
+	// the "struct" token
+	token token.Token
+
+	// name is the name of the struct type
+	name *Ident
+
+	// fields is the ordered list of field names
+	fields []*Ident
+
+	// defaults holds default values for fields which are optional
+	defaults map[string]Expression
+
+	// methods contains the functions declared in the struct body
+	methods []*Func
+}
+
+// NewStruct creates a new Struct node.
+func NewStruct(
+	token token.Token,
+	name *Ident,
+	fields []*Ident,
+	defaults map[string]Expression,
+	methods []*Func,
+) *Struct {
+	return &Struct{
+		token:    token,
+		name:     name,
+		fields:   fields,
+		defaults: defaults,
+		methods:  methods,
+	}
+}
+
+func (s *Struct) StatementNode() {}
+
+func (s *Struct) IsExpression() bool { return false }
+
+func (s *Struct) Token() token.Token { return s.token }
+
+func (s *Struct) Literal() string { return s.token.Literal }
+
+func (s *Struct) Name() *Ident { return s.name }
+
+func (s *Struct) Fields() []*Ident { return s.fields }
+
+func (s *Struct) FieldNames() []string {
+	names := make([]string, 0, len(s.fields))
+	for _, f := range s.fields {
+		names = append(names, f.value)
+	}
+	return names
+}
+
+func (s *Struct) Defaults() map[string]Expression { return s.defaults }
+
+func (s *Struct) Methods() []*Func { return s.methods }
+
+func (s *Struct) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.Literal() + " " + s.name.value + " {")
+	for _, f := range s.fields {
+		out.WriteString(" " + f.value)
+		if expr, ok := s.defaults[f.value]; ok {
+			out.WriteString(" = " + expr.String())
+		}
+		out.WriteString(";")
+	}
+	for _, m := range s.methods {
+		out.WriteString(" " + m.String() + ";")
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+// Enum is a statement that declares an enumeration type with a fixed, ordered
+// set of named members.
+type Enum struct {
+	// the "enum" token
+	token token.Token
+
+	// name is the name of the enum type
+	name *Ident
+
+	// members is the ordered list of member names
+	members []*Ident
+
+	// values holds the explicit values given to members
+	values map[string]Expression
+}
+
+// NewEnum creates a new Enum node.
+func NewEnum(token token.Token, name *Ident, members []*Ident, values map[string]Expression) *Enum {
+	return &Enum{token: token, name: name, members: members, values: values}
+}
+
+func (e *Enum) StatementNode() {}
+
+func (e *Enum) IsExpression() bool { return false }
+
+func (e *Enum) Token() token.Token { return e.token }
+
+func (e *Enum) Literal() string { return e.token.Literal }
+
+func (e *Enum) Name() *Ident { return e.name }
+
+func (e *Enum) Members() []*Ident { return e.members }
+
+func (e *Enum) Values() map[string]Expression { return e.values }
+
+func (e *Enum) String() string {
+	var out bytes.Buffer
+	out.WriteString(e.Literal() + " " + e.name.value + " {")
+	for i, m := range e.members {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(" " + m.value)
+		if expr, ok := e.values[m.value]; ok {
+			out.WriteString(" = " + expr.String())
+		}
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+// Try is a statement that runs a block of code and handles any error raised
+// within it. It has an optional catch block, which receives the error, and an
+// optional finally block, which always runs when the statement completes.
+type Try struct {
+	// the "try" token
+	token token.Token
+
+	// body is the block of code to protect
+	body *Block
+
+	// catchIdent is the optional name the caught error is bound to
+	catchIdent *Ident
+
+	// catchBlock runs if an error is raised in the body
+	catchBlock *Block
+
+	// finallyBlock always runs after the body and catch block
+	finallyBlock *Block
+}
+
+// NewTry creates a new Try node.
+func NewTry(
+	token token.Token,
+	body *Block,
+	catchIdent *Ident,
+	catchBlock *Block,
+	finallyBlock *Block,
+) *Try {
+	return &Try{
+		token:        token,
+		body:         body,
+		catchIdent:   catchIdent,
+		catchBlock:   catchBlock,
+		finallyBlock: finallyBlock,
+	}
+}
+
+func (t *Try) StatementNode() {}
+
+func (t *Try) IsExpression() bool { return false }
+
+func (t *Try) Token() token.Token { return t.token }
+
+func (t *Try) Literal() string { return t.token.Literal }
+
+func (t *Try) Body() *Block { return t.body }
+
+func (t *Try) CatchIdent() *Ident { return t.catchIdent }
+
+func (t *Try) CatchBlock() *Block { return t.catchBlock }
+
+func (t *Try) FinallyBlock() *Block { return t.finallyBlock }
+
+func (t *Try) String() string {
+	var out bytes.Buffer
+	out.WriteString("try { ")
+	out.WriteString(t.body.String())
+	out.WriteString(" }")
+	if t.catchBlock != nil {
+		out.WriteString(" catch ")
+		if t.catchIdent != nil {
+			out.WriteString(t.catchIdent.Literal() + " ")
+		}
+		out.WriteString("{ ")
+		out.WriteString(t.catchBlock.String())
+		out.WriteString(" }")
+	}
+	if t.finallyBlock != nil {
+		out.WriteString(" finally { ")
+		out.WriteString(t.finallyBlock.String())
+		out.WriteString(" }")
+	}
+	return out.String()
+}
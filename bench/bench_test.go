@@ -6,12 +6,46 @@ import (
 	"testing"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
 	"github.com/risor-io/risor/parser"
 	"github.com/risor-io/risor/vm"
 )
 
+func benchmarkScript(b *testing.B, script string, expected int64) {
+	benchmarkScriptWithGlobals(b, script, expected, nil)
+}
+
+func benchmarkScriptWithGlobals(b *testing.B, script string, expected int64, globals map[string]any) {
+	ctx := context.Background()
+
+	ast, err := parser.Parse(ctx, script)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var names []string
+	for name := range globals {
+		names = append(names, name)
+	}
+	code, err := compiler.Compile(ast, compiler.WithGlobalNames(names))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := vm.Run(ctx, code, vm.WithGlobals(globals))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result.Interface().(int64) != expected {
+			b.Fatalf("unexpected result: %v", result)
+		}
+	}
+}
+
 func BenchmarkRisor_Fibonacci35(b *testing.B) {
-	script := `
+	benchmarkScript(b, `
     func fibonacci(n) {
         if n <= 1 {
             return n
@@ -19,28 +53,142 @@ func BenchmarkRisor_Fibonacci35(b *testing.B) {
         return fibonacci(n-1) + fibonacci(n-2)
     }
     fibonacci(35)
-    `
+    `, 9227465)
+}
 
-	ctx := context.Background()
+func BenchmarkRisor_LoopSum(b *testing.B) {
+	benchmarkScript(b, `
+    func sum(n) {
+        xs := []
+        for i := 0; i < n; i++ {
+            xs.append(i)
+        }
+        total := 0
+        for i := 0; i < n; i++ {
+            total += xs[i]
+        }
+        return total
+    }
+    sum(1000000)
+    `, 499999500000)
+}
 
-	ast, err := parser.Parse(ctx, script)
+func BenchmarkRisor_LoopCount(b *testing.B) {
+	benchmarkScript(b, `
+    func count(n) {
+        evens := 0
+        for i := 0; i < n; i++ {
+            if i % 2 == 0 {
+                evens += 1
+            }
+        }
+        return evens
+    }
+    count(1000000)
+    `, 500000)
+}
+
+func BenchmarkRisor_StringMethods(b *testing.B) {
+	benchmarkScript(b, `
+    func count(n) {
+        total := 0
+        for i := 0; i < n; i++ {
+            s := " a,b,c "
+            if s.trim_space().has_prefix("a") {
+                total += s.count(",")
+            }
+        }
+        return total
+    }
+    count(100000)
+    `, 200000)
+}
+
+func BenchmarkRisor_StructFields(b *testing.B) {
+	benchmarkScript(b, `
+    struct Point {
+        x
+        y
+        func sum() { return self.x + self.y }
+    }
+    func total(n) {
+        p := Point(1, 2)
+        total := 0
+        for i := 0; i < n; i++ {
+            total += p.x * p.y + p.sum()
+        }
+        return total
+    }
+    total(100000)
+    `, 500000)
+}
+
+func BenchmarkRisor_ModuleAttrs(b *testing.B) {
+	benchmarkScriptWithGlobals(b, `
+    func total(n) {
+        total := 0
+        for i := 0; i < n; i++ {
+            total += consts.one + consts.two
+        }
+        return total
+    }
+    total(100000)
+    `, 300000, map[string]any{
+		"consts": object.NewBuiltinsModule("consts", map[string]object.Object{
+			"one": object.NewInt(1),
+			"two": object.NewInt(2),
+		}),
+	})
+}
+
+func benchmarkLoadCode(b *testing.B, marshal func(*compiler.Code) ([]byte, error), unmarshal func([]byte) (*compiler.Code, error)) {
+	ast, err := parser.Parse(context.Background(), `
+    func mergesort(arr) {
+        if len(arr) <= 1 {
+            return arr
+        }
+        mid := len(arr) / 2
+        left := mergesort(arr[:mid])
+        right := mergesort(arr[mid:])
+        output := []
+        i, j := [0, 0]
+        for i < len(left) && j < len(right) {
+            if left[i] <= right[j] {
+                output.append(left[i])
+                i++
+            } else {
+                output.append(right[j])
+                j++
+            }
+        }
+        return output + left[i:] + right[j:]
+    }
+    mergesort([5, 2, 4, 6, 1, 3])
+    `)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	code, err := compiler.Compile(ast)
+	code, err := compiler.Compile(ast, compiler.WithGlobalNames([]string{"len"}))
 	if err != nil {
 		log.Fatal(err)
 	}
-
+	data, err := marshal(code)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		result, err := vm.Run(ctx, code)
-		if err != nil {
+		if _, err := unmarshal(data); err != nil {
 			b.Fatal(err)
 		}
-		if result.Interface().(int64) != 9227465 {
-			b.Fatalf("unexpected result: %v", result)
-		}
 	}
 }
+
+func BenchmarkRisor_LoadCodeJSON(b *testing.B) {
+	benchmarkLoadCode(b, compiler.MarshalCode, compiler.UnmarshalCode)
+}
+
+func BenchmarkRisor_LoadCodeBinary(b *testing.B) {
+	benchmarkLoadCode(b, compiler.MarshalCodeBinary, compiler.UnmarshalCodeBinary)
+}
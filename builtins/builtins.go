@@ -51,6 +51,27 @@ func Sprintf(ctx context.Context, args ...object.Object) object.Object {
 	return result
 }
 
+// Format returns the value formatted according to a format spec, such as
+// ".2f" or ">10". It applies the same formatting as an f-string.
+func Format(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("format", 1, 2, args); err != nil {
+		return err
+	}
+	var spec string
+	if len(args) == 2 {
+		s, err := object.AsString(args[1])
+		if err != nil {
+			return err
+		}
+		spec = s
+	}
+	result, err := object.Format(args[0], spec)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(result)
+}
+
 func Delete(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("delete", 2, args); err != nil {
 		return err
@@ -87,6 +108,9 @@ func Set(ctx context.Context, args ...object.Object) object.Object {
 			return res
 		}
 	}
+	if err := object.IterErr(iter); err != nil {
+		return object.NewError(err)
+	}
 	return set
 }
 
@@ -121,6 +145,9 @@ func List(ctx context.Context, args ...object.Object) object.Object {
 		}
 		items = append(items, val)
 	}
+	if err := object.IterErr(iter); err != nil {
+		return object.NewError(err)
+	}
 	return object.NewList(items)
 }
 
@@ -166,6 +193,9 @@ func Map(ctx context.Context, args ...object.Object) object.Object {
 			result.Set(k.Inspect(), v)
 		}
 	}
+	if err := object.IterErr(iter); err != nil {
+		return object.NewError(err)
+	}
 	return result
 }
 
@@ -372,6 +402,9 @@ func Any(ctx context.Context, args ...object.Object) object.Object {
 				return object.True
 			}
 		}
+		if err := object.IterErr(iter); err != nil {
+			return object.NewError(err)
+		}
 	case object.Iterator:
 		for {
 			val, ok := arg.Next(ctx)
@@ -382,6 +415,9 @@ func Any(ctx context.Context, args ...object.Object) object.Object {
 				return object.True
 			}
 		}
+		if err := object.IterErr(arg); err != nil {
+			return object.NewError(err)
+		}
 	default:
 		return object.TypeErrorf("type error: any() argument must be a container (%s given)", args[0].Type())
 	}
@@ -428,6 +464,9 @@ func All(ctx context.Context, args ...object.Object) object.Object {
 				return object.False
 			}
 		}
+		if err := object.IterErr(iter); err != nil {
+			return object.NewError(err)
+		}
 	case object.Iterator:
 		for {
 			val, ok := arg.Next(ctx)
@@ -438,6 +477,9 @@ func All(ctx context.Context, args ...object.Object) object.Object {
 				return object.False
 			}
 		}
+		if err := object.IterErr(arg); err != nil {
+			return object.NewError(err)
+		}
 	default:
 		return object.TypeErrorf("type error: all() argument must be a container (%s given)", args[0].Type())
 	}
@@ -597,6 +639,9 @@ func iterKeys(ctx context.Context, iter object.Iterator) object.Object {
 		entry, _ := iter.Entry()
 		keys = append(keys, entry.Key())
 	}
+	if err := object.IterErr(iter); err != nil {
+		return object.NewError(err)
+	}
 	return object.NewList(keys)
 }
 
@@ -983,6 +1028,7 @@ func Builtins() map[string]object.Object {
 		"error":       object.NewBuiltin("error", Error),
 		"float_slice": object.NewBuiltin("float_slice", FloatSlice),
 		"float":       object.NewBuiltin("float", Float),
+		"format":      object.NewBuiltin("format", Format),
 		"getattr":     object.NewBuiltin("getattr", GetAttr),
 		"hash":        object.NewBuiltin("hash", Hash),
 		"int":         object.NewBuiltin("int", Int),
@@ -166,6 +166,20 @@ func TestCoalesce(t *testing.T) {
 	}
 }
 
+func TestFormat(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.NewString("3.14"), Format(ctx, object.NewFloat(3.14159), object.NewString(".2f")))
+	require.Equal(t, object.NewString("42"), Format(ctx, object.NewInt(42)))
+	result := Format(ctx, object.NewInt(42), object.NewString("z"))
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "value error: invalid format spec \"z\"", errObj.Message().Value())
+	result = Format(ctx)
+	errObj, ok = result.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "args error: format() takes at least 1 argument (0 given)", errObj.Message().Value())
+}
+
 func TestChunk(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
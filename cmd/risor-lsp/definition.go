@@ -1,11 +0,0 @@
-package main
-
-import (
-	"context"
-
-	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
-)
-
-func (s *Server) Definition(ctx context.Context, params *protocol.DefinitionParams) (protocol.Definition, error) {
-	return nil, nil
-}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/lint"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+	"github.com/rs/zerolog/log"
+)
+
+// cliGlobals are the globals the risor CLI adds to the default globals.
+// Scripts are usually run with the CLI, so these are not reported as
+// undefined.
+var cliGlobals = []string{
+	"aws",
+	"bcrypt",
+	"carbon",
+	"cli",
+	"color",
+	"gha",
+	"image",
+	"isatty",
+	"jmespath",
+	"k8s",
+	"net",
+	"pgx",
+	"render",
+	"semver",
+	"sql",
+	"tablewriter",
+	"template",
+	"uuid",
+	"vault",
+}
+
+// compileErrorLine matches the line number the compiler adds to its errors.
+var compileErrorLine = regexp.MustCompile(`line (\d+)\)$`)
+
+// globalNames returns the names of the globals that are available to a
+// document when it runs.
+func globalNames(doc *document) []string {
+	names := append(risor.NewConfig().GlobalNames(), cliGlobals...)
+	if strings.HasSuffix(doc.item.URI.SpanURI().Filename(), "_test.risor") {
+		names = append(names, "testing")
+	}
+	return names
+}
+
+// queueDiagnostics schedules the diagnostics of a document to be published.
+// Each document is checked on its own goroutine, and a document that changes
+// while it is being checked is checked again afterwards.
+func (s *Server) queueDiagnostics(uri protocol.DocumentURI) {
+	s.cache.diagMutex.Lock()
+	defer s.cache.diagMutex.Unlock()
+	s.cache.diagQueue[uri] = struct{}{}
+	if _, running := s.cache.diagRunning.LoadOrStore(uri, true); running {
+		return
+	}
+	go s.runDiagnostics(uri)
+}
+
+func (s *Server) runDiagnostics(uri protocol.DocumentURI) {
+	for {
+		s.cache.diagMutex.Lock()
+		if _, queued := s.cache.diagQueue[uri]; !queued {
+			s.cache.diagRunning.Delete(uri)
+			s.cache.diagMutex.Unlock()
+			return
+		}
+		delete(s.cache.diagQueue, uri)
+		s.cache.diagMutex.Unlock()
+		s.publishDiagnostics(uri)
+	}
+}
+
+func (s *Server) publishDiagnostics(uri protocol.DocumentURI) {
+	doc, err := s.cache.get(uri)
+	if err != nil {
+		log.Error().Err(err).Str("call", "publishDiagnostics").Msg("failed to get document")
+		return
+	}
+	diagnostics := diagnose(doc)
+	s.cache.mu.Lock()
+	doc.diagnostics = diagnostics
+	s.cache.mu.Unlock()
+	err = s.client.PublishDiagnostics(context.Background(), &protocol.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("call", "publishDiagnostics").Msg("failed to publish diagnostics")
+	}
+}
+
+// diagnose returns the syntax error of a document, if it has one, or else
+// the problems reported by the linter. When the linter finds no errors, the
+// document is also compiled, so that errors only the compiler detects are
+// reported too.
+func diagnose(doc *document) []protocol.Diagnostic {
+	lines := strings.Split(doc.item.Text, "\n")
+	diagnostics := []protocol.Diagnostic{}
+	if doc.err != nil {
+		var rng protocol.Range
+		var perr parser.ParserError
+		if errors.As(doc.err, &perr) {
+			rng = tokenRange(lines, perr.StartPosition(), perr.EndPosition())
+		}
+		return append(diagnostics, protocol.Diagnostic{
+			Range:    rng,
+			Severity: protocol.SeverityError,
+			Source:   "risor",
+			Message:  doc.err.Error(),
+		})
+	}
+	if doc.ast == nil {
+		return diagnostics
+	}
+	names := globalNames(doc)
+	hasErrors := false
+	for _, d := range lint.Program(doc.ast, lint.WithGlobalNames(names)) {
+		diagnostic := protocol.Diagnostic{
+			Range:    tokenRange(lines, d.Start, d.End),
+			Severity: protocol.SeverityWarning,
+			Code:     string(d.Rule),
+			Source:   "risor-lint",
+			Message:  d.Message,
+		}
+		if d.Severity == lint.Error {
+			diagnostic.Severity = protocol.SeverityError
+			hasErrors = true
+		}
+		switch d.Rule {
+		case lint.UnusedVariable, lint.UnusedImport, lint.Unreachable:
+			diagnostic.Tags = []protocol.DiagnosticTag{protocol.Unnecessary}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	if !hasErrors {
+		if diagnostic, ok := compileDiagnostic(doc.ast, lines, names); ok {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	return diagnostics
+}
+
+// compileDiagnostic compiles a document and converts a compile error to a
+// diagnostic covering the line it occurred on.
+func compileDiagnostic(program *ast.Program, lines []string, names []string) (protocol.Diagnostic, bool) {
+	_, err := compiler.Compile(program, compiler.WithGlobalNames(names))
+	if err == nil {
+		return protocol.Diagnostic{}, false
+	}
+	var rng protocol.Range
+	if m := compileErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		if n, _ := strconv.Atoi(m[1]); n > 0 && n <= len(lines) {
+			text := []rune(lines[n-1])
+			start := len(text) - len([]rune(strings.TrimLeft(string(text), " \t")))
+			end := len([]rune(strings.TrimRight(string(text), " \t\r")))
+			rng = protocol.Range{
+				Start: position(lines, n-1, start),
+				End:   position(lines, n-1, end),
+			}
+		}
+	}
+	return protocol.Diagnostic{
+		Range:    rng,
+		Severity: protocol.SeverityError,
+		Source:   "risor",
+		Message:  err.Error(),
+	}, true
+}
+
+// tokenRange converts the positions of the first and last characters of a
+// token to an LSP range, which ends after its last character.
+func tokenRange(lines []string, start, end token.Position) protocol.Range {
+	if end.Line < start.Line || (end.Line == start.Line && end.Column < start.Column) {
+		end = start
+	}
+	return protocol.Range{
+		Start: position(lines, start.Line, start.Column),
+		End:   position(lines, end.Line, end.Column+1),
+	}
+}
+
+// position converts a line and a column counted in runes to an LSP position,
+// which counts columns in UTF-16 code units.
+func position(lines []string, line, column int) protocol.Position {
+	character := column
+	if line < len(lines) {
+		character = 0
+		for i, r := range []rune(lines[line]) {
+			if i >= column {
+				break
+			}
+			if r >= 0x10000 {
+				character += 2
+			} else {
+				character++
+			}
+		}
+		if n := len([]rune(lines[line])); column > n {
+			character += column - n
+		}
+	}
+	return protocol.Position{Line: uint32(line), Character: uint32(character)}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/token"
+)
+
+// Kinds of folding ranges
+const (
+	foldComment = "comment"
+	foldImports = "imports"
+)
+
+// FoldingRange returns the ranges that can be folded: blocks and other
+// bracketed code spanning several lines, runs of comments, and runs of
+// imports. Ranges are found by lexing, so a document that does not parse
+// can still be folded up to its first lexical error.
+func (s *Server) FoldingRange(ctx context.Context, params *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return foldingRanges(doc.item.Text), nil
+}
+
+func foldingRanges(text string) []protocol.FoldingRange {
+	lines := strings.Split(text, "\n")
+	l := lexer.New(text)
+	var tokens []token.Token
+	for {
+		tok, err := l.Next()
+		if err != nil || tok.Type == token.EOF {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+
+	ranges := []protocol.FoldingRange{}
+	add := func(start, end int, kind string) {
+		if end > start {
+			ranges = append(ranges, protocol.FoldingRange{
+				StartLine: uint32(start),
+				EndLine:   uint32(end),
+				Kind:      kind,
+			})
+		}
+	}
+
+	// Brackets fold up to the line before the closing bracket, which stays
+	// visible. Only the outermost bracket opened on a line is folded.
+	var open []token.Token
+	folded := map[int]int{}
+	var brackets []protocol.FoldingRange
+	for _, tok := range tokens {
+		switch tok.Type {
+		case token.LBRACE, token.LBRACKET, token.LPAREN:
+			open = append(open, tok)
+		case token.RBRACE, token.RBRACKET, token.RPAREN:
+			if len(open) == 0 {
+				continue
+			}
+			start := open[len(open)-1].StartPosition.Line
+			open = open[:len(open)-1]
+			end := tok.StartPosition.Line - 1
+			if end <= start {
+				continue
+			}
+			if i, ok := folded[start]; ok {
+				// An outer bracket closes after the inner one
+				brackets[i].EndLine = uint32(end)
+				continue
+			}
+			folded[start] = len(brackets)
+			brackets = append(brackets, protocol.FoldingRange{
+				StartLine: uint32(start),
+				EndLine:   uint32(end),
+			})
+		}
+	}
+	ranges = append(ranges, brackets...)
+
+	// Consecutive lines that start with an import
+	first, last := -1, -1
+	for i, tok := range tokens {
+		if tok.Type != token.IMPORT && tok.Type != token.FROM {
+			continue
+		}
+		if i > 0 && tokens[i-1].Type != token.NEWLINE && tokens[i-1].Type != token.SEMICOLON {
+			continue
+		}
+		line := tok.StartPosition.Line
+		if line != last+1 || first < 0 {
+			add(first, last, foldImports)
+			first = line
+		}
+		last = line
+	}
+	add(first, last, foldImports)
+
+	// Block comments, and consecutive lines holding only a comment
+	first, last = -1, -1
+	for _, tok := range l.Comments() {
+		start, end := tok.StartPosition.Line, tok.EndPosition.Line
+		if end > start {
+			add(first, last, foldComment)
+			first, last = -1, -1
+			add(start, end, foldComment)
+			continue
+		}
+		if start >= len(lines) || strings.TrimSpace(lines[start]) != strings.TrimSpace(tok.Literal) {
+			continue
+		}
+		if start != last+1 || first < 0 {
+			add(first, last, foldComment)
+			first = start
+		}
+		last = start
+	}
+	add(first, last, foldComment)
+	return ranges
+}
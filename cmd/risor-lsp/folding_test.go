@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []protocol.FoldingRange
+	}{
+		{"empty", "", []protocol.FoldingRange{}},
+		{"single line block", "func f() { return 1 }", []protocol.FoldingRange{}},
+		{
+			"block",
+			"func f() {\n  x := 1\n  return x\n}",
+			[]protocol.FoldingRange{{StartLine: 0, EndLine: 2}},
+		},
+		{
+			"nested blocks",
+			"func f() {\n  if true {\n    return 1\n  }\n}",
+			[]protocol.FoldingRange{
+				{StartLine: 1, EndLine: 2},
+				{StartLine: 0, EndLine: 3},
+			},
+		},
+		{
+			"brackets opened on the same line",
+			"x := [{\n  \"a\": 1\n}, {\n  \"b\": 2\n}]",
+			[]protocol.FoldingRange{
+				{StartLine: 0, EndLine: 3},
+				{StartLine: 2, EndLine: 3},
+			},
+		},
+		{
+			"imports",
+			"import os\nimport math\nfrom a import b\n\nimport json",
+			[]protocol.FoldingRange{{StartLine: 0, EndLine: 2, Kind: foldImports}},
+		},
+		{
+			"line comments",
+			"// one\n// two\nx := 1 // three\n// four",
+			[]protocol.FoldingRange{{StartLine: 0, EndLine: 1, Kind: foldComment}},
+		},
+		{
+			"block comment",
+			"/* one\ntwo\n*/\nx := 1",
+			[]protocol.FoldingRange{{StartLine: 0, EndLine: 2, Kind: foldComment}},
+		},
+		{
+			"lexical error",
+			"func f() {\n  x := 1\n}\ny := \"unterminated",
+			[]protocol.FoldingRange{{StartLine: 0, EndLine: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, foldingRanges(tt.text))
+		})
+	}
+}
@@ -2,10 +2,132 @@ package main
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/format"
 )
 
+// maxDiffCells bounds the size of the table used to diff the lines of a
+// document. Larger changes are sent as a single edit.
+const maxDiffCells = 4_000_000
+
 func (s *Server) Formatting(ctx context.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
-	return nil, nil
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(ctx, doc.item.Text)
+	if err != nil {
+		// The document does not parse; leave it alone
+		return nil, nil
+	}
+	return lineEdits(doc.item.Text, formatted), nil
+}
+
+func (s *Server) RangeFormatting(ctx context.Context, params *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(ctx, doc.item.Text)
+	if err != nil {
+		return nil, nil
+	}
+	// Formatting depends on the surrounding code, so the whole document is
+	// formatted and only the edits touching the requested lines are kept.
+	var edits []protocol.TextEdit
+	for _, edit := range lineEdits(doc.item.Text, formatted) {
+		if edit.Range.End.Line < params.Range.Start.Line || edit.Range.Start.Line > params.Range.End.Line {
+			continue
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// lineEdits returns the edits that turn old into new. Each edit replaces whole
+// lines, so its positions never point into the middle of a line.
+func lineEdits(old, new string) []protocol.TextEdit {
+	a, b := splitLines(old), splitLines(new)
+
+	// Trim the lines the two versions have in common at each end
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return []protocol.TextEdit{lineEdit(prefix, prefix+len(a), b)}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Walk the table, emitting an edit for each run of differing lines
+	var edits []protocol.TextEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		startA, startB := i, j
+		for i < len(a) || j < len(b) {
+			if i < len(a) && j < len(b) && a[i] == b[j] {
+				break
+			}
+			if j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else {
+				j++
+			}
+		}
+		edits = append(edits, lineEdit(prefix+startA, prefix+i, b[startB:j]))
+	}
+	return edits
+}
+
+// lineEdit returns an edit replacing lines [start, end) with the given lines.
+func lineEdit(start, end int, lines []string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(start)},
+			End:   protocol.Position{Line: uint32(end)},
+		},
+		NewText: strings.Join(lines, ""),
+	}
+}
+
+// splitLines splits text into lines, keeping the line terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
 }
@@ -0,0 +1,173 @@
+// Command gensignatures generates the signatures of module functions used by
+// the language server for signature help. They are read from the "Function
+// signature" blocks of the module documentation.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var moduleName = regexp.MustCompile(`NewBuiltinsModule\(\s*"([a-z0-9_]+)"`)
+
+type signature struct {
+	name  string
+	label string
+	doc   string
+}
+
+func main() {
+	modules := flag.String("modules", "../../modules", "Path to directory of modules")
+	out := flag.String("out", "signatures_gen.go", "Output file")
+	flag.Parse()
+	if err := run(*modules, *out); err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(modules, out string) error {
+	docs, err := filepath.Glob(filepath.Join(modules, "*", "*.md"))
+	if err != nil {
+		return err
+	}
+	var signatures []signature
+	for _, path := range docs {
+		module, err := findModuleName(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		found, err := readSignatures(path, module)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		signatures = append(signatures, found...)
+	}
+	sort.SliceStable(signatures, func(i, j int) bool {
+		return signatures[i].name < signatures[j].name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gensignatures; DO NOT EDIT.\n\n")
+	buf.WriteString("package main\n\n")
+	buf.WriteString("// moduleSignatures holds the signatures of module functions, and of\n")
+	buf.WriteString("// modules that may be called, keyed by the name they are called by.\n")
+	buf.WriteString("var moduleSignatures = map[string][]signature{\n")
+	for i, sig := range signatures {
+		if i == 0 || signatures[i-1].name != sig.name {
+			fmt.Fprintf(&buf, "%q: {\n", sig.name)
+		}
+		fmt.Fprintf(&buf, "{label: %q, doc: %q},\n", sig.label, sig.doc)
+		if i == len(signatures)-1 || signatures[i+1].name != sig.name {
+			buf.WriteString("},\n")
+		}
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
+
+// findModuleName returns the name a module is registered under, or an empty
+// string if the directory does not define a module.
+func findModuleName(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if m := moduleName.FindSubmatch(src); m != nil {
+			return string(m[1]), nil
+		}
+	}
+	return "", nil
+}
+
+// readSignatures returns the signatures documented in a module's markdown.
+// Signatures under the "Functions" heading belong to the module, while
+// signatures elsewhere are of callable modules and builtins.
+func readSignatures(path, module string) ([]signature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var result []signature
+	var section string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "## ") {
+			section = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			continue
+		}
+		if !strings.HasPrefix(line, "```") || !strings.Contains(line, `filename="Function signature"`) {
+			continue
+		}
+		i++
+		if i >= len(lines) {
+			break
+		}
+		label := strings.TrimSpace(lines[i])
+		paren := strings.Index(label, "(")
+		if paren <= 0 {
+			continue
+		}
+		name := label[:paren]
+		if section == "Functions" && module != "" && !strings.Contains(name, ".") {
+			name = module + "." + name
+			label = module + "." + label
+		}
+		// Skip to the end of the code block, then take the paragraph that
+		// follows as the documentation
+		for i < len(lines) && !strings.HasPrefix(lines[i], "```") {
+			i++
+		}
+		var doc []string
+		for j := i + 1; j < len(lines); j++ {
+			text := strings.TrimSpace(lines[j])
+			if text == "" {
+				if len(doc) > 0 {
+					break
+				}
+				continue
+			}
+			if strings.HasPrefix(text, "```") || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "<") {
+				break
+			}
+			doc = append(doc, text)
+		}
+		result = append(result, signature{
+			name:  name,
+			label: label,
+			doc:   strings.Join(doc, " "),
+		})
+	}
+	return result, nil
+}
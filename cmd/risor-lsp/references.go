@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/lint"
+	"github.com/risor-io/risor/token"
+)
+
+func (s *Server) Definition(ctx context.Context, params *protocol.DefinitionParams) (protocol.Definition, error) {
+	_, sym, lines, err := s.symbolAt(params.TextDocument.URI, params.Position)
+	if err != nil || sym == nil || sym.Kind == "global" {
+		return nil, err
+	}
+	return protocol.Definition{{
+		URI:   params.TextDocument.URI,
+		Range: tokenRange(lines, sym.Declaration.StartPosition, sym.Declaration.EndPosition),
+	}}, nil
+}
+
+func (s *Server) References(ctx context.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+	_, sym, lines, err := s.symbolAt(params.TextDocument.URI, params.Position)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	locations := []protocol.Location{}
+	for _, tok := range occurrences(lines, sym) {
+		if tok == sym.Declaration && !params.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, protocol.Location{
+			URI:   params.TextDocument.URI,
+			Range: tokenRange(lines, tok.StartPosition, tok.EndPosition),
+		})
+	}
+	return locations, nil
+}
+
+func (s *Server) PrepareRename(ctx context.Context, params *protocol.PrepareRenameParams) (*protocol.Range, error) {
+	_, sym, lines, err := s.symbolAt(params.TextDocument.URI, params.Position)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	if sym.Kind == "global" {
+		return nil, fmt.Errorf("cannot rename global %s", sym.Name)
+	}
+	line, column := runeColumn(lines, params.Position)
+	for _, tok := range occurrences(lines, sym) {
+		if covers(tok, line, column) {
+			rng := tokenRange(lines, tok.StartPosition, tok.EndPosition)
+			return &rng, nil
+		}
+	}
+	return nil, nil
+}
+
+// Rename renames a variable, function, or other name declared in the
+// document, along with every reference to it in the same scope. Renaming an
+// import without an alias adds one, since the imported name itself cannot
+// change.
+func (s *Server) Rename(ctx context.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+	doc, sym, lines, err := s.symbolAt(params.TextDocument.URI, params.Position)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	if sym.Kind == "global" {
+		return nil, fmt.Errorf("cannot rename global %s", sym.Name)
+	}
+	if !isIdentifier(params.NewName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", params.NewName)
+	}
+	var edits []protocol.TextEdit
+	for _, tok := range occurrences(lines, sym) {
+		rng := tokenRange(lines, tok.StartPosition, tok.EndPosition)
+		if tok == sym.Declaration && sym.Unaliased {
+			edits = append(edits, protocol.TextEdit{
+				Range:   protocol.Range{Start: rng.End, End: rng.End},
+				NewText: " as " + params.NewName,
+			})
+			continue
+		}
+		edits = append(edits, protocol.TextEdit{Range: rng, NewText: params.NewName})
+	}
+	return &protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.TextDocumentEdit{{
+			TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+				Version:                doc.item.Version,
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: params.TextDocument.URI},
+			},
+			Edits: edits,
+		}},
+	}, nil
+}
+
+// symbolAt returns the symbol referred to by the identifier at a position in
+// a document, or nil if there is no identifier there. The document must
+// parse, since otherwise its AST does not match its text.
+func (s *Server) symbolAt(uri protocol.DocumentURI, pos protocol.Position) (*document, *lint.Symbol, []string, error) {
+	doc, err := s.cache.get(uri)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if doc.err != nil {
+		return nil, nil, nil, errors.New("the document has syntax errors")
+	}
+	if doc.ast == nil {
+		return doc, nil, nil, nil
+	}
+	lines := strings.Split(doc.item.Text, "\n")
+	line, column := runeColumn(lines, pos)
+	for _, sym := range lint.Symbols(doc.ast, lint.WithGlobalNames(globalNames(doc))) {
+		for _, tok := range occurrences(lines, sym) {
+			if covers(tok, line, column) {
+				return doc, sym, lines, nil
+			}
+		}
+	}
+	return doc, nil, lines, nil
+}
+
+// covers returns true if an identifier spans the given line and column, or
+// ends just before it.
+func covers(tok token.Token, line, column int) bool {
+	return tok.StartPosition.Line == line &&
+		column >= tok.StartPosition.Column &&
+		column <= tok.EndPosition.Column+1
+}
+
+// occurrences returns the identifiers that refer to a symbol, including
+// those in template strings.
+func occurrences(lines []string, sym *lint.Symbol) []token.Token {
+	tokens := append([]token.Token(nil), sym.References...)
+	for _, tmpl := range sym.Templates {
+		tokens = append(tokens, templateIdents(lines, tmpl, sym.Name)...)
+	}
+	return tokens
+}
+
+// templateIdents finds the identifiers with the given name in the
+// expressions of a template string. The expressions are read from the
+// document text the way the parser reads them: each is enclosed in braces,
+// doubled braces outside expressions are literal braces, and an expression
+// ends at the first closing brace that is outside of nested braces and
+// string literals. Attribute names are not included.
+func templateIdents(lines []string, str token.Token, name string) []token.Token {
+	type char struct {
+		r            rune
+		line, column int
+	}
+	// The characters between the quotes, with their positions in runes
+	var chars []char
+	for line := str.StartPosition.Line; line <= str.EndPosition.Line && line < len(lines); line++ {
+		for column, r := range []rune(lines[line]) {
+			if line == str.StartPosition.Line && column <= str.StartPosition.Column {
+				continue
+			}
+			if line == str.EndPosition.Line && column >= str.EndPosition.Column {
+				break
+			}
+			chars = append(chars, char{r, line, column})
+		}
+		chars = append(chars, char{'\n', line, len([]rune(lines[line]))})
+	}
+	runes := make([]rune, len(chars))
+	for i, c := range chars {
+		runes[i] = c.r
+	}
+	var result []token.Token
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '{' {
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == '{' {
+			i++
+			continue
+		}
+		end, ok := templateExprEnd(runes[i+1:])
+		if !ok {
+			break
+		}
+		expr := chars[i+1 : i+1+end]
+		// The lexer reports the line and the column in runes of each token
+		// within the expression. lineStarts maps them back to expr.
+		lineStarts := []int{0}
+		for k, c := range expr {
+			if c.r == '\n' {
+				lineStarts = append(lineStarts, k+1)
+			}
+		}
+		l := lexer.New(string(runes[i+1 : i+1+end]))
+		var prev token.Token
+		for {
+			tok, err := l.Next()
+			if err != nil || tok.Type == token.EOF {
+				break
+			}
+			if tok.Type == token.IDENT && tok.Literal == name && prev.Type != token.PERIOD {
+				offset := lineStarts[tok.StartPosition.Line] + tok.StartPosition.Column
+				start := expr[offset]
+				last := expr[offset+len([]rune(name))-1]
+				result = append(result, token.Token{
+					Type:          token.IDENT,
+					Literal:       name,
+					StartPosition: token.Position{Line: start.line, Column: start.column},
+					EndPosition:   token.Position{Line: last.line, Column: last.column},
+				})
+			}
+			prev = tok
+		}
+		i += end + 1
+	}
+	return result
+}
+
+// templateExprEnd returns the index of the brace that closes a template
+// expression, given the runes that follow its opening brace. Braces
+// nested in the expression and characters in string literals are skipped,
+// as they are by the parser.
+func templateExprEnd(runes []rune) (int, bool) {
+	var depth int
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i, true
+			}
+			depth--
+		}
+	}
+	return 0, false
+}
+
+// runeColumn converts an LSP position, which counts columns in UTF-16 code
+// units, to a line and a column counted in runes.
+func runeColumn(lines []string, pos protocol.Position) (int, int) {
+	line := int(pos.Line)
+	if line >= len(lines) {
+		return line, int(pos.Character)
+	}
+	column, character := 0, 0
+	for _, r := range lines[line] {
+		if character >= int(pos.Character) {
+			break
+		}
+		if r >= 0x10000 {
+			character += 2
+		} else {
+			character++
+		}
+		column++
+	}
+	return line, column
+}
+
+// isIdentifier returns true if the name is lexed as a single identifier.
+func isIdentifier(name string) bool {
+	l := lexer.New(name)
+	tok, err := l.Next()
+	if err != nil || tok.Type != token.IDENT || tok.Literal != name {
+		return false
+	}
+	tok, err = l.Next()
+	return err == nil && tok.Type == token.EOF
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/lint"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+const testURI = protocol.DocumentURI("file:///test.risor")
+
+// newTestServer returns a server with a parsed document in its cache.
+func newTestServer(t *testing.T, text string) *Server {
+	t.Helper()
+	program, err := parser.Parse(context.Background(), text)
+	require.Nil(t, err)
+	s := &Server{cache: newCache()}
+	require.Nil(t, s.cache.put(&document{
+		item:                 protocol.TextDocumentItem{URI: testURI, Text: text, Version: 1},
+		ast:                  program,
+		linesChangedSinceAST: map[int]bool{},
+	}))
+	return s
+}
+
+func TestTemplateIdents(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{"single", "x := 1\nprint('{x}')", []string{"1:8-1:8"}},
+		{"several", "x := 1\nprint('{x} and {x + 1}')", []string{"1:8-1:8", "1:16-1:16"}},
+		{"escaped braces", "x := 1\nprint('{{x}} {x}')", []string{"1:14-1:14"}},
+		{"attribute", "x := {}\nprint('{x.x}')", []string{"1:8-1:8"}},
+		{"longer name", "xs := 1\nprint('{xs}')", []string{"1:8-1:9"}},
+		{"unicode", "x := 1\nprint('é {x}')", []string{"1:10-1:10"}},
+		{"map literal", "x := 1\nprint('{ {\"a\": x}[\"a\"] } {x}')", []string{"1:15-1:15", "1:26-1:26"}},
+		{"brace in string", "x := 1\nprint('{\"}\" + x}')", []string{"1:14-1:14"}},
+		{"unicode in expression", "x := 1\nprint('{\"é😀\" + x}')", []string{"1:15-1:15"}},
+		{"map key and attribute", "x := 1\nprint('{ {\"x\": x}.x }')", []string{"1:15-1:15"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := parser.Parse(context.Background(), tt.source)
+			require.Nil(t, err)
+			lines := strings.Split(tt.source, "\n")
+			var sym *lint.Symbol
+			for _, s := range lint.Symbols(program) {
+				if s.Kind != "global" {
+					sym = s
+					break
+				}
+			}
+			require.NotNil(t, sym)
+			require.Len(t, sym.Templates, 1)
+			var got []string
+			for _, tok := range templateIdents(lines, sym.Templates[0], sym.Name) {
+				got = append(got, fmt.Sprintf("%d:%d-%d:%d",
+					tok.StartPosition.Line, tok.StartPosition.Column,
+					tok.EndPosition.Line, tok.EndPosition.Column))
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRuneColumn(t *testing.T) {
+	lines := []string{"abc", "é x", "😀 x", "😀😀x"}
+	tests := []struct {
+		line, character uint32
+		expectedColumn  int
+	}{
+		{0, 0, 0},
+		{0, 2, 2},
+		{0, 3, 3},
+		{1, 2, 2},
+		{2, 2, 1},
+		{2, 3, 2},
+		{3, 4, 2},
+		{3, 5, 3},
+		// Beyond the end of a line or of the document
+		{0, 10, 3},
+		{8, 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d:%d", tt.line, tt.character), func(t *testing.T) {
+			line, column := runeColumn(lines, protocol.Position{Line: tt.line, Character: tt.character})
+			require.Equal(t, int(tt.line), line)
+			require.Equal(t, tt.expectedColumn, column)
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		position protocol.Position
+		newName  string
+		expected []string
+	}{
+		{
+			name:     "variable",
+			source:   "x := 1\nfunc f() { return x + 1 }\nprint('{x}')",
+			position: protocol.Position{Line: 0, Character: 0},
+			newName:  "count",
+			expected: []string{
+				"0:0-0:1 count",
+				"1:18-1:19 count",
+				"2:8-2:9 count",
+			},
+		},
+		{
+			name:     "parameter",
+			source:   "x := 1\nfunc f(x) { return x }\nf(x)",
+			position: protocol.Position{Line: 1, Character: 19},
+			newName:  "y",
+			expected: []string{"1:7-1:8 y", "1:19-1:20 y"},
+		},
+		{
+			name:     "unaliased import",
+			source:   "import math\nmath.sqrt(4)",
+			position: protocol.Position{Line: 1, Character: 2},
+			newName:  "m",
+			expected: []string{"0:11-0:11  as m", "1:0-1:4 m"},
+		},
+		{
+			name:     "aliased import",
+			source:   "import math as mth\nmth.sqrt(4)",
+			position: protocol.Position{Line: 0, Character: 16},
+			newName:  "m",
+			expected: []string{"0:15-0:18 m", "1:0-1:3 m"},
+		},
+		{
+			name:     "template with a map literal",
+			source:   "x := 1\nprint('😀{ {\"k\": x}[\"k\"] }')",
+			position: protocol.Position{Line: 0, Character: 0},
+			newName:  "y",
+			expected: []string{"0:0-0:1 y", "1:17-1:18 y"},
+		},
+		{
+			name:     "utf-16 columns",
+			source:   "s := '😀'; x := 1\nx",
+			position: protocol.Position{Line: 1, Character: 0},
+			newName:  "y",
+			expected: []string{"0:11-0:12 y", "1:0-1:1 y"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.source)
+			edit, err := s.Rename(context.Background(), &protocol.RenameParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: testURI},
+				Position:     tt.position,
+				NewName:      tt.newName,
+			})
+			require.Nil(t, err)
+			require.NotNil(t, edit)
+			require.Len(t, edit.DocumentChanges, 1)
+			require.Equal(t, int32(1), edit.DocumentChanges[0].TextDocument.Version)
+			var got []string
+			for _, e := range edit.DocumentChanges[0].Edits {
+				got = append(got, fmt.Sprintf("%d:%d-%d:%d %s",
+					e.Range.Start.Line, e.Range.Start.Character,
+					e.Range.End.Line, e.Range.End.Character, e.NewText))
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	s := newTestServer(t, "x := 1\nprint(x)")
+	rename := func(pos protocol.Position, newName string) error {
+		_, err := s.Rename(context.Background(), &protocol.RenameParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: testURI},
+			Position:     pos,
+			NewName:      newName,
+		})
+		return err
+	}
+	err := rename(protocol.Position{Line: 1, Character: 1}, "p")
+	require.NotNil(t, err)
+	require.Equal(t, "cannot rename global print", err.Error())
+
+	err = rename(protocol.Position{Line: 0, Character: 0}, "not valid")
+	require.NotNil(t, err)
+	require.Equal(t, `"not valid" is not a valid identifier`, err.Error())
+
+	err = rename(protocol.Position{Line: 0, Character: 0}, "func")
+	require.NotNil(t, err)
+	require.Equal(t, `"func" is not a valid identifier`, err.Error())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/lint"
+	"github.com/risor-io/risor/object"
+)
+
+// semanticTokenTypes and semanticTokenModifiers make up the legend of the
+// semantic tokens. Tokens refer to types by index and to modifiers by bit.
+var (
+	semanticTokenTypes     = []string{"namespace", "function", "parameter", "variable", "struct", "enum"}
+	semanticTokenModifiers = []string{"declaration", "readonly", "defaultLibrary"}
+)
+
+const (
+	tokenNamespace uint32 = iota
+	tokenFunction
+	tokenParameter
+	tokenVariable
+	tokenStruct
+	tokenEnum
+)
+
+const (
+	modDeclaration uint32 = 1 << iota
+	modReadonly
+	modDefaultLibrary
+)
+
+// cliFunctions are the CLI globals that are functions rather than modules.
+var cliFunctions = map[string]bool{
+	"jmespath": true,
+	"render":   true,
+}
+
+// semanticToken is an identifier classified by what it refers to.
+type semanticToken struct {
+	line      int
+	start     int
+	length    int
+	typ       uint32
+	modifiers uint32
+}
+
+func (s *Server) SemanticTokensFull(ctx context.Context, params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.SemanticTokens{Data: encodeSemanticTokens(semanticTokens(doc, -1, -1))}, nil
+}
+
+func (s *Server) SemanticTokensRange(ctx context.Context, params *protocol.SemanticTokensRangeParams) (*protocol.SemanticTokens, error) {
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	tokens := semanticTokens(doc, int(params.Range.Start.Line), int(params.Range.End.Line))
+	return &protocol.SemanticTokens{Data: encodeSemanticTokens(tokens)}, nil
+}
+
+// semanticTokens classifies the identifiers of a document that are within
+// the given lines, or all of them if the lines are negative. A document that
+// does not parse has no tokens, since its AST does not match its text.
+func semanticTokens(doc *document, first, last int) []semanticToken {
+	if doc.err != nil || doc.ast == nil {
+		return nil
+	}
+	lines := strings.Split(doc.item.Text, "\n")
+	globals := risor.NewConfig().Globals()
+	var tokens []semanticToken
+	for _, sym := range lint.Symbols(doc.ast, lint.WithGlobalNames(globalNames(doc))) {
+		typ, modifiers := classify(sym, globals)
+		for _, tok := range occurrences(lines, sym) {
+			line := tok.StartPosition.Line
+			if first >= 0 && (line < first || line > last) {
+				continue
+			}
+			start := position(lines, line, tok.StartPosition.Column)
+			end := position(lines, line, tok.EndPosition.Column+1)
+			t := semanticToken{
+				line:      line,
+				start:     int(start.Character),
+				length:    int(end.Character - start.Character),
+				typ:       typ,
+				modifiers: modifiers,
+			}
+			if tok == sym.Declaration {
+				t.modifiers |= modDeclaration
+			}
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// classify returns the token type and modifiers of a symbol.
+func classify(sym *lint.Symbol, globals map[string]any) (uint32, uint32) {
+	switch sym.Kind {
+	case "parameter":
+		return tokenParameter, 0
+	case "constant":
+		return tokenVariable, modReadonly
+	case "function":
+		return tokenFunction, 0
+	case "struct":
+		return tokenStruct, 0
+	case "enum":
+		return tokenEnum, 0
+	case "import":
+		return tokenNamespace, 0
+	case "global":
+		switch globals[sym.Name].(type) {
+		case *object.Builtin:
+			return tokenFunction, modDefaultLibrary
+		case *object.Module:
+			return tokenNamespace, modDefaultLibrary
+		}
+		if cliFunctions[sym.Name] {
+			return tokenFunction, modDefaultLibrary
+		}
+		return tokenNamespace, modDefaultLibrary
+	default:
+		return tokenVariable, 0
+	}
+}
+
+// encodeSemanticTokens encodes tokens in the relative format of the LSP, in
+// which each token is five integers: the line relative to the previous token,
+// the start character relative to the previous token if on the same line,
+// the length, the type, and the modifiers.
+func encodeSemanticTokens(tokens []semanticToken) []uint32 {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].line != tokens[j].line {
+			return tokens[i].line < tokens[j].line
+		}
+		return tokens[i].start < tokens[j].start
+	})
+	data := make([]uint32, 0, len(tokens)*5)
+	prevLine, prevStart := 0, 0
+	for i, t := range tokens {
+		if i > 0 && t.line == tokens[i-1].line && t.start == tokens[i-1].start {
+			continue
+		}
+		deltaStart := t.start
+		if t.line == prevLine {
+			deltaStart = t.start - prevStart
+		}
+		data = append(data,
+			uint32(t.line-prevLine),
+			uint32(deltaStart),
+			uint32(t.length),
+			t.typ,
+			t.modifiers,
+		)
+		prevLine, prevStart = t.line, t.start
+	}
+	return data
+}
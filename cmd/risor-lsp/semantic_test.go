@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeSemanticTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokens   []semanticToken
+		expected []uint32
+	}{
+		{"empty", nil, []uint32{}},
+		{
+			"single",
+			[]semanticToken{{line: 2, start: 4, length: 3, typ: tokenVariable}},
+			[]uint32{2, 4, 3, tokenVariable, 0},
+		},
+		{
+			"same line",
+			[]semanticToken{
+				{line: 0, start: 0, length: 1, typ: tokenVariable, modifiers: modDeclaration},
+				{line: 0, start: 5, length: 4, typ: tokenFunction, modifiers: modDefaultLibrary},
+			},
+			[]uint32{
+				0, 0, 1, tokenVariable, modDeclaration,
+				0, 5, 4, tokenFunction, modDefaultLibrary,
+			},
+		},
+		{
+			"later lines are relative to the start of the line",
+			[]semanticToken{
+				{line: 0, start: 6, length: 1, typ: tokenParameter},
+				{line: 3, start: 2, length: 1, typ: tokenParameter},
+				{line: 3, start: 10, length: 2, typ: tokenEnum},
+			},
+			[]uint32{
+				0, 6, 1, tokenParameter, 0,
+				3, 2, 1, tokenParameter, 0,
+				0, 8, 2, tokenEnum, 0,
+			},
+		},
+		{
+			"unsorted",
+			[]semanticToken{
+				{line: 1, start: 4, length: 1, typ: tokenVariable},
+				{line: 0, start: 0, length: 2, typ: tokenNamespace},
+				{line: 1, start: 0, length: 1, typ: tokenStruct},
+			},
+			[]uint32{
+				0, 0, 2, tokenNamespace, 0,
+				1, 0, 1, tokenStruct, 0,
+				0, 4, 1, tokenVariable, 0,
+			},
+		},
+		{
+			"duplicates are dropped",
+			[]semanticToken{
+				{line: 0, start: 0, length: 1, typ: tokenVariable, modifiers: modReadonly},
+				{line: 0, start: 0, length: 1, typ: tokenVariable},
+			},
+			[]uint32{0, 0, 1, tokenVariable, modReadonly},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, encodeSemanticTokens(tt.tokens))
+		})
+	}
+}
@@ -15,11 +15,30 @@ type Server struct {
 	cache   *cache
 }
 
-func (s *Server) queueDiagnostics(uri protocol.DocumentURI) {}
-
 func (s *Server) DidChange(ctx context.Context, params *protocol.DidChangeTextDocumentParams) error {
 	defer s.queueDiagnostics(params.TextDocument.URI)
-	return nil
+	if len(params.ContentChanges) == 0 {
+		return nil
+	}
+	old, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	// Full sync is requested, so the last change holds the whole document
+	doc := &document{
+		item:                 old.item,
+		ast:                  old.ast,
+		linesChangedSinceAST: map[int]bool{},
+	}
+	doc.item.Text = params.ContentChanges[len(params.ContentChanges)-1].Text
+	doc.item.Version = params.TextDocument.Version
+	program, err := parser.Parse(ctx, doc.item.Text)
+	if err != nil {
+		doc.err = err
+	} else {
+		doc.ast = program
+	}
+	return s.cache.put(doc)
 }
 
 func (s *Server) DidOpen(ctx context.Context, params *protocol.DidOpenTextDocumentParams) (err error) {
@@ -47,10 +66,25 @@ func (s *Server) Initialize(ctx context.Context, params *protocol.ParamInitializ
 			CompletionProvider: protocol.CompletionOptions{
 				TriggerCharacters: []string{"."},
 			},
-			HoverProvider:              true,
-			DefinitionProvider:         true,
-			DocumentFormattingProvider: true,
-			DocumentSymbolProvider:     true,
+			HoverProvider:                   true,
+			DefinitionProvider:              true,
+			ReferencesProvider:              true,
+			RenameProvider:                  protocol.RenameOptions{PrepareProvider: true},
+			DocumentFormattingProvider:      true,
+			DocumentRangeFormattingProvider: true,
+			DocumentSymbolProvider:          true,
+			FoldingRangeProvider:            true,
+			SignatureHelpProvider: protocol.SignatureHelpOptions{
+				TriggerCharacters: []string{"(", ","},
+			},
+			SemanticTokensProvider: protocol.SemanticTokensOptions{
+				Legend: protocol.SemanticTokensLegend{
+					TokenTypes:     semanticTokenTypes,
+					TokenModifiers: semanticTokenModifiers,
+				},
+				Full:  true,
+				Range: true,
+			},
 			ExecuteCommandProvider: protocol.ExecuteCommandOptions{
 				Commands: []string{},
 			},
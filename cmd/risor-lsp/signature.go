@@ -0,0 +1,283 @@
+package main
+
+//go:generate go run ./gensignatures -modules ../../modules -out signatures_gen.go
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/token"
+)
+
+// signature describes how a function is called.
+type signature struct {
+	label string
+	doc   string
+}
+
+// builtinSignatures holds the signatures of the default builtin functions.
+var builtinSignatures = map[string]signature{
+	"all":         {"all(container) bool", "Returns true if all items in the container are truthy."},
+	"any":         {"any(container) bool", "Returns true if any item in the container is truthy."},
+	"assert":      {"assert(value, message string)", "Raises an error with the optional message if the value is not truthy."},
+	"bool":        {"bool(value) bool", "Returns the truthiness of the value, or false if no value is given."},
+	"buffer":      {"buffer(value) buffer", "Returns a new buffer, optionally holding a copy of the string or byte_slice value."},
+	"byte":        {"byte(value) byte", "Converts the value to a byte."},
+	"byte_slice":  {"byte_slice(value) byte_slice", "Converts the value to a byte_slice."},
+	"call":        {"call(fn, ...args)", "Calls the function with the given arguments."},
+	"cat":         {"cat(...paths string) string", "Returns the concatenated contents of the files."},
+	"cd":          {"cd(path string)", "Changes the current working directory."},
+	"chan":        {"chan(size int) chan", "Returns a new channel with the given buffer size."},
+	"chr":         {"chr(code int) string", "Returns the character with the given Unicode code point."},
+	"chunk":       {"chunk(list, size int) list", "Splits the list into lists of at most size items."},
+	"close":       {"close(ch chan)", "Closes the channel."},
+	"coalesce":    {"coalesce(...values)", "Returns the first value that is not nil."},
+	"cp":          {"cp(src string, dst string)", "Copies the file or directory at src to dst."},
+	"decode":      {"decode(data, codec string)", "Decodes the data using the named codec, such as \"base64\", \"hex\", or \"json\"."},
+	"delete":      {"delete(container, key)", "Removes the key from the map or set."},
+	"encode":      {"encode(value, codec string)", "Encodes the value using the named codec, such as \"base64\", \"hex\", or \"json\"."},
+	"error":       {"error(message string, ...args) error", "Returns a new error with the message, formatted with the arguments."},
+	"errorf":      {"errorf(format string, ...args) error", "Returns a new error with the message formatted with the arguments."},
+	"fetch":       {"fetch(url string, options map) http.response", "Sends an HTTP request and returns the response."},
+	"float":       {"float(value) float", "Converts the value to a float, or returns 0.0 if no value is given."},
+	"float_slice": {"float_slice(value) float_slice", "Converts the value to a float_slice."},
+	"format":      {"format(value, spec string) string", "Returns the value formatted according to the format spec, as in an f-string."},
+	"getattr":     {"getattr(object, name string, default)", "Returns the named attribute of the object, or the default if it has no such attribute."},
+	"getenv":      {"getenv(name string) string", "Returns the value of the environment variable."},
+	"hash":        {"hash(data, algorithm string = \"sha256\") byte_slice", "Returns the hash of the data using the named algorithm."},
+	"int":         {"int(value) int", "Converts the value to an int, or returns 0 if no value is given."},
+	"is_hashable": {"is_hashable(value) bool", "Returns true if the value can be used as a map key or set item."},
+	"iter":        {"iter(container) iter", "Returns an iterator over the container."},
+	"keys":        {"keys(container) list", "Returns the keys of the container as a list."},
+	"len":         {"len(container) int", "Returns the length of a string, list, map, set, or other container."},
+	"list":        {"list(container) list", "Returns a new list, optionally holding the items of the container."},
+	"ls":          {"ls(path string) list", "Returns the entries of the directory, which defaults to the current directory."},
+	"make":        {"make(type, size int)", "Returns a new list, map, set, or chan, with the given size or capacity."},
+	"map":         {"map(container) map", "Returns a new map, optionally holding the items of the container."},
+	"nslookup":    {"nslookup(host string, type string, resolver string)", "Looks up DNS records for the host."},
+	"open":        {"open(path string) file", "Opens the file for reading."},
+	"ord":         {"ord(char string) int", "Returns the Unicode code point of the character."},
+	"print":       {"print(...values)", "Prints the values separated by spaces, followed by a newline."},
+	"printf":      {"printf(format string, ...args)", "Prints the arguments formatted according to the format string."},
+	"reversed":    {"reversed(container) list", "Returns a reversed copy of the list or string."},
+	"set":         {"set(container) set", "Returns a new set, optionally holding the items of the container."},
+	"setenv":      {"setenv(name string, value string)", "Sets the value of the environment variable."},
+	"sorted":      {"sorted(container, fn func(a, b) bool) list", "Returns a sorted list of the items, optionally ordered by the less-than function."},
+	"spawn":       {"spawn(fn, ...args) thread", "Calls the function in a new goroutine and returns a thread that can be waited on."},
+	"sprintf":     {"sprintf(format string, ...args) string", "Returns the arguments formatted according to the format string."},
+	"string":      {"string(value) string", "Converts the value to a string, or returns \"\" if no value is given."},
+	"try":         {"try(...funcs)", "Calls each function in turn until one does not raise an error, and returns its result."},
+	"type":        {"type(value) string", "Returns the name of the type of the value."},
+	"unsetenv":    {"unsetenv(name string)", "Removes the environment variable."},
+}
+
+// SignatureHelp shows the signature of the function being called at the
+// cursor. Calls are found by lexing the text before the cursor, so that help
+// is available while the call is still being typed and does not parse.
+func (s *Server) SignatureHelp(ctx context.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(doc.item.Text, "\n")
+	name, active, ok := callAt(textBefore(lines, params.Position))
+	if !ok {
+		return nil, nil
+	}
+	signatures := lookupSignatures(doc.ast, name)
+	if len(signatures) == 0 {
+		return nil, nil
+	}
+	help := &protocol.SignatureHelp{}
+	for _, sig := range signatures {
+		info := protocol.SignatureInformation{
+			Label:         sig.label,
+			Documentation: sig.doc,
+		}
+		for _, param := range parameters(sig.label) {
+			info.Parameters = append(info.Parameters, protocol.ParameterInformation{Label: param})
+		}
+		help.Signatures = append(help.Signatures, info)
+	}
+	// Arguments past the last parameter belong to a variadic parameter
+	first := parameters(signatures[0].label)
+	if n := len(first); n > 0 && active >= n && strings.HasPrefix(first[n-1], "...") {
+		active = n - 1
+	}
+	help.ActiveParameter = uint32(active)
+	return help, nil
+}
+
+// textBefore returns the text of a document that precedes a position.
+func textBefore(lines []string, pos protocol.Position) string {
+	line, column := runeColumn(lines, pos)
+	if line >= len(lines) {
+		return strings.Join(lines, "\n")
+	}
+	var b strings.Builder
+	for _, text := range lines[:line] {
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	runes := []rune(lines[line])
+	if column > len(runes) {
+		column = len(runes)
+	}
+	b.WriteString(string(runes[:column]))
+	return b.String()
+}
+
+// callAt finds the innermost call that is open at the end of the text. It
+// returns the name of the function called, such as "len" or "strings.join",
+// and the index of the argument being typed. The value piped into a call is
+// its first argument.
+func callAt(text string) (string, int, bool) {
+	var tokens []token.Token
+	l := lexer.New(text)
+	for {
+		tok, err := l.Next()
+		if err != nil || tok.Type == token.EOF {
+			break
+		}
+		if tok.Type != token.NEWLINE {
+			tokens = append(tokens, tok)
+		}
+	}
+	type frame struct {
+		open   int
+		commas int
+	}
+	var stack []frame
+	for i, tok := range tokens {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			stack = append(stack, frame{open: i})
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case token.COMMA:
+			if len(stack) > 0 {
+				stack[len(stack)-1].commas++
+			}
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		f := stack[i]
+		if tokens[f.open].Type != token.LPAREN {
+			continue
+		}
+		// Collect the callee, which is a name or a dotted attribute path
+		var parts []string
+		j := f.open - 1
+		for j >= 0 && tokens[j].Type == token.IDENT {
+			parts = append([]string{tokens[j].Literal}, parts...)
+			if j == 0 || tokens[j-1].Type != token.PERIOD {
+				j--
+				break
+			}
+			j -= 2
+		}
+		if len(parts) == 0 {
+			// A parenthesized expression, which may be an argument
+			continue
+		}
+		if j >= 0 && tokens[j].Type == token.FUNC {
+			// A function declaration rather than a call
+			return "", 0, false
+		}
+		active := f.commas
+		if j >= 0 && tokens[j].Type == token.PIPE {
+			active++
+		}
+		return strings.Join(parts, "."), active, true
+	}
+	return "", 0, false
+}
+
+// lookupSignatures returns the signatures of a function declared at the top
+// level of the program, a module function, or a builtin. The program may be
+// out of date if the document does not currently parse.
+func lookupSignatures(program *ast.Program, name string) []signature {
+	if program != nil {
+		for _, stmt := range program.Statements() {
+			var fn *ast.Func
+			var fnName string
+			switch stmt := stmt.(type) {
+			case *ast.Func:
+				if stmt.Name() != nil {
+					fn, fnName = stmt, stmt.Name().Literal()
+				}
+			case *ast.Decorated:
+				fn, fnName = stmt.Func(), stmt.Func().Name().Literal()
+			case *ast.Var:
+				varName, value := stmt.Value()
+				if value, ok := value.(*ast.Func); ok {
+					fn, fnName = value, varName
+				}
+			}
+			if fn != nil && fnName == name {
+				return []signature{{label: funcLabel(name, fn)}}
+			}
+		}
+	}
+	if sigs, ok := moduleSignatures[name]; ok {
+		return sigs
+	}
+	if sig, ok := builtinSignatures[name]; ok {
+		return []signature{sig}
+	}
+	return nil
+}
+
+// funcLabel returns the signature label of a function declared in Risor.
+func funcLabel(name string, fn *ast.Func) string {
+	defaults := fn.Defaults()
+	var params []string
+	for _, param := range fn.Parameters() {
+		if expr, ok := defaults[param.Literal()]; ok {
+			params = append(params, param.Literal()+"="+expr.String())
+		} else {
+			params = append(params, param.Literal())
+		}
+	}
+	if rest := fn.RestParameter(); rest != nil {
+		params = append(params, "..."+rest.Literal())
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
+}
+
+// parameters splits the parameter list of a signature label at the commas
+// that are not nested in brackets.
+func parameters(label string) []string {
+	start := strings.Index(label, "(")
+	if start < 0 {
+		return nil
+	}
+	var params []string
+	depth := 0
+	begin := start + 1
+	for i := begin; i < len(label); i++ {
+		switch label[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				if param := strings.TrimSpace(label[begin:i]); param != "" {
+					params = append(params, param)
+				}
+				return params
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(label[begin:i]))
+				begin = i + 1
+			}
+		}
+	}
+	return params
+}
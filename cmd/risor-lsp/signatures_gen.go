@@ -0,0 +1,643 @@
+// Code generated by gensignatures; DO NOT EDIT.
+
+package main
+
+// moduleSignatures holds the signatures of module functions, and of
+// modules that may be called, keyed by the name they are called by.
+var moduleSignatures = map[string][]signature{
+	"aws.client": {
+		{label: "aws.client(service string, config map | aws.config) aws.client", doc: "Creates a new AWS client for the given service. The `config` parameter is optional and can be used to configure the client. All service API calls are made available on the client."},
+	},
+	"aws.config": {
+		{label: "aws.config(config map) aws.config", doc: "Creates a new AWS config with the given configuration map."},
+	},
+	"base64.decode": {
+		{label: "base64.decode(s string, pad bool) byte_slice", doc: "Decode base64 string s to a byte_slice, with padding if pad is true. If not provided, pad defaults to false."},
+	},
+	"base64.encode": {
+		{label: "base64.encode(b byte_slice, pad bool) string", doc: "Encode byte_slice b to a base64 string. The encoded string is padded if pad is true. If not provided, pad defaults to false."},
+	},
+	"base64.url_decode": {
+		{label: "base64.url_decode(s string, pad bool) byte_slice", doc: "Decode base64 string s to a byte slice using the alternate base64 codec. The string is understood to be padded if pad is true. If not provided, pad defaults to false."},
+	},
+	"base64.url_encode": {
+		{label: "base64.url_encode(b byte_slice, pad bool) string", doc: "Encode byte slice b to a base64 string using the alternate base64 codec. The encoded string is padded if pad is true. If not provided, pad defaults to false. The encoded string is safe for use in URLs and file names."},
+	},
+	"bcrypt.compare": {
+		{label: "bcrypt.compare(hash byte_slice, password string) bool", doc: "Compare the password with the bcrypt hash. Raises an error if the password does not match the hash."},
+	},
+	"bcrypt.hash": {
+		{label: "bcrypt.hash(password string, cost int = bcrypt.default_cost) byte_slice", doc: "Hash the password using bcrypt with the given cost. The cost is the number of rounds to use. The cost must be between bcrypt.min_cost and bcrypt.max_cost. If not provided, the cost defaults to bcrypt.default_cost (10)."},
+	},
+	"bytes.clone": {
+		{label: "bytes.clone(b byte_slice) byte_slice", doc: "Clone returns a new byte slice containing the same bytes as the given byte slice."},
+	},
+	"bytes.contains": {
+		{label: "bytes.contains(b, subslice byte_slice) bool", doc: "Contains reports whether subslice is within b."},
+	},
+	"bytes.contains_any": {
+		{label: "bytes.contains_any(b byte_slice, chars string) bool", doc: "Reports whether any of the UTF-8-encoded code points in the string are present in the byte_slice."},
+	},
+	"bytes.contains_rune": {
+		{label: "bytes.contains_rune(b byte_slice, r rune) bool", doc: "Reports whether the rune is contained in the UTF-8-encoded byte slice."},
+	},
+	"bytes.count": {
+		{label: "bytes.count(s, sep byte_slice) int", doc: "Counts the number of non-overlapping instances of sep in s. If sep is an empty slice, Count returns 1 + the number of UTF-8-encoded code points in s."},
+	},
+	"bytes.equals": {
+		{label: "bytes.equals(a, b byte_slice) bool", doc: "Reports whether a and b are the same length and contain the same bytes."},
+	},
+	"bytes.has_prefix": {
+		{label: "bytes.has_prefix(s byte_slice, prefix byte_slice) bool", doc: "Tests whether the byte slice s begins with prefix."},
+	},
+	"bytes.has_suffix": {
+		{label: "bytes.has_suffix(s byte_slice, suffix byte_slice) bool", doc: "Tests whether the byte slice s ends with suffix."},
+	},
+	"bytes.index": {
+		{label: "bytes.index(s, sep byte_slice) int", doc: "Returns the index of the first occurrence of sep in s, or -1 if sep is not present."},
+	},
+	"bytes.index_any": {
+		{label: "bytes.index_any(s byte_slice, chars string) int", doc: "Interprets s as a sequence of UTF-8-encoded code points. Returns the byte index of the first occurrence in s of any of the code points in chars. Returns -1 if chars is empty or if there are no code point in common."},
+	},
+	"bytes.index_byte": {
+		{label: "bytes.index_byte(b byte_slice, c byte) int", doc: "Returns the index of the first occurrence of c in b, or -1 if c is not present."},
+	},
+	"bytes.index_rune": {
+		{label: "bytes.index_rune(s byte_slice, r rune) int", doc: "Interprets s as a sequence of UTF-8-encoded code points. Returns the byte index of the first occurrence in s of the given rune. Returns -1 if rune is not present."},
+	},
+	"bytes.repeat": {
+		{label: "bytes.repeat(b byte_slice, count int) byte_slice", doc: "Returns a new byte slice consisting of count copies of b."},
+	},
+	"bytes.replace": {
+		{label: "bytes.replace(s, old, new byte_slice, n int) byte_slice", doc: "Returns a copy of the slice s with the first n non-overlapping instances of old replaced by new."},
+	},
+	"bytes.replace_all": {
+		{label: "bytes.replace_all(s, old, new byte_slice) byte_slice", doc: "Returns a copy of the slice s with all non-overlapping instances of old replaced by new."},
+	},
+	"carbon": {
+		{label: "carbon(input ...object)", doc: "The `carbon` module object itself is callable in order to provide a shorthand for initializing a new carbon object."},
+	},
+	"carbon.now": {
+		{label: "carbon.now(timezone ...string) carbon", doc: "Returns the current time as a carbon object. A timezone string may optionally be provided."},
+	},
+	"carbon.parse": {
+		{label: "carbon.parse(value, timezone ...string) carbon", doc: "Parses the time string into a carbon object. A timezone may optionally be provided. If the time string is invalid, an error is raised."},
+	},
+	"carbon.tomorrow": {
+		{label: "carbon.tomorrow(timezone ...string) carbon", doc: "Returns a time object for tomorrow, at the current time of day. A timezone may optionally be provided."},
+	},
+	"carbon.yesterday": {
+		{label: "carbon.yesterday(timezone ...string) carbon", doc: "Returns a time object for yesterday, at the current time of day. A timezone may optionally be provided."},
+	},
+	"cli.app": {
+		{label: "cli.app(options map) app", doc: "Returns a new app initialized with the given options. A simple app may consist of just a `name`, `description`, and `action` function. Call `.run()` on the app to run it."},
+	},
+	"cli.command": {
+		{label: "cli.command(options map) command", doc: "Returns a new command initialized with the given options. Commands are provided to an app via the app's `commands` option."},
+	},
+	"cli.flag": {
+		{label: "cli.flag(options map) flag", doc: "Returns a flag that may be used with an app or command. Supported flag types include `string`, `int`, `bool`, `float`, `string_slice`, `int_slice`, and `float_slice`."},
+	},
+	"color": {
+		{label: "color(options ...int) color.color", doc: "The `color` module object itself is callable, which is a shorthand for `color.color()`."},
+	},
+	"color.color": {
+		{label: "color.color(options ...int) color.color", doc: "Creates a new color object with the specified options."},
+	},
+	"color.set": {
+		{label: "color.set(options ...int)", doc: "Sets the terminal color to the specified options."},
+	},
+	"color.unset": {
+		{label: "color.unset()", doc: "Resets the terminal color to the default."},
+	},
+	"errors.new": {
+		{label: "errors.new(string) error", doc: "Returns a new error value with the given message."},
+	},
+	"exec": {
+		{label: "exec(args []string, opts map) result", doc: "The old form that is still supported for backwards compatibility is:"},
+		{label: "exec(name string, args []string, opts map) result", doc: "This provides a shorthand way to build and run a command. The function returns a `result` object containing the stdout and stderr produced by running the command. The `opts` argument is optional."},
+	},
+	"exec.command": {
+		{label: "exec.command(args []string) command", doc: "The old form that is still supported for backwards compatibility is:"},
+		{label: "exec.command(name string, args ...string) command", doc: "Creates a new command with the given name and arguments. The command can then be executed with its `run`, `start`, `output`, or `combined_output` methods. Before the command is run, its `path`, `dir`, and `env` attributes may be set. Read more about the [command](#command-1) type below."},
+	},
+	"exec.look_path": {
+		{label: "exec.look_path(name string) string", doc: "Searches for the named executable in the directories contained in the PATH environment variable. If the name contains a slash, it is tried directly, without consulting the PATH. Otherwise, the result is the absolute path to the named executable."},
+	},
+	"filepath.abs": {
+		{label: "filepath.abs(path string) string", doc: "Returns the absolute representation of path. If the path is not absolute it will be joined with the current working directory to create the corresponding absolute path."},
+	},
+	"filepath.base": {
+		{label: "filepath.base(path string) string", doc: "Returns the last element of path with any trailing slashes removed. If path is empty, \".\" is returned."},
+	},
+	"filepath.clean": {
+		{label: "filepath.clean(path string) string", doc: "Returns the shortest path name equivalent to path by purely lexical processing."},
+	},
+	"filepath.dir": {
+		{label: "filepath.dir(path string) string", doc: "Returns all but the last element of path, typically the path's directory."},
+	},
+	"filepath.ext": {
+		{label: "filepath.ext(path string) string", doc: "Returns the file name extension used by path. The extension is the suffix beginning at the final dot in the final element of path. The result it is empty if there is no dot."},
+	},
+	"filepath.is_abs": {
+		{label: "filepath.is_abs(path string) bool", doc: "Returns true if the path is absolute."},
+	},
+	"filepath.join": {
+		{label: "filepath.join(paths ...string) string", doc: "Returns the result of joining the given path elements with the operating system-specific path separator."},
+	},
+	"filepath.match": {
+		{label: "filepath.match(pattern, name string) bool", doc: "Returns true if the file name matches the shell pattern."},
+	},
+	"filepath.rel": {
+		{label: "filepath.rel(basepath, targpath string) string", doc: "Returns a relative path that is lexically equivalent to targpath when joined to basepath with an intervening separator."},
+	},
+	"filepath.split": {
+		{label: "filepath.split(path string) []string", doc: "Splits the path immediately following the final separator, returning a list of two items: the directory and the file name. If there is no separator in the path, an empty directory and the file name are returned."},
+	},
+	"filepath.split_list": {
+		{label: "filepath.split_list(path string) []string", doc: "Splits path immediately following the final separator, separating it into a directory and file name component. If there is no separator in path, split_list returns an empty dir and file set to path."},
+	},
+	"filepath.walk_dir": {
+		{label: "filepath.walk_dir(root string, fn func(path string))", doc: "Walks the file tree at root, calling fn for each file or directory in the tree, including root. Files are walked in lexical order. Symbolic links are not followed."},
+	},
+	"fmt.errorf": {
+		{label: "fmt.errorf(string, ...any) error", doc: "Returns a new error with the given message formatted according to the format."},
+	},
+	"fmt.print": {
+		{label: "fmt.print(...any)", doc: "Prints the given values to the standard output. Note that in Risor the output may not be printed to the terminal until a newline character is printed."},
+	},
+	"fmt.printf": {
+		{label: "fmt.printf(string, ...any)", doc: "Prints the formatted string to the standard output."},
+	},
+	"gha.add_path": {
+		{label: "gha.add_path(dir string)", doc: "Prepends directory to the PATH (for this action and future actions)"},
+	},
+	"gha.end_group": {
+		{label: "gha.end_group()", doc: "End an output group."},
+	},
+	"gha.is_debug": {
+		{label: "gha.is_debug() bool", doc: "Gets whether Actions Step Debug is on or not (by checking the `RUNNER_DEBUG` environment variable)"},
+	},
+	"gha.log_debug": {
+		{label: "gha.log_debug(msg string) bool", doc: "Writes debug message to log. This message is only shown when Action Step Debug is on. See also: [`gha.is_debug()`](#is_debug)"},
+	},
+	"gha.log_error": {
+		{label: "gha.log_error(msg string, props={})", doc: "Adds a error issue."},
+	},
+	"gha.log_notice": {
+		{label: "gha.log_notice(msg string, props={})", doc: "Adds a notice issue."},
+	},
+	"gha.log_warning": {
+		{label: "gha.log_warning(msg string, props={})", doc: "Adds a warning issue."},
+	},
+	"gha.set_env": {
+		{label: "gha.set_env(name string, value any)", doc: "Sets a GitHub Action environment variable for this action and future actions in the same job."},
+	},
+	"gha.set_output": {
+		{label: "gha.set_output(name string, value any)", doc: "Sets a GitHub Action output variable."},
+	},
+	"gha.start_group": {
+		{label: "gha.start_group(name string)", doc: "Begins an output group. Output until the next `end_group` will be foldable in this group."},
+	},
+	"http.delete": {
+		{label: "http.delete(url string, headers map, params map) request", doc: "Creates a new DELETE request with the given URL, headers, and query parameters. The headers and query parameters are optional."},
+	},
+	"http.get": {
+		{label: "http.get(url string, headers map, params map) request", doc: "Creates a new GET request with the given URL, headers, and query parameters. The headers and query parameters are optional. The request that is returned can then be executed using its `send` method. Read more about the [request](#request) type below."},
+	},
+	"http.head": {
+		{label: "http.head(url string, headers map, params map) request", doc: "Creates a new HEAD request with the given URL, headers, and query parameters. The headers and query parameters are optional."},
+	},
+	"http.listen_and_serve": {
+		{label: "http.listen_and_serve(addr string, handler func(w response_writer, r request))", doc: "Starts an HTTP server that listens on the specified address and calls the handler function to handle requests. As a convenience, the handler function may return a map or list object to be marshaled as JSON, or a string or byte slice object which will be written as the response body as-is."},
+	},
+	"http.listen_and_serve_tls": {
+		{label: "http.listen_and_serve_tls(addr, cert_file, key_file string, handler func(w response_writer, r request))", doc: "Acts the same as `listen_and_serve`, but uses the provided certificate and key files to work over HTTPS."},
+	},
+	"http.patch": {
+		{label: "http.patch(url string, headers map, body byte_slice) request", doc: "Creates a new PATCH request with the given URL, headers, and request body. The headers and request body parameters are optional."},
+	},
+	"http.post": {
+		{label: "http.post(url string, headers map, body byte_slice) request", doc: "Creates a new POST request with the given URL, headers, and request body. The headers and request body parameters are optional."},
+	},
+	"http.put": {
+		{label: "http.put(url string, headers map, body byte_slice) request", doc: "Creates a new PUT request with the given URL, headers, and request body. The headers and request body parameters are optional."},
+	},
+	"http.request": {
+		{label: "http.request(url string, options map) request", doc: "Creates a new request with the given URL and options. If provided, the options map may contain any of the following keys:"},
+	},
+	"image.decode": {
+		{label: "image.decode(b byte_slice) image", doc: "Returns an image object that is decoded from the given bytes. If a byte_buffer or io.Reader is given, it is automatically converted to a byte_slice."},
+	},
+	"image.encode": {
+		{label: "image.encode(img image, format string) byte_slice", doc: "Encodes the given image object into the given format, returning the encoded bytes."},
+	},
+	"isatty": {
+		{label: "isatty() bool", doc: "The `isatty` module object itself is callable, and returns a boolean indicating whether the process is connected to a terminal. This module-level function does not differentiate between cygwin and non-cygwin terminals, returning true in both cases."},
+	},
+	"isatty.is_cygwin_terminal": {
+		{label: "isatty.is_cygwin_terminal() bool", doc: "Returns true if the current process is connected to a Cygwin terminal."},
+	},
+	"isatty.is_terminal": {
+		{label: "isatty.is_terminal() bool", doc: "Returns true if the current process is connected to a terminal."},
+	},
+	"jmespath": {
+		{label: "jmespath(in object, expression string)", doc: "Returns the filtered object after the expression has been applied."},
+	},
+	"json.marshal": {
+		{label: "json.marshal(v object) string", doc: "Returns a JSON string representing the given value. Raises an error if the value cannot be marshalled."},
+	},
+	"json.unmarshal": {
+		{label: "json.unmarshal(s string) object", doc: "Returns the value represented by the given JSON string. Raises an error if the string cannot be unmarshalled."},
+	},
+	"json.valid": {
+		{label: "json.valid(s string) bool", doc: "Returns whether the given string is valid JSON."},
+	},
+	"k8s.apply": {
+		{label: "k8s.apply(manifest string, options object)", doc: "Can be used to apply (create or update) a kubernetes object from a JSON or YAML manifest"},
+	},
+	"k8s.delete": {
+		{label: "k8s.delete(kind string, options object) object", doc: "Can be used to delete a single object or a list of objects from the Kubernetes API."},
+	},
+	"k8s.get": {
+		{label: "k8s.get(kind string, options object) object", doc: "Can be used to get a single object or a list of objects from the Kubernetes API."},
+	},
+	"math.abs": {
+		{label: "math.abs(x number) number", doc: "Returns the absolute value of x."},
+	},
+	"math.ceil": {
+		{label: "math.ceil(x number) number", doc: "Returns the smallest integer value greater than or equal to x."},
+	},
+	"math.cos": {
+		{label: "math.cos(x number) float", doc: "Returns the cosine of x."},
+	},
+	"math.floor": {
+		{label: "math.floor(x number) number", doc: "Returns the largest integer value less than or equal to x."},
+	},
+	"math.is_inf": {
+		{label: "math.is_inf(x number) bool", doc: "Returns true if x is positive or negative infinity."},
+	},
+	"math.log": {
+		{label: "math.log(x number) float", doc: "Returns the natural logarithm of x."},
+	},
+	"math.log10": {
+		{label: "math.log10(x number) float", doc: "Returns the base 10 logarithm of x."},
+	},
+	"math.log2": {
+		{label: "math.log2(x number) float", doc: "Returns the base 2 logarithm of x."},
+	},
+	"math.max": {
+		{label: "math.max(x, y number) float", doc: "Returns the larger of x or y."},
+	},
+	"math.min": {
+		{label: "math.min(x, y number) float", doc: "Returns the smaller of x or y."},
+	},
+	"math.mod": {
+		{label: "math.mod(x, y number) float", doc: "Returns the remainder of x divided by y."},
+	},
+	"math.pow": {
+		{label: "math.pow(x, y number) float", doc: "Returns x raised to the power of y."},
+	},
+	"math.pow10": {
+		{label: "math.pow10(x number) float", doc: "Returns 10 raised to the power of x."},
+	},
+	"math.round": {
+		{label: "math.round(x number) float", doc: "Returns x rounded to the nearest integer."},
+	},
+	"math.sin": {
+		{label: "math.sin(x number) float", doc: "Returns the sine of x."},
+	},
+	"math.sqrt": {
+		{label: "math.sqrt(x number) float", doc: "Returns the square root of x."},
+	},
+	"math.sum": {
+		{label: "math.sum(list) float", doc: "Returns the sum of all numbers in a list."},
+	},
+	"math.tan": {
+		{label: "math.tan(x number) float", doc: "Returns the tangent of x."},
+	},
+	"net.interface_addrs": {
+		{label: "net.interface_addrs() []string", doc: "Returns a list of the system's network interfaces."},
+	},
+	"net.join_host_port": {
+		{label: "net.join_host_port(host, port string) string", doc: "Joins the host and port together."},
+	},
+	"net.lookup_addr": {
+		{label: "net.lookup_addr(addr string) (string, error)", doc: "Looks up the host name of the specified address."},
+	},
+	"net.lookup_host": {
+		{label: "net.lookup_host(host string) []string", doc: "Looks up the IP addresses of the specified host."},
+	},
+	"net.lookup_ip": {
+		{label: "net.lookup_ip(host string) []net.ip", doc: "Looks up the IP addresses of the specified host."},
+	},
+	"os.chdir": {
+		{label: "os.chdir(dir string)", doc: "Changes the working directory to dir."},
+	},
+	"os.create": {
+		{label: "os.create(name string) File", doc: "Creates or truncates the named file."},
+	},
+	"os.environ": {
+		{label: "os.environ() list", doc: "Returns a copy of strings representing the environment, in the form \"key=value\"."},
+	},
+	"os.exit": {
+		{label: "os.exit(code int)", doc: "Terminates the program with the given exit code."},
+	},
+	"os.getenv": {
+		{label: "os.getenv(key string) string", doc: "Returns the value of the environment variable key."},
+	},
+	"os.getpid": {
+		{label: "os.getpid() int", doc: "Returns the current process ID."},
+	},
+	"os.getuid": {
+		{label: "os.getuid() int", doc: "Returns the current user ID."},
+	},
+	"os.getwd": {
+		{label: "os.getwd() string", doc: "Returns the current working directory."},
+	},
+	"os.hostname": {
+		{label: "os.hostname() string", doc: "Returns the host name reported by the kernel."},
+	},
+	"os.mkdir": {
+		{label: "os.mkdir(path string, perm int)", doc: "Creates a new directory with the specified name and permission bits. If a permissions value is not specified, 0755 is used."},
+	},
+	"os.mkdir_all": {
+		{label: "os.mkdir_all(path string, perm int)", doc: "Creates a directory named path, along with any necessary parent directories."},
+	},
+	"os.mkdir_temp": {
+		{label: "os.mkdir_temp(dir, prefix string) string", doc: "Creates a new temporary directory in the directory dir, using prefix to generate its name."},
+	},
+	"os.open": {
+		{label: "os.open(name string) File", doc: "Opens the named file."},
+	},
+	"os.read_dir": {
+		{label: "os.read_dir(name string) list", doc: "Returns a list of directory entries sorted by filename. If a name is not specified, the current directory is used."},
+	},
+	"os.read_file": {
+		{label: "os.read_file(name string) byte_slice", doc: "Reads the named file and returns its contents."},
+	},
+	"os.remove": {
+		{label: "os.remove(name string)", doc: "Removes the named file or empty directory."},
+	},
+	"os.remove_all": {
+		{label: "os.remove_all(name string)", doc: "Removes path and any children it contains."},
+	},
+	"os.rename": {
+		{label: "os.rename(old, new string)", doc: "Renames (moves) old to new."},
+	},
+	"os.setenv": {
+		{label: "os.setenv(key, value string)", doc: "Sets the value of the environment variable key to value."},
+	},
+	"os.stat": {
+		{label: "os.stat(name string) FileInfo", doc: "Returns a FileInfo describing the named file."},
+	},
+	"os.symlink": {
+		{label: "os.symlink(old, new string)", doc: "Creates a symbolic link new pointing to old."},
+	},
+	"os.temp_dir": {
+		{label: "os.temp_dir() string", doc: "Returns the default directory to use for temporary files."},
+	},
+	"os.unsetenv": {
+		{label: "os.unsetenv(key string)", doc: "Unsets the environment variable key."},
+	},
+	"os.user_cache_dir": {
+		{label: "os.user_cache_dir() string", doc: "Returns the default root directory to use for user-specific non-essential data."},
+	},
+	"os.user_config_dir": {
+		{label: "os.user_config_dir() string", doc: "Returns the default root directory to use for user-specific configuration data."},
+	},
+	"os.user_home_dir": {
+		{label: "os.user_home_dir() string", doc: "Returns the current user's home directory."},
+	},
+	"os.write_file": {
+		{label: "os.write_file(name string, data byte_slice / string)", doc: "Writes the given byte_slice or string to the named file."},
+	},
+	"pgx.connect": {
+		{label: "pgx.connect(dsn string) conn", doc: "Connect to the database specified by the dsn string."},
+	},
+	"rand.exp_float": {
+		{label: "rand.exp_float() float", doc: "Returns an exponentially distributed float in the range (0, +math.MaxFloat64] with an exponential distribution whose rate parameter (lambda) is 1 and whose mean is 1/lambda (1)."},
+	},
+	"rand.float": {
+		{label: "rand.float() float", doc: "Returns a random float between 0 and 1."},
+	},
+	"rand.int": {
+		{label: "rand.int() int", doc: "Returns a non-negative pseudo-random 64 bit integer."},
+	},
+	"rand.intn": {
+		{label: "rand.intn(n int) int", doc: "Returns a non-negative pseudo-random 64 bit integer in the range [0, n)."},
+	},
+	"rand.norm_float": {
+		{label: "rand.norm_float() float", doc: "Returns a normally distributed float in the range [-math.MaxFloat64, +math.MaxFloat64] with standard normal distribution (mean = 0, stddev = 1)."},
+	},
+	"rand.shuffle": {
+		{label: "rand.shuffle(list)", doc: "Shuffles a list in place and returns it."},
+	},
+	"regexp.compile": {
+		{label: "regexp.compile(expr string) regexp", doc: "Compiles a regular expression string into a regexp object."},
+	},
+	"regexp.match": {
+		{label: "regexp.match(expr, s string) bool", doc: "Returns true if the string s contains any match of the regular expression pattern."},
+	},
+	"render": {
+		{label: "render(data object, template string) string", doc: "Returns the rendered template as a string. It includes all the sprig lib functions. You can access environment variables from the template under .Env and the passed values will be available under .Values in the template"},
+	},
+	"semver.build": {
+		{label: "semver.build(version string) string", doc: "Returns the build version of the given version string."},
+	},
+	"semver.compare": {
+		{label: "semver.compare(v1 int, v2 int) int", doc: "Compares v1 and v2. Returns -1 if v1 is less than v2, 0 if both are equal, 1 if v1 is greater than v2."},
+	},
+	"semver.equals": {
+		{label: "semver.equals(v1 string, v2 string) bool", doc: "Returns whether v1 and v2 are equal."},
+	},
+	"semver.major": {
+		{label: "semver.major(version string) int", doc: "Returns the major version of the given version string."},
+	},
+	"semver.minor": {
+		{label: "semver.minor(version string) int", doc: "Returns the minor version of the given version string."},
+	},
+	"semver.parse": {
+		{label: "semver.parse(version string) map", doc: "Parses the given version string and returns a map with the major, minor, patch, pre-release, and build versions."},
+	},
+	"semver.patch": {
+		{label: "semver.patch(version string) int", doc: "Returns the patch version of the given version string."},
+	},
+	"semver.pre": {
+		{label: "semver.pre(version string) string", doc: "Pre returns the pre-release version of the given version string."},
+	},
+	"semver.validate": {
+		{label: "semver.validate(version string) bool", doc: "Returns an error if the version isn't valid."},
+	},
+	"strconv.atoi": {
+		{label: "strconv.atoi(s string) int", doc: "Converts the string s to an int."},
+	},
+	"strconv.parse_bool": {
+		{label: "strconv.parse_bool(s string) bool", doc: "Converts the string s to a bool."},
+	},
+	"strconv.parse_float": {
+		{label: "strconv.parse_float(s string) float", doc: "Converts the string s to a float."},
+	},
+	"strconv.parse_int": {
+		{label: "strconv.parse_int(s string, base int = 10, bit_size int = 64) int", doc: "Converts the string s to an int."},
+	},
+	"strings.compare": {
+		{label: "strings.compare(s1, s2 string) int", doc: "Compares two strings lexicographically. Returns -1 if s1 < s2, 0 if s1 == s2, and 1 if s1 > s2."},
+	},
+	"strings.contains": {
+		{label: "strings.contains(s, substr string) bool", doc: "Returns true if the string s contains substr."},
+	},
+	"strings.count": {
+		{label: "strings.count(s, substr string) int", doc: "Returns the number of non-overlapping instances of substr in s."},
+	},
+	"strings.fields": {
+		{label: "strings.fields(s string) []string", doc: "Splits the string s around each instance of one or more consecutive white space characters, returning a slice of substrings or any empty slice if s contains only white space."},
+	},
+	"strings.has_prefix": {
+		{label: "strings.has_prefix(s, prefix string) bool", doc: "Returns true if the string s begins with prefix."},
+	},
+	"strings.has_suffix": {
+		{label: "strings.has_suffix(s, suffix string) bool", doc: "Returns true if the string s ends with suffix."},
+	},
+	"strings.index": {
+		{label: "strings.index(s, substr string) int", doc: "Returns the index of the first instance of substr in s, or -1 if substr is not present in s."},
+	},
+	"strings.join": {
+		{label: "strings.join(a []string, sep string) string", doc: "Concatenates the elements of a to create a single string. The separator string sep is placed between elements in the resulting string."},
+	},
+	"strings.last_index": {
+		{label: "strings.last_index(s, substr string) int", doc: "Returns the index of the last instance of substr in s, or -1 if substr is not present in s."},
+	},
+	"strings.repeat": {
+		{label: "strings.repeat(s string, count int) string", doc: "Repeat returns a new string consisting of `count` copies of the string `s`."},
+	},
+	"strings.replace_all": {
+		{label: "strings.replace_all(s, old, new string) string", doc: "Returns a copy of the string s with all non-overlapping instances of old replaced by new."},
+	},
+	"strings.split": {
+		{label: "strings.split(s, sep string) []string", doc: "Splits the string s around each instance of sep, returning a slice of substrings or any empty slice if s does not contain sep."},
+	},
+	"strings.to_lower": {
+		{label: "strings.to_lower(s string) string", doc: "Returns a copy of the string s with all Unicode letters mapped to their lower case."},
+	},
+	"strings.to_upper": {
+		{label: "strings.to_upper(s string) string", doc: "Returns a copy of the string s with all Unicode letters mapped to their upper case."},
+	},
+	"strings.trim": {
+		{label: "strings.trim(s, cutset string) string", doc: "Returns a slice of the string s, with all leading and trailing Unicode code points contained in cutset removed."},
+	},
+	"strings.trim_prefix": {
+		{label: "strings.trim_prefix(s, prefix string) string", doc: "Returns s without the provided leading prefix string. If s doesn't start with prefix, s is returned unchanged."},
+	},
+	"strings.trim_space": {
+		{label: "strings.trim_space(s string) string", doc: "Returns a slice of the string s, with all leading and trailing white space removed, as defined by Unicode."},
+	},
+	"strings.trim_suffix": {
+		{label: "strings.trim_suffix(s, suffix string) string", doc: "Returns s without the provided trailing suffix string. If s doesn't end with suffix, s is returned unchanged."},
+	},
+	"tablewriter": {
+		{label: "tablewriter(rows [][]string, options map, writer io.writer = os.stdout)", doc: "Renders a table with the given rows of data. The options map can be used to set properties of the table, such as the header, footer, and alignment. If a writer is not provided, it defaults to stdout."},
+	},
+	"tablewriter.writer": {
+		{label: "tablewriter.writer(writer io.writer = os.stdout) tablewriter.writer", doc: "Returns a new tablewriter.writer object that writes to the given output writer. If an output writer is not provided, it defaults to stdout."},
+	},
+	"template.add": {
+		{label: "template.add(name string, template string)", doc: "Adds a named template to the template object"},
+	},
+	"template.execute": {
+		{label: "template.execute(data object) string", doc: "Renders the templates into a string."},
+	},
+	"template.execute_template": {
+		{label: "template.execute_template(data object, name string) string", doc: "Renders the given named template into a string."},
+	},
+	"template.new": {
+		{label: "template.new(name string) template", doc: "Instanciates a new template object with the given name."},
+	},
+	"template.parse": {
+		{label: "template.parse(template string)", doc: "Parses a template into the template object"},
+	},
+	"testing.assert_eq": {
+		{label: "testing.assert_eq(actual, expected, message string = \"\")", doc: "Raises an error if the two values are not equal. The error shows both values. Strings, lists, and maps spanning more than one line are shown as a line diff."},
+	},
+	"testing.assert_false": {
+		{label: "testing.assert_false(value, message string = \"\")", doc: "Raises an error if the value is truthy."},
+	},
+	"testing.assert_ne": {
+		{label: "testing.assert_ne(actual, expected, message string = \"\")", doc: "Raises an error if the two values are equal."},
+	},
+	"testing.assert_raises": {
+		{label: "testing.assert_raises(fn callable, contains string = \"\") error", doc: "Calls the function and raises an error if it does not raise one. If `contains` is given, the message of the raised error must contain it. Returns the error raised by the function."},
+	},
+	"testing.assert_true": {
+		{label: "testing.assert_true(value, message string = \"\")", doc: "Raises an error if the value is not truthy."},
+	},
+	"testing.fail": {
+		{label: "testing.fail(message string = \"test failed\")", doc: "Fails the current test with the given message."},
+	},
+	"testing.skip": {
+		{label: "testing.skip(reason string = \"\")", doc: "Stops the current test and marks it as skipped. Unlike other errors, a skip is not caught by `try` statements."},
+	},
+	"time.after": {
+		{label: "time.after(duration float) chan", doc: "Returns a channel that receives the current time once the given duration in seconds has elapsed. This is useful as a timeout case in a `select`."},
+	},
+	"time.now": {
+		{label: "time.now() time", doc: "Returns the current time as a time object."},
+	},
+	"time.parse": {
+		{label: "time.parse(layout, value string) time", doc: "Parses a string into a time."},
+	},
+	"time.since": {
+		{label: "time.since(t time) float", doc: "Returns the elapsed time in seconds since the given time."},
+	},
+	"time.sleep": {
+		{label: "time.sleep(duration float)", doc: "Sleeps for the given duration in seconds."},
+	},
+	"time.unix": {
+		{label: "time.unix() time", doc: "Unix returns the local Time corresponding to the given Unix time, sec seconds and nsec nanoseconds since January 1, 1970 UTC"},
+	},
+	"uuid": {
+		{label: "uuid() string", doc: "The `uuid` module object itself is callable, which is a shorthand for `uuid.v4()`."},
+	},
+	"uuid.v4": {
+		{label: "uuid.v4() string", doc: "Returns a randomly generated v4 UUID."},
+	},
+	"uuid.v5": {
+		{label: "uuid.v5(namespace, name string) string", doc: "Returns a UUID based on SHA-1 hash of the namespace UUID and name."},
+	},
+	"uuid.v6": {
+		{label: "uuid.v6() string", doc: "Returns a v6 UUID. The v6 UUID is a reordering of UUIDv1 fields so it is lexicographically sortable by time."},
+	},
+	"uuid.v7": {
+		{label: "uuid.v7() string", doc: "Returns a v7 UUID. The v7 UUID is time-ordered and embeds a Unix timestamp with millisecond precision. The time-ordered aspect makes these IDs useful in some database scenarios, since database performance may be improved as compared to v4 UUIDs."},
+	},
+	"vault.connect": {
+		{label: "vault.connect(address string)", doc: "Instanciates a new client for the given Vault address"},
+	},
+	"vault.delete": {
+		{label: "vault.delete(path string) object", doc: "Deletes an object from the given path"},
+	},
+	"vault.list": {
+		{label: "vault.list(path string) object", doc: "Returns a list objects from the given path"},
+	},
+	"vault.read": {
+		{label: "vault.read(path string) object", doc: "Returns an object from the given path"},
+	},
+	"vault.read_raw": {
+		{label: "vault.read_raw(path string) object", doc: "Returns an HTTP response object from Vault from the given path"},
+	},
+	"vault.write": {
+		{label: "vault.write(data object, path string)", doc: "Writes the given object to the given path."},
+	},
+	"vault.write_raw": {
+		{label: "vault.write_raw(data byte_slice, path string)", doc: "Writes the given data to the given path."},
+	},
+	"yaml.marshal": {
+		{label: "yaml.marshal(v object) string", doc: "Returns a YAML string representing the given value. Raises an error if the value cannot be marshalled."},
+	},
+	"yaml.unmarshal": {
+		{label: "yaml.unmarshal(s string) object", doc: "Returns the value represented by the given YAML string. Raises an error if the string cannot be unmarshalled."},
+	},
+	"yaml.valid": {
+		{label: "yaml.valid(s string) bool", doc: "Returns whether the given string is valid YAML."},
+	},
+}
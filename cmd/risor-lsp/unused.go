@@ -6,7 +6,6 @@ import (
 
 	"github.com/jdbaldry/go-language-server-protocol/jsonrpc2"
 	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
-	"github.com/rs/zerolog/log"
 )
 
 func (s *Server) Initialized(context.Context, *protocol.InitializedParams) error {
@@ -53,10 +52,6 @@ func (s *Server) Exit(context.Context) error {
 	return notImplemented("Exit")
 }
 
-func (s *Server) FoldingRange(context.Context, *protocol.FoldingRangeParams) ([]protocol.FoldingRange, error) {
-	return nil, notImplemented("FoldingRange")
-}
-
 func (s *Server) Implementation(context.Context, *protocol.ImplementationParams) (protocol.Definition, error) {
 	return nil, notImplemented("Implementation")
 }
@@ -93,26 +88,10 @@ func (s *Server) PrepareCallHierarchy(context.Context, *protocol.CallHierarchyPr
 	return nil, notImplemented("PrepareCallHierarchy")
 }
 
-func (s *Server) PrepareRename(context.Context, *protocol.PrepareRenameParams) (*protocol.Range, error) {
-	return nil, notImplemented("PrepareRange")
-}
-
 func (s *Server) PrepareTypeHierarchy(context.Context, *protocol.TypeHierarchyPrepareParams) ([]protocol.TypeHierarchyItem, error) {
 	return nil, notImplemented("PrepareTypeHierarchy")
 }
 
-func (s *Server) RangeFormatting(context.Context, *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
-	return nil, notImplemented("RangeFormatting")
-}
-
-func (s *Server) References(context.Context, *protocol.ReferenceParams) ([]protocol.Location, error) {
-	return nil, notImplemented("References")
-}
-
-func (s *Server) Rename(context.Context, *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
-	return nil, notImplemented("Rename")
-}
-
 func (s *Server) Resolve(context.Context, *protocol.CompletionItem) (*protocol.CompletionItem, error) {
 	return nil, notImplemented("Resolve")
 }
@@ -133,22 +112,10 @@ func (s *Server) SelectionRange(context.Context, *protocol.SelectionRangeParams)
 	return nil, notImplemented("SelectionRange")
 }
 
-func (s *Server) SemanticTokensFull(ctx context.Context, p *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
-	log.Info().
-		Str("call", "SemanticTokensFull").
-		Interface("params", p).
-		Msg("semantic tokens")
-	return nil, notImplemented("SemanticTokensFull")
-}
-
 func (s *Server) SemanticTokensFullDelta(context.Context, *protocol.SemanticTokensDeltaParams) (interface{}, error) {
 	return nil, notImplemented("SemanticTokensFullDelta")
 }
 
-func (s *Server) SemanticTokensRange(context.Context, *protocol.SemanticTokensRangeParams) (*protocol.SemanticTokens, error) {
-	return nil, notImplemented("SemanticTokensRange")
-}
-
 func (s *Server) SemanticTokensRefresh(context.Context) error {
 	return notImplemented("SemanticTokensRefresh")
 }
@@ -161,10 +128,6 @@ func (s *Server) Shutdown(context.Context) error {
 	return nil
 }
 
-func (s *Server) SignatureHelp(context.Context, *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
-	return nil, notImplemented("SignatureHelp")
-}
-
 func (s *Server) Subtypes(context.Context, *protocol.TypeHierarchySubtypesParams) ([]protocol.TypeHierarchyItem, error) {
 	return nil, notImplemented("Subtypes")
 }
@@ -25,6 +25,7 @@ type FuncReturn struct {
 
 type FuncParam struct {
 	Name         string
+	KeywordName  string
 	Type         string
 	ReadFunc     string
 	CastFunc     string
@@ -179,6 +180,10 @@ func (m *Module) parseFuncDeclParam(name string, param *ast.Field) (FuncParam, e
 	}
 	p.Name = name
 	p.Type = typ
+	if name != "_" {
+		// Unnamed parameters can only be passed by position
+		p.KeywordName = toSnakeCase(name)
+	}
 	return p, nil
 }
 
@@ -284,3 +289,21 @@ func firstRuneLower(s string) string {
 	firstRune = unicode.ToLower(firstRune)
 	return string(firstRune) + s[1:]
 }
+
+// toSnakeCase converts a camelCase Go name to the snake_case used for Risor
+// names, such as "maxCount" to "max_count".
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Acronyms such as "URL" are kept together
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
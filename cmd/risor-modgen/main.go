@@ -260,11 +260,17 @@ func {{ .FuncGenName }}(ctx context.Context, args ...object.Object) object.Objec
 {{- end }}
 
 // addGeneratedBuiltins adds the generated builtin wrappers to the given map.
+// Their arguments may also be passed by keyword, using the parameter names.
 //
 // Useful if you want to write your own "Module()" function.
 func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.Object {
 	{{- range .ExportedFuncs }}
-	builtins["{{ .ExportedName }}"] = object.NewBuiltin("{{ .ExportedName }}", {{ .FuncGenName }})
+	builtins["{{ .ExportedName }}"] = object.NewBuiltinWithParams("{{ .ExportedName }}", {{ .FuncGenName }}, []string{
+		{{- range $index, $param := .Params -}}
+			{{- if gt $index 0 }}, {{ end -}}
+			"{{ .KeywordName }}"
+		{{- end -}}
+	})
 	{{- end }}
 	return builtins
 }
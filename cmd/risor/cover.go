@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/risor-io/risor/cmd/risor/coverage"
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/viper"
+)
+
+// newCoverage returns a Coverage if a coverage report was requested, or nil.
+func newCoverage() *vm.Coverage {
+	if viper.GetString("coverage") == "" && viper.GetString("coverage-html") == "" {
+		return nil
+	}
+	return vm.NewCoverage()
+}
+
+// writeCoverage writes the requested coverage reports and prints a summary
+// to stderr.
+func writeCoverage(cov *vm.Coverage) {
+	files := cov.Files()
+	if path := viper.GetString("coverage"); path != "" {
+		writeCoverageFile(path, files, coverage.WriteLCOV)
+	}
+	if path := viper.GetString("coverage-html"); path != "" {
+		writeCoverageFile(path, files, coverage.WriteHTML)
+	}
+	if err := coverage.WriteSummary(os.Stderr, files); err != nil {
+		fatal(err)
+	}
+}
+
+func writeCoverageFile(path string, files []vm.FileCoverage, write func(w io.Writer, files []vm.FileCoverage) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		fatal(err)
+	}
+	defer f.Close()
+	if err := write(f, files); err != nil {
+		fatal(err)
+	}
+}
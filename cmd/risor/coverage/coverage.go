@@ -0,0 +1,162 @@
+// Package coverage writes reports of the code coverage recorded by a
+// vm.Coverage.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/risor-io/risor/vm"
+)
+
+// WriteLCOV writes the coverage in the LCOV tracefile format, which is
+// understood by genhtml and most coverage services.
+func WriteLCOV(w io.Writer, files []vm.FileCoverage) error {
+	bw := bufio.NewWriter(w)
+	for _, file := range files {
+		fmt.Fprintln(bw, "TN:")
+		fmt.Fprintf(bw, "SF:%s\n", displayName(file.Filename))
+		var functionsHit int
+		for _, fn := range file.Functions {
+			fmt.Fprintf(bw, "FN:%d,%s\n", fn.Line, fn.Name)
+		}
+		for _, fn := range file.Functions {
+			fmt.Fprintf(bw, "FNDA:%d,%s\n", fn.Hits, fn.Name)
+			if fn.Hits > 0 {
+				functionsHit++
+			}
+		}
+		fmt.Fprintf(bw, "FNF:%d\n", len(file.Functions))
+		fmt.Fprintf(bw, "FNH:%d\n", functionsHit)
+		for _, line := range file.Lines {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line.Line, line.Hits)
+		}
+		fmt.Fprintf(bw, "LF:%d\n", len(file.Lines))
+		fmt.Fprintf(bw, "LH:%d\n", file.LinesCovered())
+		fmt.Fprintln(bw, "end_of_record")
+	}
+	return bw.Flush()
+}
+
+// WriteSummary writes a table of the percentage of lines covered in each file
+// followed by the total.
+func WriteSummary(w io.Writer, files []vm.FileCoverage) error {
+	width := len("total")
+	for _, file := range files {
+		if n := len(displayName(file.Filename)); n > width {
+			width = n
+		}
+	}
+	var covered, total int
+	for _, file := range files {
+		covered += file.LinesCovered()
+		total += len(file.Lines)
+		if _, err := fmt.Fprintf(w, "%-*s  %4d/%-4d  %5.1f%%\n", width, displayName(file.Filename),
+			file.LinesCovered(), len(file.Lines), file.Percent()); err != nil {
+			return err
+		}
+	}
+	percent := 100.0
+	if total > 0 {
+		percent = 100 * float64(covered) / float64(total)
+	}
+	_, err := fmt.Fprintf(w, "%-*s  %4d/%-4d  %5.1f%%\n", width, "total", covered, total, percent)
+	return err
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	Class  string
+	Hits   int
+}
+
+type htmlFile struct {
+	ID      string
+	Name    string
+	Percent float64
+	Lines   []htmlLine
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Risor coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td { padding: 2px 12px; }
+pre { font-family: monospace; line-height: 1.3; }
+.line { display: block; }
+.num { display: inline-block; width: 4em; color: #888; text-align: right; margin-right: 1em; }
+.hits { display: inline-block; width: 4em; color: #888; text-align: right; margin-right: 1em; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>Risor coverage</h1>
+<table class="summary">
+{{range .}}<tr><td><a href="#{{.ID}}">{{.Name}}</a></td><td>{{printf "%.1f" .Percent}}%</td></tr>
+{{end}}</table>
+{{range .}}<h2 id="{{.ID}}">{{.Name}}</h2>
+<pre>{{range .Lines}}<span class="line {{.Class}}"><span class="num">{{.Number}}</span><span class="hits">{{if .Class}}{{.Hits}}{{end}}</span>{{.Text}}</span>{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes an HTML page showing the source of each file with the
+// covered and uncovered lines highlighted. The sources are read from disk;
+// files that cannot be read are shown as a list of line numbers.
+func WriteHTML(w io.Writer, files []vm.FileCoverage) error {
+	var pages []htmlFile
+	for i, file := range files {
+		hits := map[int]int{}
+		last := 0
+		for _, line := range file.Lines {
+			hits[line.Line] = line.Hits
+			if line.Line > last {
+				last = line.Line
+			}
+		}
+		var source []string
+		if data, err := os.ReadFile(file.Filename); err == nil {
+			source = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		}
+		if len(source) > last {
+			last = len(source)
+		}
+		page := htmlFile{
+			ID:      fmt.Sprintf("file%d", i),
+			Name:    displayName(file.Filename),
+			Percent: file.Percent(),
+		}
+		for n := 1; n <= last; n++ {
+			line := htmlLine{Number: n}
+			if n <= len(source) {
+				line.Text = source[n-1]
+			}
+			if count, ok := hits[n]; ok {
+				line.Hits = count
+				line.Class = "uncovered"
+				if count > 0 {
+					line.Class = "covered"
+				}
+			}
+			page.Lines = append(page.Lines, line)
+		}
+		pages = append(pages, page)
+	}
+	return htmlTemplate.Execute(w, pages)
+}
+
+func displayName(filename string) string {
+	if filename == "" {
+		return "<input>"
+	}
+	return filename
+}
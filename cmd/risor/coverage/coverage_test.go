@@ -0,0 +1,63 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/vm"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = []vm.FileCoverage{
+	{
+		Filename: "testdata/example.risor",
+		Lines: []vm.LineCoverage{
+			{Line: 2, Hits: 3},
+			{Line: 5, Hits: 0},
+			{Line: 7, Hits: 1},
+		},
+		Functions: []vm.FunctionCoverage{
+			{Name: "used", Line: 2, Hits: 3},
+			{Name: "unused", Line: 5, Hits: 0},
+		},
+	},
+}
+
+func TestWriteLCOV(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteLCOV(&buf, testFiles))
+	require.Equal(t, `TN:
+SF:testdata/example.risor
+FN:2,used
+FN:5,unused
+FNDA:3,used
+FNDA:0,unused
+FNF:2
+FNH:1
+DA:2,3
+DA:5,0
+DA:7,1
+LF:3
+LH:2
+end_of_record
+`, buf.String())
+}
+
+func TestWriteSummary(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteSummary(&buf, testFiles))
+	require.Equal(t, `testdata/example.risor     2/3      66.7%
+total                      2/3      66.7%
+`, buf.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteHTML(&buf, testFiles))
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	require.Contains(t, out, `<span class="line covered"><span class="num">2</span><span class="hits">3</span>  return x * 2</span>`)
+	require.Contains(t, out, `<span class="line uncovered"><span class="num">5</span><span class="hits">0</span>  return 0</span>`)
+	require.Contains(t, out, `<span class="line "><span class="num">1</span><span class="hits"></span>func used(x) {</span>`)
+}
@@ -0,0 +1,514 @@
+// Package dap implements a Debug Adapter Protocol server for Risor, which
+// allows editors such as VS Code to run scripts under the VM debugger.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+)
+
+// Risor scripts run on a single VM, so only one thread is reported.
+const threadID = 1
+
+// Server is a debug adapter for a single debugging session. It launches one
+// Risor program and serves requests from the client until it disconnects.
+type Server struct {
+	reader     *bufio.Reader
+	writer     io.Writer
+	writeMutex sync.Mutex
+	seq        int
+	options    []risor.Option
+	debugger   *vm.Debugger
+
+	mutex      sync.Mutex
+	code       *compiler.Code
+	configured bool
+	started    bool
+	stopped    *vm.StopEvent
+	handles    [][]vm.Variable
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewServer returns a Server that reads requests from r and writes responses
+// and events to w. The given options configure the Risor programs it runs.
+func NewServer(r io.Reader, w io.Writer, options ...risor.Option) *Server {
+	s := &Server{
+		reader:  bufio.NewReader(r),
+		writer:  w,
+		options: options,
+		done:    make(chan struct{}),
+	}
+	s.debugger = vm.NewDebugger(s.onStop)
+	return s
+}
+
+// Serve handles requests until the client disconnects or the input is closed.
+func (s *Server) Serve(ctx context.Context) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	defer s.cancel()
+	for {
+		req, err := readMessage(s.reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if done := s.handle(req); done {
+			return nil
+		}
+	}
+}
+
+func (s *Server) handle(req *request) bool {
+	var body any
+	var err error
+	switch req.Command {
+	case "initialize":
+		body = capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsTerminateRequest:         true,
+			SupportsEvaluateForHovers:        true,
+		}
+		s.respond(req, body, nil)
+		s.sendEvent("initialized", nil)
+		return false
+	case "launch":
+		err = s.launch(req.Arguments)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req.Arguments)
+	case "setExceptionBreakpoints":
+		body = map[string]any{"breakpoints": []breakpoint{}}
+	case "configurationDone":
+		s.mutex.Lock()
+		s.configured = true
+		s.mutex.Unlock()
+		s.respond(req, nil, nil)
+		s.start()
+		return false
+	case "threads":
+		body = map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		body, err = s.stackTrace()
+	case "scopes":
+		body, err = s.scopes(req.Arguments)
+	case "variables":
+		body, err = s.variables(req.Arguments)
+	case "evaluate":
+		body, err = s.evaluate(req.Arguments)
+	case "continue":
+		s.resume(s.debugger.Continue)
+		body = map[string]any{"allThreadsContinued": true}
+	case "next":
+		s.resume(s.debugger.StepOver)
+	case "stepIn":
+		s.resume(s.debugger.StepIn)
+	case "stepOut":
+		s.resume(s.debugger.StepOut)
+	case "pause":
+		s.debugger.Pause()
+	case "terminate":
+		s.cancel()
+	case "disconnect":
+		s.cancel()
+		s.respond(req, nil, nil)
+		return true
+	default:
+		err = fmt.Errorf("unsupported request: %s", req.Command)
+	}
+	s.respond(req, body, err)
+	return false
+}
+
+func (s *Server) launch(args json.RawMessage) error {
+	var launchArgs launchArguments
+	if err := json.Unmarshal(args, &launchArgs); err != nil {
+		return err
+	}
+	if launchArgs.Program == "" {
+		return errors.New("a program to debug is required")
+	}
+	program, err := filepath.Abs(launchArgs.Program)
+	if err != nil {
+		return err
+	}
+	source, err := os.ReadFile(program)
+	if err != nil {
+		return err
+	}
+	ctx := s.ctx
+	cfg := risor.NewConfig(append(s.options, risor.WithFilename(program))...)
+	ast, err := parser.Parse(ctx, string(source), cfg.ParserOpts()...)
+	if err != nil {
+		return err
+	}
+	code, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+	if err != nil {
+		return err
+	}
+	if launchArgs.StopOnEntry && !launchArgs.NoDebug {
+		s.debugger.StopOnEntry()
+	}
+	s.mutex.Lock()
+	s.code = code
+	s.mutex.Unlock()
+	vmOpts := cfg.VMOpts()
+	if !launchArgs.NoDebug {
+		vmOpts = append(vmOpts, vm.WithDebugger(s.debugger))
+	}
+	go func() {
+		// Wait until the client has finished configuring breakpoints
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			return
+		}
+		s.run(vm.New(code, vmOpts...))
+	}()
+	s.start()
+	return nil
+}
+
+// start begins running the program once it has been launched and the client
+// has finished its configuration.
+func (s *Server) start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started || !s.configured || s.code == nil {
+		return
+	}
+	s.started = true
+	close(s.done)
+}
+
+func (s *Server) run(machine *vm.VirtualMachine) {
+	ctx := ros.WithOS(s.ctx, &outputOS{
+		OS:     ros.GetDefaultOS(s.ctx),
+		stdout: &outputFile{server: s, category: "stdout"},
+	})
+	exitCode := 0
+	if err := machine.Run(ctx); err != nil && s.ctx.Err() == nil {
+		exitCode = 1
+		message := err.Error()
+		var traced *errz.TracedError
+		if errors.As(err, &traced) {
+			message = traced.FriendlyErrorMessage()
+		}
+		s.sendEvent("output", map[string]any{"category": "stderr", "output": message + "\n"})
+	}
+	s.sendEvent("exited", map[string]any{"exitCode": exitCode})
+	s.sendEvent("terminated", nil)
+}
+
+// onStop is called on the VM goroutine each time the debugger pauses.
+func (s *Server) onStop(event *vm.StopEvent) {
+	s.mutex.Lock()
+	s.stopped = event
+	s.handles = nil
+	s.mutex.Unlock()
+	s.sendEvent("stopped", map[string]any{
+		"reason":            string(event.Reason),
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+}
+
+func (s *Server) resume(fn func()) {
+	s.mutex.Lock()
+	s.stopped = nil
+	s.handles = nil
+	s.mutex.Unlock()
+	fn()
+}
+
+func (s *Server) setBreakpoints(args json.RawMessage) (any, error) {
+	var bpArgs setBreakpointsArguments
+	if err := json.Unmarshal(args, &bpArgs); err != nil {
+		return nil, err
+	}
+	path, err := filepath.Abs(bpArgs.Source.Path)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	lines := codeLines(s.code, path)
+	s.mutex.Unlock()
+	var active []int
+	result := make([]breakpoint, 0, len(bpArgs.Breakpoints))
+	for _, bp := range bpArgs.Breakpoints {
+		// Before the program is compiled, breakpoints can't be checked
+		if lines != nil && !lines[bp.Line] {
+			result = append(result, breakpoint{
+				Line:    bp.Line,
+				Message: "no code on this line",
+			})
+			continue
+		}
+		active = append(active, bp.Line)
+		result = append(result, breakpoint{Verified: true, Line: bp.Line})
+	}
+	s.debugger.SetBreakpoints(path, active)
+	return map[string]any{"breakpoints": result}, nil
+}
+
+func (s *Server) stackTrace() (any, error) {
+	stopped, err := s.stoppedEvent()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]stackFrame, 0, len(stopped.Frames))
+	for i, f := range stopped.Frames {
+		frame := stackFrame{
+			ID:     i + 1,
+			Name:   f.Function,
+			Line:   f.Location.Line,
+			Column: f.Location.Column,
+		}
+		if f.Location.Filename != "" {
+			frame.Source = &source{
+				Name: filepath.Base(f.Location.Filename),
+				Path: f.Location.Filename,
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+func (s *Server) scopes(args json.RawMessage) (any, error) {
+	var scopesArgs scopesArguments
+	if err := json.Unmarshal(args, &scopesArgs); err != nil {
+		return nil, err
+	}
+	f, err := s.frame(scopesArgs.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	var result []scope
+	if len(f.Locals) > 0 {
+		result = append(result, scope{
+			Name:               "Locals",
+			PresentationHint:   "locals",
+			VariablesReference: s.newHandle(f.Locals),
+		})
+	}
+	if len(f.FreeVars) > 0 {
+		result = append(result, scope{
+			Name:               "Free Variables",
+			VariablesReference: s.newHandle(f.FreeVars),
+		})
+	}
+	result = append(result, scope{
+		Name:               "Globals",
+		VariablesReference: s.newHandle(f.Globals),
+	})
+	return map[string]any{"scopes": result}, nil
+}
+
+func (s *Server) variables(args json.RawMessage) (any, error) {
+	var varArgs variablesArguments
+	if err := json.Unmarshal(args, &varArgs); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	ref := varArgs.VariablesReference
+	if ref < 1 || ref > len(s.handles) {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("invalid variables reference: %d", ref)
+	}
+	vars := s.handles[ref-1]
+	s.mutex.Unlock()
+	result := make([]variable, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, s.describe(v.Name, v.Value))
+	}
+	return map[string]any{"variables": result}, nil
+}
+
+func (s *Server) evaluate(args json.RawMessage) (any, error) {
+	var evalArgs evaluateArguments
+	if err := json.Unmarshal(args, &evalArgs); err != nil {
+		return nil, err
+	}
+	f, err := s.frame(evalArgs.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	// Only variable names are supported, which covers hovering in the editor
+	for _, vars := range [][]vm.Variable{f.Locals, f.FreeVars, f.Globals} {
+		for _, v := range vars {
+			if v.Name == evalArgs.Expression {
+				desc := s.describe(v.Name, v.Value)
+				return map[string]any{
+					"result":             desc.Value,
+					"type":               desc.Type,
+					"variablesReference": desc.VariablesReference,
+				}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("name %q is not defined", evalArgs.Expression)
+}
+
+// describe converts an object to a DAP variable. Containers are given a
+// handle so that the client can expand them.
+func (s *Server) describe(name string, value object.Object) variable {
+	var children []vm.Variable
+	switch value := value.(type) {
+	case *object.List:
+		for i, item := range value.Value() {
+			children = append(children, vm.Variable{Name: fmt.Sprintf("[%d]", i), Value: item})
+		}
+	case *object.Map:
+		items := value.Value()
+		for _, key := range value.SortedKeys() {
+			children = append(children, vm.Variable{Name: key, Value: items[key]})
+		}
+	case *object.Struct:
+		for _, field := range value.StructType().Fields() {
+			fieldValue, _ := value.Field(field)
+			children = append(children, vm.Variable{Name: field, Value: fieldValue})
+		}
+	}
+	v := variable{
+		Name:  name,
+		Value: value.Inspect(),
+		Type:  string(value.Type()),
+	}
+	if len(children) > 0 {
+		v.VariablesReference = s.newHandle(children)
+	}
+	return v
+}
+
+func (s *Server) newHandle(vars []vm.Variable) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handles = append(s.handles, vars)
+	return len(s.handles)
+}
+
+func (s *Server) stoppedEvent() (*vm.StopEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped == nil {
+		return nil, errors.New("the program is not paused")
+	}
+	return s.stopped, nil
+}
+
+func (s *Server) frame(id int) (*vm.DebugFrame, error) {
+	stopped, err := s.stoppedEvent()
+	if err != nil {
+		return nil, err
+	}
+	if id < 1 || id > len(stopped.Frames) {
+		return nil, fmt.Errorf("invalid frame id: %d", id)
+	}
+	return &stopped.Frames[id-1], nil
+}
+
+func (s *Server) respond(req *request, body any, err error) {
+	resp := &response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		resp.Message = err.Error()
+		resp.Body = nil
+	}
+	s.send(func(seq int) any {
+		resp.Seq = seq
+		return resp
+	})
+}
+
+func (s *Server) sendEvent(name string, body any) {
+	s.send(func(seq int) any {
+		return &event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+func (s *Server) send(build func(seq int) any) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	s.seq++
+	// Write errors mean the client has gone away, which ends the session
+	// when the next read fails.
+	writeMessage(s.writer, build(s.seq))
+}
+
+// codeLines returns the set of lines in the given file that have code, or nil
+// if the code has not been compiled yet.
+func codeLines(code *compiler.Code, filename string) map[int]bool {
+	if code == nil {
+		return nil
+	}
+	lines := map[int]bool{}
+	for _, c := range code.Flatten() {
+		for i := 0; i < c.InstructionCount(); i++ {
+			if loc := c.Location(i); loc.Filename == filename && loc.IsValid() {
+				lines[loc.Line] = true
+			}
+		}
+	}
+	return lines
+}
+
+// outputOS redirects the standard output of a program to the client.
+type outputOS struct {
+	ros.OS
+	stdout ros.File
+}
+
+func (o *outputOS) Stdout() ros.File {
+	return o.stdout
+}
+
+// outputFile is a write-only file that sends each write as an output event.
+type outputFile struct {
+	server   *Server
+	category string
+}
+
+func (f *outputFile) Write(p []byte) (int, error) {
+	f.server.sendEvent("output", map[string]any{"category": f.category, "output": string(p)})
+	return len(p), nil
+}
+
+func (f *outputFile) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (f *outputFile) Stat() (fs.FileInfo, error) {
+	return nil, errors.New("stat is not supported on debugger output")
+}
+
+func (f *outputFile) Close() error {
+	return nil
+}
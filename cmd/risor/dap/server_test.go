@@ -0,0 +1,203 @@
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type message map[string]any
+
+type testClient struct {
+	t      *testing.T
+	seq    int
+	writer io.Writer
+	msgs   chan message
+}
+
+func newTestClient(t *testing.T) *testClient {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go NewServer(serverReader, serverWriter).Serve(ctx)
+	c := &testClient{t: t, writer: clientWriter, msgs: make(chan message, 100)}
+	go func() {
+		reader := bufio.NewReader(clientReader)
+		for {
+			data, err := readFrame(reader)
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(data, &msg); err == nil {
+				c.msgs <- msg
+			}
+		}
+	}()
+	return c
+}
+
+func (c *testClient) next() message {
+	c.t.Helper()
+	select {
+	case msg := <-c.msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+// request sends a request and returns its response. Any events received
+// while waiting are checked against the expected event names, in order.
+func (c *testClient) request(command string, args any, events ...string) message {
+	c.t.Helper()
+	c.seq++
+	require.Nil(c.t, writeMessage(c.writer, map[string]any{
+		"seq":       c.seq,
+		"type":      "request",
+		"command":   command,
+		"arguments": args,
+	}))
+	var resp message
+	for resp == nil || len(events) > 0 {
+		msg := c.next()
+		switch msg["type"] {
+		case "response":
+			require.Equal(c.t, command, msg["command"])
+			resp = msg
+		case "event":
+			require.NotEmpty(c.t, events, "unexpected event: %v", msg)
+			require.Equal(c.t, events[0], msg["event"])
+			events = events[1:]
+		}
+	}
+	return resp
+}
+
+func (c *testClient) expectEvent(name string) message {
+	c.t.Helper()
+	msg := c.next()
+	require.Equal(c.t, "event", msg["type"])
+	require.Equal(c.t, name, msg["event"], "message: %v", msg)
+	return msg
+}
+
+func body(msg message) map[string]any {
+	return msg["body"].(map[string]any)
+}
+
+func items(msg message, key string) []map[string]any {
+	var result []map[string]any
+	for _, item := range body(msg)[key].([]any) {
+		result = append(result, item.(map[string]any))
+	}
+	return result
+}
+
+func TestDebugSession(t *testing.T) {
+	program, err := filepath.Abs("testdata/example.risor")
+	require.Nil(t, err)
+	c := newTestClient(t)
+
+	resp := c.request("initialize", map[string]any{"adapterID": "risor"}, "initialized")
+	require.Equal(t, true, resp["success"])
+	require.Equal(t, true, body(resp)["supportsConfigurationDoneRequest"])
+
+	resp = c.request("launch", map[string]any{"program": program})
+	require.Equal(t, true, resp["success"])
+
+	resp = c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": program},
+		"breakpoints": []any{map[string]any{"line": 3}, map[string]any{"line": 4}},
+	})
+	breakpoints := items(resp, "breakpoints")
+	require.Len(t, breakpoints, 2)
+	require.Equal(t, true, breakpoints[0]["verified"])
+	require.Equal(t, false, breakpoints[1]["verified"])
+
+	c.request("configurationDone", nil)
+	stopped := c.expectEvent("stopped")
+	require.Equal(t, "breakpoint", body(stopped)["reason"])
+
+	resp = c.request("threads", nil)
+	require.Equal(t, "main", items(resp, "threads")[0]["name"])
+
+	resp = c.request("stackTrace", map[string]any{"threadId": 1})
+	frames := items(resp, "stackFrames")
+	require.Len(t, frames, 2)
+	require.Equal(t, "greet", frames[0]["name"])
+	require.Equal(t, float64(3), frames[0]["line"])
+	require.Equal(t, program, frames[0]["source"].(map[string]any)["path"])
+	require.Equal(t, "__main__", frames[1]["name"])
+	require.Equal(t, float64(6), frames[1]["line"])
+
+	resp = c.request("scopes", map[string]any{"frameId": frames[0]["id"]})
+	scopes := items(resp, "scopes")
+	require.Equal(t, "Locals", scopes[0]["name"])
+	require.Equal(t, "Globals", scopes[1]["name"])
+
+	resp = c.request("variables", map[string]any{"variablesReference": scopes[0]["variablesReference"]})
+	locals := items(resp, "variables")
+	// Named functions hold a reference to themselves as a local
+	require.Len(t, locals, 3)
+	require.Equal(t, "name", locals[0]["name"])
+	require.Equal(t, `"a"`, locals[0]["value"])
+	require.Equal(t, "greet", locals[1]["name"])
+	require.Equal(t, "function", locals[1]["type"])
+	require.Equal(t, "message", locals[2]["name"])
+	require.Equal(t, `"hello a"`, locals[2]["value"])
+
+	resp = c.request("evaluate", map[string]any{"expression": "names", "frameId": frames[0]["id"]})
+	require.Equal(t, `["a", "b"]`, body(resp)["result"])
+	resp = c.request("variables", map[string]any{"variablesReference": body(resp)["variablesReference"]})
+	require.Len(t, items(resp, "variables"), 2)
+
+	resp = c.request("evaluate", map[string]any{"expression": "missing", "frameId": frames[0]["id"]})
+	require.Equal(t, false, resp["success"])
+
+	c.request("next", map[string]any{"threadId": 1})
+	stopped = c.expectEvent("stopped")
+	require.Equal(t, "step", body(stopped)["reason"])
+	resp = c.request("stackTrace", map[string]any{"threadId": 1})
+	frames = items(resp, "stackFrames")
+	require.Equal(t, "__main__", frames[0]["name"])
+	require.Equal(t, float64(7), frames[0]["line"])
+
+	c.request("continue", map[string]any{"threadId": 1})
+	output := c.expectEvent("output")
+	require.Equal(t, "hello a\n", body(output)["output"])
+	exited := c.expectEvent("exited")
+	require.Equal(t, float64(0), body(exited)["exitCode"])
+	c.expectEvent("terminated")
+
+	resp = c.request("disconnect", nil)
+	require.Equal(t, true, resp["success"])
+}
+
+func TestDebugSessionStopOnEntry(t *testing.T) {
+	program, err := filepath.Abs("testdata/example.risor")
+	require.Nil(t, err)
+	c := newTestClient(t)
+	c.request("initialize", nil, "initialized")
+	c.request("configurationDone", nil)
+	c.request("launch", map[string]any{"program": program, "stopOnEntry": true})
+	stopped := c.expectEvent("stopped")
+	require.Equal(t, "entry", body(stopped)["reason"])
+	c.request("terminate", nil, "exited", "terminated")
+}
+
+func TestDebugSessionLaunchError(t *testing.T) {
+	c := newTestClient(t)
+	c.request("initialize", nil, "initialized")
+	resp := c.request("launch", map[string]any{"program": "testdata/missing.risor"})
+	require.Equal(t, false, resp["success"])
+	require.Contains(t, resp["message"], "no such file or directory")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/risor-io/risor/cmd/risor/dap"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const debugExample = `  risor debug
+
+  risor debug --listen 127.0.0.1:4711`
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Run a Debug Adapter Protocol server",
+	Long: `Run a Debug Adapter Protocol (DAP) server, which lets editors such as VS Code
+launch Risor scripts under the debugger. By default the server communicates
+over stdin and stdout. Use --listen to accept a connection over TCP instead.`,
+	Example: debugExample,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+		opts := getRisorOptions()
+
+		addr := viper.GetString("listen")
+		if addr == "" {
+			if err := dap.NewServer(os.Stdin, os.Stdout, opts...).Serve(ctx); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			fatal(err)
+		}
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			fatal(err)
+		}
+		defer conn.Close()
+		if err := dap.NewServer(conn, conn, opts...).Serve(ctx); err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+	debugCmd.Flags().String("listen", "", "Listen for a DAP client on this address")
+	viper.BindPFlag("listen", debugCmd.Flags().Lookup("listen"))
+}
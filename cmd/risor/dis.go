@@ -17,7 +17,9 @@ const disExample = `  risor dis -c "a := 1 + 2"
 
   risor dis ./path/to/script.risor
 
-  risor dis ./path/to/script.risor --func myfunc`
+  risor dis ./path/to/script.risor --func myfunc
+
+  risor dis -c "x := 60 * 60 * 24" --optimize`
 
 var disCmd = &cobra.Command{
 	Use:     "dis",
@@ -39,29 +41,28 @@ var disCmd = &cobra.Command{
 			fatal(err)
 		}
 		cfg := risor.NewConfig(opts...)
-		compiledCode, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+		compilerOpts := cfg.CompilerOpts()
+		optimize := viper.GetBool("optimize")
+		if optimize {
+			compilerOpts = append(compilerOpts, compiler.WithOptimization())
+		}
+		compiledCode, err := compiler.Compile(ast, compilerOpts...)
 		if err != nil {
 			fatal(err)
 		}
-		targetCode := compiledCode
+		targetCode := disTarget(compiledCode)
 
-		// If a function name was provided, disassemble its code only
-		if funcName := viper.GetString("func"); funcName != "" {
-			var fn *compiler.Function
-			for i := 0; i < compiledCode.ConstantsCount(); i++ {
-				obj, ok := compiledCode.Constant(i).(*compiler.Function)
-				if !ok {
-					continue
-				}
-				if obj.Name() == funcName {
-					fn = obj
-					break
-				}
+		// With optimization, show the code before and after the optimizer
+		if optimize {
+			unoptimizedCode, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+			if err != nil {
+				fatal(err)
 			}
-			if fn == nil {
-				fatal(fmt.Sprintf("function %q not found", funcName))
+			if err := dis.PrintOptimization(disTarget(unoptimizedCode), targetCode, os.Stdout); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
 			}
-			targetCode = fn.Code()
+			return
 		}
 
 		// Disassemble and print the instructions
@@ -74,8 +75,27 @@ var disCmd = &cobra.Command{
 	},
 }
 
+// disTarget returns the code to disassemble, which is that of the function
+// named by the --func flag if there is one.
+func disTarget(compiledCode *compiler.Code) *compiler.Code {
+	funcName := viper.GetString("func")
+	if funcName == "" {
+		return compiledCode
+	}
+	for i := 0; i < compiledCode.ConstantsCount(); i++ {
+		fn, ok := compiledCode.Constant(i).(*compiler.Function)
+		if ok && fn.Name() == funcName {
+			return fn.Code()
+		}
+	}
+	fatal(fmt.Sprintf("function %q not found", funcName))
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(disCmd)
 	disCmd.Flags().String("func", "", "Function name")
 	viper.BindPFlag("func", disCmd.Flags().Lookup("func"))
+	disCmd.Flags().Bool("optimize", false, "Show the code before and after optimization")
+	viper.BindPFlag("optimize", disCmd.Flags().Lookup("optimize"))
 }
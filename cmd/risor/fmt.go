@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/format"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const fmtExample = `  risor fmt ./path/to/script.risor
+
+  risor fmt -w .
+
+  risor fmt --check ./lib
+
+  cat script.risor | risor fmt`
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [paths...]",
+	Short: "Format Risor source code",
+	Long: `Format Risor source code in the canonical style. Directories are searched
+recursively for ".risor" files. The formatted source is written to stdout,
+unless -w is given, which rewrites the files in place. With --check, the names
+of files that are not formatted are printed and the exit status is 1 if there
+are any. Source is read from stdin when no paths are given.`,
+	Example: fmtExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+		write := viper.GetBool("write")
+		check := viper.GetBool("check")
+
+		if len(args) == 0 {
+			if write {
+				fatal("cannot use -w when reading from stdin")
+			}
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fatal(err)
+			}
+			formatted, err := format.Source(ctx, string(input))
+			if err != nil {
+				fatal(err)
+			}
+			if check {
+				if formatted != string(input) {
+					fmt.Println("<stdin>")
+					os.Exit(1)
+				}
+				return
+			}
+			fmt.Print(formatted)
+			return
+		}
+
+		files, err := findSourceFiles(args)
+		if err != nil {
+			fatal(err)
+		}
+		var unformatted, failed bool
+		for _, path := range files {
+			changed, err := formatFile(ctx, path, write, check)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, red("%s: %s", path, err))
+				failed = true
+				continue
+			}
+			if changed && check {
+				fmt.Println(path)
+				unformatted = true
+			}
+		}
+		if failed || unformatted {
+			os.Exit(1)
+		}
+	},
+}
+
+// formatFile formats one file and reports whether its formatting changed.
+// Unless write or check is set, the formatted source is printed.
+func formatFile(ctx context.Context, path string, write, check bool) (bool, error) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	formatted, err := format.Source(ctx, string(input))
+	if err != nil {
+		return false, err
+	}
+	changed := formatted != string(input)
+	switch {
+	case check:
+	case write:
+		if changed {
+			info, err := os.Stat(path)
+			if err != nil {
+				return false, err
+			}
+			if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+				return false, err
+			}
+		}
+	default:
+		fmt.Print(formatted)
+	}
+	return changed, nil
+}
+
+// findSourceFiles returns the given files, and the ".risor" files found in the
+// given directories, skipping hidden directories.
+func findSourceFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(d.Name(), ".risor") {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolP("write", "w", false, "Write the formatted source back to the files")
+	fmtCmd.Flags().Bool("check", false, "List files that are not formatted and exit with status 1 if any")
+	viper.BindPFlag("write", fmtCmd.Flags().Lookup("write"))
+	viper.BindPFlag("check", fmtCmd.Flags().Lookup("check"))
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/lint"
+	"github.com/risor-io/risor/parser"
+	"github.com/spf13/cobra"
+)
+
+const lintExample = `  risor lint
+
+  risor lint ./path/to/script.risor --format json
+
+  risor lint ./lib --disable shadow,unused-import`
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [paths...]",
+	Short: "Report likely mistakes in Risor source code",
+	Long: `Check Risor source code for likely mistakes, such as unused variables and
+imports, shadowed names, assignments to constants, unreachable code, undefined
+names, and calls to builtins with the wrong number of arguments. Directories
+are searched recursively for ".risor" files, and the current directory is used
+when no paths are given. The exit status is 1 if any problems are found.
+
+Rules may be turned off with --disable, or in the source with comments:
+"lint:ignore <rules>" applies to its own line and the next, and
+"lint:disable <rules>" applies to the whole file. Rules are separated by
+commas, and all rules are suppressed when none are listed.`,
+	Example: lintExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			fatal(fmt.Sprintf("unknown format: %s", format))
+		}
+		disable, _ := cmd.Flags().GetStringSlice("disable")
+		var disabled []lint.Rule
+		for _, name := range disable {
+			rule, err := lintRule(name)
+			if err != nil {
+				fatal(err)
+			}
+			disabled = append(disabled, rule)
+		}
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		files, err := findSourceFiles(args)
+		if err != nil {
+			fatal(err)
+		}
+		globalNames := risor.NewConfig(getRisorOptions()...).GlobalNames()
+		problems := []lintProblem{}
+		for _, path := range files {
+			names := globalNames
+			if strings.HasSuffix(path, "_test.risor") {
+				names = append(names[:len(names):len(names)], "testing")
+			}
+			found, err := lintFile(ctx, path, lint.WithGlobalNames(names), lint.WithDisabledRules(disabled...))
+			if err != nil {
+				fatal(err)
+			}
+			problems = append(problems, found...)
+		}
+
+		switch format {
+		case "json":
+			err = writeLintJSON(os.Stdout, problems)
+		default:
+			err = writeLintText(os.Stdout, problems)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// lintProblem is a diagnostic reported for a file.
+type lintProblem struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line"`
+	EndColumn int    `json:"end_column"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// lintFile checks one file. A file that fails to parse is reported as a
+// single problem with the "syntax" rule.
+func lintFile(ctx context.Context, path string, opts ...lint.Option) ([]lintProblem, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics, err := lint.Source(ctx, string(src), opts...)
+	if err != nil {
+		var perr parser.ParserError
+		if !errors.As(err, &perr) {
+			return nil, err
+		}
+		start, end := perr.StartPosition(), perr.EndPosition()
+		return []lintProblem{{
+			File:      path,
+			Line:      start.LineNumber(),
+			Column:    start.ColumnNumber(),
+			EndLine:   end.LineNumber(),
+			EndColumn: end.ColumnNumber(),
+			Rule:      "syntax",
+			Severity:  string(lint.Error),
+			Message:   perr.Error(),
+		}}, nil
+	}
+	var problems []lintProblem
+	for _, d := range diagnostics {
+		problems = append(problems, lintProblem{
+			File:      path,
+			Line:      d.Start.LineNumber(),
+			Column:    d.Start.ColumnNumber(),
+			EndLine:   d.End.LineNumber(),
+			EndColumn: d.End.ColumnNumber(),
+			Rule:      string(d.Rule),
+			Severity:  string(d.Severity),
+			Message:   d.Message,
+		})
+	}
+	return problems, nil
+}
+
+func writeLintText(w io.Writer, problems []lintProblem) error {
+	for _, p := range problems {
+		msg := fmt.Sprintf("%s:%d:%d: %s (%s)", p.File, p.Line, p.Column, p.Message, p.Rule)
+		if p.Severity == string(lint.Error) {
+			msg = red("%s", msg)
+		}
+		if _, err := fmt.Fprintln(w, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeLintJSON(w io.Writer, problems []lintProblem) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(problems)
+}
+
+func lintRule(name string) (lint.Rule, error) {
+	for _, rule := range lint.Rules() {
+		if string(rule) == name {
+			return rule, nil
+		}
+	}
+	var names []string
+	for _, rule := range lint.Rules() {
+		names = append(names, string(rule))
+	}
+	return "", fmt.Errorf("unknown lint rule %q (rules: %s)", name, strings.Join(names, ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().String("format", "text", "Output format (text or json)")
+	lintCmd.Flags().StringSlice("disable", nil, "Rules to turn off, separated by commas")
+}
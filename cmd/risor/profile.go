@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/viper"
+)
+
+// startProfiler starts a Profiler if a profile was requested, or returns nil.
+func startProfiler() *vm.Profiler {
+	if viper.GetString("profile") == "" {
+		return nil
+	}
+	p := vm.NewProfiler(0)
+	p.Start()
+	return p
+}
+
+// writeProfile stops the profiler and writes the profile to the requested
+// file.
+func writeProfile(p *vm.Profiler) {
+	p.Stop()
+	f, err := os.Create(viper.GetString("profile"))
+	if err != nil {
+		fatal(err)
+	}
+	defer f.Close()
+	if err := p.WriteProfile(f); err != nil {
+		fatal(err)
+	}
+}
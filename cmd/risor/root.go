@@ -33,6 +33,9 @@ func init() {
 	rootCmd.PersistentFlags().StringP("code", "c", "", "Code to evaluate")
 	rootCmd.PersistentFlags().Bool("stdin", false, "Read code from stdin")
 	rootCmd.PersistentFlags().String("cpu-profile", "", "Capture a CPU profile")
+	rootCmd.PersistentFlags().String("profile", "", "Write a pprof profile of the Risor code to this file")
+	rootCmd.PersistentFlags().String("coverage", "", "Write an LCOV coverage report to this file")
+	rootCmd.PersistentFlags().String("coverage-html", "", "Write an HTML coverage report to this file")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().Bool("virtual-os", false, "Enable a virtual operating system")
 	rootCmd.PersistentFlags().StringArrayP("mount", "m", []string{}, "Mount a filesystem")
@@ -43,6 +46,9 @@ func init() {
 	viper.BindPFlag("code", rootCmd.PersistentFlags().Lookup("code"))
 	viper.BindPFlag("stdin", rootCmd.PersistentFlags().Lookup("stdin"))
 	viper.BindPFlag("cpu-profile", rootCmd.PersistentFlags().Lookup("cpu-profile"))
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	viper.BindPFlag("coverage", rootCmd.PersistentFlags().Lookup("coverage"))
+	viper.BindPFlag("coverage-html", rootCmd.PersistentFlags().Lookup("coverage-html"))
 	viper.BindPFlag("no-color", rootCmd.PersistentFlags().Lookup("no-color"))
 	viper.BindPFlag("virtual-os", rootCmd.PersistentFlags().Lookup("virtual-os"))
 	viper.BindPFlag("mount", rootCmd.PersistentFlags().Lookup("mount"))
@@ -179,10 +185,27 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			fatal(err)
 		}
+		if len(args) > 0 {
+			opts = append(opts, risor.WithFilename(args[0]))
+		}
+		cov := newCoverage()
+		if cov != nil {
+			opts = append(opts, risor.WithCoverage(cov))
+		}
+		profiler := startProfiler()
+		if profiler != nil {
+			opts = append(opts, risor.WithProfiler(profiler))
+		}
 
 		// Execute the code
 		start := time.Now()
 		result, err := risor.Eval(ctx, code, opts...)
+		if profiler != nil {
+			writeProfile(profiler)
+		}
+		if cov != nil {
+			writeCoverage(cov)
+		}
 		if err != nil {
 			errMsg := err.Error()
 			if friendlyErr, ok := err.(errz.FriendlyError); ok {
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/risor-io/risor/cmd/risor/testrunner"
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const testExample = `  risor test
+
+  risor test ./lib --run "test_parse.*" -v
+
+  risor test --format junit --out report.xml
+
+  risor test --coverage coverage.lcov --coverage-html coverage.html`
+
+var testCmd = &cobra.Command{
+	Use:   "test [paths...]",
+	Short: "Run Risor tests",
+	Long: `Run the tests in Risor test scripts. Test scripts are files whose names end in
+"_test.risor". Directories are searched recursively, and the current directory
+is used when no paths are given. Each top-level function whose name begins
+with "test_" is run as a test in its own VM. The testing module is available
+to test scripts as a global.`,
+	Example: testExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+
+		opts := testrunner.Options{Risor: getRisorOptions()}
+		if pattern := viper.GetString("run"); pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fatal(fmt.Errorf("invalid --run pattern: %w", err))
+			}
+			opts.Run = re
+		}
+		files, err := testrunner.Discover(args)
+		if err != nil {
+			fatal(err)
+		}
+		cov := newCoverage()
+		if cov != nil {
+			opts.VM = append(opts.VM, vm.WithCoverage(cov))
+		}
+		profiler := startProfiler()
+		if profiler != nil {
+			opts.VM = append(opts.VM, vm.WithProfiler(profiler))
+		}
+		report := testrunner.Run(ctx, files, opts)
+		if profiler != nil {
+			writeProfile(profiler)
+		}
+
+		var w io.Writer = os.Stdout
+		if path := viper.GetString("out"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		switch format := viper.GetString("format"); format {
+		case "text", "":
+			err = testrunner.WriteText(w, report, viper.GetBool("verbose"))
+		case "json":
+			err = testrunner.WriteJSON(w, report)
+		case "junit":
+			err = testrunner.WriteJUnit(w, report)
+		default:
+			fatal(fmt.Sprintf("unknown format: %s", format))
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if cov != nil {
+			writeCoverage(cov)
+		}
+		if report.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+	testCmd.Flags().String("format", "text", "Report format (text, json, or junit)")
+	testCmd.Flags().String("run", "", "Run only tests whose names match this regular expression")
+	testCmd.Flags().String("out", "", "Write the report to this file")
+	testCmd.Flags().BoolP("verbose", "v", false, "Show passing tests and test output")
+	viper.BindPFlag("format", testCmd.Flags().Lookup("format"))
+	viper.BindPFlag("run", testCmd.Flags().Lookup("run"))
+	viper.BindPFlag("out", testCmd.Flags().Lookup("out"))
+	viper.BindPFlag("verbose", testCmd.Flags().Lookup("verbose"))
+}
@@ -0,0 +1,202 @@
+package testrunner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText writes a human readable report. Passing tests are only listed
+// when verbose is true. The output of a test is shown if it failed or if
+// verbose is true.
+func WriteText(w io.Writer, report *Report, verbose bool) error {
+	for _, result := range report.Results {
+		if result.Status == StatusPass && !verbose {
+			continue
+		}
+		name := result.Name
+		if name == "" {
+			name = "(load)"
+		}
+		label := strings.ToUpper(string(result.Status))
+		if _, err := fmt.Fprintf(w, "--- %s: %s %s (%s)\n", label, result.File, name,
+			formatSeconds(result.Duration)); err != nil {
+			return err
+		}
+		if result.Message != "" {
+			if _, err := io.WriteString(w, indent(result.Message)); err != nil {
+				return err
+			}
+		}
+		if result.Output != "" && (verbose || result.Status == StatusFail) {
+			if _, err := io.WriteString(w, indent(result.Output)); err != nil {
+				return err
+			}
+		}
+	}
+	status := "PASS"
+	if report.Failed() {
+		status = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "%s: %d passed, %d failed, %d skipped (%s)\n", status,
+		report.Count(StatusPass), report.Count(StatusFail), report.Count(StatusSkip),
+		formatSeconds(report.Duration))
+	return err
+}
+
+type jsonResult struct {
+	File     string  `json:"file"`
+	Name     string  `json:"name"`
+	Status   Status  `json:"status"`
+	Duration float64 `json:"duration"`
+	Message  string  `json:"message,omitempty"`
+	Output   string  `json:"output,omitempty"`
+}
+
+type jsonReport struct {
+	Passed   int          `json:"passed"`
+	Failed   int          `json:"failed"`
+	Skipped  int          `json:"skipped"`
+	Duration float64      `json:"duration"`
+	Results  []jsonResult `json:"results"`
+}
+
+// WriteJSON writes the report as a JSON document. Durations are in seconds.
+func WriteJSON(w io.Writer, report *Report) error {
+	doc := jsonReport{
+		Passed:   report.Count(StatusPass),
+		Failed:   report.Count(StatusFail),
+		Skipped:  report.Count(StatusSkip),
+		Duration: report.Duration.Seconds(),
+		Results:  []jsonResult{},
+	}
+	for _, result := range report.Results {
+		doc.Results = append(doc.Results, jsonResult{
+			File:     result.File,
+			Name:     result.Name,
+			Status:   result.Status,
+			Duration: result.Duration.Seconds(),
+			Message:  result.Message,
+			Output:   result.Output,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report in the JUnit XML format understood by most CI
+// systems. Each file is reported as a test suite.
+func WriteJUnit(w io.Writer, report *Report) error {
+	doc := junitTestSuites{
+		Tests:    len(report.Results),
+		Failures: report.Count(StatusFail),
+		Skipped:  report.Count(StatusSkip),
+		Time:     junitTime(report.Duration),
+	}
+	suites := map[string]int{}
+	durations := map[string]time.Duration{}
+	for _, result := range report.Results {
+		index, ok := suites[result.File]
+		if !ok {
+			index = len(doc.Suites)
+			suites[result.File] = index
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: result.File})
+		}
+		suite := &doc.Suites[index]
+		name := result.Name
+		if name == "" {
+			name = "(load)"
+		}
+		testCase := junitTestCase{
+			Name:      name,
+			ClassName: result.File,
+			Time:      junitTime(result.Duration),
+			SystemOut: result.Output,
+		}
+		switch result.Status {
+		case StatusFail:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: firstLine(result.Message), Text: result.Message}
+		case StatusSkip:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: result.Message}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+		durations[result.File] += result.Duration
+	}
+	for i := range doc.Suites {
+		doc.Suites[i].Time = junitTime(durations[doc.Suites[i].Name])
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func indent(s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		b.WriteString("    ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
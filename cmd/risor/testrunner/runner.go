@@ -0,0 +1,257 @@
+// Package testrunner discovers and runs Risor test scripts. A test script is
+// a file whose name ends in "_test.risor". Each top-level function in the
+// script whose name begins with "test_" is run as a test.
+package testrunner
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/errz"
+	modTesting "github.com/risor-io/risor/modules/testing"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+)
+
+const (
+	fileSuffix = "_test.risor"
+	testPrefix = "test_"
+)
+
+// Status is the outcome of a single test.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result describes the outcome of a single test. A script that fails to load
+// is reported as a failed result with an empty Name.
+type Result struct {
+	File     string
+	Name     string
+	Status   Status
+	Duration time.Duration
+	Message  string
+	Output   string
+}
+
+// Report holds the results of a test run.
+type Report struct {
+	Results  []Result
+	Duration time.Duration
+}
+
+// Count returns the number of results with the given status.
+func (r *Report) Count(status Status) int {
+	var count int
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns true if any test failed.
+func (r *Report) Failed() bool {
+	return r.Count(StatusFail) > 0
+}
+
+// Options configures a test run.
+type Options struct {
+	// Risor options used to compile and run each script.
+	Risor []risor.Option
+	// VM options added to the options derived from the Risor options.
+	VM []vm.Option
+	// Run selects the tests to run by name. All tests run when it is nil.
+	Run *regexp.Regexp
+}
+
+// Discover returns the test scripts found in the given paths, in sorted
+// order. Directories are searched recursively. Files named explicitly are
+// included regardless of their name.
+func Discover(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	seen := map[string]bool{}
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(d.Name(), fileSuffix) {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run runs the tests in each of the given files.
+func Run(ctx context.Context, files []string, opts Options) *Report {
+	start := time.Now()
+	report := &Report{}
+	for _, file := range files {
+		report.Results = append(report.Results, RunFile(ctx, file, opts)...)
+	}
+	report.Duration = time.Since(start)
+	return report
+}
+
+// RunFile runs the tests in one file. Each test runs in a new VM, so state
+// set by one test is not visible to the others.
+func RunFile(ctx context.Context, file string, opts Options) []Result {
+	start := time.Now()
+	code, cfg, err := load(ctx, file, opts)
+	if err != nil {
+		return []Result{{
+			File:     file,
+			Status:   StatusFail,
+			Duration: time.Since(start),
+			Message:  errorMessage(err),
+		}}
+	}
+	vmOpts := append(cfg.VMOpts(), opts.VM...)
+	var results []Result
+	for _, name := range testNames(code) {
+		if opts.Run != nil && !opts.Run.MatchString(name) {
+			continue
+		}
+		results = append(results, runTest(ctx, code, vmOpts, file, name))
+	}
+	return results
+}
+
+func load(ctx context.Context, file string, opts Options) (*compiler.Code, *risor.Config, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	options := append([]risor.Option{
+		risor.WithGlobal("testing", modTesting.Module()),
+	}, opts.Risor...)
+	options = append(options, risor.WithFilename(file))
+	cfg := risor.NewConfig(options...)
+	ast, err := parser.Parse(ctx, string(source), cfg.ParserOpts()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	code, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return code, cfg, nil
+}
+
+// testNames returns the names of the test functions declared in the code,
+// in the order they were declared.
+func testNames(code *compiler.Code) []string {
+	var names []string
+	for i := 0; i < code.ConstantsCount(); i++ {
+		fn, ok := code.Constant(i).(*compiler.Function)
+		if !ok {
+			continue
+		}
+		if name := fn.Name(); strings.HasPrefix(name, testPrefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func runTest(ctx context.Context, code *compiler.Code, vmOpts []vm.Option, file, name string) Result {
+	result := Result{File: file, Name: name}
+	stdout := ros.NewBufferFile(nil)
+	ctx = ros.WithOS(ctx, &captureOS{OS: ros.GetDefaultOS(ctx), stdout: stdout})
+	start := time.Now()
+	err := callTest(ctx, code, vmOpts, name)
+	result.Duration = time.Since(start)
+	result.Output = string(stdout.Bytes())
+	var skipErr *modTesting.SkipError
+	switch {
+	case err == nil:
+		result.Status = StatusPass
+	case errors.As(err, &skipErr):
+		result.Status = StatusSkip
+		result.Message = skipErr.Reason
+	default:
+		result.Status = StatusFail
+		result.Message = errorMessage(err)
+	}
+	return result
+}
+
+func callTest(ctx context.Context, code *compiler.Code, vmOpts []vm.Option, name string) error {
+	machine := vm.New(code, vmOpts...)
+	if err := machine.Run(ctx); err != nil {
+		return err
+	}
+	obj, err := machine.Get(name)
+	if err != nil {
+		return err
+	}
+	fn, ok := obj.(*object.Function)
+	if !ok {
+		return errz.TypeErrorf("type error: %s is not a function (got %s)", name, obj.Type())
+	}
+	_, err = machine.Call(ctx, fn, nil)
+	return err
+}
+
+func errorMessage(err error) string {
+	if friendly, ok := err.(errz.FriendlyError); ok {
+		return friendly.FriendlyErrorMessage()
+	}
+	return err.Error()
+}
+
+// captureOS redirects the standard output of a test to a buffer.
+type captureOS struct {
+	ros.OS
+	stdout ros.File
+}
+
+func (o *captureOS) Stdout() ros.File {
+	return o.stdout
+}
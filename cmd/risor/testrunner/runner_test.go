@@ -0,0 +1,120 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	files, err := Discover([]string{"testdata"})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filepath.Join("testdata", "math_test.risor"),
+		filepath.Join("testdata", "sub", "broken_test.risor"),
+	}, files)
+
+	files, err = Discover([]string{filepath.Join("testdata", "sub", "helper.risor")})
+	require.Nil(t, err)
+	require.Equal(t, []string{filepath.Join("testdata", "sub", "helper.risor")}, files)
+
+	_, err = Discover([]string{"testdata/missing"})
+	require.NotNil(t, err)
+}
+
+func TestRunFile(t *testing.T) {
+	file := filepath.Join("testdata", "math_test.risor")
+	results := RunFile(context.Background(), file, Options{})
+	require.Len(t, results, 4)
+
+	require.Equal(t, "test_add", results[0].Name)
+	require.Equal(t, StatusPass, results[0].Status)
+
+	require.Equal(t, "test_add_fails", results[1].Name)
+	require.Equal(t, StatusFail, results[1].Status)
+	require.Equal(t, "adding\n", results[1].Output)
+	require.Contains(t, results[1].Message, "sum: values are not equal")
+	require.Contains(t, results[1].Message, "at test_add_fails ("+file+":11:12)")
+
+	require.Equal(t, "test_skipped", results[2].Name)
+	require.Equal(t, StatusSkip, results[2].Status)
+	require.Equal(t, "not ready", results[2].Message)
+
+	require.Equal(t, "test_raises", results[3].Name)
+	require.Equal(t, StatusPass, results[3].Status)
+}
+
+func TestRunFilter(t *testing.T) {
+	file := filepath.Join("testdata", "math_test.risor")
+	results := RunFile(context.Background(), file, Options{Run: regexp.MustCompile("^test_add$")})
+	require.Len(t, results, 1)
+	require.Equal(t, "test_add", results[0].Name)
+}
+
+func TestRunLoadError(t *testing.T) {
+	file := filepath.Join("testdata", "sub", "broken_test.risor")
+	results := RunFile(context.Background(), file, Options{})
+	require.Len(t, results, 1)
+	require.Equal(t, "", results[0].Name)
+	require.Equal(t, StatusFail, results[0].Status)
+	require.NotEmpty(t, results[0].Message)
+}
+
+func TestReports(t *testing.T) {
+	files, err := Discover([]string{"testdata"})
+	require.Nil(t, err)
+	report := Run(context.Background(), files, Options{})
+	require.True(t, report.Failed())
+	require.Equal(t, 2, report.Count(StatusPass))
+	require.Equal(t, 2, report.Count(StatusFail))
+	require.Equal(t, 1, report.Count(StatusSkip))
+
+	var text bytes.Buffer
+	require.Nil(t, WriteText(&text, report, false))
+	require.NotContains(t, text.String(), "--- PASS")
+	require.Contains(t, text.String(), "--- FAIL: testdata/math_test.risor test_add_fails")
+	require.Contains(t, text.String(), "    adding\n")
+	require.Contains(t, text.String(), "--- SKIP: testdata/math_test.risor test_skipped")
+	require.Contains(t, text.String(), "FAIL: 2 passed, 2 failed, 1 skipped")
+
+	var doc jsonReport
+	var data bytes.Buffer
+	require.Nil(t, WriteJSON(&data, report))
+	require.Nil(t, json.Unmarshal(data.Bytes(), &doc))
+	require.Equal(t, 2, doc.Failed)
+	require.Len(t, doc.Results, 5)
+	require.Equal(t, "test_add", doc.Results[0].Name)
+
+	var junit bytes.Buffer
+	require.Nil(t, WriteJUnit(&junit, report))
+	out := junit.String()
+	require.True(t, strings.HasPrefix(out, "<?xml"))
+	require.Contains(t, out, `<testsuites tests="5" failures="2" skipped="1"`)
+	require.Contains(t, out, `<testsuite name="testdata/math_test.risor" tests="4" failures="1" skipped="1"`)
+	require.Contains(t, out, `<skipped message="not ready"></skipped>`)
+	require.Contains(t, out, `<failure message="sum: values are not equal">`)
+}
+
+func TestRunCoverage(t *testing.T) {
+	cov := vm.NewCoverage()
+	file := filepath.Join("testdata", "math_test.risor")
+	RunFile(context.Background(), file, Options{VM: []vm.Option{vm.WithCoverage(cov)}})
+	files := cov.Files()
+	require.Len(t, files, 1)
+	require.Equal(t, file, files[0].Filename)
+	for _, fn := range files[0].Functions {
+		if fn.Name == "add" {
+			require.Equal(t, 2, fn.Hits)
+		}
+		if fn.Name == "helper" {
+			require.Equal(t, 0, fn.Hits)
+		}
+	}
+}
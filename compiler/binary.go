@@ -0,0 +1,526 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/risor-io/risor/op"
+)
+
+// BytecodeVersion is the version of the binary bytecode format written by
+// MarshalCodeBinary. It must be incremented whenever the encoding or the
+// instruction set changes, so that bytecode written by one release is never
+// run by another that would interpret it differently.
+//
+// Version 2 includes the comprehension opcodes LIST_APPEND, SET_ADD, and
+// MAP_ADD, the ForIterItem flag of FOR_ITER, and LOAD_FREE_CELL.
+const BytecodeVersion = 2
+
+// bytecodeMagic identifies data written by MarshalCodeBinary.
+var bytecodeMagic = []byte("RSBC")
+
+// The header holds the magic, a uint16 version and a uint32 CRC-32 checksum
+// of the payload that follows it.
+const bytecodeHeaderSize = 10
+
+var (
+	// ErrInvalidBytecode is returned when decoding data that is not binary
+	// bytecode, or that is truncated or corrupted.
+	ErrInvalidBytecode = errors.New("invalid bytecode")
+
+	// ErrIncompatibleBytecode is returned when decoding bytecode written in a
+	// different version of the binary format.
+	ErrIncompatibleBytecode = errors.New("incompatible bytecode version")
+)
+
+// Tags that identify the type of each encoded constant.
+const (
+	constNil byte = iota
+	constFalse
+	constTrue
+	constInt
+	constFloat
+	constString
+	constFunction
+)
+
+// MarshalCodeBinary converts a Code object into a compact binary
+// representation, including its nested functions, symbol tables, constants
+// and source locations. Use UnmarshalCodeBinary to load it.
+func MarshalCodeBinary(code *Code) ([]byte, error) {
+	w := &binaryWriter{stringIndex: map[string]uint64{}}
+	if err := w.symbolTable(code.symbols); err != nil {
+		return nil, err
+	}
+	allCode := code.Flatten()
+	w.putUint(uint64(len(allCode)))
+	for _, c := range allCode {
+		if err := w.code(c); err != nil {
+			return nil, err
+		}
+	}
+	// The string table is written ahead of the body that refers to it
+	payload := binary.AppendUvarint(nil, uint64(len(w.strings)))
+	for _, s := range w.strings {
+		payload = binary.AppendUvarint(payload, uint64(len(s)))
+		payload = append(payload, s...)
+	}
+	payload = append(payload, w.body...)
+
+	data := make([]byte, 0, bytecodeHeaderSize+len(payload))
+	data = append(data, bytecodeMagic...)
+	data = binary.LittleEndian.AppendUint16(data, BytecodeVersion)
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(payload))
+	return append(data, payload...), nil
+}
+
+// UnmarshalCodeBinary converts the binary representation of a Code object,
+// as written by MarshalCodeBinary, into a Code. It returns an error wrapping
+// ErrIncompatibleBytecode if the data was written in a different version of
+// the format, or ErrInvalidBytecode if it is malformed.
+func UnmarshalCodeBinary(data []byte) (*Code, error) {
+	if len(data) < bytecodeHeaderSize || !bytes.Equal(data[:len(bytecodeMagic)], bytecodeMagic) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidBytecode)
+	}
+	version := binary.LittleEndian.Uint16(data[4:])
+	if version != BytecodeVersion {
+		return nil, fmt.Errorf("%w %d (expected %d)", ErrIncompatibleBytecode, version, BytecodeVersion)
+	}
+	payload := data[bytecodeHeaderSize:]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[6:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidBytecode)
+	}
+	r := &binaryReader{data: payload}
+	r.stringTable()
+	s := &state{SymbolTable: r.symbolTable()}
+	count := r.count()
+	constants := make([][]any, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		def, values := r.code()
+		s.Code = append(s.Code, def)
+		constants = append(constants, values)
+	}
+	if r.err == nil && r.pos != len(r.data) {
+		r.fail("unexpected data at offset %d", r.pos)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	code, err := linkCode(s, constants)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBytecode, err)
+	}
+	return code, nil
+}
+
+// binaryWriter encodes integers as varints and strings as indexes into a
+// table, so that each distinct string is only stored once.
+type binaryWriter struct {
+	body        []byte
+	strings     []string
+	stringIndex map[string]uint64
+}
+
+func (w *binaryWriter) putUint(v uint64) {
+	w.body = binary.AppendUvarint(w.body, v)
+}
+
+func (w *binaryWriter) putInt(v int64) {
+	w.body = binary.AppendVarint(w.body, v)
+}
+
+func (w *binaryWriter) putBool(v bool) {
+	if v {
+		w.body = append(w.body, 1)
+	} else {
+		w.body = append(w.body, 0)
+	}
+}
+
+func (w *binaryWriter) putString(s string) {
+	index, found := w.stringIndex[s]
+	if !found {
+		index = uint64(len(w.strings))
+		w.strings = append(w.strings, s)
+		w.stringIndex[s] = index
+	}
+	w.putUint(index)
+}
+
+func (w *binaryWriter) putStrings(values []string) {
+	w.putUint(uint64(len(values)))
+	for _, s := range values {
+		w.putString(s)
+	}
+}
+
+func (w *binaryWriter) constant(c any) error {
+	switch c := c.(type) {
+	case nil:
+		w.body = append(w.body, constNil)
+	case bool:
+		if c {
+			w.body = append(w.body, constTrue)
+		} else {
+			w.body = append(w.body, constFalse)
+		}
+	case int:
+		w.body = append(w.body, constInt)
+		w.putInt(int64(c))
+	case int64:
+		w.body = append(w.body, constInt)
+		w.putInt(c)
+	case float32:
+		w.body = append(w.body, constFloat)
+		w.body = binary.LittleEndian.AppendUint64(w.body, math.Float64bits(float64(c)))
+	case float64:
+		w.body = append(w.body, constFloat)
+		w.body = binary.LittleEndian.AppendUint64(w.body, math.Float64bits(c))
+	case string:
+		w.body = append(w.body, constString)
+		w.putString(c)
+	case *Function:
+		w.body = append(w.body, constFunction)
+		w.putString(c.id)
+		w.putString(c.name)
+		w.putStrings(c.parameters)
+		w.putString(c.restParameter)
+		return w.constants(c.defaults)
+	default:
+		return fmt.Errorf("unknown constant type: %T", c)
+	}
+	return nil
+}
+
+func (w *binaryWriter) constants(constants []any) error {
+	w.putUint(uint64(len(constants)))
+	for _, c := range constants {
+		if err := w.constant(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *binaryWriter) symbol(symbol *Symbol) error {
+	w.putString(symbol.name)
+	w.putUint(uint64(symbol.index))
+	w.putBool(symbol.isConstant)
+	return w.constant(symbol.value)
+}
+
+func (w *binaryWriter) symbolTable(table *SymbolTable) error {
+	w.putString(table.ID())
+	w.putBool(table.isBlock)
+	w.putUint(uint64(len(table.symbols)))
+	for _, symbol := range table.symbols {
+		if err := w.symbol(symbol); err != nil {
+			return err
+		}
+	}
+	// Sort the names so the output doesn't depend on map ordering
+	names := make([]string, 0, len(table.symbolsByName))
+	for name := range table.symbolsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w.putUint(uint64(len(names)))
+	for _, name := range names {
+		w.putString(name)
+		if err := w.symbol(table.symbolsByName[name]); err != nil {
+			return err
+		}
+	}
+	w.putUint(uint64(len(table.free)))
+	for _, resolution := range table.free {
+		if err := w.symbol(resolution.symbol); err != nil {
+			return err
+		}
+		w.putString(string(resolution.scope))
+		w.putInt(int64(resolution.depth))
+		w.putInt(int64(resolution.freeIndex))
+	}
+	w.putUint(uint64(len(table.children)))
+	for _, child := range table.children {
+		if err := w.symbolTable(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *binaryWriter) code(code *Code) error {
+	w.putString(code.id)
+	w.putString(code.name)
+	if code.parent != nil {
+		w.putString(code.parent.id)
+	} else {
+		w.putString("")
+	}
+	w.putString(code.symbols.ID())
+	w.putString(code.functionID)
+	w.putBool(code.isGenerator)
+	w.putUint(uint64(len(code.instructions)))
+	for _, instr := range code.instructions {
+		w.putUint(uint64(instr))
+	}
+	if err := w.constants(code.constants); err != nil {
+		return err
+	}
+	w.putStrings(code.names)
+	w.putString(code.source)
+	w.putString(code.filename)
+	// Line table offsets are increasing, so they are stored as deltas
+	table := tableFromLocations(code.locations)
+	w.putUint(uint64(len(table)))
+	offset := 0
+	for _, entry := range table {
+		w.putUint(uint64(entry[0] - offset))
+		w.putInt(int64(entry[1]))
+		w.putInt(int64(entry[2]))
+		offset = entry[0]
+	}
+	return nil
+}
+
+// binaryReader decodes data written by a binaryWriter. The first error is
+// kept in err and later reads return zero values, so callers only need to
+// check for an error once they're done.
+type binaryReader struct {
+	data    []byte
+	pos     int
+	strings []string
+	err     error
+}
+
+func (r *binaryReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s", ErrInvalidBytecode, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *binaryReader) readByte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *binaryReader) readBool() bool {
+	return r.readByte() != 0
+}
+
+func (r *binaryReader) readUint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("invalid varint at offset %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *binaryReader) readInt() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("invalid varint at offset %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *binaryReader) readFloat() float64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data)-r.pos < 8 {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+	r.pos += 8
+	return v
+}
+
+// count reads the length of a list. Every item takes at least one byte, so
+// lengths longer than the remaining data are rejected before allocating.
+func (r *binaryReader) count() int {
+	n := r.readUint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("invalid length %d at offset %d", n, r.pos)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) stringTable() {
+	count := r.count()
+	r.strings = make([]string, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		n := r.count()
+		r.strings = append(r.strings, string(r.data[r.pos:r.pos+n]))
+		r.pos += n
+	}
+}
+
+func (r *binaryReader) readString() string {
+	index := r.readUint()
+	if r.err != nil {
+		return ""
+	}
+	if index >= uint64(len(r.strings)) {
+		r.fail("invalid string index %d", index)
+		return ""
+	}
+	return r.strings[index]
+}
+
+// readStrings reads a list of strings, which is nil if the list is empty.
+func (r *binaryReader) readStrings() []string {
+	count := r.count()
+	if count == 0 {
+		return nil
+	}
+	values := make([]string, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		values = append(values, r.readString())
+	}
+	return values
+}
+
+func (r *binaryReader) constant() any {
+	switch tag := r.readByte(); tag {
+	case constNil:
+		return nil
+	case constFalse:
+		return false
+	case constTrue:
+		return true
+	case constInt:
+		return r.readInt()
+	case constFloat:
+		return r.readFloat()
+	case constString:
+		return r.readString()
+	case constFunction:
+		opts := FunctionOpts{ID: r.readString(), Name: r.readString()}
+		opts.Parameters = make([]string, 0, r.count())
+		for i := 0; i < cap(opts.Parameters) && r.err == nil; i++ {
+			opts.Parameters = append(opts.Parameters, r.readString())
+		}
+		opts.RestParameter = r.readString()
+		opts.Defaults = make([]any, 0, r.count())
+		for i := 0; i < cap(opts.Defaults) && r.err == nil; i++ {
+			opts.Defaults = append(opts.Defaults, r.constant())
+		}
+		return NewFunction(opts)
+	default:
+		r.fail("unknown constant type %d", tag)
+		return nil
+	}
+}
+
+// constants reads a list of constants, which is nil if the list is empty.
+func (r *binaryReader) constants() []any {
+	count := r.count()
+	if count == 0 {
+		return nil
+	}
+	values := make([]any, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		values = append(values, r.constant())
+	}
+	return values
+}
+
+func (r *binaryReader) symbol() *symbolDef {
+	def := &symbolDef{Name: r.readString()}
+	index := r.readUint()
+	if index > math.MaxUint16 {
+		r.fail("invalid symbol index %d", index)
+	}
+	def.Index = uint16(index)
+	def.IsConstant = r.readBool()
+	def.Value = r.constant()
+	return def
+}
+
+func (r *binaryReader) symbolTable() *symbolTableDef {
+	def := &symbolTableDef{
+		ID:            r.readString(),
+		IsBlock:       r.readBool(),
+		SymbolsByName: map[string]*symbolDef{},
+	}
+	count := r.count()
+	for i := 0; i < count && r.err == nil; i++ {
+		def.Symbols = append(def.Symbols, r.symbol())
+	}
+	count = r.count()
+	for i := 0; i < count && r.err == nil; i++ {
+		name := r.readString()
+		def.SymbolsByName[name] = r.symbol()
+	}
+	count = r.count()
+	for i := 0; i < count && r.err == nil; i++ {
+		def.Free = append(def.Free, &resolutionDef{
+			Symbol:    r.symbol(),
+			Scope:     Scope(r.readString()),
+			Depth:     int(r.readInt()),
+			FreeIndex: int(r.readInt()),
+		})
+	}
+	count = r.count()
+	for i := 0; i < count && r.err == nil; i++ {
+		def.Children = append(def.Children, r.symbolTable())
+	}
+	return def
+}
+
+func (r *binaryReader) code() (*codeDef, []any) {
+	def := &codeDef{
+		ID:            r.readString(),
+		Name:          r.readString(),
+		ParentID:      r.readString(),
+		SymbolTableID: r.readString(),
+		FunctionID:    r.readString(),
+		Generator:     r.readBool(),
+	}
+	count := r.count()
+	def.Instructions = make([]op.Code, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		instr := r.readUint()
+		if instr > math.MaxUint16 {
+			r.fail("invalid instruction %d", instr)
+		}
+		def.Instructions = append(def.Instructions, op.Code(instr))
+	}
+	constants := r.constants()
+	def.Names = r.readStrings()
+	def.Source = r.readString()
+	def.Filename = r.readString()
+	count = r.count()
+	offset := 0
+	for i := 0; i < count && r.err == nil; i++ {
+		offset += int(r.readUint())
+		def.Locations = append(def.Locations, [3]int{offset, int(r.readInt()), int(r.readInt())})
+	}
+	return def, constants
+}
@@ -0,0 +1,207 @@
+package compiler
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+// clearCompileState clears state that is only used during compilation.
+func clearCompileState(code *Code) {
+	code.loops = nil
+	code.tries = nil
+	for _, child := range code.children {
+		clearCompileState(child)
+	}
+}
+
+func TestMarshalCodeBinary(t *testing.T) {
+	tests := []string{
+		`x := 1.0; y := 2.0; x + y`,
+		`func test(a, b=2) {
+			if a > b { return a } else { return b }
+		}
+		test(1) + test(2, 3)`,
+		`func log(level, ...parts) { return [level, parts] }
+		log("info", ...[1, 2], **{"level": "debug"})`,
+		`func pair(a, b) { yield a; yield b }`,
+		`start := 10
+		func counter(a) {
+			current := a
+			return func() {
+				current++
+				return current
+			}
+		}
+		c := counter(start)
+		c()`,
+		`func f(xs) {
+			total := 0
+			for _, x := range xs {
+				if x > 2 { break }
+				try { total += x } catch e { print(e) }
+			}
+			return total
+		}
+		const limit = 3
+		string(f([1, 2, 3])) + "!" * limit`,
+	}
+	for _, source := range tests {
+		codeA, err := compileSource(source)
+		require.Nil(t, err)
+		data, err := MarshalCodeBinary(codeA)
+		require.Nil(t, err)
+		codeB, err := UnmarshalCodeBinary(data)
+		require.Nil(t, err)
+		clearCompileState(codeA)
+		require.Equal(t, codeA, codeB)
+	}
+}
+
+func TestMarshalCodeBinaryLocations(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "x := 1\nfunc f(a) {\n  return a + x\n}\nf(2)",
+		parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	codeA, err := Compile(program)
+	require.Nil(t, err)
+	data, err := MarshalCodeBinary(codeA)
+	require.Nil(t, err)
+	codeB, err := UnmarshalCodeBinary(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+	fnA, fnB := codeA.Flatten()[1], codeB.Flatten()[1]
+	for i := 0; i < fnA.InstructionCount(); i++ {
+		require.Equal(t, fnA.Location(i), fnB.Location(i))
+	}
+	require.Equal(t, SourceLocation{Filename: "main.risor", Line: 3, Column: 3}, fnB.Location(fnB.InstructionCount()-1))
+}
+
+func TestMarshalCodeBinaryConstants(t *testing.T) {
+	c := Code{symbols: NewSymbolTable()}
+	c.constants = append(c.constants, int64(1), int64(-1<<63), 2.5, "three", true, false, nil)
+	data, err := MarshalCodeBinary(&c)
+	require.Nil(t, err)
+	c2, err := UnmarshalCodeBinary(data)
+	require.Nil(t, err)
+	require.Equal(t, c.constants, c2.constants)
+
+	c.constants = append(c.constants, []int{1})
+	_, err = MarshalCodeBinary(&c)
+	require.NotNil(t, err)
+	require.Equal(t, "unknown constant type: []int", err.Error())
+}
+
+func TestMarshalCodeBinarySize(t *testing.T) {
+	code, err := compileSource(`
+	func mergesort(arr) {
+		length := len(arr)
+		if length <= 1 {
+			return arr
+		}
+		mid := length / 2
+		left := mergesort(arr[:mid])
+		right := mergesort(arr[mid:])
+		output := list(length)
+		i, j, k := [0, 0, 0]
+		for i < len(left) {
+			for j < len(right) && right[j] <= left[i] {
+				output[k] = right[j]
+				k++
+				j++
+			}
+			output[k] = left[i]
+			k++
+			i++
+		}
+		return output
+	}
+	mergesort([3, 1, 2])
+	`)
+	require.Nil(t, err)
+	jsonData, err := MarshalCode(code)
+	require.Nil(t, err)
+	data, err := MarshalCodeBinary(code)
+	require.Nil(t, err)
+	require.Less(t, len(data), len(jsonData)/2)
+
+	// The output is deterministic
+	again, err := MarshalCodeBinary(code)
+	require.Nil(t, err)
+	require.Equal(t, data, again)
+}
+
+// withChecksum updates the checksum in the header to match the payload.
+func withChecksum(data []byte) []byte {
+	binary.LittleEndian.PutUint32(data[6:], crc32.ChecksumIEEE(data[bytecodeHeaderSize:]))
+	return data
+}
+
+func TestUnmarshalCodeBinaryErrors(t *testing.T) {
+	code, err := compileSource("func f(a) { return a * 2 }; f(21)")
+	require.Nil(t, err)
+	valid, err := MarshalCodeBinary(code)
+	require.Nil(t, err)
+	jsonData, err := MarshalCode(code)
+	require.Nil(t, err)
+
+	modify := func(fn func(data []byte) []byte) []byte {
+		data := make([]byte, len(valid))
+		copy(data, valid)
+		return fn(data)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+		message  string
+	}{
+		{"empty", nil, ErrInvalidBytecode, "invalid bytecode: missing header"},
+		{"json", jsonData, ErrInvalidBytecode, "invalid bytecode: missing header"},
+		{"newer version", modify(func(data []byte) []byte {
+			binary.LittleEndian.PutUint16(data[4:], BytecodeVersion+1)
+			return data
+		}), ErrIncompatibleBytecode, fmt.Sprintf("incompatible bytecode version %d (expected %d)",
+			BytecodeVersion+1, BytecodeVersion)},
+		{"corrupted", modify(func(data []byte) []byte {
+			data[len(data)-5] ^= 0xff
+			return data
+		}), ErrInvalidBytecode, "invalid bytecode: checksum mismatch"},
+		{"truncated", modify(func(data []byte) []byte {
+			return withChecksum(data[:len(data)-3])
+		}), ErrInvalidBytecode, ""},
+		{"trailing data", modify(func(data []byte) []byte {
+			return withChecksum(append(data, 0))
+		}), ErrInvalidBytecode, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalCodeBinary(tt.data)
+			require.NotNil(t, err)
+			require.True(t, errors.Is(err, tt.expected), err.Error())
+			if tt.message != "" {
+				require.Equal(t, tt.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestUnmarshalCodeBinaryTruncated(t *testing.T) {
+	code, err := compileSource(`func f(a, b=2.5) { return a * b }; f("x")`)
+	require.Nil(t, err)
+	data, err := MarshalCodeBinary(code)
+	require.Nil(t, err)
+	// Every prefix of the payload is rejected without panicking
+	for n := bytecodeHeaderSize; n < len(data); n++ {
+		prefix := make([]byte, n)
+		copy(prefix, data)
+		_, err := UnmarshalCodeBinary(withChecksum(prefix))
+		require.True(t, errors.Is(err, ErrInvalidBytecode), "length %d", n)
+	}
+}
@@ -4,13 +4,22 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/risor-io/risor/ast"
 	"github.com/risor-io/risor/op"
 )
 
 type loop struct {
 	code        *Code
+	label       string
 	continuePos []int
 	breakPos    []int
+	tryDepth    int
+	heldValues  int
+
+	// hasIterator is set for range loops, which hold their iterator on the
+	// stack above heldValues while they run. Continuing the loop keeps it,
+	// while breaking out of the loop pops it.
+	hasIterator bool
 }
 
 func (l *loop) end() {
@@ -18,10 +27,43 @@ func (l *loop) end() {
 	code.loops = code.loops[:len(code.loops)-1]
 }
 
+// tryBlock tracks an enclosing try statement during compilation, so that
+// control flow leaving the block can remove its exception handler and run
+// its finally block.
+type tryBlock struct {
+	hasHandler bool
+	finally    *ast.Block
+}
+
+// SourceLocation identifies the position in source code that an instruction
+// was compiled from. Line and Column are 1-indexed and zero when unknown.
+type SourceLocation struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// IsValid returns true if the location refers to a known line.
+func (l SourceLocation) IsValid() bool {
+	return l.Line > 0
+}
+
+func (l SourceLocation) String() string {
+	filename := l.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+	if !l.IsValid() {
+		return filename
+	}
+	return fmt.Sprintf("%s:%d:%d", filename, l.Line, l.Column)
+}
+
 type Code struct {
 	id           string
 	name         string
 	isNamed      bool
+	isGenerator  bool
 	parent       *Code
 	children     []*Code
 	symbols      *SymbolTable
@@ -30,10 +72,25 @@ type Code struct {
 	names        []string
 	source       string
 	functionID   string
+	filename     string
+
+	// locations holds one entry per instruction word, mapping each offset
+	// in instructions back to the source location it was compiled from.
+	locations []SourceLocation
 
 	// Used during compilation only
 	loops      []*loop
+	tries      []*tryBlock
 	pipeActive bool
+
+	// The number of values held on the stack by enclosing switch and match
+	// expressions, comprehensions and range loops, which break and continue
+	// statements must pop
+	heldValues int
+
+	// The jumps of the "?." accesses in the optional chain being compiled,
+	// which skip to the end of the chain when an object is nil
+	chainJumps []int
 }
 
 func (c *Code) ID() string {
@@ -53,6 +110,12 @@ func (c *Code) IsNamed() bool {
 	return c.isNamed
 }
 
+// IsGenerator returns true if the code is the body of a function that
+// contains a yield statement. Calling such a function returns a generator.
+func (c *Code) IsGenerator() bool {
+	return c.isGenerator
+}
+
 func (c *Code) FunctionID() string {
 	return c.functionID
 }
@@ -70,6 +133,7 @@ func (c *Code) newChild(name, source, funcID string) *Code {
 		symbols:    c.symbols.NewChild(),
 		source:     source,
 		functionID: funcID,
+		filename:   c.filename,
 	}
 	c.children = append(c.children, child)
 	return child
@@ -103,6 +167,22 @@ func (c *Code) Source() string {
 	return c.source
 }
 
+// Filename returns the name of the file this code was compiled from, if known.
+func (c *Code) Filename() string {
+	return c.filename
+}
+
+// Location returns the source location of the instruction at the given
+// offset. The zero SourceLocation is returned if it is not known.
+func (c *Code) Location(offset int) SourceLocation {
+	if offset < 0 || offset >= len(c.locations) {
+		return SourceLocation{Filename: c.filename}
+	}
+	loc := c.locations[offset]
+	loc.Filename = c.filename
+	return loc
+}
+
 func (c *Code) LocalsCount() int {
 	return int(c.symbols.Count())
 }
@@ -111,6 +191,17 @@ func (c *Code) Local(index int) *Symbol {
 	return c.symbols.Symbol(uint16(index))
 }
 
+// FreeCount returns the number of free variables referenced by this code.
+func (c *Code) FreeCount() int {
+	return int(c.symbols.FreeCount())
+}
+
+// Free returns the free variable at the given index. The index corresponds to
+// the position of the variable's cell in the function's free variables.
+func (c *Code) Free(index int) *Resolution {
+	return c.symbols.Free(uint16(index))
+}
+
 func (c *Code) GlobalsCount() int {
 	return int(c.symbols.Root().Count())
 }
@@ -40,6 +40,19 @@ type Compiler struct {
 
 	// Increments with each function compiled
 	funcIndex int
+
+	// Source location of the node currently being compiled
+	location SourceLocation
+
+	// Whether to optimize the compiled code
+	optimize bool
+
+	// Notified of the names declared and referred to by the program
+	observer Observer
+
+	// Set while compiling a node that was compiled before, like a finally
+	// block, which the observer is not notified of again
+	replaying bool
 }
 
 // Option is a configuration function for a Compiler.
@@ -60,6 +73,15 @@ func WithCode(code *Code) Option {
 	}
 }
 
+// WithOptimization enables an optimization pass over the compiled code, which
+// folds operations on constants, removes branches that are never taken and
+// code that is unreachable, and shortens chains of jumps.
+func WithOptimization() Option {
+	return func(c *Compiler) {
+		c.optimize = true
+	}
+}
+
 // Compile the given AST node and return the compiled code object. This is a
 // shorthand for compiler.New(options).Compile(node).
 func Compile(node ast.Node, options ...Option) (*Code, error) {
@@ -115,7 +137,20 @@ func (c *Compiler) Compile(node ast.Node) (*Code, error) {
 	} else {
 		c.main.source = fmt.Sprintf("%s\n%s", c.main.source, node.String())
 	}
-	if err := c.compile(node); err != nil {
+	// Note which code was compiled previously, so that only the new code is
+	// optimized
+	start := len(c.main.instructions)
+	var compiled map[*Code]bool
+	if c.optimize {
+		compiled = map[*Code]bool{}
+		for _, code := range c.main.Flatten() {
+			compiled[code] = true
+		}
+	}
+	c.openScope(c.main.symbols)
+	err := c.compile(node)
+	c.closeScope(c.main.symbols)
+	if err != nil {
 		return nil, err
 	}
 	// Check for failures that happened that aren't propagated up the call
@@ -123,11 +158,39 @@ func (c *Compiler) Compile(node ast.Node) (*Code, error) {
 	if c.failure != nil {
 		return nil, c.failure
 	}
+	if c.optimize {
+		// The code compiled previously may already be running, as in the
+		// REPL, so its instruction offsets must not change
+		optimize(c.main, start)
+		for _, code := range c.main.Flatten() {
+			if !compiled[code] {
+				optimize(code, 0)
+			}
+		}
+	}
 	return c.main, nil
 }
 
 // compile the given AST node and all its children.
 func (c *Compiler) compile(node ast.Node) error {
+	// Track the source location of the node so that emitted instructions
+	// can be mapped back to it. Nodes without a token inherit the location
+	// of their parent.
+	if tok := node.Token(); tok.Type != "" {
+		prev := c.location
+		c.location = SourceLocation{
+			Line:   tok.StartPosition.LineNumber(),
+			Column: tok.StartPosition.ColumnNumber(),
+		}
+		if c.current.filename == "" {
+			c.current.filename = tok.StartPosition.File
+		}
+		defer func() { c.location = prev }()
+	}
+	if c.observing() {
+		c.observer.Enter(node)
+		defer c.observer.Leave(node)
+	}
 	switch node := node.(type) {
 	case *ast.Nil:
 		if err := c.compileNil(); err != nil {
@@ -178,7 +241,15 @@ func (c *Compiler) compile(node ast.Node) error {
 			return err
 		}
 	case *ast.For:
-		if err := c.compileFor(node); err != nil {
+		if err := c.compileFor(node, ""); err != nil {
+			return err
+		}
+	case *ast.Labeled:
+		if err := c.compileLabeled(node); err != nil {
+			return err
+		}
+	case *ast.Decorated:
+		if err := c.compileDecorated(node); err != nil {
 			return err
 		}
 	case *ast.Control:
@@ -189,6 +260,10 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileReturn(node); err != nil {
 			return err
 		}
+	case *ast.Yield:
+		if err := c.compileYield(node); err != nil {
+			return err
+		}
 	case *ast.Call:
 		if err := c.compileCall(node); err != nil {
 			return err
@@ -209,6 +284,18 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileSet(node); err != nil {
 			return err
 		}
+	case *ast.ListComprehension:
+		if err := c.compileListComprehension(node); err != nil {
+			return err
+		}
+	case *ast.MapComprehension:
+		if err := c.compileMapComprehension(node); err != nil {
+			return err
+		}
+	case *ast.SetComprehension:
+		if err := c.compileSetComprehension(node); err != nil {
+			return err
+		}
 	case *ast.Index:
 		if err := c.compileIndex(node); err != nil {
 			return err
@@ -217,6 +304,10 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileGetAttr(node); err != nil {
 			return err
 		}
+	case *ast.OptionalChain:
+		if err := c.compileOptionalChain(node); err != nil {
+			return err
+		}
 	case *ast.ObjectCall:
 		if err := c.compileObjectCall(node); err != nil {
 			return err
@@ -233,6 +324,18 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileConst(node); err != nil {
 			return err
 		}
+	case *ast.Struct:
+		if err := c.compileStruct(node); err != nil {
+			return err
+		}
+	case *ast.Enum:
+		if err := c.compileEnum(node); err != nil {
+			return err
+		}
+	case *ast.Try:
+		if err := c.compileTry(node); err != nil {
+			return err
+		}
 	case *ast.Postfix:
 		if err := c.compilePostfix(node); err != nil {
 			return err
@@ -265,6 +368,14 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileSwitch(node); err != nil {
 			return err
 		}
+	case *ast.Match:
+		if err := c.compileMatch(node); err != nil {
+			return err
+		}
+	case *ast.Select:
+		if err := c.compileSelect(node); err != nil {
+			return err
+		}
 	case *ast.MultiVar:
 		if err := c.compileMultiVar(node); err != nil {
 			return err
@@ -289,6 +400,12 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileReceive(node); err != nil {
 			return err
 		}
+	case *ast.Spread:
+		return fmt.Errorf("compile error: invalid use of %s outside of a call (line %d)",
+			node.Literal(), node.Token().StartPosition.LineNumber())
+	case *ast.KeywordArg:
+		return fmt.Errorf("compile error: invalid use of keyword argument %s outside of a call (line %d)",
+			node.Literal(), node.Token().StartPosition.LineNumber())
 	default:
 		panic(fmt.Sprintf("compile error: unknown ast node type: %T", node))
 	}
@@ -297,9 +414,15 @@ func (c *Compiler) compile(node ast.Node) error {
 
 // startLoop should be called when starting to compile a new loop. This is used
 // to understand which loop that "break" and "continue" statements should target.
-func (c *Compiler) startLoop() *loop {
+// The label is empty for loops that are not labeled.
+func (c *Compiler) startLoop(label string) *loop {
 	currentCode := c.current
-	loop := &loop{code: currentCode}
+	loop := &loop{
+		code:       currentCode,
+		label:      label,
+		tryDepth:   len(currentCode.tries),
+		heldValues: currentCode.heldValues,
+	}
 	currentCode.loops = append(currentCode.loops, loop)
 	return loop
 }
@@ -314,6 +437,18 @@ func (c *Compiler) currentLoop() *loop {
 	return loops[len(loops)-1]
 }
 
+// labeledLoop returns the enclosing loop with the given label, or nil if
+// there isn't one within the current function.
+func (c *Compiler) labeledLoop(label string) *loop {
+	loops := c.current.loops
+	for i := len(loops) - 1; i >= 0; i-- {
+		if loops[i].label == label {
+			return loops[i]
+		}
+	}
+	return nil
+}
+
 func (c *Compiler) currentPosition() int {
 	return len(c.current.instructions)
 }
@@ -350,7 +485,7 @@ func (c *Compiler) compileProgram(node *ast.Program) error {
 		c.emit(op.Nil)
 	} else {
 		for i, stmt := range statements {
-			if err := c.compile(stmt); err != nil {
+			if err := c.compileStatement(stmt); err != nil {
 				return err
 			}
 			if i < count-1 {
@@ -368,12 +503,17 @@ func (c *Compiler) compileProgram(node *ast.Program) error {
 	return nil
 }
 
+// compileStatement compiles a statement of a program or block. While an
+// observer is set, an error in the statement does not stop compilation.
+func (c *Compiler) compileStatement(node ast.Node) error {
+	if err := c.compile(node); err != nil {
+		return c.recoverable(err)
+	}
+	return nil
+}
+
 func (c *Compiler) compileBlock(node *ast.Block) error {
-	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	defer func() {
-		code.symbols = code.symbols.parent
-	}()
+	defer c.newBlock()()
 	statements := node.Statements()
 	count := len(statements)
 	if count == 0 {
@@ -381,7 +521,7 @@ func (c *Compiler) compileBlock(node *ast.Block) error {
 		c.emit(op.Nil)
 	} else {
 		for i, stmt := range statements {
-			if err := c.compile(stmt); err != nil {
+			if err := c.compileStatement(stmt); err != nil {
 				return err
 			}
 			if i < count-1 {
@@ -400,15 +540,17 @@ func (c *Compiler) compileBlock(node *ast.Block) error {
 }
 
 func (c *Compiler) compileFunctionBlock(node *ast.Block) error {
-	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	defer func() {
-		code.symbols = code.symbols.parent
-	}()
+	// The body is compiled here rather than by compile, so the observer is
+	// notified of it here
+	if c.observing() {
+		c.observer.Enter(node)
+		defer c.observer.Leave(node)
+	}
+	defer c.newBlock()()
 	statements := normalizeFunctionBlock(node)
 	count := len(statements)
 	for i, stmt := range statements {
-		if err := c.compile(stmt); err != nil {
+		if err := c.compileStatement(stmt); err != nil {
 			return err
 		}
 		if i < count-1 {
@@ -425,7 +567,11 @@ func (c *Compiler) compileVar(node *ast.Var) error {
 	if err := c.compile(expr); err != nil {
 		return err
 	}
-	sym, err := c.current.symbols.InsertVariable(name)
+	sym, err := c.declare(&Declaration{
+		Name:  name,
+		Kind:  VariableDeclaration,
+		Token: node.Ident().Token(),
+	})
 	if err != nil {
 		return err
 	}
@@ -438,11 +584,14 @@ func (c *Compiler) compileVar(node *ast.Var) error {
 }
 
 func (c *Compiler) compileIdent(node *ast.Ident) error {
-	name := node.Literal()
-	resolution, found := c.current.symbols.Resolve(name)
-	if !found {
-		return fmt.Errorf("compile error: undefined variable %q (line %d)",
-			name, node.Token().StartPosition.LineNumber())
+	resolution, err := c.resolve(node, false)
+	if err != nil {
+		return err
+	}
+	if resolution == nil {
+		// The name is undefined, but compilation continues
+		c.emit(op.Nil)
+		return nil
 	}
 	switch resolution.scope {
 	case Global:
@@ -467,10 +616,14 @@ func (c *Compiler) compileMultiVar(node *ast.MultiVar) error {
 	// Emit the Unpack opcode to unpack the tuple-like object onto the stack
 	c.emit(op.Unpack, uint16(len(names)))
 	// Iterate through the names in reverse order and assign the values
+	idents := node.Idents()
 	if node.IsWalrus() {
 		for i := len(names) - 1; i >= 0; i-- {
-			name := names[i]
-			sym, err := c.current.symbols.InsertVariable(name)
+			sym, err := c.declare(&Declaration{
+				Name:  names[i],
+				Kind:  VariableDeclaration,
+				Token: idents[i].Token(),
+			})
 			if err != nil {
 				return err
 			}
@@ -483,11 +636,13 @@ func (c *Compiler) compileMultiVar(node *ast.MultiVar) error {
 		return nil
 	}
 	for i := len(names) - 1; i >= 0; i-- {
-		name := names[i]
-		resolution, found := c.current.symbols.Resolve(name)
-		if !found {
-			return fmt.Errorf("compile error: undefined variable %q (line %d)",
-				name, node.Token().StartPosition.LineNumber())
+		resolution, err := c.resolve(idents[i], true)
+		if err != nil {
+			return err
+		}
+		if resolution == nil {
+			c.emit(op.PopTop)
+			continue
 		}
 		symbolIndex := resolution.symbol.Index()
 		switch resolution.scope {
@@ -507,6 +662,10 @@ func (c *Compiler) compileSwitch(node *ast.Switch) error {
 	if err := c.compile(node.Value()); err != nil {
 		return err
 	}
+	c.current.heldValues++
+	defer func() {
+		c.current.heldValues--
+	}()
 
 	choices := node.Choices()
 
@@ -593,22 +752,388 @@ func (c *Compiler) compileSwitch(node *ast.Switch) error {
 	return nil
 }
 
+// patternFailure is a jump taken when a pattern does not match, along with
+// the number of values it leaves on the stack above the value being matched.
+type patternFailure struct {
+	pos   int
+	depth int
+}
+
+// patternState tracks the compilation of the patterns of one match case.
+type patternState struct {
+	symbols  map[string]*Symbol
+	failures []patternFailure
+}
+
+func (c *Compiler) compileMatch(node *ast.Match) error {
+	// The value being matched stays on the stack until the end
+	if err := c.compile(node.Value()); err != nil {
+		return err
+	}
+	c.current.heldValues++
+	defer func() {
+		c.current.heldValues--
+	}()
+	var defaultCase *ast.MatchCase
+	var endPositions []int
+	for _, matchCase := range node.Cases() {
+		if matchCase.IsDefault() {
+			defaultCase = matchCase
+			continue
+		}
+		endPos, err := c.compileMatchCase(matchCase)
+		if err != nil {
+			return err
+		}
+		endPositions = append(endPositions, endPos)
+	}
+	// No case matched, so run the default block if there is one
+	if defaultCase != nil && defaultCase.Block() != nil {
+		if err := c.compile(defaultCase.Block()); err != nil {
+			return err
+		}
+	} else {
+		c.emit(op.Nil)
+	}
+	for _, pos := range endPositions {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	// Remove the matched value from beneath the result
+	c.emit(op.Swap, 1)
+	c.emit(op.PopTop)
+	return nil
+}
+
+// compileMatchCase compiles one case of a match expression, with the value
+// being matched on top of the stack. When the case matches, its block runs
+// and then jumps to the end of the match expression, from the returned
+// position. Otherwise, execution continues after the case.
+func (c *Compiler) compileMatchCase(node *ast.MatchCase) (int, error) {
+	defer c.newBlock()()
+	line := node.Token().StartPosition.LineNumber()
+
+	// Each alternative pattern must bind the same names, so that they are
+	// all defined in the guard and block regardless of which one matched.
+	patterns := node.Patterns()
+	names, err := patternNames(patterns[0], line)
+	if err != nil {
+		return 0, err
+	}
+	for _, pattern := range patterns[1:] {
+		altNames, err := patternNames(pattern, line)
+		if err != nil {
+			return 0, err
+		}
+		if !sameNames(names, altNames) {
+			err := fmt.Errorf("compile error: alternative patterns bind different names (line %d)", line)
+			if err := c.recoverable(err); err != nil {
+				return 0, err
+			}
+		}
+	}
+	state := &patternState{symbols: map[string]*Symbol{}}
+	for _, name := range names {
+		sym, err := c.declare(&Declaration{
+			Name:  name.Literal(),
+			Kind:  VariableDeclaration,
+			Token: name.Token(),
+		})
+		if err != nil {
+			return 0, err
+		}
+		state.symbols[name.Literal()] = sym
+	}
+
+	// Try each alternative against a copy of the value. If one fails, the
+	// values it left on the stack are popped before trying the next.
+	var matchedPositions []int
+	for i, pattern := range patterns {
+		state.failures = nil
+		c.emit(op.Copy, 0)
+		if err := c.compilePattern(pattern, 1, state); err != nil {
+			return 0, err
+		}
+		if i < len(patterns)-1 {
+			matchedPositions = append(matchedPositions, c.emit(op.JumpForward, Placeholder))
+			if err := c.compilePatternFailures(state.failures); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for _, pos := range matchedPositions {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return 0, err
+		}
+		c.changeOperand(pos, delta)
+	}
+
+	guardPos := -1
+	if guard := node.Guard(); guard != nil {
+		if err := c.compile(guard); err != nil {
+			return 0, err
+		}
+		guardPos = c.emit(op.PopJumpForwardIfFalse, Placeholder)
+	}
+	if node.Block() == nil {
+		// Empty case block
+		c.emit(op.Nil)
+	} else {
+		if err := c.compile(node.Block()); err != nil {
+			return 0, err
+		}
+	}
+	endPos := c.emit(op.JumpForward, Placeholder)
+
+	// Failures of the last alternative and of the guard fall through to
+	// the next case
+	if err := c.compilePatternFailures(state.failures); err != nil {
+		return 0, err
+	}
+	if guardPos >= 0 {
+		delta, err := c.calculateDelta(guardPos)
+		if err != nil {
+			return 0, err
+		}
+		c.changeOperand(guardPos, delta)
+	}
+	return endPos, nil
+}
+
+// compilePattern compiles a check that the value on top of the stack matches
+// a pattern, binding the names the pattern captures. The value is popped if
+// it matches. Otherwise, execution jumps ahead and the jump is recorded as a
+// failure, with depth being the number of values on the stack above the value
+// being matched, including the value itself.
+func (c *Compiler) compilePattern(pattern ast.Pattern, depth int, state *patternState) error {
+	fail := func(depth int) {
+		pos := c.emit(op.PopJumpForwardIfFalse, Placeholder)
+		state.failures = append(state.failures, patternFailure{pos: pos, depth: depth})
+	}
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		c.emit(op.PopTop)
+	case *ast.CapturePattern:
+		c.storePatternName(pattern.Name().Literal(), state)
+	case *ast.ValuePattern:
+		if err := c.compile(pattern.Value()); err != nil {
+			return err
+		}
+		c.emit(op.CompareOp, uint16(op.Equal))
+		fail(depth - 1)
+	case *ast.ListPattern:
+		items := pattern.Items()
+		restIndex := -1
+		for i, item := range items {
+			if _, ok := item.(*ast.RestPattern); ok {
+				restIndex = i
+			}
+		}
+		if restIndex < 0 {
+			c.emit(op.MatchList, uint16(len(items)), 0)
+			fail(depth)
+			c.emit(op.Unpack, uint16(len(items)))
+		} else {
+			c.emit(op.MatchList, uint16(len(items)-1), 1)
+			fail(depth)
+			c.emit(op.UnpackRest, uint16(restIndex), uint16(len(items)-restIndex-1))
+		}
+		// The items are pushed in order, so they are matched last to first
+		for i := len(items) - 1; i >= 0; i-- {
+			if rest, ok := items[i].(*ast.RestPattern); ok {
+				if rest.Name() == nil {
+					c.emit(op.PopTop)
+				} else {
+					c.storePatternName(rest.Name().Literal(), state)
+				}
+				continue
+			}
+			if err := c.compilePattern(items[i], depth+i, state); err != nil {
+				return err
+			}
+		}
+	case *ast.MapPattern:
+		c.emit(op.MatchType, c.current.addName("map"))
+		fail(depth)
+		values := pattern.Values()
+		for i, key := range pattern.Keys() {
+			var name string
+			switch key := key.(type) {
+			case *ast.String:
+				name = key.Value()
+			case *ast.Ident:
+				name = key.Literal()
+			default:
+				return fmt.Errorf("compile error: invalid map pattern key: %v", key)
+			}
+			c.emit(op.MatchKey, c.current.addName(name))
+			fail(depth + 1)
+			if err := c.compilePattern(values[i], depth+1, state); err != nil {
+				return err
+			}
+		}
+		c.emit(op.PopTop)
+	case *ast.TypePattern:
+		c.emit(op.MatchType, c.current.addName(pattern.Name().Literal()))
+		fail(depth)
+		attrs := pattern.Attrs()
+		for i, name := range pattern.AttrNames() {
+			c.emit(op.MatchAttr, c.current.addName(name.Literal()))
+			fail(depth + 1)
+			if err := c.compilePattern(attrs[i], depth+1, state); err != nil {
+				return err
+			}
+		}
+		if value := pattern.Value(); value != nil {
+			if err := c.compilePattern(value, depth, state); err != nil {
+				return err
+			}
+		} else {
+			c.emit(op.PopTop)
+		}
+	case *ast.RestPattern:
+		return fmt.Errorf("compile error: rest pattern outside of a list pattern (line %d)",
+			pattern.Token().StartPosition.LineNumber())
+	default:
+		return fmt.Errorf("compile error: unknown pattern type: %T", pattern)
+	}
+	return nil
+}
+
+// compilePatternFailures emits the instructions that pop the values left on
+// the stack by failed pattern checks, and points each failure at the first
+// instruction that pops the values it left.
+func (c *Compiler) compilePatternFailures(failures []patternFailure) error {
+	maxDepth := 0
+	for _, failure := range failures {
+		if failure.depth > maxDepth {
+			maxDepth = failure.depth
+		}
+	}
+	for depth := maxDepth; depth >= 0; depth-- {
+		for _, failure := range failures {
+			if failure.depth != depth {
+				continue
+			}
+			delta, err := c.calculateDelta(failure.pos)
+			if err != nil {
+				return err
+			}
+			c.changeOperand(failure.pos, delta)
+		}
+		if depth > 0 {
+			c.emit(op.PopTop)
+		}
+	}
+	return nil
+}
+
+// storePatternName pops the value on top of the stack into a name captured
+// by a pattern. The value is discarded if the name was not declared, which
+// happens only after a recoverable error.
+func (c *Compiler) storePatternName(name string, state *patternState) {
+	sym, ok := state.symbols[name]
+	if !ok {
+		c.emit(op.PopTop)
+	} else if c.current.parent == nil {
+		c.emit(op.StoreGlobal, sym.Index())
+	} else {
+		c.emit(op.StoreFast, sym.Index())
+	}
+}
+
+// patternNames returns the names a pattern captures, in the order they
+// appear. A name may only be captured once.
+func patternNames(pattern ast.Pattern, line int) ([]*ast.Ident, error) {
+	var names []*ast.Ident
+	seen := map[string]bool{}
+	add := func(name *ast.Ident) error {
+		if seen[name.Literal()] {
+			return fmt.Errorf("compile error: name %q is captured more than once in a pattern (line %d)",
+				name.Literal(), line)
+		}
+		seen[name.Literal()] = true
+		names = append(names, name)
+		return nil
+	}
+	var walk func(pattern ast.Pattern) error
+	walk = func(pattern ast.Pattern) error {
+		switch pattern := pattern.(type) {
+		case *ast.CapturePattern:
+			return add(pattern.Name())
+		case *ast.RestPattern:
+			if pattern.Name() != nil {
+				return add(pattern.Name())
+			}
+		case *ast.ListPattern:
+			for _, item := range pattern.Items() {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		case *ast.MapPattern:
+			for _, value := range pattern.Values() {
+				if err := walk(value); err != nil {
+					return err
+				}
+			}
+		case *ast.TypePattern:
+			if pattern.Value() != nil {
+				if err := walk(pattern.Value()); err != nil {
+					return err
+				}
+			}
+			for _, attr := range pattern.Attrs() {
+				if err := walk(attr); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(pattern); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// sameNames returns true if the two lists hold the same names in any order.
+func sameNames(a, b []*ast.Ident) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, name := range a {
+		set[name.Literal()] = true
+	}
+	for _, name := range b {
+		if !set[name.Literal()] {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Compiler) compileImport(node *ast.Import) error {
 	name := node.Name().String()
 	c.emit(op.LoadConst, c.constant(name))
 	c.emit(op.Import)
+	ident := node.Name()
 	if node.Alias() != nil {
-		name = node.Alias().String()
-	}
-	var sym *Symbol
-	var found bool
-	sym, found = c.current.symbols.Get(name)
-	if !found {
-		var err error
-		sym, err = c.current.symbols.InsertConstant(name)
-		if err != nil {
-			return err
-		}
+		ident = node.Alias()
+		name = ident.String()
+	}
+	sym, err := c.declare(&Declaration{
+		Name:  name,
+		Kind:  ImportDeclaration,
+		Token: ident.Token(),
+	})
+	if err != nil {
+		return err
 	}
 	if c.current.parent == nil {
 		c.emit(op.StoreGlobal, sym.Index())
@@ -638,16 +1163,17 @@ func (c *Compiler) compileFromImport(node *ast.FromImport) error {
 	c.emit(op.FromImport, uint16(len(node.Parents())), uint16(len(node.Imports())))
 	for _, im := range node.Imports() {
 		name := im.Name().String()
-		alias := aliases[name]
-		var sym *Symbol
-		var found bool
-		sym, found = c.current.symbols.Get(alias)
-		if !found {
-			var err error
-			sym, err = c.current.symbols.InsertConstant(alias)
-			if err != nil {
-				return err
-			}
+		ident := im.Name()
+		if im.Alias() != nil {
+			ident = im.Alias()
+		}
+		sym, err := c.declare(&Declaration{
+			Name:  aliases[name],
+			Kind:  ImportDeclaration,
+			Token: ident.Token(),
+		})
+		if err != nil {
+			return err
 		}
 		if c.current.parent == nil {
 			c.emit(op.StoreGlobal, sym.Index())
@@ -659,7 +1185,7 @@ func (c *Compiler) compileFromImport(node *ast.FromImport) error {
 }
 
 func (c *Compiler) compileSlice(node *ast.Slice) error {
-	if err := c.compile(node.Left()); err != nil {
+	if err := c.compileChainObject(node.Left(), node.IsOptional()); err != nil {
 		return err
 	}
 	to := node.ToIndex()
@@ -752,11 +1278,21 @@ func (c *Compiler) compileString(node *ast.String) error {
 			// Nil expression should be treated as empty string
 			if expr == nil {
 				c.emit(op.LoadConst, c.constant(""))
-				continue
-			}
-			if err := c.compile(expr); err != nil {
+			} else if err := c.compile(expr); err != nil {
 				return err
 			}
+			// Apply the conversion and format spec, if any, as in "{x!r:>10}"
+			var conversion uint16
+			switch f.Conversion() {
+			case "r":
+				conversion = op.FormatRepr
+			case "s":
+				conversion = op.FormatStr
+			}
+			if conversion != op.FormatNone || f.Spec() != "" {
+				c.emit(op.LoadConst, c.constant(f.Spec()))
+				c.emit(op.FormatValue, conversion)
+			}
 		case false:
 			// Push the fragment as a constant as TOS
 			c.emit(op.LoadConst, c.constant(f.Value()))
@@ -800,33 +1336,35 @@ func (c *Compiler) compilePipe(node *ast.Pipe) error {
 }
 
 func (c *Compiler) compilePostfix(node *ast.Postfix) error {
-	name := node.Literal()
-	resolution, found := c.current.symbols.Resolve(name)
-	if !found {
-		return fmt.Errorf("compile error: undefined variable %q (line %d)",
-			name, node.Token().StartPosition.LineNumber())
+	resolution, err := c.resolve(node, true)
+	if err != nil || resolution == nil {
+		return err
 	}
 	symbolIndex := resolution.symbol.Index()
-	// Push the named variable onto the stack
+	// The integer amount to add (1 or -1)
+	var amount int64
+	operator := node.Operator()
+	if operator == "++" {
+		amount = 1
+	} else if operator == "--" {
+		amount = -1
+	} else {
+		return fmt.Errorf("compile error: unknown postfix operator %q", operator)
+	}
+	// Push the named variable and the amount onto the stack, and run the
+	// increment or decrement as an addition
 	switch resolution.scope {
 	case Global:
 		c.emit(op.LoadGlobal, symbolIndex)
+		c.emit(op.LoadConst, c.constant(amount))
+		c.emit(op.BinaryAddFast)
 	case Local:
-		c.emit(op.LoadFast, symbolIndex)
+		c.emit(op.BinaryOpFastConst, symbolIndex, c.constant(amount), uint16(op.Add))
 	case Free:
 		c.emit(op.LoadFree, uint16(resolution.freeIndex))
+		c.emit(op.LoadConst, c.constant(amount))
+		c.emit(op.BinaryAddFast)
 	}
-	// Push the integer amount to the stack (1 or -1)
-	operator := node.Operator()
-	if operator == "++" {
-		c.emit(op.LoadConst, c.constant(int64(1)))
-	} else if operator == "--" {
-		c.emit(op.LoadConst, c.constant(int64(-1)))
-	} else {
-		return fmt.Errorf("compile error: unknown postfix operator %q", operator)
-	}
-	// Run increment or decrement as an Add BinaryOp
-	c.emit(op.BinaryOp, uint16(op.Add))
 	// Store TOS in LHS
 	switch resolution.scope {
 	case Global:
@@ -844,7 +1382,11 @@ func (c *Compiler) compileConst(node *ast.Const) error {
 	if err := c.compile(expr); err != nil {
 		return err
 	}
-	sym, err := c.current.symbols.InsertConstant(name)
+	sym, err := c.declare(&Declaration{
+		Name:  name,
+		Kind:  ConstantDeclaration,
+		Token: node.Ident().Token(),
+	})
 	if err != nil {
 		return err
 	}
@@ -856,6 +1398,158 @@ func (c *Compiler) compileConst(node *ast.Const) error {
 	return nil
 }
 
+// defaultValue returns the value of a default for a parameter or a struct
+// field. Defaults are evaluated once, so only literals of immutable types are
+// supported: int, string, bool, float, and nil.
+func defaultValue(expr ast.Expression) (any, bool) {
+	switch expr := expr.(type) {
+	case *ast.Int:
+		return expr.Value(), true
+	case *ast.String:
+		return expr.Value(), true
+	case *ast.Bool:
+		return expr.Value(), true
+	case *ast.Float:
+		return expr.Value(), true
+	case *ast.Nil:
+		return nil, true
+	}
+	return nil, false
+}
+
+func (c *Compiler) compileStruct(node *ast.Struct) error {
+	name := node.Name().Literal()
+	line := node.Token().StartPosition.LineNumber()
+	fields := node.FieldNames()
+	methods := node.Methods()
+	if len(fields) > math.MaxUint16 || len(methods) > math.MaxUint16 {
+		return fmt.Errorf("compile error: struct %q has too many members (line %d)", name, line)
+	}
+
+	// Confirm only trailing fields have defaults
+	defaults := node.Defaults()
+	var defaultsCount int
+	for _, field := range fields {
+		if _, ok := defaults[field]; ok {
+			defaultsCount++
+		} else if defaultsCount > 0 {
+			return fmt.Errorf("compile error: invalid field defaults for struct %q (line %d)", name, line)
+		}
+	}
+
+	// The struct name and field names are pushed first, followed by the
+	// default values of the trailing optional fields.
+	c.emit(op.LoadConst, c.constant(name))
+	for _, field := range fields {
+		c.emit(op.LoadConst, c.constant(field))
+	}
+	for _, field := range fields[len(fields)-defaultsCount:] {
+		value, ok := defaultValue(defaults[field])
+		if !ok {
+			return fmt.Errorf("compile error: unsupported default value for field %q of struct %q (got %s, line %d)",
+				field, name, defaults[field], line)
+		}
+		c.emit(op.LoadConst, c.constant(value))
+	}
+
+	// Declare the struct name before compiling methods, so that methods are
+	// able to refer to their own type.
+	sym, err := c.declare(&Declaration{
+		Name:  name,
+		Kind:  StructDeclaration,
+		Token: node.Name().Token(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Each method is pushed as a name and function pair. Methods receive the
+	// struct instance as an implicit leading "self" parameter.
+	for _, method := range methods {
+		self := ast.NewIdent(token.Token{
+			Type:          token.IDENT,
+			Literal:       "self",
+			StartPosition: method.Token().StartPosition,
+			EndPosition:   method.Token().EndPosition,
+		})
+		params := append([]*ast.Ident{self}, method.Parameters()...)
+		c.emit(op.LoadConst, c.constant(method.Name().Literal()))
+		if _, err := c.compileFunction(method, params, false); err != nil {
+			return err
+		}
+	}
+	c.emit(op.BuildStruct, uint16(len(fields)), uint16(defaultsCount), uint16(len(methods)))
+	if c.current.parent == nil {
+		c.emit(op.StoreGlobal, sym.Index())
+	} else {
+		c.emit(op.StoreFast, sym.Index())
+	}
+	return nil
+}
+
+func (c *Compiler) compileEnum(node *ast.Enum) error {
+	name := node.Name().Literal()
+	line := node.Token().StartPosition.LineNumber()
+	members := node.Members()
+	if len(members) > math.MaxUint16/2 {
+		return fmt.Errorf("compile error: enum %q has too many members (line %d)", name, line)
+	}
+
+	// Member values must be int or string literals. A member without a value
+	// takes the value of the previous member plus one, starting from zero.
+	var next int64
+	nextValid := true
+	seen := map[any]string{}
+	c.emit(op.LoadConst, c.constant(name))
+	for _, member := range members {
+		memberName := member.Literal()
+		var value any
+		if expr, ok := node.Values()[memberName]; ok {
+			switch expr := expr.(type) {
+			case *ast.Int:
+				value = expr.Value()
+			case *ast.String:
+				value = expr.Value()
+			case *ast.Prefix:
+				if i, ok := expr.Right().(*ast.Int); ok && expr.Operator() == "-" {
+					value = -i.Value()
+				}
+			}
+			if value == nil {
+				return fmt.Errorf("compile error: unsupported enum value (got %s, line %d)", expr, line)
+			}
+		} else if nextValid {
+			value = next
+		} else {
+			return fmt.Errorf("compile error: enum member %q requires a value (line %d)", memberName, line)
+		}
+		if other, ok := seen[value]; ok {
+			return fmt.Errorf("compile error: enum members %q and %q have the same value (line %d)",
+				other, memberName, line)
+		}
+		seen[value] = memberName
+		next, nextValid = value.(int64)
+		next++
+		c.emit(op.LoadConst, c.constant(memberName))
+		c.emit(op.LoadConst, c.constant(value))
+	}
+	sym, err := c.declare(&Declaration{
+		Name:  name,
+		Kind:  EnumDeclaration,
+		Token: node.Name().Token(),
+	})
+	if err != nil {
+		return err
+	}
+	c.emit(op.BuildEnum, uint16(len(members)))
+	if c.current.parent == nil {
+		c.emit(op.StoreGlobal, sym.Index())
+	} else {
+		c.emit(op.StoreFast, sym.Index())
+	}
+	return nil
+}
+
 func (c *Compiler) compileIn(node *ast.In) error {
 	if err := c.compile(node.Right()); err != nil {
 		return err
@@ -881,29 +1575,147 @@ func (c *Compiler) compilePrefix(node *ast.Prefix) error {
 }
 
 func (c *Compiler) compileCall(node *ast.Call) error {
-	args := node.Arguments()
-	argc := len(args)
-	if argc > MaxArgs {
-		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
-	}
 	if err := c.compile(node.Function()); err != nil {
 		return err
 	}
+	operand, err := c.compileArgs(node.Arguments())
+	if err != nil {
+		return err
+	}
+	if c.current.pipeActive {
+		c.emit(op.Partial, operand)
+	} else {
+		c.emit(op.Call, operand)
+	}
+	return nil
+}
+
+// compileArgs compiles the arguments of a call and returns the operand of the
+// Call or Partial instruction that follows. Without "...items" spreads, the
+// positional arguments are pushed individually. Otherwise, they are collected
+// into a single list. Any "**opts" spreads are merged into a map of keyword
+// arguments, which is pushed last.
+func (c *Compiler) compileArgs(args []ast.Node) (uint16, error) {
+	var positional, kwargs []ast.Node
+	var hasListSpread bool
 	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
+		if _, ok := arg.(*ast.KeywordArg); ok {
+			kwargs = append(kwargs, arg)
+			continue
 		}
+		spread, isSpread := arg.(*ast.Spread)
+		if isSpread && spread.IsMap() {
+			kwargs = append(kwargs, spread)
+			continue
+		}
+		hasListSpread = hasListSpread || isSpread
+		positional = append(positional, arg)
 	}
-	if c.current.pipeActive {
-		c.emit(op.Partial, uint16(argc))
+	var operand uint16
+	if hasListSpread {
+		if err := c.compileArgsList(positional); err != nil {
+			return 0, err
+		}
+		operand = op.CallArgsList
 	} else {
-		c.emit(op.Call, uint16(argc))
+		argc := len(positional)
+		if argc > MaxArgs {
+			return 0, fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
+		}
+		for _, arg := range positional {
+			if err := c.compile(arg); err != nil {
+				return 0, err
+			}
+		}
+		operand = uint16(argc)
+	}
+	if len(kwargs) > 0 {
+		if err := c.compileKwargs(kwargs); err != nil {
+			return 0, err
+		}
+		operand |= op.CallKwargs
 	}
+	return operand, nil
+}
+
+// compileKwargs compiles keyword arguments and map spreads into a single map,
+// evaluating them in order. Consecutive keyword arguments are built into a
+// map that is merged into the map built so far, as is each spread value.
+func (c *Compiler) compileKwargs(args []ast.Node) error {
+	var pending uint16
+	started := false
+	flush := func() {
+		if !started {
+			c.emit(op.BuildMap, pending)
+			started = true
+		} else if pending > 0 {
+			c.emit(op.BuildMap, pending)
+			c.emit(op.MapMerge)
+		}
+		pending = 0
+	}
+	for _, arg := range args {
+		if spread, ok := arg.(*ast.Spread); ok {
+			flush()
+			if err := c.compile(spread.Value()); err != nil {
+				return err
+			}
+			c.emit(op.MapMerge)
+			continue
+		}
+		kwarg := arg.(*ast.KeywordArg)
+		if pending == math.MaxUint16 {
+			return fmt.Errorf("compile error: max args limit of %d exceeded", math.MaxUint16)
+		}
+		c.emit(op.LoadConst, c.constant(kwarg.Name().Literal()))
+		if err := c.compile(kwarg.Value()); err != nil {
+			return err
+		}
+		pending++
+	}
+	flush()
+	return nil
+}
+
+// compileArgsList compiles positional arguments, some of which are spreads,
+// into a single list. Consecutive plain arguments are built into a list that
+// extends the list built so far, as does each spread value.
+func (c *Compiler) compileArgsList(args []ast.Node) error {
+	var pending uint16
+	started := false
+	flush := func() {
+		if !started {
+			c.emit(op.BuildList, pending)
+			started = true
+		} else if pending > 0 {
+			c.emit(op.BuildList, pending)
+			c.emit(op.ListExtend)
+		}
+		pending = 0
+	}
+	for _, arg := range args {
+		if spread, ok := arg.(*ast.Spread); ok {
+			flush()
+			if err := c.compile(spread.Value()); err != nil {
+				return err
+			}
+			c.emit(op.ListExtend)
+			continue
+		}
+		if pending == math.MaxUint16 {
+			return fmt.Errorf("compile error: max args limit of %d exceeded", math.MaxUint16)
+		}
+		if err := c.compile(arg); err != nil {
+			return err
+		}
+		pending++
+	}
+	flush()
 	return nil
 }
 
 func (c *Compiler) compileObjectCall(node *ast.ObjectCall) error {
-	if err := c.compile(node.Object()); err != nil {
+	if err := c.compileChainObject(node.Object(), node.IsOptional()); err != nil {
 		return err
 	}
 	expr := node.Call()
@@ -913,26 +1725,20 @@ func (c *Compiler) compileObjectCall(node *ast.ObjectCall) error {
 	}
 	name := method.Function().String()
 	c.emit(op.LoadAttr, c.current.addName(name))
-	args := method.Arguments()
-	argc := len(args)
-	if argc > MaxArgs {
-		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
-	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
+	operand, err := c.compileArgs(method.Arguments())
+	if err != nil {
+		return err
 	}
 	if c.current.pipeActive {
-		c.emit(op.Partial, uint16(len(args)))
+		c.emit(op.Partial, operand)
 	} else {
-		c.emit(op.Call, uint16(len(args)))
+		c.emit(op.Call, operand)
 	}
 	return nil
 }
 
 func (c *Compiler) compileGetAttr(node *ast.GetAttr) error {
-	if err := c.compile(node.Object()); err != nil {
+	if err := c.compileChainObject(node.Object(), node.IsOptional()); err != nil {
 		return err
 	}
 	idx := c.current.addName(node.Name())
@@ -941,13 +1747,74 @@ func (c *Compiler) compileGetAttr(node *ast.GetAttr) error {
 }
 
 func (c *Compiler) compileIndex(node *ast.Index) error {
-	if err := c.compile(node.Left()); err != nil {
+	if err := c.compileChainObject(node.Left(), node.IsOptional()); err != nil {
 		return err
 	}
 	if err := c.compile(node.Index()); err != nil {
 		return err
 	}
-	c.emit(op.BinarySubscr)
+	if node.IsOptional() {
+		c.emit(op.OptionalSubscr)
+	} else {
+		c.emit(op.BinarySubscr)
+	}
+	return nil
+}
+
+// compileOptionalChain compiles a chain of accesses containing "?." accesses,
+// each of which jumps to the end of the chain when its object is nil, leaving
+// the nil as the value of the chain.
+func (c *Compiler) compileOptionalChain(node *ast.OptionalChain) error {
+	code := c.current
+	outer := code.chainJumps
+	code.chainJumps = nil
+	defer func() {
+		code.chainJumps = outer
+	}()
+	if err := c.compile(node.Expression()); err != nil {
+		return err
+	}
+	for _, pos := range code.chainJumps {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return nil
+}
+
+// compileChainObject compiles the object of an attribute access, index
+// operation or method call. For a "?." access, the enclosing optional chain
+// is skipped when the object is nil.
+func (c *Compiler) compileChainObject(obj ast.Expression, optional bool) error {
+	if err := c.compile(obj); err != nil {
+		return err
+	}
+	if optional {
+		code := c.current
+		c.emit(op.Copy, 0)
+		code.chainJumps = append(code.chainJumps, c.emit(op.PopJumpForwardIfNil, Placeholder))
+	}
+	return nil
+}
+
+// compileNullish compiles "x ?? y", which evaluates to y only if x is nil.
+func (c *Compiler) compileNullish(node *ast.Infix) error {
+	if err := c.compile(node.Left()); err != nil {
+		return err
+	}
+	c.emit(op.Copy, 0)
+	jumpPos := c.emit(op.PopJumpForwardIfNotNil, Placeholder)
+	c.emit(op.PopTop)
+	if err := c.compile(node.Right()); err != nil {
+		return err
+	}
+	delta, err := c.calculateDelta(jumpPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, delta)
 	return nil
 }
 
@@ -971,7 +1838,9 @@ func (c *Compiler) compileMap(node *ast.Map) error {
 	count := len(items)
 	for k, v := range items {
 		switch k := k.(type) {
-		case *ast.String:
+		case *ast.String, *ast.GetAttr:
+			// Attribute keys such as Color.RED are evaluated, which allows
+			// enum members to be used as keys
 			if err := c.compile(k); err != nil {
 				return err
 			}
@@ -980,51 +1849,369 @@ func (c *Compiler) compileMap(node *ast.Map) error {
 		default:
 			return fmt.Errorf("compile error: invalid map key type: %v", k)
 		}
-		if err := c.compile(v); err != nil {
+		if err := c.compile(v); err != nil {
+			return err
+		}
+	}
+	c.emit(op.BuildMap, uint16(count))
+	return nil
+}
+
+func (c *Compiler) compileSend(node *ast.Send) error {
+	if err := c.compile(node.Channel()); err != nil {
+		return err
+	}
+	if err := c.compile(node.Value()); err != nil {
+		return err
+	}
+	c.emit(op.Send)
+	return nil
+}
+
+func (c *Compiler) compileReceive(node *ast.Receive) error {
+	if err := c.compile(node.Channel()); err != nil {
+		return err
+	}
+	c.emit(op.Receive)
+	return nil
+}
+
+func (c *Compiler) compileSelect(node *ast.Select) error {
+	// Push the channel of each case, followed by the value to send for send
+	// cases, and a flag that tells the two kinds of case apart
+	var defaultCase *ast.SelectCase
+	var cases []*ast.SelectCase
+	for _, selectCase := range node.Cases() {
+		if selectCase.IsDefault() {
+			defaultCase = selectCase
+			continue
+		}
+		switch comm := selectCase.Comm().(type) {
+		case *ast.Receive:
+			if err := c.compile(comm.Channel()); err != nil {
+				return err
+			}
+			c.emit(op.False)
+		case *ast.Send:
+			if err := c.compile(comm.Channel()); err != nil {
+				return err
+			}
+			if err := c.compile(comm.Value()); err != nil {
+				return err
+			}
+			c.emit(op.True)
+		default:
+			return fmt.Errorf("compile error: invalid select case (line %d)",
+				selectCase.Token().StartPosition.LineNumber())
+		}
+		cases = append(cases, selectCase)
+	}
+	if len(cases) >= int(op.SelectDefault) {
+		return fmt.Errorf("compile error: select statement has too many cases (line %d)",
+			node.Token().StartPosition.LineNumber())
+	}
+	operand := uint16(len(cases))
+	if defaultCase != nil {
+		operand |= op.SelectDefault
+		cases = append(cases, defaultCase)
+	}
+
+	// Select leaves the received value, the open flag, and the index of the
+	// case that proceeded on the stack. The default case has the last index.
+	c.emit(op.Select, operand)
+	if len(cases) == 0 {
+		c.emit(op.PopTop)
+		c.emit(op.PopTop)
+		c.emit(op.PopTop)
+		c.emit(op.Nil)
+		return nil
+	}
+	var endPositions []int
+	for i, selectCase := range cases {
+		last := i == len(cases)-1
+		nextPos := -1
+		if !last {
+			c.emit(op.Copy, 0)
+			c.emit(op.LoadConst, c.constant(int64(i)))
+			c.emit(op.CompareOp, uint16(op.Equal))
+			nextPos = c.emit(op.PopJumpForwardIfFalse, Placeholder)
+		}
+		if err := c.compileSelectCase(selectCase); err != nil {
+			return err
+		}
+		if !last {
+			endPositions = append(endPositions, c.emit(op.JumpForward, Placeholder))
+			delta, err := c.calculateDelta(nextPos)
+			if err != nil {
+				return err
+			}
+			c.changeOperand(nextPos, delta)
+		}
+	}
+	for _, pos := range endPositions {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return nil
+}
+
+// compileSelectCase pops the index, open flag, and received value left by
+// Select, binding the names of the case, and then compiles its block.
+func (c *Compiler) compileSelectCase(node *ast.SelectCase) error {
+	code := c.current
+	defer c.newBlock()()
+	store := func(name *ast.Ident) error {
+		sym, err := c.declare(&Declaration{
+			Name:  name.Literal(),
+			Kind:  VariableDeclaration,
+			Token: name.Token(),
+		})
+		if err != nil {
+			return err
+		}
+		if code.symbols.IsGlobal() {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+		return nil
+	}
+	c.emit(op.PopTop) // the index
+	names := node.Names()
+	if len(names) > 1 {
+		if err := store(names[1]); err != nil {
+			return err
+		}
+	} else {
+		c.emit(op.PopTop)
+	}
+	if len(names) > 0 {
+		if err := store(names[0]); err != nil {
+			return err
+		}
+	} else {
+		c.emit(op.PopTop)
+	}
+	if node.Block() == nil {
+		c.emit(op.Nil)
+		return nil
+	}
+	return c.compile(node.Block())
+}
+
+func (c *Compiler) compileSet(node *ast.Set) error {
+	items := node.Items()
+	count := len(items)
+	for _, expr := range items {
+		if err := c.compile(expr); err != nil {
+			return err
+		}
+	}
+	c.emit(op.BuildSet, uint16(count))
+	return nil
+}
+
+func (c *Compiler) compileListComprehension(node *ast.ListComprehension) error {
+	c.emit(op.BuildList, 0)
+	return c.compileComprehension(node.Clauses(), func(depth uint16) error {
+		if err := c.compile(node.Element()); err != nil {
+			return err
+		}
+		c.emit(op.ListAppend, depth)
+		return nil
+	})
+}
+
+func (c *Compiler) compileMapComprehension(node *ast.MapComprehension) error {
+	c.emit(op.BuildMap, 0)
+	return c.compileComprehension(node.Clauses(), func(depth uint16) error {
+		if err := c.compile(node.Key()); err != nil {
+			return err
+		}
+		if err := c.compile(node.Value()); err != nil {
+			return err
+		}
+		c.emit(op.MapAdd, depth)
+		return nil
+	})
+}
+
+func (c *Compiler) compileSetComprehension(node *ast.SetComprehension) error {
+	c.emit(op.BuildSet, 0)
+	return c.compileComprehension(node.Clauses(), func(depth uint16) error {
+		if err := c.compile(node.Element()); err != nil {
+			return err
+		}
+		c.emit(op.SetAdd, depth)
+		return nil
+	})
+}
+
+// compileComprehension compiles the for clauses of a comprehension as nested
+// loops, with the container being built on the stack beneath the iterators
+// of the loops. The innermost loop calls element to add to the container,
+// which is found at the given depth below the value being added.
+func (c *Compiler) compileComprehension(clauses []*ast.ForClause, element func(depth uint16) error) error {
+	code := c.current
+	endBlock := c.newBlock()
+	heldValues := code.heldValues
+	defer func() {
+		endBlock()
+		code.heldValues = heldValues
+	}()
+	code.heldValues++ // the container
+
+	type clauseLoop struct {
+		iterPos int
+		skipPos []int
+	}
+	loops := make([]clauseLoop, 0, len(clauses))
+	for _, clause := range clauses {
+		if err := c.compile(clause.Iterable()); err != nil {
+			return err
+		}
+		c.emit(op.GetIter)
+		code.heldValues++
+
+		names := clause.Names()
+		nameCount := uint16(len(names))
+		if nameCount == 1 {
+			nameCount |= op.ForIterItem
+		}
+		loop := clauseLoop{iterPos: c.emit(op.ForIter, 0, nameCount)}
+		for _, name := range names {
+			sym, err := c.declare(&Declaration{
+				Name:  name.Literal(),
+				Kind:  VariableDeclaration,
+				Token: name.Token(),
+			})
+			if err != nil {
+				return err
+			}
+			if code.symbols.IsGlobal() {
+				c.emit(op.StoreGlobal, sym.Index())
+			} else {
+				c.emit(op.StoreFast, sym.Index())
+			}
+		}
+
+		// Skip to the next item when a condition is false
+		for _, cond := range clause.Conditions() {
+			if err := c.compile(cond); err != nil {
+				return err
+			}
+			loop.skipPos = append(loop.skipPos, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+		}
+		loops = append(loops, loop)
+	}
+
+	if err := element(uint16(len(clauses))); err != nil {
+		return err
+	}
+
+	// Close the loops from the innermost outward
+	for i := len(loops) - 1; i >= 0; i-- {
+		loop := loops[i]
+		for _, pos := range loop.skipPos {
+			delta, err := c.calculateDelta(pos)
+			if err != nil {
+				return err
+			}
+			c.changeOperand(pos, delta)
+		}
+		delta, err := c.calculateDelta(loop.iterPos)
+		if err != nil {
+			return err
+		}
+		c.emit(op.JumpBackward, delta)
+		delta, err = c.calculateDelta(loop.iterPos)
+		if err != nil {
 			return err
 		}
+		c.changeOperand(loop.iterPos, delta)
 	}
-	c.emit(op.BuildMap, uint16(count))
 	return nil
 }
 
-func (c *Compiler) compileSend(node *ast.Send) error {
-	if err := c.compile(node.Channel()); err != nil {
+func (c *Compiler) compileFunc(node *ast.Func) error {
+	code, err := c.compileFunction(node, node.Parameters(), false)
+	if err != nil {
 		return err
 	}
-	if err := c.compile(node.Value()); err != nil {
-		return err
+	// If the function was named, we store it as a named variable in the current
+	// code. Otherwise, we just leave it on the stack.
+	if code.isNamed {
+		funcSymbol, err := c.declare(&Declaration{
+			Name:  code.name,
+			Kind:  FunctionDeclaration,
+			Token: node.Name().Token(),
+		})
+		if err != nil {
+			return err
+		}
+		if c.current.parent == nil {
+			c.emit(op.StoreGlobal, funcSymbol.Index())
+		} else {
+			c.emit(op.StoreFast, funcSymbol.Index())
+		}
 	}
-	c.emit(op.Send)
 	return nil
 }
 
-func (c *Compiler) compileReceive(node *ast.Receive) error {
-	if err := c.compile(node.Channel()); err != nil {
+func (c *Compiler) compileDecorated(node *ast.Decorated) error {
+	fn := node.Func()
+	// The name is declared before the body is compiled so that recursive
+	// calls resolve to the decorated function rather than the original.
+	funcSymbol, err := c.declare(&Declaration{
+		Name:  fn.Name().Literal(),
+		Kind:  FunctionDeclaration,
+		Token: fn.Name().Token(),
+	})
+	if err != nil {
 		return err
 	}
-	c.emit(op.Receive)
-	return nil
-}
-
-func (c *Compiler) compileSet(node *ast.Set) error {
-	items := node.Items()
-	count := len(items)
-	for _, expr := range items {
-		if err := c.compile(expr); err != nil {
+	if _, err := c.compileFunction(fn, fn.Parameters(), true); err != nil {
+		return err
+	}
+	// The decorators are evaluated in source order and then applied from the
+	// innermost outward. The original function stays on the stack below them
+	// so that the result can present its name and signature.
+	decorators := node.Decorators()
+	for _, decorator := range decorators {
+		if err := c.compile(decorator); err != nil {
 			return err
 		}
 	}
-	c.emit(op.BuildSet, uint16(count))
+	c.emit(op.Copy, uint16(len(decorators)))
+	for range decorators {
+		c.emit(op.Call, 1)
+	}
+	c.emit(op.WrapFunction)
+	if c.current.parent == nil {
+		c.emit(op.StoreGlobal, funcSymbol.Index())
+	} else {
+		c.emit(op.StoreFast, funcSymbol.Index())
+	}
 	return nil
 }
 
-func (c *Compiler) compileFunc(node *ast.Func) error {
+// compileFunction compiles the body of a function with the given parameters
+// and emits the instructions that push the function object onto the stack.
+// A decorated function does not bind its own name, which instead refers to
+// the decorated result in the enclosing scope.
+func (c *Compiler) compileFunction(node *ast.Func, parameters []*ast.Ident, decorated bool) (*Code, error) {
 	// Python cell variables:
 	// https://stackoverflow.com/questions/23757143/what-is-a-cell-in-the-context-of-an-interpreter-or-compiler
 
-	if len(node.Parameters()) > 255 {
-		return fmt.Errorf("compile error: function exceeded parameter limit of 255")
+	paramsCount := len(parameters)
+	if node.RestParameter() != nil {
+		paramsCount++
+	}
+	if paramsCount > 255 {
+		return nil, fmt.Errorf("compile error: function exceeded parameter limit of 255")
 	}
 
 	// The function has an optional name. If it is named, the name will be
@@ -1042,34 +2229,29 @@ func (c *Compiler) compileFunc(node *ast.Func) error {
 	// Setting current here means subsequent calls to compile will add to this
 	// code object instead of the parent.
 	c.current = code
+	c.openScope(code.symbols)
+	defer func() {
+		c.closeScope(code.symbols)
+		c.current = code.parent
+	}()
 
 	// Make it quick to look up the index of a parameter
 	paramsIdx := map[string]int{}
-	params := node.ParameterNames()
-	for i, name := range params {
+	params := make([]string, 0, len(parameters))
+	for i, param := range parameters {
+		name := param.Literal()
+		params = append(params, name)
 		paramsIdx[name] = i
 	}
 
-	// Build an array of default values for parameters, supporting only
-	// the basic types of int, string, bool, float, and nil.
+	// Build an array of default values for parameters
 	defaults := make([]any, len(params))
 	defaultsSet := map[int]bool{}
 	for name, expr := range node.Defaults() {
-		var value any
-		switch expr := expr.(type) {
-		case *ast.Int:
-			value = expr.Value()
-		case *ast.String:
-			value = expr.Value()
-		case *ast.Bool:
-			value = expr.Value()
-		case *ast.Float:
-			value = expr.Value()
-		case *ast.Nil:
-			value = nil
-		default:
+		value, ok := defaultValue(expr)
+		if !ok {
 			line := node.Token().StartPosition.Line + 1
-			return fmt.Errorf("compile error: unsupported default value (got %s, line %d)", expr, line)
+			return nil, fmt.Errorf("compile error: unsupported default value (got %s, line %d)", expr, line)
 		}
 		index := paramsIdx[name]
 		defaults[index] = value
@@ -1089,30 +2271,59 @@ func (c *Compiler) compileFunc(node *ast.Func) error {
 				} else {
 					msg = fmt.Sprintf("%s anonymous function", msg)
 				}
-				return fmt.Errorf("%s (line %d)", msg, node.Token().StartPosition.Line+1)
+				return nil, fmt.Errorf("%s (line %d)", msg, node.Token().StartPosition.Line+1)
 			}
 		}
 	}
 
-	// Add the parameter names to the symbol table
-	for _, arg := range node.Parameters() {
-		if _, err := code.symbols.InsertVariable(arg.Literal()); err != nil {
-			return err
+	// Add the parameter names to the symbol table, followed by the rest
+	// parameter if there is one. Parameters before those of the node, like
+	// the self parameter of methods, are implicit.
+	implicitCount := len(parameters) - len(node.Parameters())
+	for i, arg := range parameters {
+		if _, err := c.declare(&Declaration{
+			Name:     arg.Literal(),
+			Kind:     ParameterDeclaration,
+			Token:    arg.Token(),
+			Implicit: i < implicitCount,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	var restParameter string
+	if rest := node.RestParameter(); rest != nil {
+		restParameter = rest.Literal()
+		if _, err := c.declare(&Declaration{
+			Name:  restParameter,
+			Kind:  ParameterDeclaration,
+			Token: rest.Token(),
+		}); err != nil {
+			return nil, err
 		}
 	}
 
 	// Add the function's own name to its symbol table. This supports recursive
 	// calls to the function. Later when we create the function object, we'll
-	// add the object value to the table.
-	if code.isNamed {
-		if _, err := code.symbols.InsertConstant(functionName); err != nil {
-			return err
+	// add the object value to the table. The slot is still reserved for a
+	// decorated function, under a name that no identifier can refer to.
+	if code.isNamed && decorated {
+		if _, err := code.symbols.InsertConstant("@" + functionName); err != nil {
+			return nil, err
+		}
+	} else if code.isNamed {
+		if _, err := c.declare(&Declaration{
+			Name:     functionName,
+			Kind:     FunctionDeclaration,
+			Token:    node.Name().Token(),
+			Implicit: true,
+		}); err != nil {
+			return nil, err
 		}
 	}
 
 	// Compile the function body
 	if err := c.compileFunctionBlock(node.Body()); err != nil {
-		return err
+		return nil, err
 	}
 
 	// We're done compiling the function, so switch back to compiling the parent
@@ -1120,51 +2331,73 @@ func (c *Compiler) compileFunc(node *ast.Func) error {
 
 	// Create the function that contains the compiled code
 	fn := NewFunction(FunctionOpts{
-		ID:         functionID,
-		Name:       functionName,
-		Parameters: params,
-		Defaults:   defaults,
-		Code:       code,
+		ID:            functionID,
+		Name:          functionName,
+		Parameters:    params,
+		RestParameter: restParameter,
+		Defaults:      defaults,
+		Code:          code,
 	})
 
 	// Emit the code to load the function object onto the stack. If there are
-	// free variables, we use LoadClosure, otherwise we use LoadConst.
+	// free variables, we use LoadClosure, otherwise we use LoadConst. Free
+	// variables of this function that are locals of the current function get
+	// a new cell. Those declared further out are free in the current function
+	// as well, so it shares its own cell, since the frame that declared the
+	// variable may have returned by the time this function is created.
 	freeCount := code.symbols.FreeCount()
 	if freeCount > 0 {
 		for i := uint16(0); i < freeCount; i++ {
 			resolution := code.symbols.Free(i)
-			c.emit(op.MakeCell, resolution.symbol.Index(), uint16(resolution.depth-1))
+			if resolution.depth > 1 {
+				outer, ok := c.current.symbols.Resolve(resolution.symbol.name)
+				if !ok || outer.scope != Free {
+					return nil, fmt.Errorf("compile error: unresolved free variable %q", resolution.symbol.name)
+				}
+				c.emit(op.LoadFreeCell, uint16(outer.freeIndex))
+			} else {
+				c.emit(op.MakeCell, resolution.symbol.Index(), 0)
+			}
 		}
 		c.emit(op.LoadClosure, c.constant(fn), freeCount)
 	} else {
 		c.emit(op.LoadConst, c.constant(fn))
 	}
 
-	// If the function was named, we store it as a named variable in the current
-	// code. Otherwise, we just leave it on the stack.
-	if code.isNamed {
-		funcSymbol, err := c.current.symbols.InsertConstant(functionName)
-		if err != nil {
-			return err
-		}
-		if c.current.parent == nil {
-			c.emit(op.StoreGlobal, funcSymbol.Index())
-		} else {
-			c.emit(op.StoreFast, funcSymbol.Index())
-		}
-	}
-	return nil
+	return code, nil
 }
 
 func (c *Compiler) compileControl(node *ast.Control) error {
 	literal := node.Literal()
-	loop := c.currentLoop()
+	var loop *loop
+	if label := node.Label(); label != nil {
+		loop = c.labeledLoop(label.Literal())
+		if loop == nil {
+			return fmt.Errorf("compile error: invalid %s label %s (line %d)",
+				literal, label.Literal(), node.Token().StartPosition.LineNumber())
+		}
+	} else {
+		loop = c.currentLoop()
+	}
 	if loop == nil {
 		if literal == "break" {
 			return fmt.Errorf("compile error: invalid break statement outside of a loop")
 		}
 		return fmt.Errorf("compile error: invalid continue statement outside of a loop")
 	}
+	// Leaving the loop also leaves any try statements within it
+	if err := c.unwindTries(loop.tryDepth); err != nil {
+		return err
+	}
+	// Pop the values of any switch or match expressions within the loop, as
+	// well as the iterators of any range loops being left
+	keep := loop.heldValues
+	if literal == "continue" && loop.hasIterator {
+		keep++
+	}
+	for i := keep; i < c.current.heldValues; i++ {
+		c.emit(op.PopTop)
+	}
 	if literal == "break" {
 		position := c.emit(op.JumpForward, Placeholder)
 		loop.breakPos = append(loop.breakPos, position)
@@ -1187,10 +2420,172 @@ func (c *Compiler) compileReturn(node *ast.Return) error {
 			return err
 		}
 	}
+	// Run the finally blocks of any enclosing try statements. The return
+	// value stays on the stack while they run.
+	if err := c.unwindTries(0); err != nil {
+		return err
+	}
 	c.emit(op.ReturnValue)
 	return nil
 }
 
+func (c *Compiler) compileYield(node *ast.Yield) error {
+	if c.current.parent == nil {
+		return fmt.Errorf("compile error: yield statement outside of a function (line %d)",
+			node.Token().StartPosition.LineNumber())
+	}
+	value := node.Value()
+	if value == nil {
+		c.emit(op.Nil)
+	} else {
+		if err := c.compile(value); err != nil {
+			return err
+		}
+	}
+	c.current.isGenerator = true
+	c.emit(op.Yield)
+	return nil
+}
+
+// unwindTries emits the instructions needed to exit all try statements in
+// the current code that are nested deeper than the given depth, innermost
+// first. Each exception handler is removed and each finally block is run.
+func (c *Compiler) unwindTries(depth int) error {
+	code := c.current
+	tries := code.tries
+	defer func() { code.tries = tries }()
+	for i := len(tries) - 1; i >= depth; i-- {
+		// A finally block is compiled as if outside its own try statement
+		code.tries = tries[:i]
+		if tries[i].hasHandler {
+			c.emit(op.PopExcept)
+		}
+		if tries[i].finally != nil {
+			if err := c.replayStatementBlock(tries[i].finally); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileStatementBlock compiles a block whose value is discarded.
+func (c *Compiler) compileStatementBlock(node *ast.Block) error {
+	if err := c.compile(node); err != nil {
+		return err
+	}
+	c.emit(op.PopTop)
+	return nil
+}
+
+// replayStatementBlock compiles a block whose value is discarded, like
+// compileStatementBlock, for a block that is compiled more than once. The
+// observer is only notified of the block when it is compiled normally.
+func (c *Compiler) replayStatementBlock(node *ast.Block) error {
+	replaying := c.replaying
+	c.replaying = true
+	defer func() { c.replaying = replaying }()
+	return c.compileStatementBlock(node)
+}
+
+func (c *Compiler) compileTry(node *ast.Try) error {
+	code := c.current
+	finally := node.FinallyBlock()
+
+	// Protect the body with an exception handler
+	handlerPos := c.emit(op.PushExcept, Placeholder)
+	code.tries = append(code.tries, &tryBlock{hasHandler: true, finally: finally})
+	if err := c.compileStatementBlock(node.Body()); err != nil {
+		return err
+	}
+	code.tries = code.tries[:len(code.tries)-1]
+	c.emit(op.PopExcept)
+	if finally != nil {
+		if err := c.compileStatementBlock(finally); err != nil {
+			return err
+		}
+	}
+	var endPositions []int
+	endPositions = append(endPositions, c.emit(op.JumpForward, Placeholder))
+
+	// The handler is entered with the raised error on top of the stack
+	delta, err := c.calculateDelta(handlerPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(handlerPos, delta)
+
+	if catch := node.CatchBlock(); catch != nil {
+		// If there is a finally block, it must still run if the catch block
+		// raises an error, so the catch block gets its own handler.
+		var finallyHandlerPos int
+		if finally != nil {
+			finallyHandlerPos = c.emit(op.PushExcept, Placeholder)
+			code.tries = append(code.tries, &tryBlock{hasHandler: true, finally: finally})
+		}
+		if err := c.compileCatch(node.CatchIdent(), catch); err != nil {
+			return err
+		}
+		if finally == nil {
+			endPositions = append(endPositions, c.emit(op.JumpForward, Placeholder))
+		} else {
+			code.tries = code.tries[:len(code.tries)-1]
+			c.emit(op.PopExcept)
+			if err := c.replayStatementBlock(finally); err != nil {
+				return err
+			}
+			endPositions = append(endPositions, c.emit(op.JumpForward, Placeholder))
+			delta, err := c.calculateDelta(finallyHandlerPos)
+			if err != nil {
+				return err
+			}
+			c.changeOperand(finallyHandlerPos, delta)
+		}
+	}
+
+	// Run the finally block then raise the error again
+	if finally != nil {
+		if err := c.replayStatementBlock(finally); err != nil {
+			return err
+		}
+		c.emit(op.Raise)
+	}
+
+	for _, pos := range endPositions {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return nil
+}
+
+// compileCatch compiles a catch block. The caught error is on top of the
+// stack and is bound to the given name, if one was provided.
+func (c *Compiler) compileCatch(ident *ast.Ident, block *ast.Block) error {
+	code := c.current
+	defer c.newBlock()()
+	if ident == nil {
+		c.emit(op.PopTop)
+	} else {
+		sym, err := c.declare(&Declaration{
+			Name:  ident.Literal(),
+			Kind:  ParameterDeclaration,
+			Token: ident.Token(),
+		})
+		if err != nil {
+			return err
+		}
+		if code.parent == nil {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+	}
+	return c.compileStatementBlock(block)
+}
+
 func (c *Compiler) compileSetItem(node *ast.Assign) error {
 	// StoreSubscr / STORE_SUBSCR
 	// Implements TOS1[TOS] = TOS2.
@@ -1218,16 +2613,20 @@ func (c *Compiler) compileAssign(node *ast.Assign) error {
 		return c.compileSetItem(node)
 	}
 	lineNum := node.Token().StartPosition.LineNumber()
-	name := node.Name()
-	resolution, found := c.current.symbols.Resolve(name)
-	if !found {
-		return fmt.Errorf("compile error: undefined variable %q (line %d)", name, lineNum)
+	resolution, err := c.resolve(node.Ident(), true)
+	if err != nil {
+		return err
 	}
-	sym := resolution.symbol
-	if sym.IsConstant() {
-		return fmt.Errorf("compile error: cannot assign to constant %q (line %d)", name, lineNum)
+	if resolution == nil {
+		// The name is undefined, but the value is still compiled so that
+		// the names it refers to are resolved
+		if err := c.compile(node.Value()); err != nil {
+			return err
+		}
+		c.emit(op.PopTop)
+		return nil
 	}
-	symbolIndex := sym.Index()
+	symbolIndex := resolution.symbol.Index()
 	if node.Operator() == "=" {
 		if err := c.compile(node.Value()); err != nil {
 			return err
@@ -1242,6 +2641,25 @@ func (c *Compiler) compileAssign(node *ast.Assign) error {
 		}
 		return nil
 	}
+	var opType op.BinaryOpType
+	switch node.Operator() {
+	case "+=":
+		opType = op.Add
+	case "-=":
+		opType = op.Subtract
+	case "*=":
+		opType = op.Multiply
+	case "/=":
+		opType = op.Divide
+	default:
+		return fmt.Errorf("compile error: unknown assignment operator %q (line %d)",
+			node.Operator(), lineNum)
+	}
+	if value, ok := numberLiteral(node.Value()); ok && resolution.scope == Local {
+		c.emit(op.BinaryOpFastConst, symbolIndex, c.constant(value), uint16(opType))
+		c.emit(op.StoreFast, symbolIndex)
+		return nil
+	}
 	// Push LHS as TOS
 	switch resolution.scope {
 	case Global:
@@ -1255,17 +2673,8 @@ func (c *Compiler) compileAssign(node *ast.Assign) error {
 	if err := c.compile(node.Value()); err != nil {
 		return err
 	}
-	// Result becomes TOS
-	switch node.Operator() {
-	case "+=":
-		c.emit(op.BinaryOp, uint16(op.Add))
-	case "-=":
-		c.emit(op.BinaryOp, uint16(op.Subtract))
-	case "*=":
-		c.emit(op.BinaryOp, uint16(op.Multiply))
-	case "/=":
-		c.emit(op.BinaryOp, uint16(op.Divide))
-	}
+	// Result becomes TOS. The variable on the LHS is not a literal.
+	c.emitBinaryOp(opType, nil, node.Value())
 	// Store TOS in LHS
 	switch resolution.scope {
 	case Global:
@@ -1290,7 +2699,7 @@ func (c *Compiler) compileSetAttr(node *ast.SetAttr) error {
 	return nil
 }
 
-func (c *Compiler) compileForRange(forNode *ast.For, names []string, container ast.Node) error {
+func (c *Compiler) compileForRange(forNode *ast.For, label string, names []*ast.Ident, container ast.Node) error {
 	if err := c.compile(container); err != nil {
 		return err
 	}
@@ -1298,18 +2707,25 @@ func (c *Compiler) compileForRange(forNode *ast.For, names []string, container a
 	c.emit(op.GetIter)
 
 	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
+	endBlock := c.newBlock()
+	loop := c.startLoop(label)
+	loop.hasIterator = true
+	code.heldValues++
 	defer func() {
 		loop.end()
-		code.symbols = code.symbols.parent
+		endBlock()
+		code.heldValues--
 	}()
 
 	iterPos := c.emit(op.ForIter, 0, uint16(len(names)))
 
 	// assign the current value of the iterator to the loop variable
 	for _, name := range names {
-		sym, err := code.symbols.InsertVariable(name)
+		sym, err := c.declare(&Declaration{
+			Name:  name.Literal(),
+			Kind:  VariableDeclaration,
+			Token: name.Token(),
+		})
 		if err != nil {
 			return err
 		}
@@ -1360,13 +2776,12 @@ func (c *Compiler) compileForRange(forNode *ast.For, names []string, container a
 	return nil
 }
 
-func (c *Compiler) compileForCondition(forNode *ast.For, condition ast.Expression) error {
-	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
+func (c *Compiler) compileForCondition(forNode *ast.For, label string, condition ast.Expression) error {
+	endBlock := c.newBlock()
+	loop := c.startLoop(label)
 	defer func() {
 		loop.end()
-		code.symbols = code.symbols.parent
+		endBlock()
 	}()
 	startPos := c.currentPosition()
 	if err := c.compile(condition); err != nil {
@@ -1405,10 +2820,19 @@ func (c *Compiler) compileForCondition(forNode *ast.For, condition ast.Expressio
 	return nil
 }
 
-func (c *Compiler) compileFor(node *ast.For) error {
+func (c *Compiler) compileLabeled(node *ast.Labeled) error {
+	label := node.Label().Literal()
+	if c.labeledLoop(label) != nil {
+		return fmt.Errorf("compile error: label %s is already defined (line %d)",
+			label, node.Token().StartPosition.LineNumber())
+	}
+	return c.compileFor(node.Loop(), label)
+}
+
+func (c *Compiler) compileFor(node *ast.For, label string) error {
 	// Simple loop e.g. `for { ... }`
 	if node.IsSimpleLoop() {
-		return c.compileSimpleFor(node)
+		return c.compileSimpleFor(node, label)
 	}
 
 	lineNum := node.Token().StartPosition.LineNumber()
@@ -1418,38 +2842,39 @@ func (c *Compiler) compileFor(node *ast.For) error {
 		cond := node.Condition()
 		switch cond := cond.(type) {
 		case *ast.Var:
-			name, rhs := cond.Value()
+			_, rhs := cond.Value()
+			names := []*ast.Ident{cond.Ident()}
 			if rangeNode, ok := rhs.(*ast.Range); ok {
-				return c.compileForRange(node, []string{name}, rangeNode.Container())
+				return c.compileForRange(node, label, names, rangeNode.Container())
 			} else {
-				return c.compileForRange(node, []string{name}, rhs)
+				return c.compileForRange(node, label, names, rhs)
 			}
 		case *ast.MultiVar:
-			names, rhs := cond.Value()
+			_, rhs := cond.Value()
+			names := cond.Idents()
 			if len(names) != 2 {
 				return fmt.Errorf("compile error: invalid for loop (line %d)", lineNum)
 			}
 			if rangeNode, ok := rhs.(*ast.Range); ok {
-				return c.compileForRange(node, names, rangeNode.Container())
+				return c.compileForRange(node, label, names, rangeNode.Container())
 			} else {
-				return c.compileForRange(node, names, rhs)
+				return c.compileForRange(node, label, names, rhs)
 			}
 		case *ast.Range:
-			return c.compileForRange(node, nil, cond.Container())
+			return c.compileForRange(node, label, nil, cond.Container())
 		case ast.Expression:
-			return c.compileForCondition(node, cond)
+			return c.compileForCondition(node, label, cond)
 		default:
 			return fmt.Errorf("compile error: invalid for loop (line %d)", lineNum)
 		}
 	}
 
 	// For-Condition loop e.g. `for i := 0; i < 10; i++ { ... }`
-	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
+	endBlock := c.newBlock()
+	loop := c.startLoop(label)
 	defer func() {
 		loop.end()
-		code.symbols = code.symbols.parent
+		endBlock()
 	}()
 
 	// Compile the init statement if present
@@ -1530,13 +2955,12 @@ func (c *Compiler) compileFor(node *ast.For) error {
 	return nil
 }
 
-func (c *Compiler) compileSimpleFor(node *ast.For) error {
-	code := c.current
-	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
+func (c *Compiler) compileSimpleFor(node *ast.For, label string) error {
+	endBlock := c.newBlock()
+	loop := c.startLoop(label)
 	defer func() {
 		loop.end()
-		code.symbols = code.symbols.parent
+		endBlock()
 	}()
 	startPos := c.currentPosition()
 	if err := c.compile(node.Consequence()); err != nil {
@@ -1636,47 +3060,145 @@ func (c *Compiler) compileInfix(node *ast.Infix) error {
 		return c.compileAnd(node)
 	} else if operator == "||" {
 		return c.compileOr(node)
+	} else if operator == "??" {
+		return c.compileNullish(node)
 	}
 	// Non-short-circuit operators
+	if opType, ok := binaryOperators[operator]; ok {
+		if local, constant, ok := c.fastConstOperands(node.Left(), node.Right()); ok {
+			c.emit(op.BinaryOpFastConst, local, constant, uint16(opType))
+			return nil
+		}
+		if err := c.compileOperands(node); err != nil {
+			return err
+		}
+		c.emitBinaryOp(opType, node.Left(), node.Right())
+		return nil
+	}
+	if opType, ok := compareOperators[operator]; ok {
+		if local, constant, ok := c.fastConstOperands(node.Left(), node.Right()); ok {
+			c.emit(op.CompareOpFastConst, local, constant, uint16(opType))
+			return nil
+		}
+		if err := c.compileOperands(node); err != nil {
+			return err
+		}
+		c.emitCompareOp(opType, node.Left(), node.Right())
+		return nil
+	}
+	return fmt.Errorf("compile error: unknown operator %q (line %d)", node.Operator(), lineNum)
+}
+
+var binaryOperators = map[string]op.BinaryOpType{
+	"+":  op.Add,
+	"-":  op.Subtract,
+	"*":  op.Multiply,
+	"/":  op.Divide,
+	"%":  op.Modulo,
+	"**": op.Power,
+	"<<": op.LShift,
+	">>": op.RShift,
+}
+
+var compareOperators = map[string]op.CompareOpType{
+	">":  op.GreaterThan,
+	">=": op.GreaterThanOrEqual,
+	"<":  op.LessThan,
+	"<=": op.LessThanOrEqual,
+	"==": op.Equal,
+	"!=": op.NotEqual,
+}
+
+func (c *Compiler) compileOperands(node *ast.Infix) error {
 	if err := c.compile(node.Left()); err != nil {
 		return err
 	}
-	if err := c.compile(node.Right()); err != nil {
-		return err
+	return c.compile(node.Right())
+}
+
+// emitBinaryOp emits a binary operation on the given operands. Additions and
+// subtractions use the opcodes with an integer fast path unless the operands
+// are known not to be integers or are both literals.
+func (c *Compiler) emitBinaryOp(opType op.BinaryOpType, left, right ast.Node) {
+	if !mayBeInts(left, right) {
+		c.emit(op.BinaryOp, uint16(opType))
+		return
 	}
-	switch operator {
-	case "+":
-		c.emit(op.BinaryOp, uint16(op.Add))
-	case "-":
-		c.emit(op.BinaryOp, uint16(op.Subtract))
-	case "*":
-		c.emit(op.BinaryOp, uint16(op.Multiply))
-	case "/":
-		c.emit(op.BinaryOp, uint16(op.Divide))
-	case "%":
-		c.emit(op.BinaryOp, uint16(op.Modulo))
-	case "**":
-		c.emit(op.BinaryOp, uint16(op.Power))
-	case "<<":
-		c.emit(op.BinaryOp, uint16(op.LShift))
-	case ">>":
-		c.emit(op.BinaryOp, uint16(op.RShift))
-	case ">":
-		c.emit(op.CompareOp, uint16(op.GreaterThan))
-	case ">=":
-		c.emit(op.CompareOp, uint16(op.GreaterThanOrEqual))
-	case "<":
-		c.emit(op.CompareOp, uint16(op.LessThan))
-	case "<=":
-		c.emit(op.CompareOp, uint16(op.LessThanOrEqual))
-	case "==":
-		c.emit(op.CompareOp, uint16(op.Equal))
-	case "!=":
-		c.emit(op.CompareOp, uint16(op.NotEqual))
+	switch opType {
+	case op.Add:
+		c.emit(op.BinaryAddFast)
+	case op.Subtract:
+		c.emit(op.BinarySubtractFast)
 	default:
-		return fmt.Errorf("compile error: unknown operator %q (line %d)", node.Operator(), lineNum)
+		c.emit(op.BinaryOp, uint16(opType))
 	}
-	return nil
+}
+
+// emitCompareOp emits a comparison of the given operands, using CompareOpFast
+// under the same conditions as emitBinaryOp.
+func (c *Compiler) emitCompareOp(opType op.CompareOpType, left, right ast.Node) {
+	if mayBeInts(left, right) {
+		c.emit(op.CompareOpFast, uint16(opType))
+	} else {
+		c.emit(op.CompareOp, uint16(opType))
+	}
+}
+
+// mayBeInts reports whether an operation on the given operands could benefit
+// from an integer fast path. This is not the case when either operand is a
+// literal of another type, or when both are literals and the operation can
+// be folded into a constant.
+func mayBeInts(left, right ast.Node) bool {
+	leftLiteral, leftInt := literalKind(left)
+	rightLiteral, rightInt := literalKind(right)
+	if leftLiteral && !leftInt || rightLiteral && !rightInt {
+		return false
+	}
+	return !(leftLiteral && rightLiteral)
+}
+
+// literalKind reports whether the node is a literal and whether it is an
+// integer literal.
+func literalKind(node ast.Node) (literal, integer bool) {
+	switch node.(type) {
+	case *ast.Int:
+		return true, true
+	case *ast.Float, *ast.String, *ast.Bool, *ast.Nil, *ast.List, *ast.Map, *ast.Set:
+		return true, false
+	}
+	return false, false
+}
+
+// fastConstOperands returns the indexes of the local variable and constant
+// for an operation on a local variable and a number, like "n - 1", which is
+// compiled to a single instruction.
+func (c *Compiler) fastConstOperands(left, right ast.Node) (uint16, uint16, bool) {
+	ident, ok := left.(*ast.Ident)
+	if !ok {
+		return 0, 0, false
+	}
+	value, ok := numberLiteral(right)
+	if !ok {
+		return 0, 0, false
+	}
+	resolution, found := c.current.symbols.Resolve(ident.Literal())
+	if !found || resolution.scope != Local {
+		return 0, 0, false
+	}
+	// The identifier is not compiled on its own, so the observer is
+	// notified of it here
+	c.reference(ident, resolution.symbol, false)
+	return resolution.symbol.Index(), c.constant(value), true
+}
+
+func numberLiteral(node ast.Node) (any, bool) {
+	switch node := node.(type) {
+	case *ast.Int:
+		return node.Value(), true
+	case *ast.Float:
+		return node.Value(), true
+	}
+	return nil, false
 }
 
 func (c *Compiler) compileAnd(node *ast.Infix) error {
@@ -1756,20 +3278,14 @@ func (c *Compiler) compileDeferStmt(node *ast.Defer) error {
 }
 
 func (c *Compiler) compilePartial(call *ast.Call) error {
-	args := call.Arguments()
-	argc := len(args)
-	if argc > MaxArgs {
-		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
-	}
 	if err := c.compile(call.Function()); err != nil {
 		return err
 	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
+	operand, err := c.compileArgs(call.Arguments())
+	if err != nil {
+		return err
 	}
-	c.emit(op.Partial, uint16(argc))
+	c.emit(op.Partial, operand)
 	return nil
 }
 
@@ -1784,17 +3300,11 @@ func (c *Compiler) compilePartialObjectCall(node *ast.ObjectCall) error {
 	}
 	name := method.Function().String()
 	c.emit(op.LoadAttr, c.current.addName(name))
-	args := method.Arguments()
-	argc := len(args)
-	if argc > MaxArgs {
-		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
-	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
+	operand, err := c.compileArgs(method.Arguments())
+	if err != nil {
+		return err
 	}
-	c.emit(op.Partial, uint16(len(args)))
+	c.emit(op.Partial, operand)
 	return nil
 }
 
@@ -1813,6 +3323,9 @@ func (c *Compiler) emit(opcode op.Code, operands ...uint16) int {
 	code := c.current
 	pos := len(code.instructions)
 	code.instructions = append(code.instructions, inst...)
+	for range inst {
+		code.locations = append(code.locations, c.location)
+	}
 	return pos
 }
 
@@ -1840,6 +3353,8 @@ func normalizeFunctionBlock(node *ast.Block) []ast.Node {
 	returnNil := ast.NewReturn(token.Token{}, ast.NewNil(token.Token{}))
 	statements := node.Statements()
 	count := len(statements)
+	// The statements are copied so that the AST is left unmodified
+	statements = append(make([]ast.Node, 0, count+1), statements...)
 	if count == 0 {
 		return []ast.Node{returnNil}
 	}
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/risor-io/risor/ast"
@@ -79,6 +80,16 @@ func TestCompileErrors(t *testing.T) {
 			input:  "const a = 1; a = 2",
 			errMsg: "compile error: cannot assign to constant \"a\" (line 1)",
 		},
+		{
+			name:   "cannot assign to constant in a multiple assignment",
+			input:  "const a = 1\nb := 2\na, b = [3, 4]",
+			errMsg: "compile error: cannot assign to constant \"a\" (line 3)",
+		},
+		{
+			name:   "cannot increment a function",
+			input:  "func f() {}\nf++",
+			errMsg: "compile error: cannot assign to constant \"f\" (line 2)",
+		},
 		{
 			name:   "invalid for loop",
 			input:  "\nfor a, b, c := range [1, 2, 3] {}",
@@ -89,6 +100,21 @@ func TestCompileErrors(t *testing.T) {
 			input:  "\n defer func() {}()",
 			errMsg: "compile error: defer statement outside of a function (line 2)",
 		},
+		{
+			name:   "alternative patterns bind different names",
+			input:  "x := 1\nmatch x {\ncase [a], [b]: 1\n}",
+			errMsg: "compile error: alternative patterns bind different names (line 3)",
+		},
+		{
+			name:   "name captured more than once",
+			input:  "x := 1\nmatch x {\ncase [a, {\"a\": a}]: 1\n}",
+			errMsg: "compile error: name \"a\" is captured more than once in a pattern (line 3)",
+		},
+		{
+			name:   "yield outside of a function",
+			input:  "x := 1\nyield x",
+			errMsg: "compile error: yield statement outside of a function (line 2)",
+		},
 	}
 	for _, tt := range testCase {
 		t.Run(tt.name, func(t *testing.T) {
@@ -119,3 +145,501 @@ for _, v := range [1, 2, 3] {
 	require.NotNil(t, err)
 	require.Equal(t, "compile error: undefined variable \"undefined_var\" (line 4)", err.Error())
 }
+
+func TestCompileSpreadCall(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "f(1, ...a, 2, **m)")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"f", "a", "m"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	// Global names are indexed alphabetically: a, f, m
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 1,
+		op.LoadConst, 0,
+		op.BuildList, 1,
+		op.LoadGlobal, 0,
+		op.ListExtend,
+		op.LoadConst, 1,
+		op.BuildList, 1,
+		op.ListExtend,
+		op.BuildMap, 0,
+		op.LoadGlobal, 2,
+		op.MapMerge,
+		op.Call, op.Code(op.CallArgsList | op.CallKwargs),
+	}, codes)
+}
+
+func TestCompileKeywordCall(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "f(1, a=2, **m, b=3)")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"f", "m"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.LoadConst, 0,
+		op.LoadConst, 1,
+		op.LoadConst, 2,
+		op.BuildMap, 1,
+		op.LoadGlobal, 1,
+		op.MapMerge,
+		op.LoadConst, 3,
+		op.LoadConst, 4,
+		op.BuildMap, 1,
+		op.MapMerge,
+		op.Call, op.Code(1 | op.CallKwargs),
+	}, codes)
+	require.Equal(t, "a", code.Constant(1))
+	require.Equal(t, "b", code.Constant(3))
+}
+
+func TestCompileMatch(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "match v { case [a, _]: a }")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"v"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.Copy, 0,
+		op.MatchList, 2, 0,
+		op.PopJumpForwardIfFalse, 11,
+		op.Unpack, 2,
+		op.PopTop,
+		op.StoreGlobal, 1,
+		op.LoadGlobal, 1,
+		op.JumpForward, 4,
+		op.PopTop,
+		op.Nil,
+		op.Swap, 1,
+		op.PopTop,
+	}, codes)
+}
+
+func TestCompileSelect(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "select { case x := <-a: x\ndefault: 1 }")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"a"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.False,
+		op.Select, op.Code(op.SelectDefault | 1),
+		op.Copy, 0,
+		op.LoadConst, 0,
+		op.CompareOp, op.Code(op.Equal),
+		op.PopJumpForwardIfFalse, 10,
+		op.PopTop,
+		op.PopTop,
+		op.StoreGlobal, 1,
+		op.LoadGlobal, 1,
+		op.JumpForward, 7,
+		op.PopTop,
+		op.PopTop,
+		op.PopTop,
+		op.LoadConst, 1,
+	}, codes)
+}
+
+func TestCompileDecorated(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "@a\n@b(1)\nfunc f() {}")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"a", "b"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	// The decorators are applied bottom-up and the result is bound to "f"
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.LoadGlobal, 0,
+		op.LoadGlobal, 1,
+		op.LoadConst, 1,
+		op.Call, 1,
+		op.Copy, 2,
+		op.Call, 1,
+		op.Call, 1,
+		op.WrapFunction,
+		op.StoreGlobal, 2,
+		op.Nil,
+	}, codes)
+}
+
+func TestCompileNestedClosure(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `func f(x) {
+		return func() {
+			return func() { return x }
+		}
+	}`)
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	codes := code.Flatten()
+	require.Len(t, codes, 4)
+	instrs := func(code *Code) []op.Code {
+		var result []op.Code
+		for i := 0; i < code.InstructionCount(); i++ {
+			result = append(result, code.Instruction(i))
+		}
+		return result
+	}
+	// The function declaring x creates its cell
+	require.Equal(t, op.MakeCell, instrs(codes[1])[0])
+	// The middle function passes on its own cell for x, rather than looking
+	// for x in a frame that may have returned
+	middle := instrs(codes[2])
+	require.Equal(t, op.LoadFreeCell, middle[0])
+	require.NotContains(t, middle, op.MakeCell)
+}
+
+func TestCompileEnum(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "enum Color { RED, GREEN = -2, BLUE }")
+	require.Nil(t, err)
+	c, err := New()
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.LoadConst, 1,
+		op.LoadConst, 2,
+		op.LoadConst, 3,
+		op.LoadConst, 4,
+		op.LoadConst, 5,
+		op.LoadConst, 6,
+		op.BuildEnum, 3,
+		op.StoreGlobal, 0,
+		op.Nil,
+	}, codes)
+	var constants []any
+	for i := 0; i < code.ConstantsCount(); i++ {
+		constants = append(constants, code.Constant(i))
+	}
+	require.Equal(t, []any{
+		"Color",
+		"RED", int64(0),
+		"GREEN", int64(-2),
+		"BLUE", int64(-1),
+	}, constants)
+}
+
+func TestCompileFormatSpec(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "'{x:>5} {x!r}'")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"x"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.LoadConst, 0,
+		op.FormatValue, op.Code(op.FormatNone),
+		op.LoadConst, 1,
+		op.LoadGlobal, 0,
+		op.LoadConst, 2,
+		op.FormatValue, op.Code(op.FormatRepr),
+		op.BuildString, 3,
+	}, codes)
+	require.Equal(t, ">5", code.Constant(0))
+	require.Equal(t, "", code.Constant(2))
+}
+
+func TestCompileBreakRangeLoop(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "outer: for _, x := range v { for y := range x { break outer } }")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"v"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	// Breaking out of both loops pops both iterators
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.GetIter,
+		op.ForIter, 27, 2,
+		op.StoreGlobal, 1,
+		op.StoreGlobal, 2,
+		op.LoadGlobal, 2,
+		op.GetIter,
+		op.ForIter, 13, 1,
+		op.StoreGlobal, 3,
+		op.PopTop,
+		op.PopTop,
+		op.JumpForward, 10,
+		op.Nil,
+		op.PopTop,
+		op.JumpBackward, 11,
+		op.Nil,
+		op.PopTop,
+		op.JumpBackward, 25,
+		op.Nil,
+	}, codes)
+}
+
+func TestCompileListComprehension(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "[x for x in v if x]")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"v"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.BuildList, 0,
+		op.LoadGlobal, 0,
+		op.GetIter,
+		op.ForIter, 15, op.Code(op.ForIterItem | 1),
+		op.StoreGlobal, 1,
+		op.LoadGlobal, 1,
+		op.PopJumpForwardIfFalse, 6,
+		op.LoadGlobal, 1,
+		op.ListAppend, 1,
+		op.JumpBackward, 13,
+	}, codes)
+}
+
+func TestCompileOptionalChain(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "a?.b ?? c")
+	require.Nil(t, err)
+	c, err := New(WithGlobalNames([]string{"a", "c"}))
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 0,
+		op.Copy, 0,
+		op.PopJumpForwardIfNil, 4,
+		op.LoadAttr, 0,
+		op.Copy, 0,
+		op.PopJumpForwardIfNotNil, 5,
+		op.PopTop,
+		op.LoadGlobal, 1,
+	}, codes)
+}
+
+func TestCompileSpecializedOps(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `
+	func f(a, b) {
+		a += 1
+		b += 2.5
+		return [a - 1, a < 10, a + b, a <= b, a * b, b - a, 1 + a]
+	}`)
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	fn, ok := code.Constant(0).(*Function)
+	require.True(t, ok)
+	fnCode := fn.Code()
+	var codes []op.Code
+	for i := 0; i < fnCode.InstructionCount(); i++ {
+		codes = append(codes, fnCode.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.BinaryOpFastConst, 0, 0, op.Code(op.Add),
+		op.StoreFast, 0,
+		op.BinaryOpFastConst, 1, 1, op.Code(op.Add),
+		op.StoreFast, 1,
+		op.BinaryOpFastConst, 0, 2, op.Code(op.Subtract),
+		op.CompareOpFastConst, 0, 3, op.Code(op.LessThan),
+		op.LoadFast, 0,
+		op.LoadFast, 1,
+		op.BinaryAddFast,
+		op.LoadFast, 0,
+		op.LoadFast, 1,
+		op.CompareOpFast, op.Code(op.LessThanOrEqual),
+		op.LoadFast, 0,
+		op.LoadFast, 1,
+		op.BinaryOp, op.Code(op.Multiply),
+		op.LoadFast, 1,
+		op.LoadFast, 0,
+		op.BinarySubtractFast,
+		op.LoadConst, 4,
+		op.LoadFast, 0,
+		op.BinaryAddFast,
+		op.BuildList, 7,
+		op.ReturnValue,
+	}, codes)
+	require.Equal(t, int64(1), fnCode.Constant(0))
+	require.Equal(t, 2.5, fnCode.Constant(1))
+	require.Equal(t, int64(10), fnCode.Constant(3))
+}
+
+func TestCompileSpecializedOpsGlobal(t *testing.T) {
+	// Global variables are not combined with constants
+	code, err := compileSource("x := 1; x += 1; x < 3")
+	require.Nil(t, err)
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.StoreGlobal, 4,
+		op.LoadGlobal, 4,
+		op.LoadConst, 1,
+		op.BinaryAddFast,
+		op.StoreGlobal, 4,
+		op.LoadGlobal, 4,
+		op.LoadConst, 2,
+		op.CompareOpFast, op.Code(op.LessThan),
+	}, codes)
+}
+
+func TestCompileSpecializedOpsLiterals(t *testing.T) {
+	// Operands that are known not to be integers use the generic opcodes
+	tests := []struct {
+		input    string
+		expected []op.Code
+	}{
+		{`x := "a"; x + "b"`, []op.Code{op.BinaryOp, op.Code(op.Add)}},
+		{`x := "a"; "b" + x`, []op.Code{op.BinaryOp, op.Code(op.Add)}},
+		{`x := 1; x + 2.5`, []op.Code{op.BinaryOp, op.Code(op.Add)}},
+		{`x := [1]; x + [2]`, []op.Code{op.BuildList, 1, op.BinaryOp, op.Code(op.Add)}},
+		{`x := 1; x - 2`, []op.Code{op.LoadConst, 1, op.BinarySubtractFast}},
+		{`x := 1; 2 - x`, []op.Code{op.LoadGlobal, 4, op.BinarySubtractFast}},
+		{`x := "a"; x == "b"`, []op.Code{op.CompareOp, op.Code(op.Equal)}},
+		{`x := 1; x == 2`, []op.Code{op.CompareOpFast, op.Code(op.Equal)}},
+		// Operations on two literals are left to the optimizer
+		{`1 + 2`, []op.Code{op.BinaryOp, op.Code(op.Add)}},
+		{`1 < 2`, []op.Code{op.CompareOp, op.Code(op.LessThan)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code, err := compileSource(tt.input)
+			require.Nil(t, err)
+			var codes []op.Code
+			for i := 0; i < code.InstructionCount(); i++ {
+				codes = append(codes, code.Instruction(i))
+			}
+			require.Equal(t, tt.expected, codes[len(codes)-len(tt.expected):])
+		})
+	}
+}
+
+// recorder is an Observer that records the scopes, declarations and
+// references it is notified of.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) Enter(node ast.Node) {}
+
+func (r *recorder) Leave(node ast.Node) {}
+
+func (r *recorder) OpenScope(table *SymbolTable) {
+	r.events = append(r.events, "open")
+}
+
+func (r *recorder) CloseScope(table *SymbolTable) {
+	r.events = append(r.events, "close")
+}
+
+func (r *recorder) Declare(decl *Declaration) {
+	event := fmt.Sprintf("%s %s %d:%d", decl.Kind, decl.Name,
+		decl.Token.StartPosition.LineNumber(), decl.Token.StartPosition.ColumnNumber())
+	if decl.Implicit {
+		event = "implicit " + event
+	}
+	if decl.Symbol == nil {
+		event = "redeclared " + event
+	}
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) Reference(ref *Reference) {
+	event := "read"
+	if ref.Assign {
+		event = "assign"
+	}
+	if ref.Symbol == nil {
+		event = "undefined"
+	}
+	r.events = append(r.events, fmt.Sprintf("%s %s %d:%d", event, ref.Name,
+		ref.Token.StartPosition.LineNumber(), ref.Token.StartPosition.ColumnNumber()))
+}
+
+func TestCompileObserver(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `x := 1
+func f(a) {
+  try { return a + x } finally { print(z) }
+}
+x := 2
+const c = 3
+c = y`)
+	require.Nil(t, err)
+	r := &recorder{}
+	c, err := New(WithGlobalNames([]string{"print"}), WithObserver(r))
+	require.Nil(t, err)
+	_, err = c.Compile(program)
+	// The first error is returned, though compilation continues past it
+	require.NotNil(t, err)
+	require.Equal(t, "compile error: undefined variable \"z\" (line 3)", err.Error())
+	// The finally block is compiled for each way of leaving the try block,
+	// but it is observed once
+	require.Equal(t, []string{
+		"open",
+		"variable x 1:1",
+		"open",
+		"parameter a 2:8",
+		"implicit function f 2:6",
+		"open",
+		"open",
+		"read a 3:16",
+		"read x 3:20",
+		"close",
+		"open",
+		"read print 3:34",
+		"undefined z 3:40",
+		"close",
+		"close",
+		"close",
+		"function f 2:6",
+		"redeclared variable x 5:1",
+		"constant c 6:7",
+		"assign c 7:1",
+		"undefined y 7:5",
+		"close",
+	}, r.events)
+}
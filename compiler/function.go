@@ -7,11 +7,12 @@ import (
 )
 
 type Function struct {
-	id         string
-	name       string
-	parameters []string
-	defaults   []any
-	code       *Code
+	id            string
+	name          string
+	parameters    []string
+	restParameter string
+	defaults      []any
+	code          *Code
 }
 
 func (f *Function) ID() string {
@@ -34,6 +35,12 @@ func (f *Function) Parameter(index int) string {
 	return f.parameters[index]
 }
 
+// RestParameter returns the name of the parameter that receives any
+// additional arguments as a list, or an empty string if there is none.
+func (f *Function) RestParameter() string {
+	return f.restParameter
+}
+
 func (f *Function) DefaultsCount() int {
 	return len(f.defaults)
 }
@@ -59,6 +66,9 @@ func (f *Function) String() string {
 		}
 		parameters = append(parameters, name)
 	}
+	if f.restParameter != "" {
+		parameters = append(parameters, "..."+f.restParameter)
+	}
 	out.WriteString("func")
 	if f.name != "" {
 		out.WriteString(" " + f.name)
@@ -81,19 +91,21 @@ func (f *Function) String() string {
 }
 
 type FunctionOpts struct {
-	ID         string
-	Name       string
-	Parameters []string
-	Defaults   []any
-	Code       *Code
+	ID            string
+	Name          string
+	Parameters    []string
+	RestParameter string
+	Defaults      []any
+	Code          *Code
 }
 
 func NewFunction(opts FunctionOpts) *Function {
 	return &Function{
-		id:         opts.ID,
-		name:       opts.Name,
-		parameters: opts.Parameters,
-		defaults:   opts.Defaults,
-		code:       opts.Code,
+		id:            opts.ID,
+		name:          opts.Name,
+		parameters:    opts.Parameters,
+		restParameter: opts.RestParameter,
+		defaults:      opts.Defaults,
+		code:          opts.Code,
 	}
 }
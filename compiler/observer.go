@@ -0,0 +1,205 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/token"
+)
+
+// DeclarationKind describes what introduced a name.
+type DeclarationKind string
+
+const (
+	VariableDeclaration  DeclarationKind = "variable"
+	ParameterDeclaration DeclarationKind = "parameter"
+	ConstantDeclaration  DeclarationKind = "constant"
+	FunctionDeclaration  DeclarationKind = "function"
+	StructDeclaration    DeclarationKind = "struct"
+	EnumDeclaration      DeclarationKind = "enum"
+	ImportDeclaration    DeclarationKind = "import"
+)
+
+// Declaration describes a name declared by a program.
+type Declaration struct {
+	Name  string
+	Kind  DeclarationKind
+	Token token.Token
+
+	// Table is the symbol table the name is declared in.
+	Table *SymbolTable
+
+	// Symbol is the declared symbol. It is nil if the name was already
+	// declared in the same table. An import of a name that was already
+	// declared binds the existing symbol instead.
+	Symbol *Symbol
+
+	// Implicit is set for names the program does not spell out: the self
+	// parameter of methods, and the name of a function within its own body.
+	Implicit bool
+}
+
+// Reference describes a name read or written by a program.
+type Reference struct {
+	Name  string
+	Token token.Token
+
+	// Node is the node that refers to the name, which is an *ast.Ident
+	// except for increments and decrements like "x++".
+	Node ast.Node
+
+	// Symbol is the symbol the name resolved to, or nil if it is undefined.
+	Symbol *Symbol
+
+	// Assign is set when the name is written rather than read.
+	Assign bool
+}
+
+// Observer is notified as a program is compiled, so that tools like linters
+// can analyze it with the same scoping rules the compiler uses. Each node is
+// observed once, even when the compiler emits its code more than once.
+//
+// While an observer is set, errors that affect a single name, like a
+// reference to an undefined variable, and errors that affect a single
+// statement do not stop compilation. The first error is still returned by
+// Compile, and the compiled code should not be used.
+type Observer interface {
+	// Enter is called before a node is compiled and Leave after.
+	Enter(node ast.Node)
+	Leave(node ast.Node)
+
+	// OpenScope is called when a symbol table is created for a function or
+	// a block, and CloseScope when it is left. The table of the entrypoint
+	// is opened and closed by each call to Compile.
+	OpenScope(table *SymbolTable)
+	CloseScope(table *SymbolTable)
+
+	// Declare is called for each name declared by the program.
+	Declare(decl *Declaration)
+
+	// Reference is called for each name the program refers to.
+	Reference(ref *Reference)
+}
+
+// WithObserver configures the compiler to notify the given observer as it
+// compiles a program.
+func WithObserver(observer Observer) Option {
+	return func(c *Compiler) {
+		c.observer = observer
+	}
+}
+
+// observing returns true if the observer should be notified.
+func (c *Compiler) observing() bool {
+	return c.observer != nil && !c.replaying
+}
+
+// recoverable returns the given error, unless an observer is set. In that
+// case, the error is recorded so that Compile returns it, and nil is returned
+// so that compilation continues.
+func (c *Compiler) recoverable(err error) error {
+	if c.observer == nil {
+		return err
+	}
+	if c.failure == nil {
+		c.failure = err
+	}
+	return nil
+}
+
+func (c *Compiler) openScope(table *SymbolTable) {
+	if c.observing() {
+		c.observer.OpenScope(table)
+	}
+}
+
+func (c *Compiler) closeScope(table *SymbolTable) {
+	if c.observing() {
+		c.observer.CloseScope(table)
+	}
+}
+
+// newBlock starts a block scope within the current code and returns a
+// function that ends it.
+func (c *Compiler) newBlock() func() {
+	code := c.current
+	code.symbols = code.symbols.NewBlock()
+	c.openScope(code.symbols)
+	return func() {
+		c.closeScope(code.symbols)
+		code.symbols = code.symbols.parent
+	}
+}
+
+// declare adds the name described by decl to the current symbol table.
+// Variables and parameters may be assigned later, while the other kinds of
+// names are constants. A name that is already declared in the table is an
+// error, except for imports, which bind the existing symbol.
+func (c *Compiler) declare(decl *Declaration) (*Symbol, error) {
+	table := c.current.symbols
+	decl.Table = table
+	existing, found := table.Get(decl.Name)
+	var err error
+	if found && decl.Kind == ImportDeclaration {
+		decl.Symbol = existing
+	} else {
+		switch decl.Kind {
+		case VariableDeclaration, ParameterDeclaration:
+			decl.Symbol, err = table.InsertVariable(decl.Name)
+		default:
+			decl.Symbol, err = table.InsertConstant(decl.Name)
+		}
+	}
+	if c.observing() {
+		c.observer.Declare(decl)
+	}
+	if err != nil {
+		if !found {
+			return nil, err
+		}
+		// Continue with the existing symbol if the error is recoverable
+		if err := c.recoverable(err); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	return decl.Symbol, nil
+}
+
+// resolve looks up the name referred to by the given node, which is read or,
+// if assign is set, written. The resolution is nil if the name is undefined
+// and the error is recoverable.
+func (c *Compiler) resolve(node ast.Node, assign bool) (*Resolution, error) {
+	name := node.Literal()
+	line := node.Token().StartPosition.LineNumber()
+	resolution, found := c.current.symbols.Resolve(name)
+	var symbol *Symbol
+	if found {
+		symbol = resolution.symbol
+	}
+	c.reference(node, symbol, assign)
+	if !found {
+		err := fmt.Errorf("compile error: undefined variable %q (line %d)", name, line)
+		return nil, c.recoverable(err)
+	}
+	if assign && symbol.IsConstant() {
+		err := fmt.Errorf("compile error: cannot assign to constant %q (line %d)", name, line)
+		if err := c.recoverable(err); err != nil {
+			return nil, err
+		}
+	}
+	return resolution, nil
+}
+
+// reference notifies the observer that the node refers to the symbol.
+func (c *Compiler) reference(node ast.Node, symbol *Symbol, assign bool) {
+	if c.observing() {
+		c.observer.Reference(&Reference{
+			Name:   node.Literal(),
+			Token:  node.Token(),
+			Node:   node,
+			Symbol: symbol,
+			Assign: assign,
+		})
+	}
+}
@@ -0,0 +1,708 @@
+package compiler
+
+import (
+	"math"
+
+	"github.com/risor-io/risor/op"
+)
+
+// maxOptimizerPasses limits the number of times the optimizations are applied
+// to a code object. Each pass may enable further optimizations in the next,
+// but in practice the code stops changing after a few passes.
+const maxOptimizerPasses = 10
+
+// instruction is a decoded instruction being optimized. Jumps refer to their
+// destination directly, so that instructions can be removed and the jump
+// offsets recalculated afterwards.
+type instruction struct {
+	opcode   op.Code
+	operands []uint16
+	location SourceLocation
+	target   *instruction
+	removed  bool
+}
+
+// optimizer applies peephole optimizations to the instructions of a code
+// object, from a starting offset to the end. The optimizations are:
+//   - constant folding of binary, comparison, and unary operations
+//   - elimination of branches on constant conditions and of unreachable code
+//   - threading of jumps that land on unconditional jumps
+//   - removal of no-ops, jumps to the next instruction, and values that are
+//     pushed only to be popped
+type optimizer struct {
+	code   *Code
+	start  int
+	instrs []*instruction
+}
+
+// optimize rewrites the instructions of the code from the given offset on.
+// Instructions before the offset are left as-is, which allows code that is
+// compiled incrementally to be optimized as it grows. The code is unchanged
+// if it contains jumps that can't be decoded.
+func optimize(code *Code, start int) {
+	o := &optimizer{code: code, start: start}
+	if !o.decode() {
+		return
+	}
+	for i := 0; i < maxOptimizerPasses; i++ {
+		changed := o.foldConstants()
+		changed = o.eliminateDeadBranches() || changed
+		changed = o.removeUnreachable() || changed
+		changed = o.threadJumps() || changed
+		changed = o.removeRedundant() || changed
+		if !changed {
+			break
+		}
+	}
+	o.encode()
+}
+
+func isJump(opcode op.Code) bool {
+	switch opcode {
+	case op.JumpForward, op.JumpBackward, op.PopJumpForwardIfFalse,
+		op.PopJumpForwardIfTrue, op.PopJumpForwardIfNil,
+		op.PopJumpForwardIfNotNil, op.ForIter, op.PushExcept:
+		return true
+	}
+	return false
+}
+
+func isConditionalJump(opcode op.Code) bool {
+	switch opcode {
+	case op.PopJumpForwardIfFalse, op.PopJumpForwardIfTrue,
+		op.PopJumpForwardIfNil, op.PopJumpForwardIfNotNil:
+		return true
+	}
+	return false
+}
+
+// isTerminator returns true if execution never continues to the instruction
+// following one with the given opcode.
+func isTerminator(opcode op.Code) bool {
+	switch opcode {
+	case op.JumpForward, op.JumpBackward, op.ReturnValue, op.Raise, op.Halt:
+		return true
+	}
+	return false
+}
+
+// decode the instructions into o.instrs. The last entry is a placeholder for
+// the end of the code, since jumps may land there.
+func (o *optimizer) decode() bool {
+	code := o.code
+	byOffset := map[int]*instruction{}
+	jumpOffsets := map[*instruction]int{}
+	for pos := o.start; pos < len(code.instructions); {
+		opcode := code.instructions[pos]
+		count := op.GetInfo(opcode).OperandCount
+		if pos+count >= len(code.instructions) {
+			return false
+		}
+		instr := &instruction{
+			opcode:   opcode,
+			operands: make([]uint16, count),
+			location: code.locations[pos],
+		}
+		for i := 0; i < count; i++ {
+			instr.operands[i] = uint16(code.instructions[pos+1+i])
+		}
+		if isJump(opcode) {
+			if opcode == op.JumpBackward {
+				jumpOffsets[instr] = pos - int(instr.operands[0])
+			} else {
+				jumpOffsets[instr] = pos + int(instr.operands[0])
+			}
+		}
+		byOffset[pos] = instr
+		o.instrs = append(o.instrs, instr)
+		pos += 1 + count
+	}
+	end := &instruction{opcode: op.Invalid}
+	byOffset[len(code.instructions)] = end
+	o.instrs = append(o.instrs, end)
+	for instr, offset := range jumpOffsets {
+		target, ok := byOffset[offset]
+		if !ok {
+			return false
+		}
+		instr.target = target
+	}
+	return true
+}
+
+// encode writes the optimized instructions back to the code.
+func (o *optimizer) encode() {
+	offsets := make(map[*instruction]int, len(o.instrs))
+	pos := o.start
+	for _, instr := range o.instrs {
+		offsets[instr] = pos
+		pos += 1 + len(instr.operands)
+	}
+	instructions := make([]op.Code, 0, pos)
+	locations := make([]SourceLocation, 0, pos)
+	instructions = append(instructions, o.code.instructions[:o.start]...)
+	locations = append(locations, o.code.locations[:o.start]...)
+	for _, instr := range o.instrs[:len(o.instrs)-1] {
+		opcode := instr.opcode
+		if instr.target != nil {
+			delta := offsets[instr.target] - offsets[instr]
+			switch {
+			case opcode == op.JumpForward && delta < 0:
+				opcode = op.JumpBackward
+			case opcode == op.JumpBackward && delta > 0:
+				opcode = op.JumpForward
+			}
+			if delta < 0 && opcode != op.JumpBackward {
+				// Only unconditional jumps may go backward, and the
+				// optimizations never move other jump targets backward
+				return
+			}
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta > math.MaxUint16 {
+				return
+			}
+			instr.operands[0] = uint16(delta)
+		}
+		instructions = append(instructions, opcode)
+		locations = append(locations, instr.location)
+		for _, operand := range instr.operands {
+			instructions = append(instructions, op.Code(operand))
+			locations = append(locations, instr.location)
+		}
+	}
+	o.code.instructions = instructions
+	o.code.locations = locations
+}
+
+// targets returns the set of instructions that jumps land on.
+func (o *optimizer) targets() map[*instruction]bool {
+	targets := map[*instruction]bool{}
+	for _, instr := range o.instrs {
+		if instr.target != nil {
+			targets[instr.target] = true
+		}
+	}
+	return targets
+}
+
+// compact drops the removed instructions, moving jumps that land on them to
+// the next instruction that remains.
+func (o *optimizer) compact() {
+	var next *instruction
+	replacements := map[*instruction]*instruction{}
+	for i := len(o.instrs) - 1; i >= 0; i-- {
+		instr := o.instrs[i]
+		if instr.removed {
+			replacements[instr] = next
+		} else {
+			next = instr
+		}
+	}
+	kept := o.instrs[:0]
+	for _, instr := range o.instrs {
+		if instr.removed {
+			continue
+		}
+		if replacement, ok := replacements[instr.target]; ok {
+			instr.target = replacement
+		}
+		kept = append(kept, instr)
+	}
+	o.instrs = kept
+}
+
+// constantValue returns the value pushed by an instruction that loads a
+// constant. Nil is returned as a nil value.
+func (o *optimizer) constantValue(instr *instruction) (any, bool) {
+	switch instr.opcode {
+	case op.Nil:
+		return nil, true
+	case op.True:
+		return true, true
+	case op.False:
+		return false, true
+	case op.LoadConst:
+		switch value := o.code.constants[instr.operands[0]].(type) {
+		case int64, float64, string, bool:
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// setConstant changes the instruction to one that loads the given value.
+func (o *optimizer) setConstant(instr *instruction, value any) bool {
+	switch value := value.(type) {
+	case nil:
+		instr.opcode, instr.operands = op.Nil, nil
+	case bool:
+		if value {
+			instr.opcode, instr.operands = op.True, nil
+		} else {
+			instr.opcode, instr.operands = op.False, nil
+		}
+	default:
+		index, ok := o.constant(value)
+		if !ok {
+			return false
+		}
+		instr.opcode, instr.operands = op.LoadConst, []uint16{index}
+	}
+	instr.target = nil
+	return true
+}
+
+// constant returns the index of the given constant, adding it to the code if
+// it isn't present already.
+func (o *optimizer) constant(value any) (uint16, bool) {
+	for i, existing := range o.code.constants {
+		if sameConstant(existing, value) {
+			return uint16(i), true
+		}
+	}
+	if len(o.code.constants) >= math.MaxUint16 {
+		return 0, false
+	}
+	o.code.constants = append(o.code.constants, value)
+	return uint16(len(o.code.constants) - 1), true
+}
+
+func sameConstant(a, b any) bool {
+	switch a := a.(type) {
+	case int64:
+		b, ok := b.(int64)
+		return ok && a == b
+	case float64:
+		// Compare the bits so that 0.0 and -0.0 are kept distinct
+		b, ok := b.(float64)
+		return ok && math.Float64bits(a) == math.Float64bits(b)
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	}
+	return false
+}
+
+// foldConstants replaces operations on constants with their result. The
+// results match those of the operations at runtime, and operations that
+// would fail at runtime, like division by zero, are left in place.
+func (o *optimizer) foldConstants() bool {
+	targets := o.targets()
+	changed := false
+	folded := make([]*instruction, 0, len(o.instrs))
+	for _, instr := range o.instrs {
+		n := len(folded)
+		switch instr.opcode {
+		case op.BinaryOp, op.BinaryAddFast, op.BinarySubtractFast,
+			op.CompareOp, op.CompareOpFast:
+			if n < 2 || targets[instr] || targets[folded[n-1]] {
+				break
+			}
+			a, aOk := o.constantValue(folded[n-2])
+			b, bOk := o.constantValue(folded[n-1])
+			if !aOk || !bOk {
+				break
+			}
+			var result any
+			var ok bool
+			switch instr.opcode {
+			case op.BinaryOp:
+				result, ok = foldBinaryOp(op.BinaryOpType(instr.operands[0]), a, b)
+			case op.BinaryAddFast:
+				result, ok = foldBinaryOp(op.Add, a, b)
+			case op.BinarySubtractFast:
+				result, ok = foldBinaryOp(op.Subtract, a, b)
+			default:
+				result, ok = foldCompareOp(op.CompareOpType(instr.operands[0]), a, b)
+			}
+			if ok && o.setConstant(folded[n-2], result) {
+				folded = folded[:n-1]
+				changed = true
+				continue
+			}
+		case op.UnaryNot, op.UnaryNegative:
+			if n < 1 || targets[instr] {
+				break
+			}
+			value, ok := o.constantValue(folded[n-1])
+			if !ok {
+				break
+			}
+			var result any
+			if instr.opcode == op.UnaryNot {
+				result, ok = !isTruthy(value), true
+			} else {
+				result, ok = negate(value)
+			}
+			if ok && o.setConstant(folded[n-1], result) {
+				changed = true
+				continue
+			}
+		}
+		folded = append(folded, instr)
+	}
+	o.instrs = folded
+	return changed
+}
+
+func isTruthy(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case int64:
+		return value != 0
+	case float64:
+		return value != 0.0
+	case string:
+		return value != ""
+	}
+	return true
+}
+
+func negate(value any) (any, bool) {
+	switch value := value.(type) {
+	case int64:
+		return -value, true
+	case float64:
+		return -value, true
+	}
+	return nil, false
+}
+
+func foldBinaryOp(opType op.BinaryOpType, a, b any) (any, bool) {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return foldIntOp(opType, a, b)
+		case float64:
+			if opType == op.Power {
+				return int64(math.Pow(float64(a), b)), true
+			}
+			return foldFloatOp(opType, float64(a), b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return foldFloatOp(opType, a, float64(b))
+		case float64:
+			return foldFloatOp(opType, a, b)
+		}
+	case string:
+		if b, ok := b.(string); ok && opType == op.Add {
+			return a + b, true
+		}
+	}
+	return nil, false
+}
+
+func foldIntOp(opType op.BinaryOpType, a, b int64) (any, bool) {
+	switch opType {
+	case op.Add:
+		return a + b, true
+	case op.Subtract:
+		return a - b, true
+	case op.Multiply:
+		return a * b, true
+	case op.Divide:
+		if b != 0 {
+			return a / b, true
+		}
+	case op.Modulo:
+		if b != 0 {
+			return a % b, true
+		}
+	case op.Xor:
+		return a ^ b, true
+	case op.Power:
+		return int64(math.Pow(float64(a), float64(b))), true
+	case op.LShift:
+		return a << uint(b), true
+	case op.RShift:
+		return a >> uint(b), true
+	case op.BitwiseAnd:
+		return a & b, true
+	case op.BitwiseOr:
+		return a | b, true
+	}
+	return nil, false
+}
+
+// foldFloatOp folds an operation on floats. Results that are infinite or
+// NaN are not folded, since such constants can't be marshaled as JSON.
+func foldFloatOp(opType op.BinaryOpType, a, b float64) (any, bool) {
+	var result float64
+	switch opType {
+	case op.Add:
+		result = a + b
+	case op.Subtract:
+		result = a - b
+	case op.Multiply:
+		result = a * b
+	case op.Divide:
+		result = a / b
+	case op.Power:
+		result = math.Pow(a, b)
+	default:
+		return nil, false
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return nil, false
+	}
+	return result, true
+}
+
+func foldCompareOp(opType op.CompareOpType, a, b any) (any, bool) {
+	var cmp int
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			cmp = compareValues(a, b)
+		case float64:
+			cmp = compareValues(float64(a), b)
+		case string:
+			return foldMismatchedEquality(opType)
+		default:
+			return nil, false
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			cmp = compareValues(a, float64(b))
+		case float64:
+			cmp = compareValues(a, b)
+		case string:
+			return foldMismatchedEquality(opType)
+		default:
+			return nil, false
+		}
+	case string:
+		switch b := b.(type) {
+		case string:
+			cmp = compareValues(a, b)
+		case int64, float64:
+			return foldMismatchedEquality(opType)
+		default:
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	switch opType {
+	case op.Equal:
+		return cmp == 0, true
+	case op.NotEqual:
+		return cmp != 0, true
+	case op.LessThan:
+		return cmp < 0, true
+	case op.LessThanOrEqual:
+		return cmp <= 0, true
+	case op.GreaterThan:
+		return cmp > 0, true
+	case op.GreaterThanOrEqual:
+		return cmp >= 0, true
+	}
+	return nil, false
+}
+
+// foldMismatchedEquality folds a comparison of a string and a number. These
+// are never equal, while ordering them is an error at runtime.
+func foldMismatchedEquality(opType op.CompareOpType) (any, bool) {
+	switch opType {
+	case op.Equal:
+		return false, true
+	case op.NotEqual:
+		return true, true
+	}
+	return nil, false
+}
+
+// compareValues compares two values the same way as the Compare methods of
+// the corresponding objects.
+func compareValues[T int64 | float64 | string](a, b T) int {
+	if a == b {
+		return 0
+	}
+	if a > b {
+		return 1
+	}
+	return -1
+}
+
+// eliminateDeadBranches replaces conditional jumps on constant conditions
+// with an unconditional jump, or removes them if they are never taken.
+func (o *optimizer) eliminateDeadBranches() bool {
+	targets := o.targets()
+	changed := false
+	for i := 0; i+1 < len(o.instrs); i++ {
+		load, jump := o.instrs[i], o.instrs[i+1]
+		if !isConditionalJump(jump.opcode) || targets[jump] {
+			continue
+		}
+		value, ok := o.constantValue(load)
+		if !ok {
+			continue
+		}
+		var taken bool
+		switch jump.opcode {
+		case op.PopJumpForwardIfFalse:
+			taken = !isTruthy(value)
+		case op.PopJumpForwardIfTrue:
+			taken = isTruthy(value)
+		case op.PopJumpForwardIfNil:
+			taken = value == nil
+		case op.PopJumpForwardIfNotNil:
+			taken = value != nil
+		}
+		if taken {
+			load.opcode, load.operands = op.JumpForward, []uint16{0}
+			load.target = jump.target
+		} else {
+			load.removed = true
+		}
+		jump.removed = true
+		changed = true
+		i++
+	}
+	if changed {
+		o.compact()
+	}
+	return changed
+}
+
+// removeUnreachable removes the instructions that can't be reached from the
+// start of the code by any path of execution.
+func (o *optimizer) removeUnreachable() bool {
+	index := make(map[*instruction]int, len(o.instrs))
+	for i, instr := range o.instrs {
+		index[instr] = i
+	}
+	reached := make([]bool, len(o.instrs))
+	pending := []int{0}
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if i >= len(o.instrs) || reached[i] {
+			continue
+		}
+		reached[i] = true
+		instr := o.instrs[i]
+		if instr.target != nil {
+			pending = append(pending, index[instr.target])
+		}
+		if !isTerminator(instr.opcode) {
+			pending = append(pending, i+1)
+		}
+	}
+	changed := false
+	// The placeholder for the end of the code is always kept
+	for i, instr := range o.instrs[:len(o.instrs)-1] {
+		if !reached[i] {
+			instr.removed = true
+			changed = true
+		}
+	}
+	if changed {
+		o.compact()
+	}
+	return changed
+}
+
+// threadJumps makes jumps that land on an unconditional jump go directly to
+// its destination. Conditional jumps can only jump forward, so they are only
+// threaded to destinations later in the code.
+func (o *optimizer) threadJumps() bool {
+	index := make(map[*instruction]int, len(o.instrs))
+	for i, instr := range o.instrs {
+		index[instr] = i
+	}
+	changed := false
+	for i, instr := range o.instrs {
+		if instr.target == nil || instr.opcode == op.PushExcept {
+			continue
+		}
+		target := instr.target
+		for steps := 0; steps < len(o.instrs); steps++ {
+			if target.opcode != op.JumpForward && target.opcode != op.JumpBackward {
+				break
+			}
+			if target.target == target || target.target == instr {
+				break
+			}
+			target = target.target
+		}
+		if target == instr.target {
+			continue
+		}
+		unconditional := instr.opcode == op.JumpForward || instr.opcode == op.JumpBackward
+		if !unconditional && index[target] <= i {
+			continue
+		}
+		instr.target = target
+		changed = true
+	}
+	return changed
+}
+
+// removeRedundant removes no-ops, jumps to the next instruction, and values
+// that are popped as soon as they are pushed.
+func (o *optimizer) removeRedundant() bool {
+	targets := o.targets()
+	changed := false
+	for i, instr := range o.instrs {
+		if instr.removed {
+			continue
+		}
+		switch instr.opcode {
+		case op.Nop:
+			instr.removed = true
+			changed = true
+		case op.JumpForward:
+			if next := o.next(i); next != nil && instr.target == next {
+				instr.removed = true
+				changed = true
+			}
+		case op.PopTop:
+			if targets[instr] || i == 0 {
+				break
+			}
+			prev := o.instrs[i-1]
+			if !prev.removed && isPurePush(prev.opcode) {
+				prev.removed = true
+				instr.removed = true
+				changed = true
+			}
+		}
+	}
+	if changed {
+		o.compact()
+	}
+	return changed
+}
+
+// next returns the instruction after the one at the given index that has not
+// been removed.
+func (o *optimizer) next(i int) *instruction {
+	for _, instr := range o.instrs[i+1:] {
+		if !instr.removed {
+			return instr
+		}
+	}
+	return nil
+}
+
+// isPurePush returns true if the opcode only pushes a value onto the stack,
+// without side effects or the possibility of an error.
+func isPurePush(opcode op.Code) bool {
+	switch opcode {
+	case op.LoadConst, op.LoadFast, op.LoadGlobal, op.LoadFree,
+		op.Nil, op.True, op.False, op.Copy:
+		return true
+	}
+	return false
+}
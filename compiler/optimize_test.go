@@ -0,0 +1,261 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func compileOptimized(t *testing.T, input string, globals ...string) *Code {
+	t.Helper()
+	program, err := parser.Parse(context.Background(), input)
+	require.Nil(t, err)
+	code, err := Compile(program, WithGlobalNames(globals), WithOptimization())
+	require.Nil(t, err)
+	return code
+}
+
+func instructions(code *Code) []op.Code {
+	var codes []op.Code
+	for i := 0; i < code.InstructionCount(); i++ {
+		codes = append(codes, code.Instruction(i))
+	}
+	return codes
+}
+
+func TestOptimizeConstantFolding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"1024 * 1024", int64(1048576)},
+		{"60 * 60 * 24", int64(86400)},
+		{"(1 + 2) * (3 + 4)", int64(21)},
+		{"2 ** 10", int64(1024)},
+		{"7 / 2", int64(3)},
+		{"-7 % 3", int64(-1)},
+		{"(1 << 4) + (32 >> 4)", int64(18)},
+		{"1 + 0.5", 1.5},
+		{"10 / 4.0", 2.5},
+		{"-(2.5)", -2.5},
+		{`"a" + "b" + "c"`, "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := compileOptimized(t, tt.input)
+			codes := instructions(code)
+			require.Equal(t, []op.Code{op.LoadConst, codes[1]}, codes)
+			require.Equal(t, tt.expected, code.Constant(int(codes[1])))
+		})
+	}
+}
+
+func TestOptimizeConstantComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected op.Code
+	}{
+		{"1 < 2", op.True},
+		{"2.5 >= 3", op.False},
+		{"1 == 1.0", op.True},
+		{`"abc" < "abd"`, op.True},
+		{`"1" == 1`, op.False},
+		{`"1" != 1`, op.True},
+		{"!0", op.True},
+		{`!"x"`, op.False},
+		{"!nil", op.True},
+		{"!(1 > 2)", op.True},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := compileOptimized(t, tt.input)
+			require.Equal(t, []op.Code{tt.expected}, instructions(code))
+		})
+	}
+}
+
+func TestOptimizeKeepsFailingOperations(t *testing.T) {
+	// Operations that fail at runtime are left for the VM to report
+	tests := []struct {
+		input    string
+		expected []op.Code
+	}{
+		{"1 / 0", []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Divide),
+		}},
+		{"1 % 0", []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Modulo),
+		}},
+		{`"a" < 1`, []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.CompareOp, op.Code(op.LessThan),
+		}},
+		{`"a" * 2`, []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Multiply),
+		}},
+		{`-"a"`, []op.Code{
+			op.LoadConst, 0,
+			op.UnaryNegative,
+		}},
+		// Infinite and NaN results would not survive MarshalCode
+		{"1.0 / 0", []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Divide),
+		}},
+		{"0.0 / 0.0", []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Divide),
+		}},
+		{"10.0 ** 400.0", []op.Code{
+			op.LoadConst, 0,
+			op.LoadConst, 1,
+			op.BinaryOp, op.Code(op.Power),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := compileOptimized(t, tt.input)
+			require.Equal(t, tt.expected, instructions(code))
+		})
+	}
+}
+
+func TestOptimizeNonFiniteMarshal(t *testing.T) {
+	code := compileOptimized(t, "x := 1.0 / 0.0; y := 2.0 ** 2000.0")
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	loaded, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, instructions(code), instructions(loaded))
+}
+
+func TestOptimizeDeadBranches(t *testing.T) {
+	code := compileOptimized(t, "if false { a } else { b }", "a", "b")
+	require.Equal(t, []op.Code{op.LoadGlobal, 1}, instructions(code))
+
+	code = compileOptimized(t, "if 1 > 0 { a } else { b }", "a", "b")
+	require.Equal(t, []op.Code{op.LoadGlobal, 0}, instructions(code))
+
+	code = compileOptimized(t, "if false { a }; b", "a", "b")
+	require.Equal(t, []op.Code{op.LoadGlobal, 1}, instructions(code))
+
+	code = compileOptimized(t, "for false { a }; b", "a", "b")
+	require.Equal(t, []op.Code{op.LoadGlobal, 1}, instructions(code))
+}
+
+func TestOptimizeUnreachableCode(t *testing.T) {
+	code := compileOptimized(t, "func f() { return 1; g() }", "g")
+	fn, ok := code.Constant(0).(*Function)
+	require.True(t, ok)
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.ReturnValue,
+	}, instructions(fn.Code()))
+}
+
+func TestOptimizeJumpThreading(t *testing.T) {
+	code := compileOptimized(t, `
+	for _, x := range v {
+		if x {
+			if x > 1 { continue }
+			f(x)
+		}
+	}`, "v", "f")
+	codes := instructions(code)
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 1,
+		op.GetIter,
+		op.ForIter, 33, 2,
+		op.StoreGlobal, 2,
+		op.StoreGlobal, 3,
+		op.LoadGlobal, 3,
+		op.PopJumpForwardIfFalse, 20,
+		op.LoadGlobal, 3,
+		op.LoadConst, 0,
+		op.CompareOpFast, op.Code(op.GreaterThan),
+		op.PopJumpForwardIfFalse, 4,
+		// The continue jumps straight back to the loop
+		op.JumpBackward, 19,
+		op.LoadGlobal, 0,
+		op.LoadGlobal, 3,
+		op.Call, 1,
+		op.JumpForward, 3,
+		op.Nil,
+		op.PopTop,
+		op.JumpBackward, 31,
+		op.Nil,
+	}, codes)
+}
+
+func TestOptimizeRedundantInstructions(t *testing.T) {
+	code := compileOptimized(t, "func f(x) { 42; x; true; return x }")
+	fn, ok := code.Constant(0).(*Function)
+	require.True(t, ok)
+	require.Equal(t, []op.Code{
+		op.LoadFast, 0,
+		op.ReturnValue,
+	}, instructions(fn.Code()))
+}
+
+func TestOptimizeLocations(t *testing.T) {
+	code := compileOptimized(t, "x := 2 * 3\nif false { y := 1 }\nx.foo")
+	codes := instructions(code)
+	require.Equal(t, []op.Code{
+		op.LoadConst, codes[1],
+		op.StoreGlobal, 0,
+		op.LoadGlobal, 0,
+		op.LoadAttr, 0,
+	}, codes)
+	require.Equal(t, 1, code.Location(0).Line)
+	require.Equal(t, 3, code.Location(4).Line)
+	require.Equal(t, 3, code.Location(6).Line)
+}
+
+func TestOptimizeIncremental(t *testing.T) {
+	// Code that was compiled before is left unchanged, since it may already
+	// be running, as in the REPL
+	c, err := New(WithOptimization())
+	require.Nil(t, err)
+	program, err := parser.Parse(context.Background(), "x := 1; y := 2")
+	require.Nil(t, err)
+	code, err := c.Compile(program)
+	require.Nil(t, err)
+	before := instructions(code)
+
+	program, err = parser.Parse(context.Background(), "if false { x } else { y * (3 + 4) }")
+	require.Nil(t, err)
+	code, err = c.Compile(program)
+	require.Nil(t, err)
+	codes := instructions(code)
+	require.Equal(t, before, codes[:len(before)])
+	require.Equal(t, []op.Code{
+		op.LoadGlobal, 1,
+		op.LoadConst, codes[len(before)+3],
+		op.BinaryOp, op.Code(op.Multiply),
+	}, codes[len(before):])
+	require.Equal(t, int64(7), code.Constant(int(codes[len(before)+3])))
+}
+
+func TestOptimizeDisabledByDefault(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "1 + 2")
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.LoadConst, 1,
+		op.BinaryOp, op.Code(op.Add),
+	}, instructions(code))
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/risor-io/risor/op"
 )
 
-// MarshalCode converts a Code object into a JSON representation.
+// MarshalCode converts a Code object into a JSON representation. This is
+// useful for debugging, while MarshalCodeBinary produces a smaller, versioned
+// format that is faster to load.
 func MarshalCode(code *Code) ([]byte, error) {
 	cdef, err := stateFromCode(code)
 	if err != nil {
@@ -27,10 +29,11 @@ func UnmarshalCode(data []byte) (*Code, error) {
 
 // Used to marshal a Function.
 type functionDef struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Parameters []string          `json:"parameters"`
-	Defaults   []json.RawMessage `json:"defaults"`
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Parameters    []string          `json:"parameters"`
+	RestParameter string            `json:"rest_parameter,omitempty"`
+	Defaults      []json.RawMessage `json:"defaults"`
 }
 
 type constantDef struct {
@@ -95,10 +98,13 @@ type codeDef struct {
 	ParentID      string            `json:"parent_id,omitempty"`
 	SymbolTableID string            `json:"symbol_table_id"`
 	FunctionID    string            `json:"function_id,omitempty"`
+	Generator     bool              `json:"generator,omitempty"`
 	Instructions  []op.Code         `json:"instructions,omitempty"`
 	Constants     []json.RawMessage `json:"constants,omitempty"`
 	Names         []string          `json:"names,omitempty"`
 	Source        string            `json:"source,omitempty"`
+	Filename      string            `json:"filename,omitempty"`
+	Locations     [][3]int          `json:"locations,omitempty"`
 }
 
 // A representation of a Code object that can be marshalled more easily.
@@ -109,6 +115,24 @@ type state struct {
 
 // Builds a Code object from its marshalled state.
 func codeFromState(state *state) (*Code, error) {
+	constants := make([][]any, 0, len(state.Code))
+	for _, c := range state.Code {
+		values, err := unmarshalConstants(c.Constants)
+		if err != nil {
+			return nil, err
+		}
+		constants = append(constants, values)
+	}
+	return linkCode(state, constants)
+}
+
+// linkCode builds the Code objects described by the state and links them to
+// their parents, symbol tables and functions. The constants hold the decoded
+// constants of each entry in state.Code.
+func linkCode(state *state, constants [][]any) (*Code, error) {
+	if len(state.Code) == 0 {
+		return nil, fmt.Errorf("no code found")
+	}
 	table, err := symbolTableFromDefinition(state.SymbolTable)
 	if err != nil {
 		return nil, err
@@ -116,7 +140,7 @@ func codeFromState(state *state) (*Code, error) {
 	codes := make([]*Code, 0, len(state.Code))
 	functionsByID := map[string]*Function{}
 	codesByID := map[string]*Code{}
-	for _, c := range state.Code {
+	for i, c := range state.Code {
 		codeSymbols, found := table.FindTable(c.SymbolTableID)
 		if !found {
 			return nil, fmt.Errorf("symbol table not found: %s", c.SymbolTableID)
@@ -125,28 +149,29 @@ func codeFromState(state *state) (*Code, error) {
 		if !found && c.ParentID != "" {
 			return nil, fmt.Errorf("parent code not found: %s", c.ParentID)
 		}
-		constants, err := unmarshalConstants(c.Constants)
-		if err != nil {
-			return nil, err
-		}
 		code := &Code{
 			id:           c.ID,
 			parent:       parent,
 			name:         c.Name,
 			isNamed:      c.Name != "" && c.Name != "__main__",
+			isGenerator:  c.Generator,
 			functionID:   c.FunctionID,
 			symbols:      codeSymbols,
 			instructions: CopyInstructions(c.Instructions),
-			constants:    constants,
+			constants:    constants[i],
 			names:        copyStrings(c.Names),
 			source:       c.Source,
+			filename:     c.Filename,
+		}
+		if code.locations, err = locationsFromTable(c.Locations, len(code.instructions)); err != nil {
+			return nil, err
 		}
 		codesByID[code.id] = code
 		codes = append(codes, code)
 		if parent != nil {
 			parent.children = append(parent.children, code)
 		}
-		for _, constant := range constants {
+		for _, constant := range code.constants {
 			if fn, ok := constant.(*Function); ok {
 				functionsByID[fn.id] = fn
 			}
@@ -206,10 +231,11 @@ func unmarshalConstant(constant json.RawMessage) (any, error) {
 			return nil, err
 		}
 		f := NewFunction(FunctionOpts{
-			ID:         def.Value.ID,
-			Name:       def.Value.Name,
-			Parameters: def.Value.Parameters,
-			Defaults:   defaults,
+			ID:            def.Value.ID,
+			Name:          def.Value.Name,
+			Parameters:    def.Value.Parameters,
+			RestParameter: def.Value.RestParameter,
+			Defaults:      defaults,
 		})
 		return f, nil
 	default:
@@ -339,11 +365,14 @@ func stateFromCode(code *Code) (*state, error) {
 			ID:            code.id,
 			Constants:     constants,
 			FunctionID:    code.functionID,
+			Generator:     code.isGenerator,
 			SymbolTableID: code.symbols.ID(),
 			Instructions:  CopyInstructions(code.instructions),
 			Name:          code.name,
 			Names:         copyStrings(code.names),
 			Source:        code.source,
+			Filename:      code.filename,
+			Locations:     tableFromLocations(code.locations),
 		}
 		if code.parent != nil {
 			cdef.ParentID = code.parent.id
@@ -395,10 +424,11 @@ func definitionFromFunction(function *Function) (*functionDef, error) {
 		return nil, err
 	}
 	return &functionDef{
-		ID:         function.id,
-		Name:       function.name,
-		Parameters: copyStrings(function.parameters),
-		Defaults:   defaults,
+		ID:            function.id,
+		Name:          function.name,
+		Parameters:    copyStrings(function.parameters),
+		RestParameter: function.restParameter,
+		Defaults:      defaults,
 	}, nil
 }
 
@@ -411,6 +441,44 @@ func definitionFromResolution(resolution *Resolution) *resolutionDef {
 	}
 }
 
+// tableFromLocations compresses per-instruction source locations into a line
+// table. Each entry is an (offset, line, column) triple that applies to all
+// instructions from that offset until the next entry.
+func tableFromLocations(locations []SourceLocation) [][3]int {
+	var table [][3]int
+	var prev SourceLocation
+	for i, loc := range locations {
+		if i > 0 && loc.Line == prev.Line && loc.Column == prev.Column {
+			continue
+		}
+		table = append(table, [3]int{i, loc.Line, loc.Column})
+		prev = loc
+	}
+	return table
+}
+
+// locationsFromTable expands a line table into per-instruction locations.
+func locationsFromTable(table [][3]int, count int) ([]SourceLocation, error) {
+	if len(table) == 0 {
+		return nil, nil
+	}
+	locations := make([]SourceLocation, count)
+	for i, entry := range table {
+		start := entry[0]
+		end := count
+		if i+1 < len(table) {
+			end = table[i+1][0]
+		}
+		if start < 0 || start > end || end > count {
+			return nil, fmt.Errorf("invalid line table entry at offset %d", start)
+		}
+		for j := start; j < end; j++ {
+			locations[j] = SourceLocation{Line: entry[1], Column: entry[2]}
+		}
+	}
+	return locations, nil
+}
+
 func copyStrings(src []string) []string {
 	if src == nil {
 		return nil
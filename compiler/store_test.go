@@ -56,6 +56,43 @@ func TestMarshalCode2(t *testing.T) {
 	require.Equal(t, codeA, codeB)
 }
 
+func TestMarshalVariadicCode(t *testing.T) {
+	codeA, err := compileSource(`
+	func log(level, ...parts) {
+		return [level, parts]
+	}
+	log("info", ...[1, 2], **{"level": "debug"})
+	`)
+	require.Nil(t, err)
+	data, err := MarshalCode(codeA)
+	require.Nil(t, err)
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+	fn, ok := codeB.Constant(0).(*Function)
+	require.True(t, ok)
+	require.Equal(t, "parts", fn.RestParameter())
+}
+
+func TestMarshalGeneratorCode(t *testing.T) {
+	codeA, err := compileSource(`
+	func pair(a, b) {
+		yield a
+		yield b
+	}
+	`)
+	require.Nil(t, err)
+	data, err := MarshalCode(codeA)
+	require.Nil(t, err)
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+	fn, ok := codeB.Constant(0).(*Function)
+	require.True(t, ok)
+	require.True(t, fn.Code().IsGenerator())
+	require.False(t, codeB.IsGenerator())
+}
+
 func TestMarshalCode3(t *testing.T) {
 	codeA, err := compileSource(`
 	start := 10
@@ -180,3 +217,37 @@ func TestCompiledInstructions(t *testing.T) {
 		{op.BinaryOp, op.Code(op.Add)},
 	}, instrs)
 }
+
+func TestMarshalCodeLocations(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "x := 1\nfunc f(a) {\n  return a + x\n}\nf(2)",
+		parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	codeA, err := Compile(program)
+	require.Nil(t, err)
+	require.Equal(t, "main.risor", codeA.Filename())
+	require.Equal(t, SourceLocation{Filename: "main.risor", Line: 1, Column: 6}, codeA.Location(0))
+
+	fn := codeA.Flatten()[1]
+	require.Equal(t, "main.risor", fn.Filename())
+	require.Equal(t, 3, fn.Location(0).Line)
+
+	data, err := MarshalCode(codeA)
+	require.Nil(t, err)
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+	for i := 0; i < codeA.InstructionCount(); i++ {
+		require.Equal(t, codeA.Location(i), codeB.Location(i))
+	}
+}
+
+func TestUnmarshalInvalidLineTable(t *testing.T) {
+	codeA, err := compileSource("x := 1")
+	require.Nil(t, err)
+	state, err := stateFromCode(codeA)
+	require.Nil(t, err)
+	state.Code[0].Locations = [][3]int{{5, 1, 1}, {2, 1, 1}}
+	_, err = codeFromState(state)
+	require.NotNil(t, err)
+	require.Equal(t, "invalid line table entry at offset 5", err.Error())
+}
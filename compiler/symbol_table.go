@@ -210,6 +210,14 @@ func (t *SymbolTable) Resolve(name string) (*Resolution, bool) {
 			}
 			// Free variable
 			depth := t.FunctionDepth() - ancestor.FunctionDepth()
+			if depth > 1 {
+				// The variable belongs to a function that encloses the parent
+				// function, so the parent must capture it as well in order to
+				// pass its cell along to this function
+				if _, ok := activeFunc.parent.Resolve(name); !ok {
+					return nil, false
+				}
+			}
 			freeIndex := len(activeFunc.free)
 			rs := &Resolution{symbol: sym, scope: Free, depth: depth, freeIndex: freeIndex}
 			activeFunc.freeByName[name] = rs
@@ -67,8 +67,26 @@ func Disassemble(code *compiler.Code) ([]Instruction, error) {
 			annotation = fmt.Sprintf("%v", name)
 		case "BINARY_OP":
 			annotation = op.BinaryOpType(val[1]).String()
-		case "COMPARE_OP":
+		case "BINARY_ADD_FAST":
+			annotation = op.Add.String()
+		case "BINARY_SUBTRACT_FAST":
+			annotation = op.Subtract.String()
+		case "COMPARE_OP", "COMPARE_OP_FAST":
 			annotation = op.CompareOpType(val[1]).String()
+		case "BINARY_OP_FAST_CONST", "COMPARE_OP_FAST_CONST":
+			name, err := getLocalVariableName(code, int(val[1]))
+			if err != nil {
+				return nil, err
+			}
+			value, err := getConstantValue(code, int(val[2]))
+			if err != nil {
+				return nil, err
+			}
+			operator := op.BinaryOpType(val[3]).String()
+			if val[0] == op.CompareOpFastConst {
+				operator = op.CompareOpType(val[3]).String()
+			}
+			annotation = fmt.Sprintf("%s %s %v", name, operator, value)
 		case "LOAD_CONST":
 			constant, err = getConstantValue(code, int(val[1]))
 			if err != nil {
@@ -178,3 +196,21 @@ func getName(code *compiler.Code, index int) (string, error) {
 	}
 	return code.Name(index), nil
 }
+
+// PrintOptimization prints the instructions of code compiled without and with
+// optimization, one after the other, to show the effect of the optimizer.
+func PrintOptimization(before, after *compiler.Code, writer io.Writer) error {
+	beforeInstructions, err := Disassemble(before)
+	if err != nil {
+		return err
+	}
+	afterInstructions, err := Disassemble(after)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "Before optimization (%d instructions):\n", len(beforeInstructions))
+	Print(beforeInstructions, writer)
+	fmt.Fprintf(writer, "\nAfter optimization (%d instructions):\n", len(afterInstructions))
+	Print(afterInstructions, writer)
+	return nil
+}
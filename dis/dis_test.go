@@ -46,3 +46,68 @@ func TestFunctionDissasembly(t *testing.T) {
 `)
 	require.Equal(t, expected+"\n", result)
 }
+
+func TestSpecializedOpsDisassembly(t *testing.T) {
+	ast, err := parser.Parse(context.Background(), `func f(n) { return n < 2 ? n : n - 1 + n }`)
+	require.Nil(t, err)
+	code, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	instructions, err := Disassemble(code.Constant(0).(*compiler.Function).Code())
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	Print(instructions, &buf)
+	expected := strings.TrimSpace(`
++--------+---------------------------+----------+-------+
+| OFFSET |          OPCODE           | OPERANDS | INFO  |
++--------+---------------------------+----------+-------+
+|      0 | COMPARE_OP_FAST_CONST     |  0, 0, 1 | n < 2 |
+|      4 | POP_JUMP_FORWARD_IF_FALSE |        6 |       |
+|      6 | LOAD_FAST                 |        0 | n     |
+|      8 | JUMP_FORWARD              |        9 |       |
+|     10 | BINARY_OP_FAST_CONST      |  0, 1, 2 | n - 1 |
+|     14 | LOAD_FAST                 |        0 | n     |
+|     16 | BINARY_ADD_FAST           |          | +     |
+|     17 | RETURN_VALUE              |          |       |
++--------+---------------------------+----------+-------+
+`)
+	require.Equal(t, expected+"\n", buf.String())
+}
+
+func TestPrintOptimization(t *testing.T) {
+	ast, err := parser.Parse(context.Background(), `x := 60 * 60; if false { x }`)
+	require.Nil(t, err)
+	before, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	after, err := compiler.Compile(ast, compiler.WithOptimization())
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	require.Nil(t, PrintOptimization(before, after, &buf))
+	expected := strings.TrimSpace(`
+Before optimization (9 instructions):
++--------+---------------------------+----------+------+
+| OFFSET |          OPCODE           | OPERANDS | INFO |
++--------+---------------------------+----------+------+
+|      0 | LOAD_CONST                |        0 | 60   |
+|      2 | LOAD_CONST                |        1 | 60   |
+|      4 | BINARY_OP                 |        3 | *    |
+|      6 | STORE_GLOBAL              |        0 | x    |
+|      8 | FALSE                     |          |      |
+|      9 | POP_JUMP_FORWARD_IF_FALSE |        6 |      |
+|     11 | LOAD_GLOBAL               |        0 | x    |
+|     13 | JUMP_FORWARD              |        3 |      |
+|     15 | NIL                       |          |      |
++--------+---------------------------+----------+------+
+
+After optimization (3 instructions):
++--------+--------------+----------+------+
+| OFFSET |    OPCODE    | OPERANDS | INFO |
++--------+--------------+----------+------+
+|      0 | LOAD_CONST   |        2 | 3600 |
+|      2 | STORE_GLOBAL |        0 | x    |
+|      4 | NIL          |          |      |
++--------+--------------+----------+------+
+`)
+	require.Equal(t, expected+"\n", buf.String())
+}
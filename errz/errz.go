@@ -2,7 +2,10 @@
 // friendly message in addition to the default error message.
 package errz
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 var typeErrorsAreFatal = false
 
@@ -108,3 +111,73 @@ func AreTypeErrorsFatal() bool {
 func SetTypeErrorsAreFatal(fatal bool) {
 	typeErrorsAreFatal = fatal
 }
+
+// StackFrame describes one active function call in a Risor stack trace.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (f StackFrame) String() string {
+	file := f.File
+	if file == "" {
+		file = "<input>"
+	}
+	if f.Line > 0 {
+		file = fmt.Sprintf("%s:%d:%d", file, f.Line, f.Column)
+	}
+	return fmt.Sprintf("%s (%s)", f.Function, file)
+}
+
+// TracedError wraps an error that occurred during evaluation together with
+// the Risor stack trace that was active at the time. The innermost frame is
+// first in the trace.
+type TracedError struct {
+	Err   error
+	Trace []StackFrame
+}
+
+func (t *TracedError) Error() string {
+	return t.Err.Error()
+}
+
+func (t *TracedError) Unwrap() error {
+	return t.Err
+}
+
+// StackTrace returns the frames that were active when the error occurred.
+func (t *TracedError) StackTrace() []StackFrame {
+	return t.Trace
+}
+
+// maxRepeatedFrames is the number of identical consecutive frames shown in a
+// stack trace before the rest are summarized, as happens with deep recursion.
+const maxRepeatedFrames = 3
+
+// FriendlyErrorMessage returns the error message followed by the stack trace.
+func (t *TracedError) FriendlyErrorMessage() string {
+	var b strings.Builder
+	b.WriteString(t.Err.Error())
+	for i := 0; i < len(t.Trace); {
+		frame := t.Trace[i]
+		count := 1
+		for i+count < len(t.Trace) && t.Trace[i+count] == frame {
+			count++
+		}
+		for j := 0; j < count && j < maxRepeatedFrames; j++ {
+			b.WriteString("\n    at ")
+			b.WriteString(frame.String())
+		}
+		if count > maxRepeatedFrames {
+			fmt.Fprintf(&b, "\n    ... previous frame repeated %d more times", count-maxRepeatedFrames)
+		}
+		i += count
+	}
+	return b.String()
+}
+
+func NewTracedError(err error, trace []StackFrame) *TracedError {
+	return &TracedError{Err: err, Trace: trace}
+}
@@ -0,0 +1,53 @@
+// Package format implements the canonical formatting of Risor source code.
+//
+// Formatting is driven by the AST, so the layout of the input does not affect
+// the result, with a few exceptions that let authors keep control over
+// readability: comments are kept in place, a single blank line is kept where
+// the input had one or more, and lists, maps, sets, and call arguments are
+// printed one element per line when the input placed a line break after the
+// opening bracket.
+package format
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+)
+
+// Source formats the given Risor source code. An error is returned if the
+// source cannot be parsed.
+func Source(ctx context.Context, src string) (string, error) {
+	program, err := parser.Parse(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return "", err
+	}
+	p := newPrinter(src, tokens, program.Comments())
+	p.statements(program.Statements())
+	p.flush(token.Position{Char: len(p.src) + 1})
+	return p.String(), nil
+}
+
+// tokenize returns all tokens in the source, excluding newlines. The printer
+// uses these to find closing brackets, which the AST does not record.
+func tokenize(src string) ([]token.Token, error) {
+	l := lexer.New(src)
+	var tokens []token.Token
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Type == token.EOF {
+			return tokens, nil
+		}
+		if tok.Type != token.NEWLINE {
+			tokens = append(tokens, tok)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package format
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "spacing",
+			input: "x:=1+2*3\ny  =  [1,2,3]\nz:={\"a\":1,\"b\":2}",
+			want:  "x := 1 + 2 * 3\ny = [1, 2, 3]\nz := {\"a\": 1, \"b\": 2}\n",
+		},
+		{
+			name:  "parentheses",
+			input: "a := (1 + 2) * 3\nb := 1 + (2 * 3)\nc := 1 - (2 - 3)\nd := (x + y).foo()\ne := -(x + 1)\nf := !(x in y)\ng := (((x)))",
+			want:  "a := (1 + 2) * 3\nb := 1 + 2 * 3\nc := 1 - (2 - 3)\nd := (x + y).foo()\ne := -(x + 1)\nf := !(x in y)\ng := x\n",
+		},
+		{
+			name:  "semicolons",
+			input: "x := 1; y := 2;",
+			want:  "x := 1\ny := 2\n",
+		},
+		{
+			name:  "blocks",
+			input: "func add(a, b=1) { return a+b }\nif x > 1 { print(x) } else if x < 0 { print(-x) } else {}",
+			want:  "func add(a, b=1) {\n\treturn a + b\n}\nif x > 1 {\n\tprint(x)\n} else if x < 0 {\n\tprint(-x)\n} else {}\n",
+		},
+		{
+			name:  "match",
+			input: "x := match v {\ncase  [a,...rest] if a>0 : a\ncase {\"kind\":\"pod\", name:n}, Pod(name=n):\n  n\ncase int( n ), -1, http.OK: 1\n\ndefault:\n}",
+			want:  "x := match v {\ncase [a, ...rest] if a > 0:\n\ta\ncase {\"kind\": \"pod\", name: n}, Pod(name=n):\n\tn\ncase int(n), -1, http.OK:\n\t1\n\ndefault:\n}\n",
+		},
+		{
+			name:  "comprehensions",
+			input: "a := [x*2 for x in items if x>0]\nb := {k:v for k,v in m}\nc := {x for x in s for y in x if y}\nd := [\n  x.name # name\n  for x in people\n    if x.active\n]",
+			want:  "a := [x * 2 for x in items if x > 0]\nb := {k: v for k, v in m}\nc := {x for x in s for y in x if y}\nd := [\n\tx.name # name\n\tfor x in people if x.active\n]\n",
+		},
+		{
+			name:  "optional chaining",
+			input: "x := resp?.data . items?.[0]?.name??\"none\"\ny := a?.get( 1 )?.[1:]",
+			want:  "x := resp?.data.items?.[0]?.name ?? \"none\"\ny := a?.get(1)?.[1:]\n",
+		},
+		{
+			name:  "labeled loops",
+			input: "outer :\nfor _,row := range rows { for _, x := range row { if x { continue   outer }; break outer } }",
+			want:  "outer: for _, row := range rows {\n\tfor _, x := range row {\n\t\tif x {\n\t\t\tcontinue outer\n\t\t}\n\t\tbreak outer\n\t}\n}\n",
+		},
+		{
+			name:  "select",
+			input: "x := select {\ncase v,ok:=<-a : v\ncase b<-1:\ncase <- time.after(1):\n  nil\ndefault:\n}",
+			want:  "x := select {\ncase v, ok := <-a:\n\tv\ncase b <- 1:\ncase <-time.after(1):\n\tnil\ndefault:\n}\n",
+		},
+		{
+			name:  "decorators",
+			input: "@ retry( 3 )\n\n@timed\nfunc fetch(url) { return url }",
+			want:  "@retry(3)\n@timed\nfunc fetch(url) {\n\treturn url\n}\n",
+		},
+		{
+			name:  "enums",
+			input: "enum Status { PENDING,RUNNING=5, // active\n FAILED }",
+			want:  "enum Status {\n\tPENDING\n\tRUNNING = 5 // active\n\tFAILED\n}\n",
+		},
+		{
+			name:  "generators",
+			input: "func gen(n) { for i:=0;i<n;i++ { yield i*2 }; yield }",
+			want:  "func gen(n) {\n\tfor i := 0; i < n; i++ {\n\t\tyield i * 2\n\t}\n\tyield\n}\n",
+		},
+		{
+			name:  "variadics",
+			input: "func log(level,...parts) { print(level,... parts) }\nlog(1, ...[2,3], **  opts)\nfunc(...xs) {}",
+			want:  "func log(level, ...parts) {\n\tprint(level, ...parts)\n}\nlog(1, ...[2, 3], **opts)\nfunc(...xs) {}\n",
+		},
+		{
+			name:  "keyword arguments",
+			input: "fetch(url,timeout = 5,**opts,retries=n+1)",
+			want:  "fetch(url, timeout=5, **opts, retries=n + 1)\n",
+		},
+		{
+			name:  "comments",
+			input: "# leading\nx := 1 // trailing\n\n\n/* block */\nfunc f() {\n  y := 2 # inside\n  // before close\n}\n// end\n",
+			want:  "# leading\nx := 1 // trailing\n\n/* block */\nfunc f() {\n\ty := 2 # inside\n\t// before close\n}\n// end\n",
+		},
+		{
+			name:  "comment after brace",
+			input: "if x { // why\n}\nfor { # forever\n  break\n}",
+			want:  "if x { // why\n}\nfor { # forever\n\tbreak\n}\n",
+		},
+		{
+			name:  "multiline list",
+			input: "x := [\n  1, // one\n  2\n]\ny := [1,\n 2]",
+			want:  "x := [\n\t1, // one\n\t2,\n]\ny := [1, 2]\n",
+		},
+		{
+			name:  "multiline call",
+			input: "foo(\n  a,\n\n  b)\nbar(func() {\n  baz()\n})",
+			want:  "foo(\n\ta,\n\n\tb,\n)\nbar(func() {\n\tbaz()\n})\n",
+		},
+		{
+			name:  "loops",
+			input: "for i:=0;i<3;i++ { print(i) }\nfor k, v := range m {}\nfor x < 3 { x++ }",
+			want:  "for i := 0; i < 3; i++ {\n\tprint(i)\n}\nfor k, v := range m {}\nfor x < 3 {\n\tx++\n}\n",
+		},
+		{
+			name:  "switch",
+			input: "switch x {\ncase 1,2:\n  print(\"a\")\n// other\ndefault:\n}",
+			want:  "switch x {\ncase 1, 2:\n\tprint(\"a\")\n// other\ndefault:\n}\n",
+		},
+		{
+			name:  "strings",
+			input: "a := 'hi {name}'\nb := `raw\\n`\nc := \"esc\\t\"",
+			want:  "a := 'hi {name}'\nb := `raw\\n`\nc := \"esc\\t\"\n",
+		},
+		{
+			name:  "pipes",
+			input: "x := a | b |\n  c",
+			want:  "x := a | b |\n\tc\n",
+		},
+		{
+			name:  "struct and try",
+			input: "struct Point { x; y = 1\n func sum() { return self.x+self.y } }\ntry { f() } catch e { print(e) } finally { g() }",
+			want:  "struct Point {\n\tx\n\ty = 1\n\tfunc sum() {\n\t\treturn self.x + self.y\n\t}\n}\ntry {\n\tf()\n} catch e {\n\tprint(e)\n} finally {\n\tg()\n}\n",
+		},
+		{
+			name:  "imports",
+			input: "import os as o\nfrom a.b import (c as d,\n e)",
+			want:  "import os as o\nfrom a.b import (c as d, e)\n",
+		},
+		{
+			name:  "empty",
+			input: "\n\n",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Source(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+			// Formatting is idempotent
+			again, err := Source(context.Background(), got)
+			require.Nil(t, err)
+			require.Equal(t, got, again)
+		})
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	_, err := Source(context.Background(), "x := (")
+	require.NotNil(t, err)
+}
+
+func TestSourceGolden(t *testing.T) {
+	input, err := os.ReadFile("testdata/example.input")
+	require.Nil(t, err)
+	golden, err := os.ReadFile("testdata/example.golden")
+	require.Nil(t, err)
+	got, err := Source(context.Background(), string(input))
+	require.Nil(t, err)
+	require.Equal(t, string(golden), got)
+	again, err := Source(context.Background(), got)
+	require.Nil(t, err)
+	require.Equal(t, got, again)
+}
@@ -0,0 +1,1134 @@
+package format
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+)
+
+var closers = map[token.Type]string{
+	token.LPAREN:   ")",
+	token.LBRACKET: "]",
+	token.LBRACE:   "}",
+}
+
+// printer writes the canonical form of an AST. Comments are not part of the
+// AST, so they are interleaved with the nodes by position: before each node
+// that starts a line, the printer flushes the comments that precede it in the
+// source.
+type printer struct {
+	src      []rune
+	tokens   []token.Token
+	depths   []int       // bracket depth after each token
+	index    map[int]int // token index by starting character
+	closing  map[int]int // closing bracket token index by opening index
+	comments []*ast.Comment
+	next     int // index of the next comment to print
+	out      []byte
+	indent   int
+	bol      bool // at the beginning of an output line
+	fresh    bool // nothing written since an opening bracket
+}
+
+func newPrinter(src string, tokens []token.Token, comments []*ast.Comment) *printer {
+	p := &printer{
+		src:      []rune(src),
+		tokens:   tokens,
+		depths:   make([]int, len(tokens)),
+		index:    map[int]int{},
+		closing:  map[int]int{},
+		comments: comments,
+		bol:      true,
+		fresh:    true,
+	}
+	var stack []int
+	for i, tok := range tokens {
+		p.index[tok.StartPosition.Char] = i
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			stack = append(stack, i)
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if len(stack) > 0 {
+				p.closing[stack[len(stack)-1]] = i
+				stack = stack[:len(stack)-1]
+			}
+		}
+		p.depths[i] = len(stack)
+	}
+	return p
+}
+
+func (p *printer) String() string {
+	out := strings.TrimRight(string(p.out), "\n")
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+func (p *printer) write(s string) {
+	if p.bol {
+		for i := 0; i < p.indent; i++ {
+			p.out = append(p.out, '\t')
+		}
+		p.bol = false
+	}
+	p.out = append(p.out, s...)
+	p.fresh = false
+}
+
+func (p *printer) newline() {
+	p.out = append(p.out, '\n')
+	p.bol = true
+}
+
+// open ends a line that opened a block or bracket.
+func (p *printer) open() {
+	p.newline()
+	p.fresh = true
+}
+
+// blankLine writes an empty line, unless at the start of the output or of a
+// block, or after another empty line.
+func (p *printer) blankLine() {
+	if p.fresh || len(p.out) == 0 || bytes.HasSuffix(p.out, []byte("\n\n")) {
+		return
+	}
+	p.out = append(p.out, '\n')
+}
+
+// flush writes the comments that begin before the given position. A comment
+// that follows code on its source line is appended to the last output line.
+func (p *printer) flush(pos token.Position) {
+	for p.next < len(p.comments) {
+		c := p.comments[p.next]
+		start := c.Token().StartPosition
+		if start.Char >= pos.Char {
+			return
+		}
+		p.next++
+		if !p.bol {
+			p.newline()
+		}
+		if p.trailing(start) && len(p.out) > 0 && !bytes.HasSuffix(p.out, []byte("\n\n")) {
+			p.out = append(p.out[:len(p.out)-1], ' ')
+			p.out = append(p.out, c.Text()...)
+			p.newline()
+			continue
+		}
+		if p.blankBefore(start) {
+			p.blankLine()
+		}
+		p.write(c.Text())
+		p.newline()
+	}
+}
+
+// pending returns true if any comment not yet printed begins before the given
+// position.
+func (p *printer) pending(pos token.Position) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Token().StartPosition.Char < pos.Char
+}
+
+// trailing returns true if there is code before the position on its line.
+func (p *printer) trailing(pos token.Position) bool {
+	for i := pos.LineStart; i < pos.Char && i < len(p.src); i++ {
+		if !unicode.IsSpace(p.src[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// blankBefore returns true if the position starts its line and the source
+// line before it is empty.
+func (p *printer) blankBefore(pos token.Position) bool {
+	if pos.Line == 0 || p.trailing(pos) {
+		return false
+	}
+	for i := pos.LineStart - 2; i >= 0 && p.src[i] != '\n'; i-- {
+		if !unicode.IsSpace(p.src[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lineBreak returns true if the source has a line break between two positions.
+func (p *printer) lineBreak(from, to token.Position) bool {
+	for i := from.Char; i < to.Char && i < len(p.src); i++ {
+		if p.src[i] == '\n' {
+			return true
+		}
+	}
+	return false
+}
+
+// closingOf returns the bracket that closes the given opening bracket.
+func (p *printer) closingOf(open token.Token) (token.Token, bool) {
+	i, ok := p.index[open.StartPosition.Char]
+	if !ok {
+		return token.Token{}, false
+	}
+	j, ok := p.closing[i]
+	if !ok {
+		return token.Token{}, false
+	}
+	return p.tokens[j], true
+}
+
+// directComments returns true if a comment not yet printed lies between the
+// brackets and is not nested within other brackets.
+func (p *printer) directComments(open, end token.Token) bool {
+	depth := p.depths[p.index[open.StartPosition.Char]]
+	for _, c := range p.comments[p.next:] {
+		start := c.Token().StartPosition
+		if start.Char >= end.StartPosition.Char {
+			break
+		}
+		if start.Char <= open.StartPosition.Char {
+			continue
+		}
+		// The depth at the comment is the depth after the token before it
+		i := sort.Search(len(p.tokens), func(i int) bool {
+			return p.tokens[i].StartPosition.Char >= start.Char
+		})
+		if i > 0 && p.depths[i-1] == depth {
+			return true
+		}
+	}
+	return false
+}
+
+// statements writes each statement on its own line.
+func (p *printer) statements(stmts []ast.Node) {
+	for i, stmt := range stmts {
+		// "x++" is parsed as the expression "x" followed by the postfix
+		// statement, which repeats the identifier
+		if ident, ok := stmt.(*ast.Ident); ok && i+1 < len(stmts) {
+			if postfix, ok := stmts[i+1].(*ast.Postfix); ok &&
+				postfix.Token().StartPosition.Char == ident.Token().StartPosition.Char {
+				continue
+			}
+		}
+		pos := start(stmt)
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		p.node(stmt)
+		p.newline()
+	}
+}
+
+func (p *printer) block(b *ast.Block) {
+	p.write("{")
+	end, ok := p.closingOf(b.Token())
+	if len(b.Statements()) == 0 && (!ok || !p.pending(end.StartPosition)) {
+		p.write("}")
+		return
+	}
+	p.open()
+	p.indent++
+	p.statements(b.Statements())
+	if ok {
+		p.flush(end.StartPosition)
+	}
+	p.indent--
+	p.write("}")
+}
+
+// elements writes a bracketed, comma separated list of nodes. The list is
+// written one element per line if the source has a line break after the
+// opening bracket, or has comments between the elements.
+func (p *printer) elements(open token.Token, items []ast.Node, print func(ast.Node)) {
+	end, ok := p.closingOf(open)
+	multiline := false
+	if ok {
+		multiline = p.directComments(open, end)
+		if len(items) > 0 && p.lineBreak(open.StartPosition, start(items[0])) {
+			multiline = true
+		}
+	}
+	p.write(open.Literal)
+	if !multiline {
+		for i, item := range items {
+			if i > 0 {
+				p.write(", ")
+			}
+			print(item)
+		}
+		p.write(closers[open.Type])
+		return
+	}
+	p.open()
+	p.indent++
+	for _, item := range items {
+		pos := start(item)
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		print(item)
+		p.write(",")
+		p.newline()
+	}
+	p.flush(end.StartPosition)
+	p.indent--
+	p.write(closers[open.Type])
+}
+
+// text returns the source text of a token.
+func (p *printer) text(tok token.Token) string {
+	end := tok.EndPosition.Char + 1
+	if end > len(p.src) {
+		end = len(p.src)
+	}
+	return string(p.src[tok.StartPosition.Char:end])
+}
+
+// node writes a statement or expression.
+func (p *printer) node(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Var:
+		name, value := node.Value()
+		if node.IsWalrus() {
+			p.write(name + " := ")
+		} else {
+			p.write("var " + name + " = ")
+		}
+		p.expr(value)
+	case *ast.MultiVar:
+		names, value := node.Value()
+		list := strings.Join(names, ", ")
+		if node.IsWalrus() {
+			p.write(list + " := ")
+		} else if node.Token().Type == token.VAR {
+			p.write("var " + list + " = ")
+		} else {
+			p.write(list + " = ")
+		}
+		p.expr(value)
+	case *ast.Const:
+		name, value := node.Value()
+		p.write("const " + name + " = ")
+		p.expr(value)
+	case *ast.Return:
+		p.write("return")
+		if node.Value() != nil {
+			p.write(" ")
+			p.expr(node.Value())
+		}
+	case *ast.Yield:
+		p.write("yield")
+		if node.Value() != nil {
+			p.write(" ")
+			p.expr(node.Value())
+		}
+	case *ast.Control:
+		p.write(node.Literal())
+		if node.Label() != nil {
+			p.write(" " + node.Label().Literal())
+		}
+	case *ast.Block:
+		p.block(node)
+	case *ast.For:
+		p.forLoop(node)
+	case *ast.Labeled:
+		p.write(node.Label().Literal() + ": ")
+		p.forLoop(node.Loop())
+	case *ast.Decorated:
+		for _, decorator := range node.Decorators() {
+			p.write("@")
+			p.expr(decorator)
+			p.newline()
+		}
+		p.function(node.Func())
+	case *ast.Assign:
+		if node.Index() != nil {
+			p.expr(node.Index())
+		} else {
+			p.write(node.Name())
+		}
+		p.write(" " + node.Operator() + " ")
+		p.expr(node.Value())
+	case *ast.Import:
+		p.write("import ")
+		p.importName(node)
+	case *ast.FromImport:
+		p.fromImport(node)
+	case *ast.Postfix:
+		p.write(node.Literal() + node.Operator())
+	case *ast.SetAttr:
+		p.operand(node.Object(), trailingBinding(node.Object()) < parser.INDEX)
+		p.write("." + node.Name() + " = ")
+		p.expr(node.Value())
+	case *ast.Go:
+		p.write("go ")
+		p.expr(node.Call())
+	case *ast.Defer:
+		p.write("defer ")
+		p.expr(node.Call())
+	case *ast.Send:
+		p.operand(node.Channel(), trailingBinding(node.Channel()) < parser.CALL)
+		p.write(" <- ")
+		p.operand(node.Value(), binding(node.Value()) <= parser.CALL)
+	case *ast.Struct:
+		p.structDecl(node)
+	case *ast.Enum:
+		p.enumDecl(node)
+	case *ast.Try:
+		p.write("try ")
+		p.block(node.Body())
+		if node.CatchBlock() != nil {
+			p.write(" catch ")
+			if node.CatchIdent() != nil {
+				p.write(node.CatchIdent().Literal() + " ")
+			}
+			p.block(node.CatchBlock())
+		}
+		if node.FinallyBlock() != nil {
+			p.write(" finally ")
+			p.block(node.FinallyBlock())
+		}
+	default:
+		p.expr(node)
+	}
+}
+
+// operand writes an expression, in parentheses if required.
+func (p *printer) operand(node ast.Node, parens bool) {
+	if parens {
+		p.write("(")
+		p.expr(node)
+		p.write(")")
+	} else {
+		p.expr(node)
+	}
+}
+
+func (p *printer) expr(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Ident:
+		p.write(node.Literal())
+	case *ast.Int, *ast.Float, *ast.Bool, *ast.Nil:
+		p.write(node.Token().Literal)
+	case *ast.String:
+		p.write(p.text(node.Token()))
+	case *ast.Prefix:
+		p.write(node.Operator())
+		p.operand(node.Right(), prefixParens(node.Right()))
+	case *ast.Infix:
+		q := parser.Precedence(token.Type(node.Operator()))
+		p.operand(node.Left(), trailingBinding(node.Left()) < q)
+		p.write(" " + node.Operator())
+		p.continued(node.Token().StartPosition, node.Right(), binding(node.Right()) <= q)
+	case *ast.Ternary:
+		p.operand(node.Condition(), trailingBinding(node.Condition()) < parser.TERNARY)
+		p.write(" ? ")
+		p.operand(node.IfTrue(), binding(node.IfTrue()) <= parser.TERNARY)
+		p.write(" : ")
+		p.operand(node.IfFalse(), binding(node.IfFalse()) <= parser.TERNARY)
+	case *ast.In:
+		p.operand(node.Left(), trailingBinding(node.Left()) < parser.PREFIX)
+		p.write(" in ")
+		p.operand(node.Right(), binding(node.Right()) <= parser.PREFIX)
+	case *ast.Range:
+		p.write("range ")
+		p.operand(node.Container(), binding(node.Container()) <= parser.PREFIX)
+	case *ast.Receive:
+		p.write("<-")
+		p.expr(node.Channel())
+	case *ast.Pipe:
+		exprs := node.Expressions()
+		p.operand(exprs[0], trailingBinding(exprs[0]) < parser.PIPE)
+		for i, expr := range exprs[1:] {
+			p.write(" |")
+			p.continued(start(exprs[i]), expr, binding(expr) <= parser.PIPE)
+		}
+	case *ast.Call:
+		p.operand(node.Function(), trailingBinding(node.Function()) < parser.CALL)
+		p.elements(node.Token(), node.Arguments(), p.node)
+	case *ast.Spread:
+		p.write(node.Literal())
+		p.expr(node.Value())
+	case *ast.KeywordArg:
+		p.write(node.Literal())
+		p.write("=")
+		p.expr(node.Value())
+	case *ast.ObjectCall:
+		p.operand(node.Object(), trailingBinding(node.Object()) < parser.INDEX)
+		p.period(node.IsOptional())
+		p.expr(node.Call())
+	case *ast.GetAttr:
+		p.operand(node.Object(), trailingBinding(node.Object()) < parser.INDEX)
+		p.period(node.IsOptional())
+		p.write(node.Name())
+	case *ast.OptionalChain:
+		p.expr(node.Expression())
+	case *ast.Index:
+		p.operand(node.Left(), trailingBinding(node.Left()) < parser.INDEX)
+		if node.IsOptional() {
+			p.write("?.")
+		}
+		p.write("[")
+		p.expr(node.Index())
+		p.write("]")
+	case *ast.Slice:
+		p.operand(node.Left(), trailingBinding(node.Left()) < parser.INDEX)
+		if node.IsOptional() {
+			p.write("?.")
+		}
+		p.write("[")
+		if node.FromIndex() != nil {
+			p.expr(node.FromIndex())
+		}
+		p.write(":")
+		if node.ToIndex() != nil {
+			p.expr(node.ToIndex())
+		}
+		p.write("]")
+	case *ast.Func:
+		p.function(node)
+	case *ast.If:
+		p.ifExpr(node)
+	case *ast.Switch:
+		p.switchExpr(node)
+	case *ast.Match:
+		p.matchExpr(node)
+	case *ast.Select:
+		p.selectExpr(node)
+	case *ast.List:
+		p.elements(node.Token(), nodes(node.Items()), p.expr)
+	case *ast.Set:
+		p.elements(node.Token(), nodes(node.Items()), p.expr)
+	case *ast.Map:
+		items := node.Items()
+		keys := make([]ast.Expression, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return start(keys[i]).Char < start(keys[j]).Char
+		})
+		p.elements(node.Token(), nodes(keys), func(key ast.Node) {
+			p.expr(key)
+			p.write(": ")
+			p.expr(items[key.(ast.Expression)])
+		})
+	case *ast.ListComprehension:
+		p.comprehension(node.Token(), node.Element(), func() {
+			p.expr(node.Element())
+		}, node.Clauses())
+	case *ast.SetComprehension:
+		p.comprehension(node.Token(), node.Element(), func() {
+			p.expr(node.Element())
+		}, node.Clauses())
+	case *ast.MapComprehension:
+		p.comprehension(node.Token(), node.Key(), func() {
+			p.expr(node.Key())
+			p.write(": ")
+			p.expr(node.Value())
+		}, node.Clauses())
+	default:
+		p.write(node.String())
+	}
+}
+
+// comprehension writes a comprehension, using print to write the part before
+// its for clauses, which begins with first. The comprehension is written with
+// that part and each clause on its own line if the source has a line break
+// after the opening bracket, or has comments within the comprehension.
+func (p *printer) comprehension(open token.Token, first ast.Node, print func(), clauses []*ast.ForClause) {
+	end, ok := p.closingOf(open)
+	multiline := false
+	if ok {
+		multiline = p.directComments(open, end) || p.lineBreak(open.StartPosition, start(first))
+	}
+	p.write(open.Literal)
+	if !multiline {
+		print()
+		for _, clause := range clauses {
+			p.write(" ")
+			p.forClause(clause)
+		}
+		p.write(closers[open.Type])
+		return
+	}
+	p.open()
+	p.indent++
+	p.flush(start(first))
+	print()
+	for _, clause := range clauses {
+		p.newline()
+		p.flush(clause.Token().StartPosition)
+		p.forClause(clause)
+	}
+	p.newline()
+	p.flush(end.StartPosition)
+	p.indent--
+	p.write(closers[open.Type])
+}
+
+func (p *printer) forClause(clause *ast.ForClause) {
+	names := make([]string, 0, len(clause.Names()))
+	for _, name := range clause.Names() {
+		names = append(names, name.Literal())
+	}
+	p.write("for " + strings.Join(names, ", ") + " in ")
+	p.expr(clause.Iterable())
+	for _, cond := range clause.Conditions() {
+		p.write(" if ")
+		p.expr(cond)
+	}
+}
+
+// period writes the "." or "?." before the name of an attribute.
+func (p *printer) period(optional bool) {
+	if optional {
+		p.write("?.")
+	} else {
+		p.write(".")
+	}
+}
+
+// continued writes the right hand operand of an operator that was written
+// last. If the operand started on a new line in the source, it starts on a new
+// line in the output too, indented one level.
+func (p *printer) continued(from token.Position, node ast.Node, parens bool) {
+	pos := start(node)
+	if !p.lineBreak(from, pos) {
+		p.write(" ")
+		p.operand(node, parens)
+		return
+	}
+	p.indent++
+	p.newline()
+	p.flush(pos)
+	p.operand(node, parens)
+	p.indent--
+}
+
+func (p *printer) function(fn *ast.Func) {
+	p.write("func")
+	if fn.Name() != nil {
+		p.write(" " + fn.Name().Literal())
+	}
+	p.write("(")
+	defaults := fn.Defaults()
+	for i, param := range fn.Parameters() {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Literal())
+		if value, ok := defaults[param.Literal()]; ok {
+			p.write("=")
+			p.expr(value)
+		}
+	}
+	if rest := fn.RestParameter(); rest != nil {
+		if len(fn.Parameters()) > 0 {
+			p.write(", ")
+		}
+		p.write("..." + rest.Literal())
+	}
+	p.write(") ")
+	p.block(fn.Body())
+}
+
+func (p *printer) ifExpr(node *ast.If) {
+	p.write("if ")
+	p.expr(node.Condition())
+	p.write(" ")
+	p.block(node.Consequence())
+	alt := node.Alternative()
+	if alt == nil {
+		return
+	}
+	p.write(" else ")
+	// The parser wraps an "else if" in a block that begins at the "if"
+	if stmts := alt.Statements(); alt.Token().Type == token.IF && len(stmts) == 1 {
+		if nested, ok := stmts[0].(*ast.If); ok {
+			p.ifExpr(nested)
+			return
+		}
+	}
+	p.block(alt)
+}
+
+func (p *printer) forLoop(node *ast.For) {
+	p.write("for ")
+	switch {
+	case node.IsSimpleLoop():
+	case node.Init() == nil && node.Post() == nil && !p.hasSemicolon(node):
+		p.node(node.Condition())
+		p.write(" ")
+	default:
+		if node.Init() != nil {
+			p.node(node.Init())
+		}
+		p.write("; ")
+		if node.Condition() != nil {
+			p.node(node.Condition())
+		}
+		p.write(";")
+		if node.Post() != nil {
+			p.write(" ")
+			p.node(node.Post())
+		}
+		p.write(" ")
+	}
+	p.block(node.Consequence())
+}
+
+// hasSemicolon returns true if the loop has the three part form, which may
+// omit both the init and post statements.
+func (p *printer) hasSemicolon(node *ast.For) bool {
+	i, ok := p.index[node.Token().StartPosition.Char]
+	if !ok {
+		return false
+	}
+	open := node.Consequence().Token().StartPosition.Char
+	for ; i < len(p.tokens) && p.tokens[i].StartPosition.Char < open; i++ {
+		if p.tokens[i].Type == token.SEMICOLON {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) switchExpr(node *ast.Switch) {
+	p.write("switch ")
+	p.expr(node.Value())
+	p.write(" {")
+	p.open()
+	for _, choice := range node.Choices() {
+		pos := choice.Token().StartPosition
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		if choice.IsDefault() {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			for i, expr := range choice.Expressions() {
+				if i > 0 {
+					p.write(", ")
+				}
+				p.expr(expr)
+			}
+			p.write(":")
+		}
+		p.open()
+		if choice.Block() != nil {
+			p.indent++
+			p.statements(choice.Block().Statements())
+			p.indent--
+		}
+	}
+	var first ast.Node
+	if choices := node.Choices(); len(choices) > 0 {
+		first = choices[0]
+	}
+	if end, ok := p.switchEnd(node, first); ok {
+		p.indent++
+		p.flush(end.StartPosition)
+		p.indent--
+	}
+	p.write("}")
+}
+
+// switchEnd returns the brace that closes a switch, match or select
+// expression. The opening brace is the last one before the first case, if
+// there is one.
+func (p *printer) switchEnd(node ast.Node, first ast.Node) (token.Token, bool) {
+	i := p.index[node.Token().StartPosition.Char]
+	if first != nil {
+		for j := p.index[first.Token().StartPosition.Char]; j > i; j-- {
+			if p.tokens[j].Type == token.LBRACE {
+				return p.closingOf(p.tokens[j])
+			}
+		}
+		return token.Token{}, false
+	}
+	for ; i < len(p.tokens); i++ {
+		if p.tokens[i].Type == token.LBRACE {
+			return p.closingOf(p.tokens[i])
+		}
+	}
+	return token.Token{}, false
+}
+
+func (p *printer) matchExpr(node *ast.Match) {
+	p.write("match ")
+	p.expr(node.Value())
+	p.write(" {")
+	p.open()
+	for _, matchCase := range node.Cases() {
+		pos := matchCase.Token().StartPosition
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		if matchCase.IsDefault() {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			for i, pattern := range matchCase.Patterns() {
+				if i > 0 {
+					p.write(", ")
+				}
+				p.pattern(pattern)
+			}
+			if guard := matchCase.Guard(); guard != nil {
+				p.write(" if ")
+				p.expr(guard)
+			}
+			p.write(":")
+		}
+		p.open()
+		if matchCase.Block() != nil {
+			p.indent++
+			p.statements(matchCase.Block().Statements())
+			p.indent--
+		}
+	}
+	var first ast.Node
+	if cases := node.Cases(); len(cases) > 0 {
+		first = cases[0]
+	}
+	if end, ok := p.switchEnd(node, first); ok {
+		p.indent++
+		p.flush(end.StartPosition)
+		p.indent--
+	}
+	p.write("}")
+}
+
+func (p *printer) selectExpr(node *ast.Select) {
+	p.write("select {")
+	p.open()
+	for _, selectCase := range node.Cases() {
+		pos := selectCase.Token().StartPosition
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		if selectCase.IsDefault() {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			for i, name := range selectCase.Names() {
+				if i > 0 {
+					p.write(", ")
+				}
+				p.write(name.Literal())
+			}
+			if len(selectCase.Names()) > 0 {
+				p.write(" := ")
+			}
+			p.node(selectCase.Comm())
+			p.write(":")
+		}
+		p.open()
+		if selectCase.Block() != nil {
+			p.indent++
+			p.statements(selectCase.Block().Statements())
+			p.indent--
+		}
+	}
+	var first ast.Node
+	if cases := node.Cases(); len(cases) > 0 {
+		first = cases[0]
+	}
+	if end, ok := p.switchEnd(node, first); ok {
+		p.indent++
+		p.flush(end.StartPosition)
+		p.indent--
+	}
+	p.write("}")
+}
+
+// pattern writes a pattern of a match case.
+func (p *printer) pattern(node ast.Pattern) {
+	switch node := node.(type) {
+	case *ast.ValuePattern:
+		p.expr(node.Value())
+	case *ast.ListPattern:
+		items := make([]ast.Node, 0, len(node.Items()))
+		for _, item := range node.Items() {
+			items = append(items, item)
+		}
+		p.elements(node.Token(), items, func(item ast.Node) {
+			p.pattern(item.(ast.Pattern))
+		})
+	case *ast.MapPattern:
+		values := map[ast.Node]ast.Pattern{}
+		for i, key := range node.Keys() {
+			values[key] = node.Values()[i]
+		}
+		p.elements(node.Token(), nodes(node.Keys()), func(key ast.Node) {
+			p.expr(key)
+			p.write(": ")
+			p.pattern(values[key])
+		})
+	case *ast.TypePattern:
+		p.write(node.Name().Literal() + "(")
+		if node.Value() != nil {
+			p.pattern(node.Value())
+		}
+		for i, name := range node.AttrNames() {
+			if i > 0 || node.Value() != nil {
+				p.write(", ")
+			}
+			p.write(name.Literal() + "=")
+			p.pattern(node.Attrs()[i])
+		}
+		p.write(")")
+	default:
+		p.write(node.String())
+	}
+}
+
+func (p *printer) importName(node *ast.Import) {
+	p.write(node.Name().Literal())
+	if node.Alias() != nil {
+		p.write(" as " + node.Alias().Literal())
+	}
+}
+
+func (p *printer) fromImport(node *ast.FromImport) {
+	var parents []string
+	for _, parent := range node.Parents() {
+		parents = append(parents, parent.Literal())
+	}
+	p.write("from " + strings.Join(parents, ".") + " import ")
+	imports := node.Imports()
+	if !node.IsGrouped() {
+		for i, imp := range imports {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.importName(imp)
+		}
+		return
+	}
+	// The opening parenthesis follows the "import" keyword
+	open := token.Token{Type: token.LPAREN, Literal: "("}
+	if i, ok := p.index[imports[0].Token().StartPosition.Char]; ok && i+1 < len(p.tokens) {
+		open = p.tokens[i+1]
+	}
+	items := make([]ast.Node, 0, len(imports))
+	for _, imp := range imports {
+		items = append(items, imp.Name())
+	}
+	p.elements(open, items, func(node ast.Node) {
+		for _, imp := range imports {
+			if imp.Name() == node {
+				p.importName(imp)
+			}
+		}
+	})
+}
+
+func (p *printer) structDecl(node *ast.Struct) {
+	p.write("struct " + node.Name().Literal() + " {")
+	// Fields and methods are written in source order
+	var members []ast.Node
+	for _, field := range node.Fields() {
+		members = append(members, field)
+	}
+	for _, method := range node.Methods() {
+		members = append(members, method)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return start(members[i]).Char < start(members[j]).Char
+	})
+	var end token.Token
+	var ok bool
+	if i, found := p.index[node.Name().Token().StartPosition.Char]; found && i+1 < len(p.tokens) {
+		end, ok = p.closingOf(p.tokens[i+1])
+	}
+	if len(members) == 0 && (!ok || !p.pending(end.StartPosition)) {
+		p.write("}")
+		return
+	}
+	p.open()
+	p.indent++
+	defaults := node.Defaults()
+	for _, member := range members {
+		pos := start(member)
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		switch member := member.(type) {
+		case *ast.Ident:
+			p.write(member.Literal())
+			if value, ok := defaults[member.Literal()]; ok {
+				p.write(" = ")
+				p.expr(value)
+			}
+		case *ast.Func:
+			p.function(member)
+		}
+		p.newline()
+	}
+	if ok {
+		p.flush(end.StartPosition)
+	}
+	p.indent--
+	p.write("}")
+}
+
+func (p *printer) enumDecl(node *ast.Enum) {
+	p.write("enum " + node.Name().Literal() + " {")
+	var end token.Token
+	var ok bool
+	if i, found := p.index[node.Name().Token().StartPosition.Char]; found && i+1 < len(p.tokens) {
+		end, ok = p.closingOf(p.tokens[i+1])
+	}
+	members := node.Members()
+	if len(members) == 0 && (!ok || !p.pending(end.StartPosition)) {
+		p.write("}")
+		return
+	}
+	p.open()
+	p.indent++
+	values := node.Values()
+	for _, member := range members {
+		pos := start(member)
+		p.flush(pos)
+		if p.blankBefore(pos) {
+			p.blankLine()
+		}
+		p.write(member.Literal())
+		if value, ok := values[member.Literal()]; ok {
+			p.write(" = ")
+			p.expr(value)
+		}
+		p.newline()
+	}
+	if ok {
+		p.flush(end.StartPosition)
+	}
+	p.indent--
+	p.write("}")
+}
+
+func nodes(exprs []ast.Expression) []ast.Node {
+	result := make([]ast.Node, 0, len(exprs))
+	for _, expr := range exprs {
+		result = append(result, expr)
+	}
+	return result
+}
+
+// start returns the position of the first token of a node. The token of many
+// nodes is their operator, which follows their first operand.
+func start(node ast.Node) token.Position {
+	switch node := node.(type) {
+	case *ast.Infix:
+		return start(node.Left())
+	case *ast.Ternary:
+		return start(node.Condition())
+	case *ast.In:
+		return start(node.Left())
+	case *ast.Pipe:
+		return start(node.Expressions()[0])
+	case *ast.Call:
+		return start(node.Function())
+	case *ast.ObjectCall:
+		return start(node.Object())
+	case *ast.GetAttr:
+		return start(node.Object())
+	case *ast.OptionalChain:
+		return start(node.Expression())
+	case *ast.Index:
+		return start(node.Left())
+	case *ast.Slice:
+		return start(node.Left())
+	case *ast.SetAttr:
+		return start(node.Object())
+	case *ast.Send:
+		return start(node.Channel())
+	case *ast.Assign:
+		if node.Index() != nil {
+			return start(node.Index())
+		}
+	case *ast.ValuePattern:
+		return start(node.Value())
+	}
+	return node.Token().StartPosition
+}
+
+// binding returns the precedence of the operator that combines the top level
+// of an expression. An operand whose binding is not greater than the
+// precedence of the operator it appears beside must be parenthesized.
+func binding(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.Infix:
+		return parser.Precedence(token.Type(node.Operator()))
+	case *ast.Ternary:
+		return parser.TERNARY
+	case *ast.Pipe:
+		return parser.PIPE
+	case *ast.In:
+		return parser.PREFIX
+	case *ast.Call:
+		return parser.CALL
+	case *ast.ObjectCall, *ast.GetAttr, *ast.Index, *ast.Slice, *ast.OptionalChain:
+		return parser.INDEX
+	}
+	return parser.HIGHEST
+}
+
+// trailingBinding returns the precedence that the last operand of an
+// expression was parsed with. When the expression is followed by an operator
+// of greater precedence, that operator would bind to the last operand instead,
+// so the expression must be parenthesized.
+func trailingBinding(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.Infix:
+		q := parser.Precedence(token.Type(node.Operator()))
+		return lastOperand(q, node.Right(), binding(node.Right()) <= q)
+	case *ast.Prefix:
+		return lastOperand(parser.PREFIX, node.Right(), prefixParens(node.Right()))
+	case *ast.In:
+		return lastOperand(parser.PREFIX, node.Right(), binding(node.Right()) <= parser.PREFIX)
+	case *ast.Range:
+		return lastOperand(parser.PREFIX, node.Container(), binding(node.Container()) <= parser.PREFIX)
+	case *ast.Ternary:
+		return lastOperand(parser.TERNARY, node.IfFalse(), binding(node.IfFalse()) <= parser.TERNARY)
+	case *ast.Pipe:
+		exprs := node.Expressions()
+		last := exprs[len(exprs)-1]
+		return lastOperand(parser.PIPE, last, binding(last) <= parser.PIPE)
+	case *ast.Receive:
+		return parser.LOWEST
+	}
+	return parser.HIGHEST
+}
+
+func lastOperand(precedence int, operand ast.Node, parens bool) int {
+	if parens {
+		return precedence
+	}
+	return min(precedence, trailingBinding(operand))
+}
+
+// prefixParens returns true if the operand of a prefix operator must be
+// parenthesized. Nested prefix operators are parenthesized so that "- -x" is
+// not written as the decrement operator.
+func prefixParens(operand ast.Node) bool {
+	if _, ok := operand.(*ast.Prefix); ok {
+		return true
+	}
+	return binding(operand) <= parser.PREFIX
+}
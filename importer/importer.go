@@ -64,11 +64,11 @@ func (i *LocalImporter) Import(ctx context.Context, name string) (*object.Module
 	if code, ok := i.codeCache[name]; ok {
 		return object.NewModule(name, code), nil
 	}
-	source, found := readFileWithExtensions(i.sourceDir, name, i.extensions)
+	source, path, found := readFileWithExtensions(i.sourceDir, name, i.extensions)
 	if !found {
 		return nil, fmt.Errorf("import error: module %q not found", name)
 	}
-	ast, err := parser.Parse(ctx, source)
+	ast, err := parser.Parse(ctx, source, parser.WithFile(path))
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +84,13 @@ func (i *LocalImporter) Import(ctx context.Context, name string) (*object.Module
 	return object.NewModule(name, code), nil
 }
 
-func readFileWithExtensions(dir, name string, extensions []string) (string, bool) {
+func readFileWithExtensions(dir, name string, extensions []string) (string, string, bool) {
 	for _, ext := range extensions {
 		fullPath := filepath.Join(dir, name+ext)
 		bytes, err := os.ReadFile(fullPath)
 		if err == nil {
-			return string(bytes), true
+			return string(bytes), fullPath, true
 		}
 	}
-	return "", false
+	return "", "", false
 }
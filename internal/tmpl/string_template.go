@@ -1,7 +1,10 @@
 // Package tmpl is used to parse Risor string templates.
 package tmpl
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Fragment struct {
 	// value is the fragment text. If the fragment is an expression, this will
@@ -9,6 +12,12 @@ type Fragment struct {
 	value string
 	// isVariable is true if this is an expression, false if it is raw text.
 	isVariable bool
+	// conversion is the conversion that follows a "!" after the expression,
+	// either "r" or "s", or empty if there is none.
+	conversion string
+	// spec is the format spec that follows a ":" after the expression, or
+	// empty if there is none.
+	spec string
 }
 
 // Value returns the fragment text. If the fragment is an expression, this will
@@ -22,6 +31,19 @@ func (f *Fragment) IsVariable() bool {
 	return f.isVariable
 }
 
+// Conversion returns the conversion applied to the value of an expression
+// before it is formatted: "r" for its representation, "s" for its string
+// form, or empty if the expression has no conversion.
+func (f *Fragment) Conversion() string {
+	return f.conversion
+}
+
+// Spec returns the format spec of an expression, as in "{price:.2f}", or
+// empty if the expression has no format spec.
+func (f *Fragment) Spec() string {
+	return f.spec
+}
+
 // Template defines a string template which may contain any number of
 // expressions within.
 type Template struct {
@@ -59,19 +81,48 @@ func Parse(s string) (*Template, error) {
 	// Iterate through all runes in the string to find ${variable}s. We build up
 	// a list of string "fragments", which are either raw text or variables.
 	var curFragment *Fragment
+	// Within an expression, the depth of nested braces and the quote of the
+	// string literal being read, if any. An expression ends at the first "}"
+	// outside of nested braces and string literals, so that it may contain
+	// map literals.
+	var depth int
+	var quote rune
 	for i := 0; i < len(runes); i++ {
 		char := getChar(i)
 		peekChar := getChar(i + 1)
+		if curFragment != nil && curFragment.IsVariable() {
+			if quote != 0 {
+				if char == '\\' && i+1 < len(runes) {
+					curFragment.value += string(char)
+					i++
+					char = peekChar
+				} else if char == quote {
+					quote = 0
+				}
+			} else {
+				switch char {
+				case '"', '\'', '`':
+					quote = char
+				case '{':
+					depth++
+				case '}':
+					if depth == 0 {
+						// Closed expression
+						curFragment.split()
+						curFragment = nil
+						continue
+					}
+					depth--
+				}
+			}
+			curFragment.value += string(char)
+			continue
+		}
 		if char == '{' && peekChar == '{' {
 			// Escaped { literal
 			char = '{'
 			i++
 		} else if char == '}' {
-			if curFragment != nil && curFragment.IsVariable() {
-				// Closed expression
-				curFragment = nil
-				continue
-			}
 			if peekChar == '}' {
 				// Escaped } literal
 				char = '}'
@@ -81,20 +132,13 @@ func Parse(s string) (*Template, error) {
 				return nil, fmt.Errorf("invalid '}' in template: %v", s)
 			}
 		} else if char == '{' {
-			// Start of an expression. Error if we're already in an expression.
-			if curFragment != nil && curFragment.IsVariable() {
-				return nil, fmt.Errorf("invalid '{' in template: %v", s)
-			}
+			// Start of an expression
 			curFragment = &Fragment{
 				isVariable: true,
 				value:      "",
 			}
 			template.fragments = append(template.fragments, curFragment)
 			continue
-		} else if curFragment != nil && curFragment.IsVariable() && char == '}' {
-			// End of an expression
-			curFragment = nil
-			continue
 		}
 		// Append current character to the current fragment
 		if curFragment == nil {
@@ -112,3 +156,60 @@ func Parse(s string) (*Template, error) {
 	}
 	return template, nil
 }
+
+// split moves the conversion and format spec that may follow the expression
+// of the fragment, as in "{name!r:>10}", into their own fields. The spec is
+// introduced by the first ":" that is outside of brackets, braces, and string
+// literals and that does not belong to a ternary "?" expression.
+func (f *Fragment) split() {
+	runes := []rune(f.value)
+	var depth, ternaries int
+	var quote rune
+	end := len(runes)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '?':
+			// "?." and "??" are operators rather than the start of a ternary
+			if depth == 0 && i+1 < len(runes) && runes[i+1] != '.' && runes[i+1] != '?' &&
+				(i == 0 || runes[i-1] != '?') {
+				ternaries++
+			}
+		case ':':
+			if depth > 0 {
+				continue
+			}
+			if ternaries > 0 {
+				ternaries--
+				continue
+			}
+			f.spec = string(runes[i+1:])
+			end = i
+		}
+		if end < len(runes) {
+			break
+		}
+	}
+	// A conversion is a "!" followed by a single letter at the end of the
+	// expression, which is distinct from the "!=" operator
+	if end >= 3 && runes[end-2] == '!' && (runes[end-1] == 'r' || runes[end-1] == 's') &&
+		strings.TrimSpace(string(runes[:end-2])) != "" {
+		f.conversion = string(runes[end-1])
+		end -= 2
+	}
+	f.value = string(runes[:end])
+}
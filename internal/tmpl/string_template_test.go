@@ -44,6 +44,21 @@ func TestParseString(t *testing.T) {
 				{value: "{1}", isVariable: false},
 			},
 		},
+		{
+			`{ x.update({"foo": 1}) } {"}" + y}`,
+			[]*Fragment{
+				{value: ` x.update({"foo": 1}) `, isVariable: true},
+				{value: " ", isVariable: false},
+				{value: `"}" + y`, isVariable: true},
+			},
+		},
+		{
+			`{ {"a": {"b": 1}}["a"] }}}`,
+			[]*Fragment{
+				{value: ` {"a": {"b": 1}}["a"] `, isVariable: true},
+				{value: "}", isVariable: false},
+			},
+		},
 	}
 	for _, tc := range tests {
 		res, err := Parse(tc.input)
@@ -53,6 +68,38 @@ func TestParseString(t *testing.T) {
 	}
 }
 
+func TestParseFormatSpecs(t *testing.T) {
+	tests := []struct {
+		input      string
+		value      string
+		conversion string
+		spec       string
+	}{
+		{"{price:.2f}", "price", "", ".2f"},
+		{"{name!r}", "name", "r", ""},
+		{"{name!s:>10}", "name", "s", ">10"},
+		{"{x[1:2]}", "x[1:2]", "", ""},
+		{"{x[1:2]:^5}", "x[1:2]", "", "^5"},
+		{"{ok ? a : b}", "ok ? a : b", "", ""},
+		{"{ok ? a : b:<3}", "ok ? a : b", "", "<3"},
+		{"{a?.b ?? c:>4}", "a?.b ?? c", "", ">4"},
+		{`{m["a:b"]:x}`, `m["a:b"]`, "", "x"},
+		{`{ {"a": 1}["a"]:>3}`, ` {"a": 1}["a"]`, "", ">3"},
+		{"{a != b}", "a != b", "", ""},
+		{"{!r}", "!r", "", ""},
+		{"{t:%H:%M}", "t", "", "%H:%M"},
+	}
+	for _, tc := range tests {
+		res, err := Parse(tc.input)
+		require.Nil(t, err)
+		require.Len(t, res.Fragments(), 1)
+		f := res.Fragments()[0]
+		require.Equal(t, tc.value, f.Value())
+		require.Equal(t, tc.conversion, f.Conversion())
+		require.Equal(t, tc.spec, f.Spec())
+	}
+}
+
 func TestParseStringErrors(t *testing.T) {
 	tests := []struct {
 		input   string
@@ -60,7 +107,8 @@ func TestParseStringErrors(t *testing.T) {
 	}{
 		{"{", `missing '}' in template: {`},
 		{"a{0} {cd", `missing '}' in template: a{0} {cd`},
-		{`{ x.update({"foo": 1}) }`, `invalid '{' in template: { x.update({"foo": 1}) }`},
+		{`{ x.update({"foo": 1}) `, `missing '}' in template: { x.update({"foo": 1}) `},
+		{`{"}"`, `missing '}' in template: {"}"`},
 		{"{a}}", `invalid '}' in template: {a}}`},
 		{"}a", `invalid '}' in template: }a`},
 	}
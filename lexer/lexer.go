@@ -52,6 +52,9 @@ type Lexer struct {
 
 	// Name of the file be read
 	file string
+
+	// Comments encountered so far, in source order
+	comments []token.Token
 }
 
 // Option is a configuration function for a Lexer.
@@ -107,16 +110,16 @@ func (l *Lexer) Next() (token.Token, error) {
 	l.skipTabsAndSpaces()
 	l.tokenStartPosition = l.Position()
 
-	// skip single-line comments
+	// Comments are not returned as tokens, but are recorded so that tools
+	// like the formatter can reproduce them
 	if l.ch == rune('#') ||
 		(l.ch == rune('/') && l.peekChar() == rune('/')) {
 		l.skipComment()
 		return l.Next()
 	}
-
-	// multi-line comments
 	if l.ch == rune('/') && l.peekChar() == rune('*') {
 		l.skipMultiLineComment()
+		return l.Next()
 	}
 
 	if l.prevToken.Type == token.EOF {
@@ -150,7 +153,17 @@ func (l *Lexer) Next() (token.Token, error) {
 	case rune(';'):
 		tok = l.newToken(token.SEMICOLON, string(l.ch))
 	case rune('?'):
-		tok = l.newToken(token.QUESTION, string(l.ch))
+		if l.peekChar() == rune('.') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.QUESTION_PERIOD, string(ch)+string(l.ch))
+		} else if l.peekChar() == rune('?') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.NULLISH, string(ch)+string(l.ch))
+		} else {
+			tok = l.newToken(token.QUESTION, string(l.ch))
+		}
 	case rune('('):
 		tok = l.newToken(token.LPAREN, string(l.ch))
 	case rune(')'):
@@ -158,7 +171,13 @@ func (l *Lexer) Next() (token.Token, error) {
 	case rune(','):
 		tok = l.newToken(token.COMMA, string(l.ch))
 	case rune('.'):
-		tok = l.newToken(token.PERIOD, string(l.ch))
+		if l.peekChar() == rune('.') && l.peekCharAt(2) == rune('.') {
+			l.readChar()
+			l.readChar()
+			tok = l.newToken(token.ELLIPSIS, "...")
+		} else {
+			tok = l.newToken(token.PERIOD, string(l.ch))
+		}
 	case rune('+'):
 		if l.peekChar() == rune('+') {
 			ch := l.ch
@@ -173,6 +192,8 @@ func (l *Lexer) Next() (token.Token, error) {
 		}
 	case rune('%'):
 		tok = l.newToken(token.MOD, string(l.ch))
+	case rune('@'):
+		tok = l.newToken(token.AT, string(l.ch))
 	case rune('{'):
 		tok = l.newToken(token.LBRACE, string(l.ch))
 	case rune('}'):
@@ -402,18 +423,23 @@ func (l *Lexer) skipTabsAndSpaces() {
 
 // Skip a comment until the end of the line
 func (l *Lexer) skipComment() {
-	for l.ch != '\n' && l.ch != rune(0) {
+	start := l.position
+	for l.peekChar() != '\n' && l.peekChar() != rune(0) {
 		l.readChar()
 	}
+	l.addComment(start)
+	l.readChar()
 	l.skipTabsAndSpaces()
 }
 
 // Consume all tokens until we've had the close of a multi-line comment
 func (l *Lexer) skipMultiLineComment() {
+	start := l.position
 	found := false
 	for !found {
 		// break at the end of our input.
 		if l.ch == rune(0) {
+			l.addComment(start)
 			found = true
 		}
 		// otherwise keep going until we find "*/"
@@ -421,12 +447,35 @@ func (l *Lexer) skipMultiLineComment() {
 			found = true
 			// Our current position is "*", so skip forward to consume the "/"
 			l.readChar()
+			l.addComment(start)
 		}
 		l.readChar()
 	}
 	l.skipTabsAndSpaces()
 }
 
+// addComment records a comment that begins at the given character index and
+// ends at the current character.
+func (l *Lexer) addComment(start int) {
+	end := l.position
+	if end >= len(l.characters) {
+		end = len(l.characters) - 1
+	}
+	text := strings.TrimRight(string(l.characters[start:end+1]), "\r")
+	l.comments = append(l.comments, token.Token{
+		Type:          token.COMMENT,
+		Literal:       text,
+		StartPosition: l.tokenStartPosition,
+		EndPosition:   l.Position(),
+	})
+}
+
+// Comments returns the comments read so far, in the order they appear in the
+// input. Comments are not returned by Next.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 // Read a decimal, hex, or octal number
 func (l *Lexer) readNumber(onlyDecimal bool) (NumberType, string, error) {
 	str := string(l.ch)
@@ -624,6 +673,16 @@ func (l *Lexer) peekChar() rune {
 	return l.characters[l.nextPosition]
 }
 
+// peekCharAt returns the character the given distance ahead of the current
+// one, where a distance of 1 is the next character.
+func (l *Lexer) peekCharAt(distance int) rune {
+	pos := l.nextPosition + distance - 1
+	if pos >= len(l.characters) {
+		return rune(0)
+	}
+	return l.characters[pos]
+}
+
 // GetLineText returns the text of the line containing the given token.
 func (l *Lexer) GetLineText(t token.Token) string {
 	if len(l.characters) == 0 {
@@ -529,6 +529,107 @@ baz.qux();
 	}
 }
 
+func TestEllipsis(t *testing.T) {
+	input := `f(...args, **opts)..`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "args"},
+		{token.COMMA, ","},
+		{token.POW, "**"},
+		{token.IDENT, "opts"},
+		{token.RPAREN, ")"},
+		{token.PERIOD, "."},
+		{token.PERIOD, "."},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q", i, tt, tok)
+		}
+	}
+}
+
+func TestOptionalOperators(t *testing.T) {
+	input := `a?.b?.[0] ?? c ? d : e`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.QUESTION_PERIOD, "?."},
+		{token.IDENT, "b"},
+		{token.QUESTION_PERIOD, "?."},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "c"},
+		{token.QUESTION, "?"},
+		{token.IDENT, "d"},
+		{token.COLON, ":"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q", i, tt, tok)
+		}
+	}
+}
+
+func TestDecorator(t *testing.T) {
+	input := "@cache(10)\nfunc f() {}"
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.AT, "@"},
+		{token.IDENT, "cache"},
+		{token.LPAREN, "("},
+		{token.INT, "10"},
+		{token.RPAREN, ")"},
+		{token.NEWLINE, "\n"},
+		{token.FUNC, "func"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong, expected=%q, got=%q", i, tt, tok)
+		}
+	}
+}
+
 // TestDiv is designed to test that a division is recognized; that it is
 // not confused with a regular-expression.
 func TestDiv(t *testing.T) {
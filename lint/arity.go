@@ -0,0 +1,81 @@
+package lint
+
+import "fmt"
+
+// arity is the number of arguments a builtin function accepts. A max of -1
+// means there is no upper limit.
+type arity struct {
+	min int
+	max int
+}
+
+// builtinArity holds the argument counts accepted by the default builtins.
+var builtinArity = map[string]arity{
+	"all":         {1, 1},
+	"any":         {1, 1},
+	"assert":      {1, 2},
+	"bool":        {0, 1},
+	"buffer":      {0, 1},
+	"byte":        {0, 1},
+	"byte_slice":  {0, 1},
+	"call":        {1, -1},
+	"chan":        {0, 1},
+	"chr":         {1, 1},
+	"chunk":       {2, 2},
+	"close":       {1, 1},
+	"decode":      {2, 2},
+	"delete":      {2, 2},
+	"encode":      {2, 2},
+	"error":       {1, -1},
+	"float":       {0, 1},
+	"float_slice": {0, 1},
+	"format":      {1, 2},
+	"getattr":     {2, 3},
+	"hash":        {1, 2},
+	"int":         {0, 1},
+	"is_hashable": {1, 1},
+	"iter":        {1, 1},
+	"keys":        {1, 1},
+	"len":         {1, 1},
+	"list":        {0, 1},
+	"make":        {1, 2},
+	"map":         {0, 1},
+	"ord":         {1, 1},
+	"reversed":    {1, 1},
+	"set":         {0, 1},
+	"sorted":      {1, 2},
+	"spawn":       {1, -1},
+	"sprintf":     {1, -1},
+	"string":      {0, 1},
+	"try":         {1, -1},
+	"type":        {1, 1},
+}
+
+// check returns a message and false if the function cannot be called with
+// the given number of arguments.
+func (a arity) check(name string, count int) (string, bool) {
+	if count >= a.min && (a.max < 0 || count <= a.max) {
+		return "", true
+	}
+	var want string
+	switch {
+	case a.min == a.max:
+		want = plural(a.min)
+	case a.max < 0:
+		want = "at least " + plural(a.min)
+	case a.min == 0:
+		want = "at most " + plural(a.max)
+	case a.max == a.min+1:
+		want = fmt.Sprintf("%d or %d arguments", a.min, a.max)
+	default:
+		want = fmt.Sprintf("%d to %d arguments", a.min, a.max)
+	}
+	return fmt.Sprintf("%s takes %s (%d given)", name, want, count), false
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
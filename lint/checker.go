@@ -0,0 +1,373 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/token"
+)
+
+// kindGlobal is the kind of the globals a program refers to, which are not
+// declared by the program itself.
+const kindGlobal compiler.DeclarationKind = "global"
+
+// binding records a name declared by the program, whether it is read, and
+// every identifier that refers to it.
+type binding struct {
+	name string
+	kind compiler.DeclarationKind
+	tok  token.Token
+	used bool
+	refs []token.Token
+	// templates holds the template strings that refer to the binding, since
+	// the positions of identifiers within them are not known.
+	templates []token.Token
+	// implicit is set for names the program does not spell out, such as the
+	// self parameter of methods.
+	implicit bool
+	// unaliased is set for imports without an alias, whose declaring
+	// identifier also names the module or attribute being imported.
+	unaliased bool
+}
+
+// checker observes the compilation of a program, so that names are declared
+// and resolved by the compiler itself, in the symbol tables it creates.
+type checker struct {
+	bindings map[*compiler.Symbol]*binding
+	// symbols holds the bindings whose references are recorded. Besides the
+	// names declared by the program, it includes globals and the name of each
+	// function within its own body.
+	symbols  map[*compiler.Symbol]*binding
+	globals  []*binding
+	declared []*binding
+	// scopes holds the bindings declared directly in each symbol table
+	scopes map[*compiler.SymbolTable][]*binding
+	// selfNames holds the bindings of function names within their own
+	// bodies, by the identifier that names the function
+	selfNames map[token.Token]*binding
+	// unaliased holds the identifiers declared by imports without an alias
+	unaliased map[token.Token]bool
+	// resolved holds the symbols that identifiers were resolved to
+	resolved map[*ast.Ident]*compiler.Symbol
+	// pipeStages holds the calls that are stages of a pipe
+	pipeStages  map[*ast.Call]bool
+	diagnostics []Diagnostic
+	// templates holds the template strings being compiled, innermost last
+	templates []token.Token
+}
+
+func newChecker() *checker {
+	return &checker{
+		bindings:   map[*compiler.Symbol]*binding{},
+		symbols:    map[*compiler.Symbol]*binding{},
+		scopes:     map[*compiler.SymbolTable][]*binding{},
+		selfNames:  map[token.Token]*binding{},
+		unaliased:  map[token.Token]bool{},
+		resolved:   map[*ast.Ident]*compiler.Symbol{},
+		pipeStages: map[*ast.Call]bool{},
+	}
+}
+
+// check compiles the program with the given globals, observing it as it is
+// compiled. Compile errors are not returned: the problems the rules cover are
+// reported as diagnostics, and the compiler continues past them. Others, like
+// a return statement outside of a function, leave the rest of the statement
+// they occur in unchecked.
+func (c *checker) check(program *ast.Program, globalNames []string) {
+	comp, err := compiler.New(
+		compiler.WithGlobalNames(globalNames),
+		compiler.WithObserver(c),
+	)
+	if err != nil {
+		return
+	}
+	comp.Compile(program)
+}
+
+func (c *checker) report(rule Rule, tok token.Token, format string, args ...any) {
+	if n := len(c.templates); n > 0 {
+		// Positions within templates are relative to the expression
+		tok = c.templates[n-1]
+	}
+	c.reportRange(rule, tok.StartPosition, tok.EndPosition, format, args...)
+}
+
+func (c *checker) reportRange(rule Rule, start, end token.Position, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Rule:     rule,
+		Severity: rule.Severity(),
+		Message:  fmt.Sprintf(format, args...),
+		Start:    start,
+		End:      end,
+	})
+}
+
+// Enter implements compiler.Observer.
+func (c *checker) Enter(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		c.statements(node.Statements())
+	case *ast.Block:
+		c.statements(node.Statements())
+	case *ast.Import:
+		if node.Alias() == nil {
+			c.unaliased[node.Name().Token()] = true
+		}
+	case *ast.FromImport:
+		for _, im := range node.Imports() {
+			if im.Alias() == nil {
+				c.unaliased[im.Name().Token()] = true
+			}
+		}
+	case *ast.Pipe:
+		// The piped value is passed as an extra argument to each stage
+		for _, expr := range node.Expressions()[1:] {
+			if call, ok := expr.(*ast.Call); ok {
+				c.pipeStages[call] = true
+			}
+		}
+	case *ast.String:
+		if len(node.TemplateExpressions()) > 0 {
+			c.templates = append(c.templates, node.Token())
+		}
+	}
+}
+
+// Leave implements compiler.Observer.
+func (c *checker) Leave(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.String:
+		if len(node.TemplateExpressions()) > 0 {
+			c.templates = c.templates[:len(c.templates)-1]
+		}
+	case *ast.Call:
+		c.call(node)
+	case *ast.Go:
+		if call, ok := node.Call().(*ast.Call); ok {
+			c.call(call)
+		}
+	case *ast.Defer:
+		if call, ok := node.Call().(*ast.Call); ok {
+			c.call(call)
+		}
+	}
+}
+
+// OpenScope implements compiler.Observer.
+func (c *checker) OpenScope(table *compiler.SymbolTable) {}
+
+// CloseScope implements compiler.Observer. It reports the names declared in
+// the scope that were never read. Variables declared at the top level of a
+// program are module attributes, so they are not reported.
+func (c *checker) CloseScope(table *compiler.SymbolTable) {
+	for _, b := range c.scopes[table] {
+		if b.used || b.name == "_" {
+			continue
+		}
+		switch {
+		case b.kind == compiler.ImportDeclaration:
+			c.report(UnusedImport, b.tok, "%s imported and not used", b.name)
+		case b.kind == compiler.VariableDeclaration && table.Parent() != nil:
+			c.report(UnusedVariable, b.tok, "%s declared and not used", b.name)
+		}
+	}
+	delete(c.scopes, table)
+}
+
+// Declare implements compiler.Observer.
+func (c *checker) Declare(decl *compiler.Declaration) {
+	if decl.Symbol == nil {
+		c.report(Redeclared, decl.Token, "%s redeclared in this scope", decl.Name)
+		return
+	}
+	b := &binding{name: decl.Name, kind: decl.Kind, tok: decl.Token, implicit: decl.Implicit}
+	if decl.Kind == compiler.FunctionDeclaration {
+		if decl.Implicit {
+			// The references to a function from within its own body are
+			// added to the function once it is declared
+			c.selfNames[decl.Token] = b
+			c.symbols[decl.Symbol] = b
+			return
+		}
+		if self, ok := c.selfNames[decl.Token]; ok {
+			b.refs = append(b.refs, self.refs...)
+			b.templates = append(b.templates, self.templates...)
+			delete(c.selfNames, decl.Token)
+		}
+	}
+	if decl.Kind != compiler.ParameterDeclaration && decl.Name != "_" {
+		c.shadow(decl)
+	}
+	b.refs = append(b.refs, decl.Token)
+	b.unaliased = decl.Kind == compiler.ImportDeclaration && c.unaliased[decl.Token]
+	c.bindings[decl.Symbol] = b
+	c.symbols[decl.Symbol] = b
+	c.declared = append(c.declared, b)
+	c.scopes[decl.Table] = append(c.scopes[decl.Table], b)
+}
+
+// shadow reports a declaration that hides a name the program declared in an
+// enclosing scope.
+func (c *checker) shadow(decl *compiler.Declaration) {
+	for table := decl.Table.Parent(); table != nil; table = table.Parent() {
+		sym, ok := table.Get(decl.Name)
+		if !ok {
+			continue
+		}
+		if outer := c.bindings[sym]; outer != nil {
+			pos := outer.tok.StartPosition
+			c.report(Shadow, decl.Token, "declaration of %s shadows %s declared at line %d:%d",
+				decl.Name, outer.kind, pos.LineNumber(), pos.ColumnNumber())
+		}
+		return
+	}
+}
+
+// Reference implements compiler.Observer. Within a template string, the
+// string is recorded instead of the identifier. Writing a name does not count
+// as using it.
+func (c *checker) Reference(ref *compiler.Reference) {
+	if ref.Symbol == nil {
+		c.report(Undefined, ref.Token, "%s is not defined", ref.Name)
+		return
+	}
+	if ident, ok := ref.Node.(*ast.Ident); ok {
+		c.resolved[ident] = ref.Symbol
+	}
+	b := c.symbols[ref.Symbol]
+	if b == nil {
+		// Any other symbol is a global
+		b = &binding{name: ref.Name, kind: kindGlobal}
+		c.symbols[ref.Symbol] = b
+		c.globals = append(c.globals, b)
+	}
+	if n := len(c.templates); n > 0 {
+		if m := len(b.templates); m == 0 || b.templates[m-1] != c.templates[n-1] {
+			b.templates = append(b.templates, c.templates[n-1])
+		}
+	} else {
+		b.refs = append(b.refs, ref.Token)
+	}
+	if !ref.Assign {
+		b.used = true
+		return
+	}
+	if ref.Symbol.IsConstant() {
+		kind := compiler.ConstantDeclaration
+		if declared := c.bindings[ref.Symbol]; declared != nil {
+			kind = declared.kind
+		}
+		c.report(ConstAssign, ref.Token, "cannot assign to %s %s", kind, ref.Name)
+	}
+}
+
+// statements checks a list of statements, reporting the first statement that
+// follows one that always transfers control elsewhere.
+func (c *checker) statements(statements []ast.Node) {
+	for i := 1; i < len(statements); i++ {
+		if !terminates(statements[i-1]) {
+			continue
+		}
+		stmt := statements[i]
+		end := stmt.Token().EndPosition
+		start := start(stmt)
+		if end.Char < start.Char {
+			end = start
+		}
+		c.reportRange(Unreachable, start, end, "unreachable code")
+		return
+	}
+}
+
+// call checks a function call. Unless the call is a stage of a pipe, which
+// receives an extra argument, calls to builtins have their arguments counted.
+func (c *checker) call(node *ast.Call) {
+	if c.pipeStages[node] {
+		return
+	}
+	ident, ok := node.Function().(*ast.Ident)
+	if !ok {
+		return
+	}
+	sym, ok := c.resolved[ident]
+	if !ok || c.bindings[sym] != nil {
+		return
+	}
+	// The number of arguments unpacked from a spread is unknown, and keyword
+	// arguments are checked when the call is made
+	args := node.Arguments()
+	for _, arg := range args {
+		switch arg.(type) {
+		case *ast.Spread, *ast.KeywordArg:
+			return
+		}
+	}
+	if a, ok := builtinArity[ident.Literal()]; ok {
+		if msg, ok := a.check(ident.Literal(), len(args)); !ok {
+			c.reportRange(ArgCount, start(node), node.Token().EndPosition, "%s", msg)
+		}
+	}
+}
+
+// sortedGlobals returns the globals the program refers to, sorted by name.
+func (c *checker) sortedGlobals() []*binding {
+	globals := append([]*binding(nil), c.globals...)
+	sort.Slice(globals, func(i, j int) bool {
+		return globals[i].name < globals[j].name
+	})
+	return globals
+}
+
+// terminates returns true if control never continues past the statement.
+func terminates(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.Return, *ast.Control:
+		return true
+	case *ast.Block:
+		statements := node.Statements()
+		return len(statements) > 0 && terminates(statements[len(statements)-1])
+	case *ast.If:
+		return node.Alternative() != nil &&
+			terminates(node.Consequence()) && terminates(node.Alternative())
+	}
+	return false
+}
+
+// start returns the position of the first character of a node. Operators
+// are the tokens of their nodes, so the start is found via the left operand.
+func start(node ast.Node) token.Position {
+	switch node := node.(type) {
+	case *ast.Infix:
+		return start(node.Left())
+	case *ast.Ternary:
+		return start(node.Condition())
+	case *ast.In:
+		return start(node.Left())
+	case *ast.Pipe:
+		return start(node.Expressions()[0])
+	case *ast.Call:
+		return start(node.Function())
+	case *ast.ObjectCall:
+		return start(node.Object())
+	case *ast.GetAttr:
+		return start(node.Object())
+	case *ast.OptionalChain:
+		return start(node.Expression())
+	case *ast.Index:
+		return start(node.Left())
+	case *ast.Slice:
+		return start(node.Left())
+	case *ast.SetAttr:
+		return start(node.Object())
+	case *ast.Send:
+		return start(node.Channel())
+	case *ast.Assign:
+		if node.Index() != nil {
+			return start(node.Index())
+		}
+		return node.Ident().Token().StartPosition
+	}
+	return node.Token().StartPosition
+}
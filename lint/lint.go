@@ -0,0 +1,229 @@
+// Package lint implements static checks for Risor programs.
+//
+// Programs are checked as they are compiled, by observing the compiler as it
+// declares and resolves names, so that a program is analyzed with exactly the
+// scoping rules it would be compiled with. Problems are reported as diagnostics, each one
+// produced by a named rule. Rules may be disabled by the caller, or by
+// comments in the source:
+//
+//	x := compute() // lint:ignore unused-variable
+//
+//	// lint:disable shadow
+//
+// A "lint:ignore" comment suppresses the listed rules on its own line and on
+// the line that follows it. A "lint:disable" comment suppresses the listed
+// rules in the whole file. When no rules are listed, all rules are suppressed.
+package lint
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+)
+
+// Rule identifies a check performed by the linter.
+type Rule string
+
+const (
+	// UnusedVariable reports local variables that are never read.
+	UnusedVariable Rule = "unused-variable"
+
+	// UnusedImport reports imported modules that are never referenced.
+	UnusedImport Rule = "unused-import"
+
+	// Shadow reports declarations that hide a name declared in an enclosing
+	// scope.
+	Shadow Rule = "shadow"
+
+	// ConstAssign reports assignments to constants, imports, functions, and
+	// structs.
+	ConstAssign Rule = "const-assign"
+
+	// Unreachable reports statements following a return, break, or continue.
+	Unreachable Rule = "unreachable"
+
+	// Undefined reports references to names that are not declared.
+	Undefined Rule = "undefined"
+
+	// Redeclared reports names declared twice in the same scope.
+	Redeclared Rule = "redeclared"
+
+	// ArgCount reports calls to builtin functions with the wrong number of
+	// arguments.
+	ArgCount Rule = "arg-count"
+)
+
+// Rules returns all rules known to the linter.
+func Rules() []Rule {
+	return []Rule{
+		UnusedVariable,
+		UnusedImport,
+		Shadow,
+		ConstAssign,
+		Unreachable,
+		Undefined,
+		Redeclared,
+		ArgCount,
+	}
+}
+
+// Severity indicates how serious a diagnostic is. Errors describe code that
+// fails to compile or is certain to fail when run.
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Severity returns the severity of the diagnostics produced by the rule.
+func (r Rule) Severity() Severity {
+	switch r {
+	case ConstAssign, Undefined, Redeclared, ArgCount:
+		return Error
+	default:
+		return Warning
+	}
+}
+
+// Diagnostic describes a problem found in a program.
+type Diagnostic struct {
+	Rule     Rule
+	Severity Severity
+	Message  string
+
+	// Start and End give the source range of the problem. End is the
+	// position of the last character in the range.
+	Start token.Position
+	End   token.Position
+}
+
+// Option is a configuration function for the linter.
+type Option func(*config)
+
+type config struct {
+	globalNames []string
+	disabled    map[Rule]bool
+}
+
+// WithGlobalNames declares the names of the globals that will be available
+// to the program when it runs. Any other name that the program references
+// without declaring it is reported as undefined.
+func WithGlobalNames(names []string) Option {
+	return func(cfg *config) {
+		cfg.globalNames = make([]string, len(names))
+		copy(cfg.globalNames, names)
+	}
+}
+
+// WithDisabledRules turns off the given rules.
+func WithDisabledRules(rules ...Rule) Option {
+	return func(cfg *config) {
+		for _, rule := range rules {
+			cfg.disabled[rule] = true
+		}
+	}
+}
+
+// Source parses and checks the given source code. An error is returned if
+// the source cannot be parsed.
+func Source(ctx context.Context, src string, opts ...Option) ([]Diagnostic, error) {
+	program, err := parser.Parse(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return Program(program, opts...), nil
+}
+
+// Program checks the given program and returns the problems found, ordered
+// by their position in the source.
+func Program(program *ast.Program, opts ...Option) []Diagnostic {
+	cfg := &config{disabled: map[Rule]bool{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	c := newChecker()
+	c.check(program, cfg.globalNames)
+
+	dirs := parseDirectives(program.Comments())
+	var result []Diagnostic
+	for _, d := range c.diagnostics {
+		if cfg.disabled[d.Rule] || dirs.suppresses(d) {
+			continue
+		}
+		result = append(result, d)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Start, result[j].Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return result
+}
+
+// directives holds the rules suppressed by comments in a program. The rule
+// "all" stands for every rule.
+type directives struct {
+	file  map[Rule]bool
+	lines map[int]map[Rule]bool
+}
+
+func parseDirectives(comments []*ast.Comment) *directives {
+	dirs := &directives{file: map[Rule]bool{}, lines: map[int]map[Rule]bool{}}
+	for _, comment := range comments {
+		text := comment.Text()
+		if comment.IsBlock() {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		} else if strings.HasPrefix(text, "//") {
+			text = text[2:]
+		} else {
+			text = strings.TrimPrefix(text, "#")
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		rules := map[Rule]bool{}
+		for _, field := range fields[1:] {
+			for _, name := range strings.Split(field, ",") {
+				if name != "" {
+					rules[Rule(name)] = true
+				}
+			}
+		}
+		if len(rules) == 0 {
+			rules["all"] = true
+		}
+		switch fields[0] {
+		case "lint:disable":
+			for rule := range rules {
+				dirs.file[rule] = true
+			}
+		case "lint:ignore":
+			line := comment.Token().StartPosition.Line
+			for _, l := range []int{line, line + 1} {
+				if dirs.lines[l] == nil {
+					dirs.lines[l] = map[Rule]bool{}
+				}
+				for rule := range rules {
+					dirs.lines[l][rule] = true
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+func (dirs *directives) suppresses(d Diagnostic) bool {
+	if dirs.file["all"] || dirs.file[d.Rule] {
+		return true
+	}
+	rules := dirs.lines[d.Start.Line]
+	return rules["all"] || rules[d.Rule]
+}
@@ -0,0 +1,339 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/builtins"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func globalNames() []string {
+	var names []string
+	for name := range builtins.Builtins() {
+		names = append(names, name)
+	}
+	return append(names, "print")
+}
+
+// check lints the source and returns each diagnostic formatted as
+// "line:column rule: message".
+func check(t *testing.T, src string, opts ...Option) []string {
+	t.Helper()
+	opts = append([]Option{WithGlobalNames(globalNames())}, opts...)
+	diagnostics, err := Source(context.Background(), src, opts...)
+	require.Nil(t, err)
+	var result []string
+	for _, d := range diagnostics {
+		result = append(result, fmt.Sprintf("%d:%d %s: %s",
+			d.Start.LineNumber(), d.Start.ColumnNumber(), d.Rule, d.Message))
+	}
+	return result
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "clean",
+			input: "import os\nx := 1\nfunc f(a) { y := a + x; return y }\nprint(f(2), os)",
+		},
+		{
+			name:  "unused variable",
+			input: "func f() {\n  x := 1\n  y := 2\n  y = 3\n  a, _ := [1, 2]\n}",
+			want: []string{
+				"2:3 unused-variable: x declared and not used",
+				"3:3 unused-variable: y declared and not used",
+				"5:3 unused-variable: a declared and not used",
+			},
+		},
+		{
+			name:  "globals and closures",
+			input: "x := 1\nfunc f() {\n  y := 1\n  return func() { return y }\n}",
+		},
+		{
+			name:  "unused range variable",
+			input: "func f(m) {\n  for k, v := range m { print(v) }\n}",
+			want:  []string{"2:7 unused-variable: k declared and not used"},
+		},
+		{
+			name:  "unused import",
+			input: "import os\nimport json as j\nfrom a.b import c, d as e\nimport len\nprint(c)",
+			want: []string{
+				"1:8 unused-import: os imported and not used",
+				"2:16 unused-import: j imported and not used",
+				"3:25 unused-import: e imported and not used",
+				"4:8 unused-import: len imported and not used",
+			},
+		},
+		{
+			name:  "shadow",
+			input: "x := 1\nfunc f(x) {\n  if x {\n    x := 2\n    print(x)\n  }\n}",
+			want:  []string{"4:5 shadow: declaration of x shadows parameter declared at line 2:8"},
+		},
+		{
+			name:  "const assign",
+			input: "const a = 1\na = 2\nimport os\nos = 1\nfunc f() {}\nf++\nstruct P { x }\nP, b = [1, 2]",
+			want: []string{
+				"2:1 const-assign: cannot assign to constant a",
+				"3:8 unused-import: os imported and not used",
+				"4:1 const-assign: cannot assign to import os",
+				"6:1 const-assign: cannot assign to function f",
+				"8:1 const-assign: cannot assign to struct P",
+				"8:4 undefined: b is not defined",
+			},
+		},
+		{
+			name:  "unreachable",
+			input: "func f(x) {\n  if x { return 1 } else { return 2 }\n  print(x)\n  print(x)\n}\nfor {\n  break\n  print(1)\n}",
+			want: []string{
+				"3:3 unreachable: unreachable code",
+				"8:3 unreachable: unreachable code",
+			},
+		},
+		{
+			name:  "undefined",
+			input: "x := y + 1\nfunc f() { return g() }\nfunc g() {}\nprint(x.z, {a: 1})",
+			want: []string{
+				"1:6 undefined: y is not defined",
+				"2:19 undefined: g is not defined",
+			},
+		},
+		{
+			name:  "undefined in template",
+			input: "x := 1\nprint('{x} {y}')",
+			want:  []string{"2:7 undefined: y is not defined"},
+		},
+		{
+			name:  "redeclared",
+			input: "x := 1\nx := 2\nlen := 3",
+			want: []string{
+				"2:1 redeclared: x redeclared in this scope",
+				"3:1 redeclared: len redeclared in this scope",
+			},
+		},
+		{
+			name:  "arg count",
+			input: "len()\nlen([1], 2)\ngetattr(1)\nsprintf()\nint(1, 2)\nprint(1, 2, 3)\n[1] | len\n[1] | sorted(func(a, b) { return a < b })\nlen2 := func(a, b) {}\nlen2(1)",
+			want: []string{
+				"1:1 arg-count: len takes 1 argument (0 given)",
+				"2:1 arg-count: len takes 1 argument (2 given)",
+				"3:1 arg-count: getattr takes 2 or 3 arguments (1 given)",
+				"4:1 arg-count: sprintf takes at least 1 argument (0 given)",
+				"5:1 arg-count: int takes at most 1 argument (2 given)",
+			},
+		},
+		{
+			name:  "variadics",
+			input: "func log(level, ...parts) { print(level, ...parts) }\nargs := [1]\nlen(...args)\nlog(**opts)",
+			want:  []string{"4:7 undefined: opts is not defined"},
+		},
+		{
+			name:  "keyword arguments",
+			input: "func f(a, b=1) { return a + b }\nf(1, b=c)\nlen(x=1)",
+			want:  []string{"2:8 undefined: c is not defined"},
+		},
+		{
+			name:  "generators",
+			input: "func gen(n) {\n  x := n * 2\n  yield x\n  yield y\n  yield\n  print(n)\n}",
+			want:  []string{"4:9 undefined: y is not defined"},
+		},
+		{
+			name:  "match",
+			input: "func f(v) {\n  return match v {\n  case [a, b] if a > limit: a\n  case {\"kind\": k}, [k]: k\n  case int(n), http.OK: 1\n  }\n}",
+			want: []string{
+				"3:12 unused-variable: b declared and not used",
+				"3:22 undefined: limit is not defined",
+				"5:12 unused-variable: n declared and not used",
+				"5:16 undefined: http is not defined",
+			},
+		},
+		{
+			name:  "comprehensions",
+			input: "func f(m) {\n  a := [x for x, y in m if x > limit]\n  print(x)\n  return {k: a for k, v in m}\n}",
+			want: []string{
+				"2:18 unused-variable: y declared and not used",
+				"2:32 undefined: limit is not defined",
+				"3:9 undefined: x is not defined",
+				"4:23 unused-variable: v declared and not used",
+			},
+		},
+		{
+			name:  "optional chaining",
+			input: "func f(r) { return r?.data[key]?.get(x) ?? fallback }",
+			want: []string{
+				"1:28 undefined: key is not defined",
+				"1:38 undefined: x is not defined",
+				"1:44 undefined: fallback is not defined",
+			},
+		},
+		{
+			name:  "select",
+			input: "func f(a, b) {\n  return select {\n  case v, ok := <-a: v\n  case b <- x: 1\n  case <-done:\n  }\n}",
+			want: []string{
+				"3:11 unused-variable: ok declared and not used",
+				"4:13 undefined: x is not defined",
+				"5:10 undefined: done is not defined",
+			},
+		},
+		{
+			name:  "labeled loops",
+			input: "func f(rows) {\n  outer: for _, row := range rows {\n    for x := range row { continue outer }\n  }\n}",
+			want: []string{
+				"3:9 unused-variable: x declared and not used",
+			},
+		},
+		{
+			name:  "decorators",
+			input: "func timed(fn) { return fn }\n@timed\n@retry(3)\nfunc f(x) { return f(x) }",
+			want: []string{
+				"3:2 undefined: retry is not defined",
+			},
+		},
+		{
+			name:  "enums",
+			input: "enum Color { RED }\nprint(Color.RED, Colour.RED)",
+			want: []string{
+				"2:18 undefined: Colour is not defined",
+			},
+		},
+		{
+			name:  "strings and structs",
+			input: "func f(name) {\n  greeting := 'hello {name}'\n  return greeting\n}\nstruct S {\n  x = 1\n  func get() { return self.x }\n}\nprint(f, S)",
+		},
+		{
+			name:  "try",
+			input: "func f() {\n  try {\n    x := 1\n  } catch e {\n    y := 2\n  }\n}",
+			want: []string{
+				"3:5 unused-variable: x declared and not used",
+				"5:5 unused-variable: y declared and not used",
+			},
+		},
+		{
+			name:  "finally",
+			input: "func f(x) {\n  try {\n    if x { return 1 }\n  } finally {\n    y := 2\n  }\n}",
+			want:  []string{"5:5 unused-variable: y declared and not used"},
+		},
+		{
+			name:  "after a compile error",
+			input: "defer print(1)\nprint(y)",
+			want:  []string{"2:7 undefined: y is not defined"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, check(t, tt.input))
+		})
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	src := `func f() {
+  a := 1 // lint:ignore unused-variable
+  // lint:ignore
+  b := 2
+  # lint:ignore shadow
+  c := 3
+  d := undefined_name /* lint:ignore undefined,unused-variable */
+}`
+	require.Equal(t, []string{"6:3 unused-variable: c declared and not used"}, check(t, src))
+
+	src = "// lint:disable unused-variable\nfunc f() { x := 1; y := z }"
+	require.Equal(t, []string{"2:25 undefined: z is not defined"}, check(t, src))
+}
+
+func TestDisabledRules(t *testing.T) {
+	src := "func f() { x := 1; y := z }"
+	got := check(t, src, WithDisabledRules(UnusedVariable))
+	require.Equal(t, []string{"1:25 undefined: z is not defined"}, got)
+}
+
+func TestSeverity(t *testing.T) {
+	diagnostics, err := Source(context.Background(), "func f() { x := y }")
+	require.Nil(t, err)
+	require.Len(t, diagnostics, 2)
+	require.Equal(t, UnusedVariable, diagnostics[0].Rule)
+	require.Equal(t, Warning, diagnostics[0].Severity)
+	require.Equal(t, Undefined, diagnostics[1].Rule)
+	require.Equal(t, Error, diagnostics[1].Severity)
+}
+
+func TestSourceError(t *testing.T) {
+	_, err := Source(context.Background(), "x := (")
+	require.NotNil(t, err)
+}
+
+func TestBuiltinArity(t *testing.T) {
+	// Every builtin with a known arity must exist
+	known := builtins.Builtins()
+	var names []string
+	for name := range builtinArity {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, ok := known[name]
+		require.True(t, ok, name)
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	src := `import os
+import json as j
+x := 1
+func f(x) {
+  y := x + len(x)
+  return f(y)
+}
+struct P {
+  func get() { return self }
+}
+if true {
+  x := 2
+  print(x, j)
+}
+x = f(x)`
+	program, err := parser.Parse(context.Background(), src)
+	require.Nil(t, err)
+	var got []string
+	for _, sym := range Symbols(program, WithGlobalNames(globalNames())) {
+		var refs []string
+		for _, tok := range sym.References {
+			refs = append(refs, fmt.Sprintf("%d:%d", tok.StartPosition.LineNumber(), tok.StartPosition.ColumnNumber()))
+		}
+		got = append(got, fmt.Sprintf("%s %s %s", sym.Kind, sym.Name, strings.Join(refs, " ")))
+	}
+	require.Equal(t, []string{
+		"global len 5:12",
+		"global print 13:3",
+		"import os 1:8",
+		"import j 2:16 13:12",
+		"variable x 3:1 15:1 15:7",
+		"parameter x 4:8 5:8 5:16",
+		"variable y 5:3 6:12",
+		"function f 4:6 6:10 15:5",
+		"struct P 8:8",
+		"variable x 12:3 13:9",
+	}, got)
+
+	symbols := Symbols(program, WithGlobalNames(globalNames()))
+	require.True(t, symbols[2].Unaliased)
+	require.False(t, symbols[3].Unaliased)
+
+	// Identifiers in templates are known only by the string they appear in
+	program, err = parser.Parse(context.Background(), "x := 1\nprint('{x} and {x + 1}', `{x}`)")
+	require.Nil(t, err)
+	symbols = Symbols(program)
+	require.Len(t, symbols, 1)
+	require.Len(t, symbols[0].References, 1)
+	require.Len(t, symbols[0].Templates, 1)
+	require.Equal(t, 1, symbols[0].Templates[0].StartPosition.Line)
+	require.Equal(t, 6, symbols[0].Templates[0].StartPosition.Column)
+}
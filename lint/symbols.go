@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"sort"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/token"
+)
+
+// Symbol is a name declared by a program, or a global the program refers to,
+// along with every identifier that refers to it. Names are resolved with the
+// compiler's scoping rules, so two variables with the same name in different
+// scopes are different symbols.
+type Symbol struct {
+	Name string
+	// Kind is what introduced the name: "variable", "parameter", "constant",
+	// "function", "struct", "enum", "import", or "global".
+	Kind string
+	// Declaration is the identifier that declares the name. It is the zero
+	// token for globals.
+	Declaration token.Token
+	// Unaliased is set for imports without an alias. The declaring identifier
+	// is then also the name of the module or attribute being imported.
+	Unaliased bool
+	// References holds the identifiers that refer to the symbol in source
+	// order, including its declaration.
+	References []token.Token
+	// Templates holds the template strings whose expressions refer to the
+	// symbol. Identifiers within templates are parsed separately from the
+	// rest of the program, so their positions are not known.
+	Templates []token.Token
+}
+
+// Symbols returns the globals the program refers to, followed by the symbols
+// the program declares in the order they are declared. Undefined names are
+// not included.
+func Symbols(program *ast.Program, opts ...Option) []*Symbol {
+	cfg := &config{disabled: map[Rule]bool{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	c := newChecker()
+	c.check(program, cfg.globalNames)
+
+	var result []*Symbol
+	for _, b := range append(c.sortedGlobals(), c.declared...) {
+		if b.implicit || (len(b.refs) == 0 && len(b.templates) == 0) {
+			continue
+		}
+		sym := &Symbol{
+			Name:       b.name,
+			Kind:       string(b.kind),
+			Unaliased:  b.unaliased,
+			References: append([]token.Token(nil), b.refs...),
+			Templates:  append([]token.Token(nil), b.templates...),
+		}
+		if b.kind != kindGlobal {
+			sym.Declaration = b.tok
+		}
+		sortTokens(sym.References)
+		sortTokens(sym.Templates)
+		result = append(result, sym)
+	}
+	return result
+}
+
+func sortTokens(tokens []token.Token) {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].StartPosition.Char < tokens[j].StartPosition.Char
+	})
+}
@@ -75,9 +75,9 @@ func LogError(ctx context.Context, args ...object.Object) object.Object {
 }
 
 //risor:export start_group
-func startGroup(ctx context.Context, msg string) object.Object {
+func startGroup(ctx context.Context, name string) object.Object {
 	stdout := os.GetDefaultOS(ctx).Stdout()
-	return runWorkflowCommand(stdout, "group", msg, nil)
+	return runWorkflowCommand(stdout, "group", name, nil)
 }
 
 //risor:export end_group
@@ -87,51 +87,51 @@ func endGroup(ctx context.Context) object.Object {
 }
 
 //risor:export set_output
-func setOutput(ctx context.Context, key string, value object.Object) object.Object {
+func setOutput(ctx context.Context, name string, value object.Object) object.Object {
 	printableValue := printableValue(value)
 
 	ros := os.GetDefaultOS(ctx)
 	outputFile := ros.Getenv("GITHUB_OUTPUT")
 	if outputFile != "" {
-		return appendWorkflowFile(ros, outputFile, workflowFileKeyValue(key, printableValue))
+		return appendWorkflowFile(ros, outputFile, workflowFileKeyValue(name, printableValue))
 	}
 
 	stdout := ros.Stdout()
 	// Using "::set-output::" command is deprecated, but it's a good enough fallback
-	return runWorkflowCommand(stdout, "set-output", value, map[string]any{"name": key})
+	return runWorkflowCommand(stdout, "set-output", value, map[string]any{"name": name})
 }
 
 //risor:export set_env
-func setEnv(ctx context.Context, key string, value object.Object) object.Object {
+func setEnv(ctx context.Context, name string, value object.Object) object.Object {
 	valueStr := fmt.Sprint(printableValue(value))
 
 	ros := os.GetDefaultOS(ctx)
-	ros.Setenv(key, valueStr)
+	ros.Setenv(name, valueStr)
 
 	envFile := ros.Getenv("GITHUB_ENV")
 	if envFile != "" {
-		return appendWorkflowFile(ros, envFile, workflowFileKeyValue(key, valueStr))
+		return appendWorkflowFile(ros, envFile, workflowFileKeyValue(name, valueStr))
 	}
 
 	stdout := ros.Stdout()
 	// Using "::set-env::" command is deprecated, but it's a good enough fallback
-	return runWorkflowCommand(stdout, "set-env", value, map[string]any{"name": key})
+	return runWorkflowCommand(stdout, "set-env", value, map[string]any{"name": name})
 }
 
 //risor:export add_path
-func addPath(ctx context.Context, path string) object.Object {
+func addPath(ctx context.Context, dir string) object.Object {
 	ros := os.GetDefaultOS(ctx)
 	oldPath := ros.Getenv("PATH")
-	ros.Setenv("PATH", fmt.Sprintf("%s%c%s", path, ros.PathListSeparator(), oldPath))
+	ros.Setenv("PATH", fmt.Sprintf("%s%c%s", dir, ros.PathListSeparator(), oldPath))
 
 	pathFile := ros.Getenv("GITHUB_PATH")
 	if pathFile != "" {
-		return appendWorkflowFile(ros, pathFile, path)
+		return appendWorkflowFile(ros, pathFile, dir)
 	}
 
 	stdout := ros.Stdout()
 	// Using "::add-path::" command is deprecated, but it's a good enough fallback
-	return runWorkflowCommand(stdout, "add-path", path, nil)
+	return runWorkflowCommand(stdout, "add-path", dir, nil)
 }
 
 func Module() *object.Module {
@@ -34,11 +34,11 @@ func StartGroup(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewArgsError("gha.start_group", 1, len(args))
 	}
-	msgParam, err := object.AsString(args[0])
+	nameParam, err := object.AsString(args[0])
 	if err != nil {
 		return err
 	}
-	result := startGroup(ctx, msgParam)
+	result := startGroup(ctx, nameParam)
 	return result
 }
 
@@ -58,12 +58,12 @@ func SetOutput(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return object.NewArgsError("gha.set_output", 2, len(args))
 	}
-	keyParam, err := object.AsString(args[0])
+	nameParam, err := object.AsString(args[0])
 	if err != nil {
 		return err
 	}
 	valueParam := args[1]
-	result := setOutput(ctx, keyParam, valueParam)
+	result := setOutput(ctx, nameParam, valueParam)
 	return result
 }
 
@@ -73,12 +73,12 @@ func SetEnv(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return object.NewArgsError("gha.set_env", 2, len(args))
 	}
-	keyParam, err := object.AsString(args[0])
+	nameParam, err := object.AsString(args[0])
 	if err != nil {
 		return err
 	}
 	valueParam := args[1]
-	result := setEnv(ctx, keyParam, valueParam)
+	result := setEnv(ctx, nameParam, valueParam)
 	return result
 }
 
@@ -88,24 +88,25 @@ func AddPath(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewArgsError("gha.add_path", 1, len(args))
 	}
-	pathParam, err := object.AsString(args[0])
+	dirParam, err := object.AsString(args[0])
 	if err != nil {
 		return err
 	}
-	result := addPath(ctx, pathParam)
+	result := addPath(ctx, dirParam)
 	return result
 }
 
 // addGeneratedBuiltins adds the generated builtin wrappers to the given map.
+// Their arguments may also be passed by keyword, using the parameter names.
 //
 // Useful if you want to write your own "Module()" function.
 func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.Object {
-	builtins["is_debug"] = object.NewBuiltin("is_debug", IsDebug)
-	builtins["log_debug"] = object.NewBuiltin("log_debug", LogDebug)
-	builtins["start_group"] = object.NewBuiltin("start_group", StartGroup)
-	builtins["end_group"] = object.NewBuiltin("end_group", EndGroup)
-	builtins["set_output"] = object.NewBuiltin("set_output", SetOutput)
-	builtins["set_env"] = object.NewBuiltin("set_env", SetEnv)
-	builtins["add_path"] = object.NewBuiltin("add_path", AddPath)
+	builtins["is_debug"] = object.NewBuiltinWithParams("is_debug", IsDebug, []string{})
+	builtins["log_debug"] = object.NewBuiltinWithParams("log_debug", LogDebug, []string{"msg"})
+	builtins["start_group"] = object.NewBuiltinWithParams("start_group", StartGroup, []string{"name"})
+	builtins["end_group"] = object.NewBuiltinWithParams("end_group", EndGroup, []string{})
+	builtins["set_output"] = object.NewBuiltinWithParams("set_output", SetOutput, []string{"name", "value"})
+	builtins["set_env"] = object.NewBuiltinWithParams("set_env", SetEnv, []string{"name", "value"})
+	builtins["add_path"] = object.NewBuiltinWithParams("add_path", AddPath, []string{"dir"})
 	return builtins
 }
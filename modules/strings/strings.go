@@ -27,8 +27,8 @@ func count(s, substr string) int {
 }
 
 //risor:export
-func compare(a, b string) int {
-	return strings.Compare(a, b)
+func compare(s1, s2 string) int {
+	return strings.Compare(s1, s2)
 }
 
 //risor:export
@@ -37,8 +37,8 @@ func repeat(s string, count int) string {
 }
 
 //risor:export
-func join(list []string, sep string) string {
-	return strings.Join(list, sep)
+func join(a []string, sep string) string {
+	return strings.Join(a, sep)
 }
 
 //risor:export
@@ -87,8 +87,8 @@ func trimPrefix(s, prefix string) string {
 }
 
 //risor:export trim_suffix
-func trimSuffix(s, prefix string) string {
-	return strings.TrimSuffix(s, prefix)
+func trimSuffix(s, suffix string) string {
+	return strings.TrimSuffix(s, suffix)
 }
 
 //risor:export trim_space
@@ -86,15 +86,15 @@ func Compare(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return object.NewArgsError("strings.compare", 2, len(args))
 	}
-	aParam, err := object.AsString(args[0])
+	s1Param, err := object.AsString(args[0])
 	if err != nil {
 		return err
 	}
-	bParam, err := object.AsString(args[1])
+	s2Param, err := object.AsString(args[1])
 	if err != nil {
 		return err
 	}
-	result := compare(aParam, bParam)
+	result := compare(s1Param, s2Param)
 	return object.NewInt(int64(result))
 }
 
@@ -129,7 +129,7 @@ func Join(ctx context.Context, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return object.NewArgsError("strings.join", 2, len(args))
 	}
-	listParam, err := object.AsStringSlice(args[0])
+	aParam, err := object.AsStringSlice(args[0])
 	if err != nil {
 		return err
 	}
@@ -137,7 +137,7 @@ func Join(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	result := join(listParam, sepParam)
+	result := join(aParam, sepParam)
 	return object.NewString(result)
 }
 
@@ -305,11 +305,11 @@ func TrimSuffix(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	prefixParam, err := object.AsString(args[1])
+	suffixParam, err := object.AsString(args[1])
 	if err != nil {
 		return err
 	}
-	result := trimSuffix(sParam, prefixParam)
+	result := trimSuffix(sParam, suffixParam)
 	return object.NewString(result)
 }
 
@@ -328,27 +328,28 @@ func TrimSpace(ctx context.Context, args ...object.Object) object.Object {
 }
 
 // addGeneratedBuiltins adds the generated builtin wrappers to the given map.
+// Their arguments may also be passed by keyword, using the parameter names.
 //
 // Useful if you want to write your own "Module()" function.
 func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.Object {
-	builtins["contains"] = object.NewBuiltin("contains", Contains)
-	builtins["has_prefix"] = object.NewBuiltin("has_prefix", HasPrefix)
-	builtins["has_suffix"] = object.NewBuiltin("has_suffix", HasSuffix)
-	builtins["count"] = object.NewBuiltin("count", Count)
-	builtins["compare"] = object.NewBuiltin("compare", Compare)
-	builtins["repeat"] = object.NewBuiltin("repeat", Repeat)
-	builtins["join"] = object.NewBuiltin("join", Join)
-	builtins["split"] = object.NewBuiltin("split", Split)
-	builtins["fields"] = object.NewBuiltin("fields", Fields)
-	builtins["index"] = object.NewBuiltin("index", Index)
-	builtins["last_index"] = object.NewBuiltin("last_index", LastIndex)
-	builtins["replace_all"] = object.NewBuiltin("replace_all", ReplaceAll)
-	builtins["to_lower"] = object.NewBuiltin("to_lower", ToLower)
-	builtins["to_upper"] = object.NewBuiltin("to_upper", ToUpper)
-	builtins["trim"] = object.NewBuiltin("trim", Trim)
-	builtins["trim_prefix"] = object.NewBuiltin("trim_prefix", TrimPrefix)
-	builtins["trim_suffix"] = object.NewBuiltin("trim_suffix", TrimSuffix)
-	builtins["trim_space"] = object.NewBuiltin("trim_space", TrimSpace)
+	builtins["contains"] = object.NewBuiltinWithParams("contains", Contains, []string{"s", "substr"})
+	builtins["has_prefix"] = object.NewBuiltinWithParams("has_prefix", HasPrefix, []string{"s", "prefix"})
+	builtins["has_suffix"] = object.NewBuiltinWithParams("has_suffix", HasSuffix, []string{"s", "suffix"})
+	builtins["count"] = object.NewBuiltinWithParams("count", Count, []string{"s", "substr"})
+	builtins["compare"] = object.NewBuiltinWithParams("compare", Compare, []string{"s1", "s2"})
+	builtins["repeat"] = object.NewBuiltinWithParams("repeat", Repeat, []string{"s", "count"})
+	builtins["join"] = object.NewBuiltinWithParams("join", Join, []string{"a", "sep"})
+	builtins["split"] = object.NewBuiltinWithParams("split", Split, []string{"s", "sep"})
+	builtins["fields"] = object.NewBuiltinWithParams("fields", Fields, []string{"s"})
+	builtins["index"] = object.NewBuiltinWithParams("index", Index, []string{"s", "substr"})
+	builtins["last_index"] = object.NewBuiltinWithParams("last_index", LastIndex, []string{"s", "substr"})
+	builtins["replace_all"] = object.NewBuiltinWithParams("replace_all", ReplaceAll, []string{"s", "old", "new"})
+	builtins["to_lower"] = object.NewBuiltinWithParams("to_lower", ToLower, []string{"s"})
+	builtins["to_upper"] = object.NewBuiltinWithParams("to_upper", ToUpper, []string{"s"})
+	builtins["trim"] = object.NewBuiltinWithParams("trim", Trim, []string{"s", "cutset"})
+	builtins["trim_prefix"] = object.NewBuiltinWithParams("trim_prefix", TrimPrefix, []string{"s", "prefix"})
+	builtins["trim_suffix"] = object.NewBuiltinWithParams("trim_suffix", TrimSuffix, []string{"s", "suffix"})
+	builtins["trim_space"] = object.NewBuiltinWithParams("trim_space", TrimSpace, []string{"s"})
 	return builtins
 }
 
@@ -0,0 +1,92 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/risor-io/risor/object"
+)
+
+// Diff describes the difference between an expected and an actual value.
+// Lists, maps, and multi-line strings of the same type are compared line by
+// line, with removed lines prefixed by "-" and added lines by "+".
+func Diff(expected, actual object.Object) string {
+	if expected.Type() == actual.Type() {
+		expectedLines := diffLines(expected)
+		actualLines := diffLines(actual)
+		if len(expectedLines) > 1 || len(actualLines) > 1 {
+			lines := []string{"  --- expected", "  +++ actual"}
+			for _, line := range lineDiff(expectedLines, actualLines) {
+				lines = append(lines, "  "+line)
+			}
+			return strings.Join(lines, "\n")
+		}
+	}
+	return fmt.Sprintf("  expected: %s\n  actual:   %s", expected.Inspect(), actual.Inspect())
+}
+
+func diffLines(obj object.Object) []string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return strings.Split(obj.Value(), "\n")
+	case *object.List:
+		items := obj.Value()
+		lines := make([]string, 0, len(items))
+		for _, item := range items {
+			lines = append(lines, item.Inspect())
+		}
+		return lines
+	case *object.Map:
+		items := obj.Value()
+		keys := obj.SortedKeys()
+		lines := make([]string, 0, len(keys))
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("%q: %s", key, items[key].Inspect()))
+		}
+		return lines
+	default:
+		return []string{obj.Inspect()}
+	}
+}
+
+// lineDiff returns a line diff of a and b based on their longest common
+// subsequence of lines.
+func lineDiff(a, b []string) []string {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var result []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "- "+a[i])
+			i++
+		default:
+			result = append(result, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		result = append(result, "+ "+b[j])
+	}
+	return result
+}
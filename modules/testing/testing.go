@@ -0,0 +1,181 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/risor-io/risor/arg"
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+)
+
+// AssertionError is raised when a testing assertion fails.
+type AssertionError struct {
+	Message string
+}
+
+func (e *AssertionError) Error() string {
+	return e.Message
+}
+
+// SkipError is raised by skip() to stop a test and mark it as skipped. It is
+// fatal so that try statements in the test do not swallow it.
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	if e.Reason == "" {
+		return "skipped"
+	}
+	return "skipped: " + e.Reason
+}
+
+func (e *SkipError) IsFatal() bool {
+	return true
+}
+
+var _ errz.Error = (*SkipError)(nil)
+
+func assertionFailed(args []object.Object, format string, a ...any) *object.Error {
+	message := fmt.Sprintf(format, a...)
+	if len(args) > 0 {
+		message = describe(args[0]) + ": " + message
+	}
+	return object.NewError(&AssertionError{Message: message})
+}
+
+// describe returns a string as-is and any other object in its inspected form.
+func describe(obj object.Object) string {
+	if s, ok := obj.(*object.String); ok {
+		return s.Value()
+	}
+	return obj.Inspect()
+}
+
+func AssertEq(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.assert_eq", 2, 3, args); err != nil {
+		return err
+	}
+	actual, expected := args[0], args[1]
+	if actual.Equals(expected).IsTruthy() {
+		return object.Nil
+	}
+	return assertionFailed(args[2:], "values are not equal\n%s", Diff(expected, actual))
+}
+
+func AssertNe(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.assert_ne", 2, 3, args); err != nil {
+		return err
+	}
+	if !args[0].Equals(args[1]).IsTruthy() {
+		return object.Nil
+	}
+	return assertionFailed(args[2:], "values are equal: %s", args[0].Inspect())
+}
+
+func AssertTrue(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.assert_true", 1, 2, args); err != nil {
+		return err
+	}
+	if args[0].IsTruthy() {
+		return object.Nil
+	}
+	return assertionFailed(args[1:], "expected a truthy value (got %s)", args[0].Inspect())
+}
+
+func AssertFalse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.assert_false", 1, 2, args); err != nil {
+		return err
+	}
+	if !args[0].IsTruthy() {
+		return object.Nil
+	}
+	return assertionFailed(args[1:], "expected a falsy value (got %s)", args[0].Inspect())
+}
+
+func AssertRaises(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.assert_raises", 1, 2, args); err != nil {
+		return err
+	}
+	var wantMessage string
+	if len(args) == 2 {
+		msg, err := object.AsString(args[1])
+		if err != nil {
+			return err
+		}
+		wantMessage = msg
+	}
+	var callErr error
+	switch fn := args[0].(type) {
+	case *object.Function:
+		callFunc, found := object.GetCallFunc(ctx)
+		if !found {
+			return object.EvalErrorf("eval error: context did not contain a call function")
+		}
+		_, callErr = callFunc(ctx, fn, nil)
+	case object.Callable:
+		result := fn.Call(ctx)
+		if err, ok := result.(*object.Error); ok && err.IsRaised() {
+			callErr = err.Value()
+		}
+	default:
+		return object.TypeErrorf("type error: testing.assert_raises() expected a callable (%s given)",
+			args[0].Type())
+	}
+	if callErr == nil {
+		return assertionFailed(nil, "expected an error to be raised")
+	}
+	var skipErr *SkipError
+	if errors.As(callErr, &skipErr) {
+		return object.NewError(callErr)
+	}
+	if wantMessage != "" && !strings.Contains(callErr.Error(), wantMessage) {
+		return assertionFailed(nil, "expected an error containing %q (got %q)",
+			wantMessage, callErr.Error())
+	}
+	return object.NewError(callErr).WithRaised(false)
+}
+
+func Fail(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.fail", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return object.NewError(&AssertionError{Message: "test failed"})
+	}
+	msg, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewError(&AssertionError{Message: msg})
+}
+
+func Skip(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("testing.skip", 0, 1, args); err != nil {
+		return err
+	}
+	var reason string
+	if len(args) == 1 {
+		msg, err := object.AsString(args[0])
+		if err != nil {
+			return err
+		}
+		reason = msg
+	}
+	return object.NewError(&SkipError{Reason: reason})
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("testing", map[string]object.Object{
+		"assert_eq":     object.NewBuiltin("assert_eq", AssertEq),
+		"assert_ne":     object.NewBuiltin("assert_ne", AssertNe),
+		"assert_true":   object.NewBuiltin("assert_true", AssertTrue),
+		"assert_false":  object.NewBuiltin("assert_false", AssertFalse),
+		"assert_raises": object.NewBuiltin("assert_raises", AssertRaises),
+		"fail":          object.NewBuiltin("fail", Fail),
+		"skip":          object.NewBuiltin("skip", Skip),
+	})
+}
@@ -0,0 +1,87 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertEq(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.Nil, AssertEq(ctx, object.NewInt(1), object.NewInt(1)))
+
+	result := AssertEq(ctx, object.NewInt(1), object.NewInt(2), object.NewString("sum"))
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.True(t, errObj.IsRaised())
+	require.Equal(t, "sum: values are not equal\n  expected: 2\n  actual:   1", errObj.Value().Error())
+	var assertErr *AssertionError
+	require.True(t, errors.As(errObj.Value(), &assertErr))
+}
+
+func TestAssertEqDiff(t *testing.T) {
+	ctx := context.Background()
+	actual := object.NewList([]object.Object{object.NewInt(1), object.NewInt(3), object.NewInt(4)})
+	expected := object.NewList([]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(4)})
+	result := AssertEq(ctx, actual, expected).(*object.Error)
+	require.Equal(t, `values are not equal
+  --- expected
+  +++ actual
+    1
+  - 2
+  + 3
+    4`, result.Value().Error())
+
+	actualMap := object.NewMap(map[string]object.Object{"a": object.NewInt(1), "b": object.NewString("x")})
+	expectedMap := object.NewMap(map[string]object.Object{"a": object.NewInt(1), "b": object.NewString("y")})
+	result = AssertEq(ctx, actualMap, expectedMap).(*object.Error)
+	require.Equal(t, `values are not equal
+  --- expected
+  +++ actual
+    "a": 1
+  - "b": "y"
+  + "b": "x"`, result.Value().Error())
+}
+
+func TestAssertTrueFalse(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.Nil, AssertTrue(ctx, object.True))
+	require.Equal(t, object.Nil, AssertFalse(ctx, object.NewInt(0)))
+	result := AssertTrue(ctx, object.NewList(nil), object.NewString("items")).(*object.Error)
+	require.Equal(t, "items: expected a truthy value (got [])", result.Value().Error())
+	result = AssertNe(ctx, object.NewInt(1), object.NewInt(1)).(*object.Error)
+	require.Equal(t, "values are equal: 1", result.Value().Error())
+}
+
+func TestAssertRaises(t *testing.T) {
+	ctx := context.Background()
+	raises := object.NewBuiltin("raises", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.Errorf("boom")
+	})
+	noop := object.NewBuiltin("noop", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.Nil
+	})
+	result := AssertRaises(ctx, raises, object.NewString("boo"))
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.False(t, errObj.IsRaised())
+	require.Equal(t, "boom", errObj.Value().Error())
+
+	result = AssertRaises(ctx, raises, object.NewString("other"))
+	require.Equal(t, `expected an error containing "other" (got "boom")`,
+		result.(*object.Error).Value().Error())
+
+	result = AssertRaises(ctx, noop)
+	require.Equal(t, "expected an error to be raised", result.(*object.Error).Value().Error())
+}
+
+func TestSkip(t *testing.T) {
+	result := Skip(context.Background(), object.NewString("not ready")).(*object.Error)
+	var skipErr *SkipError
+	require.True(t, errors.As(result.Value(), &skipErr))
+	require.Equal(t, "not ready", skipErr.Reason)
+	require.True(t, skipErr.IsFatal())
+}
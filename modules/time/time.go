@@ -66,6 +66,27 @@ func Sleep(ctx context.Context, args ...object.Object) object.Object {
 	return object.Nil
 }
 
+func After(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("time.after", 1, args); err != nil {
+		return err
+	}
+	d, err := object.AsFloat(args[0])
+	if err != nil {
+		return err
+	}
+	ch := object.NewChan(1)
+	timer := time.NewTimer(time.Duration(d*1000) * time.Millisecond)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case t := <-timer.C:
+			ch.Value() <- object.NewTime(t)
+		}
+	}()
+	return ch
+}
+
 func Since(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("time.since", 1, args); err != nil {
 		return err
@@ -79,6 +100,7 @@ func Since(ctx context.Context, args ...object.Object) object.Object {
 
 func Module() *object.Module {
 	return object.NewBuiltinsModule("time", map[string]object.Object{
+		"after":       object.NewBuiltin("after", After),
 		"now":         object.NewBuiltin("now", Now),
 		"parse":       object.NewBuiltin("parse", Parse),
 		"sleep":       object.NewBuiltin("sleep", Sleep),
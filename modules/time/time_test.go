@@ -60,6 +60,18 @@ func TestSleep(t *testing.T) {
 	require.True(t, elapsed < 250*time.Millisecond) // Allow some margin for error
 }
 
+func TestAfter(t *testing.T) {
+	start := time.Now()
+	got := After(context.Background(), object.NewFloat(0.1))
+	ch, ok := got.(*object.Chan)
+	require.True(t, ok)
+
+	value, err := ch.Receive(context.Background())
+	require.Nil(t, err)
+	require.IsType(t, &object.Time{}, value)
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+}
+
 func TestSince(t *testing.T) {
 	now := time.Now()
 	time.Sleep(100 * time.Millisecond)
@@ -3,16 +3,24 @@ package object
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/op"
 )
 
-var _ Callable = (*Builtin)(nil) // Ensure that *Builtin implements Callable
+var (
+	_ Callable       = (*Builtin)(nil) // Ensure that *Builtin implements Callable
+	_ KwargsCallable = (*Builtin)(nil) // Ensure that *Builtin implements KwargsCallable
+)
 
 // BuiltinFunction holds the type of a built-in function.
 type BuiltinFunction func(ctx context.Context, args ...Object) Object
 
+// KwargsBuiltinFunction holds the type of a built-in function that receives
+// keyword arguments. The kwargs map is nil when none are given.
+type KwargsBuiltinFunction func(ctx context.Context, kwargs map[string]Object, args ...Object) Object
+
 // Builtin wraps func and implements Object interface.
 type Builtin struct {
 	*base
@@ -20,6 +28,12 @@ type Builtin struct {
 	// The function that this object wraps.
 	fn BuiltinFunction
 
+	// The function called when keyword arguments are given (optional)
+	kwfn KwargsBuiltinFunction
+
+	// The parameter names that keyword arguments are bound to (optional)
+	params []string
+
 	// The name of the function.
 	name string
 
@@ -55,6 +69,38 @@ func (b *Builtin) Call(ctx context.Context, args ...Object) Object {
 	return b.fn(ctx, args...)
 }
 
+// AcceptsKwargs returns true if the builtin was created to receive keyword
+// arguments, using NewKwargsBuiltin or NewBuiltinWithParams.
+func (b *Builtin) AcceptsKwargs() bool {
+	return b.kwfn != nil || b.params != nil
+}
+
+// Params returns the parameter names that keyword arguments are bound to, if
+// the builtin was created with NewBuiltinWithParams.
+func (b *Builtin) Params() []string {
+	return b.params
+}
+
+// CallWithKwargs calls the builtin with positional and keyword arguments.
+// An error is returned if keyword arguments are given to a builtin that does
+// not accept them.
+func (b *Builtin) CallWithKwargs(ctx context.Context, kwargs map[string]Object, args ...Object) Object {
+	if len(kwargs) == 0 {
+		return b.fn(ctx, args...)
+	}
+	if b.kwfn != nil {
+		return b.kwfn(ctx, kwargs, args...)
+	}
+	if b.params != nil {
+		bound, err := BindKwargs(b.Key(), b.params, args, kwargs)
+		if err != nil {
+			return err
+		}
+		return b.fn(ctx, bound...)
+	}
+	return ArgsErrorf("args error: builtin function %q does not accept keyword arguments", b.Key())
+}
+
 func (b *Builtin) Inspect() string {
 	if b.module == nil {
 		return fmt.Sprintf("builtin(%s)", b.name)
@@ -147,6 +193,68 @@ func NewBuiltin(name string, fn BuiltinFunction, module ...*Module) *Builtin {
 	return b
 }
 
+// NewKwargsBuiltin creates a builtin function that receives keyword
+// arguments along with its positional arguments.
+func NewKwargsBuiltin(name string, fn KwargsBuiltinFunction, module ...*Module) *Builtin {
+	b := NewBuiltin(name, func(ctx context.Context, args ...Object) Object {
+		return fn(ctx, nil, args...)
+	}, module...)
+	b.kwfn = fn
+	return b
+}
+
+// NewBuiltinWithParams creates a builtin function whose arguments may also be
+// passed by keyword, using the given parameter names. Keyword arguments are
+// moved to the positions of their parameters before fn is called.
+func NewBuiltinWithParams(name string, fn BuiltinFunction, params []string, module ...*Module) *Builtin {
+	b := NewBuiltin(name, fn, module...)
+	b.params = params
+	if b.params == nil {
+		b.params = []string{}
+	}
+	return b
+}
+
+// BindKwargs returns the arguments of a call to the named function with each
+// keyword argument moved to the position of its parameter. Parameters after
+// the last one given are left out, so the function can check for missing
+// arguments as it would when called with positional arguments only.
+func BindKwargs(fn string, params []string, args []Object, kwargs map[string]Object) ([]Object, *Error) {
+	if len(kwargs) == 0 {
+		return args, nil
+	}
+	if len(args) > len(params) {
+		return nil, ArgsErrorf("args error: %s() takes at most %d positional arguments (%d given)",
+			fn, len(params), len(args))
+	}
+	bound := make([]Object, len(params))
+	copy(bound, args)
+	count := len(args)
+	names := make([]string, 0, len(kwargs))
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := kwargs[name]
+		index := slices.Index(params, name)
+		if index < 0 {
+			return nil, ArgsErrorf("args error: %s() got an unexpected keyword argument %q", fn, name)
+		}
+		if bound[index] != nil {
+			return nil, ArgsErrorf("args error: %s() got multiple values for argument %q", fn, name)
+		}
+		bound[index] = value
+		count = max(count, index+1)
+	}
+	for i, value := range bound[:count] {
+		if value == nil {
+			return nil, ArgsErrorf("args error: %s() missing argument %q", fn, params[i])
+		}
+	}
+	return bound[:count], nil
+}
+
 func NewErrorHandler(name string, fn BuiltinFunction, module ...*Module) *Builtin {
 	b := NewBuiltin(name, fn, module...)
 	b.isErrorHandler = true
@@ -0,0 +1,42 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinWithParams(t *testing.T) {
+	ctx := context.Background()
+	b := NewBuiltinWithParams("sub", func(ctx context.Context, args ...Object) Object {
+		if len(args) != 2 {
+			return NewArgsError("sub", 2, len(args))
+		}
+		return NewInt(args[0].(*Int).Value() - args[1].(*Int).Value())
+	}, []string{"a", "b"})
+	require.True(t, b.AcceptsKwargs())
+	require.Equal(t, []string{"a", "b"}, b.Params())
+
+	require.Equal(t, NewInt(1), b.Call(ctx, NewInt(3), NewInt(2)))
+	require.Equal(t, NewInt(1), b.CallWithKwargs(ctx, map[string]Object{"b": NewInt(2)}, NewInt(3)))
+	require.Equal(t, NewInt(-1), b.CallWithKwargs(ctx, map[string]Object{"b": NewInt(3), "a": NewInt(2)}))
+
+	result := b.CallWithKwargs(ctx, map[string]Object{"c": NewInt(1)}, NewInt(3))
+	require.Equal(t, "args error: sub() got an unexpected keyword argument \"c\"", result.(*Error).Message().Value())
+	result = b.CallWithKwargs(ctx, map[string]Object{"a": NewInt(1)}, NewInt(3))
+	require.Equal(t, "args error: sub() got multiple values for argument \"a\"", result.(*Error).Message().Value())
+	result = b.CallWithKwargs(ctx, map[string]Object{"b": NewInt(1)})
+	require.Equal(t, "args error: sub() missing argument \"a\"", result.(*Error).Message().Value())
+}
+
+func TestBuiltinWithoutKwargs(t *testing.T) {
+	b := NewBuiltin("len", func(ctx context.Context, args ...Object) Object {
+		return NewInt(int64(len(args)))
+	})
+	require.False(t, b.AcceptsKwargs())
+	result := b.CallWithKwargs(context.Background(), map[string]Object{"x": NewInt(1)})
+	require.Equal(t, "args error: builtin function \"len\" does not accept keyword arguments",
+		result.(*Error).Message().Value())
+	require.Equal(t, NewInt(1), b.CallWithKwargs(context.Background(), nil, Nil))
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/op"
@@ -169,6 +170,64 @@ func (c *Chan) Value() chan Object {
 	return c.value
 }
 
+// SelectCase is one operation of a select over channels. Value holds the
+// value to send, or is nil for a receive.
+type SelectCase struct {
+	Chan  *Chan
+	Value Object
+}
+
+// Select blocks until one of the given cases can proceed, and returns its
+// index. For a receive, it also returns the received value and whether the
+// channel was open. If hasDefault is set and no case is ready, it returns
+// len(cases) rather than blocking.
+func Select(ctx context.Context, cases []SelectCase, hasDefault bool) (index int, value Object, ok bool, err error) {
+	// Translate a "send on closed channel" panic to an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("exec error: %v", r)
+		}
+	}()
+	selectCases := make([]reflect.SelectCase, 0, len(cases)+1)
+	for _, c := range cases {
+		if c.Value != nil {
+			selectCases = append(selectCases, reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: reflect.ValueOf(c.Chan.value),
+				Send: reflect.ValueOf(&c.Value).Elem(),
+			})
+		} else {
+			selectCases = append(selectCases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(c.Chan.value),
+			})
+		}
+	}
+	if hasDefault {
+		selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	} else {
+		selectCases = append(selectCases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(ctx.Done()),
+		})
+	}
+	chosen, received, recvOK := reflect.Select(selectCases)
+	if chosen == len(cases) {
+		if hasDefault {
+			return chosen, Nil, false, nil
+		}
+		return 0, nil, false, ctx.Err()
+	}
+	if cases[chosen].Value != nil {
+		return chosen, Nil, true, nil
+	}
+	if !recvOK {
+		return chosen, Nil, false, nil
+	}
+	value, _ = received.Interface().(Object)
+	return chosen, value, true, nil
+}
+
 func NewChan(size int) *Chan {
 	return &Chan{
 		capacity: size,
@@ -0,0 +1,244 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// enumTypeCount is used to give each enum type a unique ID, which keeps the
+// hash keys of members of different enums with the same name distinct.
+var enumTypeCount atomic.Int64
+
+// EnumType is an immutable enumeration type created by an enum declaration.
+// It holds an ordered set of members, each with a name and a value.
+type EnumType struct {
+	*base
+	id          int64
+	name        string
+	members     []*EnumMember
+	memberIndex map[string]*EnumMember
+}
+
+func (t *EnumType) Type() Type {
+	return ENUM
+}
+
+func (t *EnumType) Name() string {
+	return t.name
+}
+
+// Members returns the members of the enum, in declaration order.
+func (t *EnumType) Members() []*EnumMember {
+	return t.members
+}
+
+// Member returns the member with the given name, if it exists.
+func (t *EnumType) Member(name string) (*EnumMember, bool) {
+	member, ok := t.memberIndex[name]
+	return member, ok
+}
+
+func (t *EnumType) Inspect() string {
+	names := make([]string, 0, len(t.members))
+	for _, member := range t.members {
+		names = append(names, member.name)
+	}
+	return fmt.Sprintf("enum %s(%s)", t.name, strings.Join(names, ", "))
+}
+
+func (t *EnumType) String() string {
+	return t.Inspect()
+}
+
+func (t *EnumType) Interface() interface{} {
+	return nil
+}
+
+func (t *EnumType) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "__name__":
+		return NewString(t.name), true
+	case "__members__":
+		return t.list(), true
+	}
+	if member, ok := t.memberIndex[name]; ok {
+		return member, true
+	}
+	return nil, false
+}
+
+func (t *EnumType) SetAttr(name string, value Object) error {
+	return TypeErrorf("type error: cannot set attribute %q on enum %s", name, t.name)
+}
+
+func (t *EnumType) Equals(other Object) Object {
+	if t == other {
+		return True
+	}
+	return False
+}
+
+func (t *EnumType) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for enum: %v", opType)
+}
+
+func (t *EnumType) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal enum")
+}
+
+func (t *EnumType) list() *List {
+	items := make([]Object, 0, len(t.members))
+	for _, member := range t.members {
+		items = append(items, member)
+	}
+	return NewList(items)
+}
+
+// Iter returns an iterator over the members of the enum, in declaration order.
+func (t *EnumType) Iter() Iterator {
+	return t.list().Iter()
+}
+
+// GetItem returns the member with the given name.
+func (t *EnumType) GetItem(key Object) (Object, *Error) {
+	name, ok := key.(*String)
+	if !ok {
+		return nil, TypeErrorf("type error: enum key must be a string (got %s)", key.Type())
+	}
+	member, found := t.memberIndex[name.value]
+	if !found {
+		return nil, Errorf("key error: %q", name.value)
+	}
+	return member, nil
+}
+
+func (t *EnumType) GetSlice(s Slice) (Object, *Error) {
+	return nil, TypeErrorf("type error: enum does not support slice operations")
+}
+
+func (t *EnumType) SetItem(key, value Object) *Error {
+	return TypeErrorf("type error: enum %s does not support item assignment", t.name)
+}
+
+func (t *EnumType) DelItem(key Object) *Error {
+	return TypeErrorf("type error: enum %s does not support item deletion", t.name)
+}
+
+// Contains returns true if the given item is a member of the enum.
+func (t *EnumType) Contains(item Object) *Bool {
+	member, ok := item.(*EnumMember)
+	return NewBool(ok && member.typ == t)
+}
+
+func (t *EnumType) Len() *Int {
+	return NewInt(int64(len(t.members)))
+}
+
+// Call returns the member with the given value.
+func (t *EnumType) Call(ctx context.Context, args ...Object) Object {
+	if len(args) != 1 {
+		return NewArgsError(t.name, 1, len(args))
+	}
+	for _, member := range t.members {
+		if member.value.Equals(args[0]) == True {
+			return member
+		}
+	}
+	return Errorf("value error: %s is not a valid %s", args[0].Inspect(), t.name)
+}
+
+// NewEnumType creates a new enum type with members of the given names and
+// values. The names and values slices must be the same length.
+func NewEnumType(name string, names []string, values []Object) *EnumType {
+	t := &EnumType{
+		id:          enumTypeCount.Add(1),
+		name:        name,
+		members:     make([]*EnumMember, 0, len(names)),
+		memberIndex: make(map[string]*EnumMember, len(names)),
+	}
+	for i, memberName := range names {
+		member := &EnumMember{typ: t, name: memberName, value: values[i], index: i}
+		t.members = append(t.members, member)
+		t.memberIndex[memberName] = member
+	}
+	return t
+}
+
+// EnumMember is a member of an EnumType. Members are unique, so two members
+// are only equal if they are the same member.
+type EnumMember struct {
+	*base
+	typ   *EnumType
+	name  string
+	value Object
+	index int
+}
+
+func (m *EnumMember) Type() Type {
+	return ENUM_MEMBER
+}
+
+// EnumType returns the enum this member belongs to.
+func (m *EnumMember) EnumType() *EnumType {
+	return m.typ
+}
+
+func (m *EnumMember) Name() string {
+	return m.name
+}
+
+func (m *EnumMember) Value() Object {
+	return m.value
+}
+
+func (m *EnumMember) Inspect() string {
+	return m.typ.name + "." + m.name
+}
+
+func (m *EnumMember) String() string {
+	return m.Inspect()
+}
+
+func (m *EnumMember) Interface() interface{} {
+	return m.name
+}
+
+func (m *EnumMember) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "name":
+		return NewString(m.name), true
+	case "value":
+		return m.value, true
+	}
+	return nil, false
+}
+
+func (m *EnumMember) SetAttr(name string, value Object) error {
+	return TypeErrorf("type error: cannot set attribute %q on enum member %s", name, m.Inspect())
+}
+
+func (m *EnumMember) Equals(other Object) Object {
+	if m == other {
+		return True
+	}
+	return False
+}
+
+func (m *EnumMember) HashKey() HashKey {
+	return HashKey{Type: m.Type(), IntValue: m.typ.id, StrValue: m.name}
+}
+
+func (m *EnumMember) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for enum_member: %v", opType)
+}
+
+// MarshalJSON encodes the member as its name.
+func (m *EnumMember) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.name)
+}
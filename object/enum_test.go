@@ -0,0 +1,108 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumType(t *testing.T) {
+	typ := NewEnumType("Color", []string{"RED", "GREEN"}, []Object{NewInt(0), NewInt(5)})
+	require.Equal(t, ENUM, typ.Type())
+	require.Equal(t, "enum Color(RED, GREEN)", typ.Inspect())
+	require.Equal(t, NewInt(2), typ.Len())
+
+	green, ok := typ.Member("GREEN")
+	require.True(t, ok)
+	require.Equal(t, ENUM_MEMBER, green.Type())
+	require.Equal(t, "Color.GREEN", green.Inspect())
+	require.Equal(t, NewInt(5), green.Value())
+	require.Equal(t, typ, green.EnumType())
+
+	attr, ok := typ.GetAttr("GREEN")
+	require.True(t, ok)
+	require.Equal(t, green, attr)
+	require.Equal(t, green, typ.Call(context.Background(), NewInt(5)))
+	require.Equal(t, ERROR, typ.Call(context.Background(), NewInt(1)).Type())
+	require.NotNil(t, typ.SetAttr("RED", NewInt(1)))
+
+	var names []string
+	iter := typ.Iter()
+	for {
+		item, ok := iter.Next(context.Background())
+		if !ok {
+			break
+		}
+		names = append(names, item.(*EnumMember).Name())
+	}
+	require.Equal(t, []string{"RED", "GREEN"}, names)
+}
+
+func TestEnumMemberEquals(t *testing.T) {
+	a := NewEnumType("A", []string{"X"}, []Object{NewInt(0)})
+	b := NewEnumType("B", []string{"X"}, []Object{NewInt(0)})
+	ax, _ := a.Member("X")
+	bx, _ := b.Member("X")
+	require.Equal(t, True, ax.Equals(ax))
+	require.Equal(t, False, ax.Equals(bx))
+	require.Equal(t, False, ax.Equals(NewInt(0)))
+	require.NotEqual(t, ax.HashKey(), bx.HashKey())
+	require.Equal(t, NewInt(1), NewSet([]Object{ax, ax}).(*Set).Len())
+}
+
+func TestEnumMarshalJSON(t *testing.T) {
+	typ := NewEnumType("Color", []string{"RED"}, []Object{NewInt(0)})
+	red, _ := typ.Member("RED")
+	data, err := json.Marshal(NewList([]Object{red}))
+	require.Nil(t, err)
+	require.Equal(t, `["RED"]`, string(data))
+	require.Equal(t, "RED", red.Interface())
+
+	_, err = json.Marshal(typ)
+	require.NotNil(t, err)
+}
+
+func TestEnumMapKeys(t *testing.T) {
+	a := NewEnumType("Color", []string{"RED"}, []Object{NewInt(0)})
+	b := NewEnumType("Color", []string{"RED"}, []Object{NewInt(0)})
+	ar, _ := a.Member("RED")
+	br, _ := b.Member("RED")
+
+	m := NewMap(map[string]Object{"Color.RED": NewInt(1)})
+	require.Nil(t, m.SetItem(ar, NewInt(2)))
+	require.Nil(t, m.SetItem(br, NewInt(3)))
+	require.Equal(t, 3, m.Size())
+	value, err := m.GetItem(ar)
+	require.Nil(t, err)
+	require.Equal(t, NewInt(2), value)
+	value, err = m.GetItem(br)
+	require.Nil(t, err)
+	require.Equal(t, NewInt(3), value)
+	require.Equal(t, NewInt(1), m.Get("Color.RED"))
+	require.Equal(t, NewList([]Object{NewString("Color.RED"), ar, br}), m.Keys())
+
+	other := NewMap(map[string]Object{"Color.RED": NewInt(1)})
+	require.Equal(t, False, m.Equals(other))
+	require.Nil(t, other.SetItem(ar, NewInt(2)))
+	require.Nil(t, other.SetItem(br, NewInt(3)))
+	require.Equal(t, True, m.Equals(other))
+
+	require.Nil(t, m.DelItem(ar))
+	require.Equal(t, False, m.Contains(ar))
+	require.Equal(t, True, m.Contains(br))
+	_, err = m.GetItem(ar)
+	require.NotNil(t, err)
+	require.Equal(t, "key error: Color.RED", err.Message().Value())
+
+	data, jsonErr := json.Marshal(NewMap(map[string]Object{"a": NewInt(1)}).Copy())
+	require.Nil(t, jsonErr)
+	require.Equal(t, `{"a":1}`, string(data))
+	c := NewMap(nil)
+	require.Nil(t, c.SetItem(br, NewInt(3)))
+	data, jsonErr = json.Marshal(c)
+	require.Nil(t, jsonErr)
+	require.Equal(t, `{"Color.RED":3}`, string(data))
+	require.Equal(t, map[string]any{"Color.RED": int64(3)}, c.Interface())
+}
@@ -0,0 +1,266 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatSpec is a parsed format spec, which has the form:
+//
+//	[[fill]align][sign][#][0][width][grouping][.precision][type]
+type formatSpec struct {
+	fill      rune
+	align     rune
+	sign      rune
+	alternate bool
+	width     int
+	grouping  rune
+	precision int
+	verb      rune
+}
+
+func parseFormatSpec(spec string) (*formatSpec, error) {
+	f := &formatSpec{fill: ' ', precision: -1}
+	runes := []rune(spec)
+	i := 0
+	isAlign := func(r rune) bool { return strings.ContainsRune("<>^=", r) }
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		f.fill, f.align = runes[0], runes[1]
+		i = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		f.align = runes[0]
+		i = 1
+	}
+	if i < len(runes) && strings.ContainsRune("+- ", runes[i]) {
+		f.sign = runes[i]
+		i++
+	}
+	if i < len(runes) && runes[i] == '#' {
+		f.alternate = true
+		i++
+	}
+	if i < len(runes) && runes[i] == '0' {
+		// Zero padding goes between the sign and the digits, unless an
+		// alignment was given explicitly
+		if f.align == 0 {
+			f.fill, f.align = '0', '='
+		}
+		i++
+	}
+	start := i
+	for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+		i++
+	}
+	if i > start {
+		f.width, _ = strconv.Atoi(string(runes[start:i]))
+	}
+	if i < len(runes) && (runes[i] == ',' || runes[i] == '_') {
+		f.grouping = runes[i]
+		i++
+	}
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		start = i
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("value error: invalid format spec %q (expected a precision)", spec)
+		}
+		f.precision, _ = strconv.Atoi(string(runes[start:i]))
+	}
+	if i < len(runes) && strings.ContainsRune("bdoxXeEfFgGs%", runes[i]) {
+		f.verb = runes[i]
+		i++
+	}
+	if i < len(runes) {
+		return nil, fmt.Errorf("value error: invalid format spec %q", spec)
+	}
+	return f, nil
+}
+
+// Format formats an object according to a format spec, as used in f-strings
+// like "{price:.2f}" and by the format builtin. The spec has the form:
+//
+//	[[fill]align][sign][#][0][width][grouping][.precision][type]
+//
+// The alignment is "<" (left), ">" (right), "^" (center), or "=" (padding
+// after the sign). The sign is "+", "-", or " ", and the grouping is "," or
+// "_". Integers accept the types "d", "b", "o", "x", and "X"; floats accept
+// "e", "E", "f", "F", "g", "G", and "%". Integers are converted to floats
+// when formatted with a float type. Other values are formatted as strings,
+// with the "s" type, where the precision is the maximum length.
+func Format(obj Object, spec string) (string, error) {
+	f, err := parseFormatSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	switch obj := obj.(type) {
+	case *Int:
+		if f.verb == 0 || strings.ContainsRune("dboxX", f.verb) {
+			return f.formatInt(obj.value)
+		}
+		return f.formatFloat(float64(obj.value), obj)
+	case *Float:
+		return f.formatFloat(obj.value, obj)
+	case *String:
+		return f.formatString(obj.value, obj)
+	}
+	return f.formatString(FormatString(obj), obj)
+}
+
+// FormatString returns the text that represents an object in an f-string or
+// string concatenation. Strings are returned as-is rather than quoted.
+func FormatString(obj Object) string {
+	switch obj := obj.(type) {
+	case *String:
+		return obj.value
+	case *Error:
+		return obj.Value().Error()
+	}
+	return obj.Inspect()
+}
+
+func (f *formatSpec) invalid(verb rune, obj Object) error {
+	return fmt.Errorf("value error: invalid format type %q for %s", verb, obj.Type())
+}
+
+func (f *formatSpec) formatString(s string, obj Object) (string, error) {
+	if f.verb != 0 && f.verb != 's' {
+		return "", f.invalid(f.verb, obj)
+	}
+	if f.sign != 0 || f.alternate || f.grouping != 0 || f.align == '=' {
+		return "", fmt.Errorf("value error: invalid format spec for %s", obj.Type())
+	}
+	if f.precision >= 0 && utf8.RuneCountInString(s) > f.precision {
+		s = string([]rune(s)[:f.precision])
+	}
+	return f.pad("", s, '<'), nil
+}
+
+func (f *formatSpec) formatInt(value int64) (string, error) {
+	if f.precision >= 0 {
+		return "", fmt.Errorf("value error: precision is not allowed for integers")
+	}
+	negative := value < 0
+	magnitude := uint64(value)
+	if negative {
+		magnitude = -magnitude
+	}
+	var digits, prefix string
+	groupSize := 3
+	switch f.verb {
+	case 'b':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 2), "0b", 4
+	case 'o':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 8), "0o", 4
+	case 'x':
+		digits, prefix, groupSize = strconv.FormatUint(magnitude, 16), "0x", 4
+	case 'X':
+		digits, prefix, groupSize = strings.ToUpper(strconv.FormatUint(magnitude, 16)), "0X", 4
+	default:
+		digits = strconv.FormatUint(magnitude, 10)
+	}
+	if !f.alternate {
+		prefix = ""
+	}
+	digits = group(digits, f.grouping, groupSize)
+	return f.pad(f.signOf(negative)+prefix, digits, '>'), nil
+}
+
+func (f *formatSpec) formatFloat(value float64, obj Object) (string, error) {
+	verb := f.verb
+	if verb != 0 && !strings.ContainsRune("eEfFgG%", verb) {
+		return "", f.invalid(verb, obj)
+	}
+	negative := math.Signbit(value) && !math.IsNaN(value)
+	magnitude := math.Abs(value)
+	var digits string
+	switch {
+	case math.IsInf(magnitude, 0):
+		digits = "inf"
+	case math.IsNaN(magnitude):
+		digits = "nan"
+	case verb == 0 && f.precision < 0:
+		digits = strconv.FormatFloat(magnitude, 'f', -1, 64)
+	default:
+		precision := f.precision
+		if precision < 0 {
+			precision = 6
+		}
+		switch verb {
+		case 0:
+			digits = strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+		case '%':
+			digits = strconv.FormatFloat(magnitude*100, 'f', precision, 64) + "%"
+		case 'g', 'G':
+			digits = strconv.FormatFloat(magnitude, 'g', max(precision, 1), 64)
+		default:
+			digits = strconv.FormatFloat(magnitude, byte(verb|0x20), precision, 64)
+		}
+	}
+	if verb == 'E' || verb == 'F' || verb == 'G' {
+		digits = strings.ToUpper(digits)
+	}
+	if f.grouping != 0 {
+		// Group the digits of the integer part only
+		end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' })
+		if end < 0 {
+			end = len(digits)
+		}
+		digits = group(digits[:end], f.grouping, 3) + digits[end:]
+	}
+	return f.pad(f.signOf(negative), digits, '>'), nil
+}
+
+func (f *formatSpec) signOf(negative bool) string {
+	if negative {
+		return "-"
+	}
+	if f.sign == '+' || f.sign == ' ' {
+		return string(f.sign)
+	}
+	return ""
+}
+
+// pad pads the sign and body to the width of the spec, using the given
+// alignment if the spec does not specify one.
+func (f *formatSpec) pad(sign, body string, align rune) string {
+	if f.align != 0 {
+		align = f.align
+	}
+	n := f.width - utf8.RuneCountInString(sign) - utf8.RuneCountInString(body)
+	if n <= 0 {
+		return sign + body
+	}
+	switch align {
+	case '<':
+		return sign + body + strings.Repeat(string(f.fill), n)
+	case '^':
+		left := n / 2
+		return strings.Repeat(string(f.fill), left) + sign + body + strings.Repeat(string(f.fill), n-left)
+	case '=':
+		return sign + strings.Repeat(string(f.fill), n) + body
+	default:
+		return strings.Repeat(string(f.fill), n) + sign + body
+	}
+}
+
+// group inserts the separator between each group of size digits, counting
+// from the right.
+func group(digits string, separator rune, size int) string {
+	if separator == 0 || len(digits) <= size {
+		return digits
+	}
+	var out strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%size == 0 {
+			out.WriteRune(separator)
+		}
+		out.WriteRune(c)
+	}
+	return out.String()
+}
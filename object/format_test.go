@@ -0,0 +1,85 @@
+package object
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value    Object
+		spec     string
+		expected string
+	}{
+		{NewInt(42), "", "42"},
+		{NewInt(42), "5", "   42"},
+		{NewInt(42), "<5", "42   "},
+		{NewInt(42), "^6", "  42  "},
+		{NewInt(42), "*>6", "****42"},
+		{NewInt(-42), "=6", "-   42"},
+		{NewInt(-42), "06", "-00042"},
+		{NewInt(42), "+", "+42"},
+		{NewInt(42), " ", " 42"},
+		{NewInt(1234567), ",", "1,234,567"},
+		{NewInt(-1234567), "_", "-1_234_567"},
+		{NewInt(255), "x", "ff"},
+		{NewInt(255), "#X", "0XFF"},
+		{NewInt(5), "#b", "0b101"},
+		{NewInt(8), "o", "10"},
+		{NewInt(65535), "_x", "ffff"},
+		{NewInt(1048575), "_x", "f_ffff"},
+		{NewInt(3), ".2f", "3.00"},
+		{NewFloat(3.14159), "", "3.14159"},
+		{NewFloat(3.14159), ".2f", "3.14"},
+		{NewFloat(3.14159), ".3", "3.14"},
+		{NewFloat(3.14159), "8.2f", "    3.14"},
+		{NewFloat(-3.14159), "+.1f", "-3.1"},
+		{NewFloat(1234567.891), ",.2f", "1,234,567.89"},
+		{NewFloat(0.256), ".1%", "25.6%"},
+		{NewFloat(1000000), "e", "1.000000e+06"},
+		{NewFloat(1000000), ".2E", "1.00E+06"},
+		{NewFloat(0.00001234), "g", "1.234e-05"},
+		{NewFloat(math.Inf(1)), "f", "inf"},
+		{NewFloat(math.Inf(-1)), "F", "-INF"},
+		{NewString("abc"), "", "abc"},
+		{NewString("abc"), ">5", "  abc"},
+		{NewString("abc"), "*^7", "**abc**"},
+		{NewString("abcdef"), ".3", "abc"},
+		{NewString("abcdef"), "<5.2s", "ab   "},
+		{True, ">6", "  true"},
+		{Nil, "<5", "nil  "},
+		{NewList([]Object{NewInt(1)}), "", "[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value.Inspect()+":"+tt.spec, func(t *testing.T) {
+			result, err := Format(tt.value, tt.spec)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		value    Object
+		spec     string
+		expected string
+	}{
+		{NewInt(1), "q", "value error: invalid format spec \"q\""},
+		{NewInt(1), "5.", "value error: invalid format spec \"5.\" (expected a precision)"},
+		{NewInt(1), ".2d", "value error: precision is not allowed for integers"},
+		{NewInt(1), "s", "value error: invalid format type 's' for int"},
+		{NewFloat(1), "x", "value error: invalid format type 'x' for float"},
+		{NewString("a"), "d", "value error: invalid format type 'd' for string"},
+		{NewString("a"), "+", "value error: invalid format spec for string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value.Inspect()+":"+tt.spec, func(t *testing.T) {
+			_, err := Format(tt.value, tt.spec)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
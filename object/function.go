@@ -16,12 +16,19 @@ type Function struct {
 	*base
 	name          string
 	parameters    []string
+	restParameter string
 	defaults      []Object
 	defaultsCount int
 	code          *compiler.Code
 	fn            *compiler.Function
 	instructions  []op.Code
 	freeVars      []*Cell
+
+	// wrapped is the function that a decorator replaced with this one, if
+	// any. The function presents the name and signature of the one it wraps,
+	// while calls run the wrapper returned by the decorator.
+	wrapped *Function
+	wrapper *Function
 }
 
 func (f *Function) Type() Type {
@@ -29,10 +36,16 @@ func (f *Function) Type() Type {
 }
 
 func (f *Function) Name() string {
+	if f.wrapped != nil {
+		return f.wrapped.Name()
+	}
 	return f.name
 }
 
 func (f *Function) Inspect() string {
+	if f.wrapped != nil {
+		return f.wrapped.Inspect()
+	}
 	var out bytes.Buffer
 	parameters := make([]string, 0)
 	for i, name := range f.parameters {
@@ -41,6 +54,9 @@ func (f *Function) Inspect() string {
 		}
 		parameters = append(parameters, name)
 	}
+	if f.restParameter != "" {
+		parameters = append(parameters, "..."+f.restParameter)
+	}
 	out.WriteString("func")
 	if f.name != "" {
 		out.WriteString(" " + f.name)
@@ -63,6 +79,9 @@ func (f *Function) Inspect() string {
 }
 
 func (f *Function) String() string {
+	if f.wrapped != nil {
+		return f.wrapped.String()
+	}
 	if f.name != "" {
 		return fmt.Sprintf("func %s() { ... }", f.name)
 	}
@@ -125,14 +144,57 @@ func (f *Function) Function() *compiler.Function {
 }
 
 func (f *Function) Parameters() []string {
+	if f.wrapped != nil {
+		return f.wrapped.Parameters()
+	}
 	return f.parameters
 }
 
+// RestParameter returns the name of the parameter that receives any
+// additional arguments as a list, or an empty string if there is none.
+func (f *Function) RestParameter() string {
+	if f.wrapped != nil {
+		return f.wrapped.RestParameter()
+	}
+	return f.restParameter
+}
+
 func (f *Function) Defaults() []Object {
+	if f.wrapped != nil {
+		return f.wrapped.Defaults()
+	}
 	return f.defaults
 }
 
+// Wraps returns a function that presents the name and signature of the given
+// function, as when a decorator replaces a function with a wrapper that calls
+// it. Calling the returned function runs this function, which is available
+// from Wrapper.
+func (f *Function) Wraps(wrapped *Function) *Function {
+	fn := *f
+	fn.wrapped = wrapped
+	fn.wrapper = f
+	return &fn
+}
+
+// Wrapped returns the function that this function wraps, or nil if it does
+// not wrap another function.
+func (f *Function) Wrapped() *Function {
+	return f.wrapped
+}
+
+// Wrapper returns the function that runs when this function is called, if
+// it was created by Wraps, or nil otherwise. Arguments are bound against the
+// signature this function presents and then passed to the wrapper, whose own
+// name appears in stack traces.
+func (f *Function) Wrapper() *Function {
+	return f.wrapper
+}
+
 func (f *Function) RequiredArgsCount() int {
+	if f.wrapped != nil {
+		return f.wrapped.RequiredArgsCount()
+	}
 	return len(f.parameters) - f.defaultsCount
 }
 
@@ -187,6 +249,7 @@ func NewFunction(fn *compiler.Function) *Function {
 		name:          fn.Name(),
 		code:          fn.Code(),
 		parameters:    parameters,
+		restParameter: fn.RestParameter(),
 		defaults:      defaults,
 		defaultsCount: defaultsCount,
 	}
@@ -199,6 +262,7 @@ func NewClosure(
 	return &Function{
 		name:          fn.name,
 		parameters:    fn.parameters,
+		restParameter: fn.restParameter,
 		defaults:      fn.defaults,
 		defaultsCount: fn.defaultsCount,
 		code:          fn.Code(),
@@ -0,0 +1,192 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+var errGeneratorRunning = errors.New("value error: generator is already running")
+
+// ResumeFunc resumes a suspended generator function. It returns the next
+// value the function yields, or false once the function has returned.
+type ResumeFunc func(ctx context.Context) (Object, bool, error)
+
+// Generator is an iterator over the values yielded by a call to a function
+// that contains a yield statement. The function does not start running until
+// the first call to Next, and each call to Next runs it until it yields its
+// next value or returns.
+type Generator struct {
+	*base
+	fn      *Function
+	resume  ResumeFunc
+	mutex   sync.Mutex
+	pos     int64
+	done    bool
+	current Object
+	err     error
+}
+
+func (g *Generator) Type() Type {
+	return GENERATOR
+}
+
+func (g *Generator) Inspect() string {
+	name := g.fn.Name()
+	if name == "" {
+		name = "anonymous"
+	}
+	return fmt.Sprintf("generator(%s)", name)
+}
+
+func (g *Generator) String() string {
+	return g.Inspect()
+}
+
+// Interface returns nil. The values of a generator are only available by
+// running its function on a VM, which is not possible here, and converting
+// a generator would also consume it.
+func (g *Generator) Interface() interface{} {
+	return nil
+}
+
+func (g *Generator) Equals(other Object) Object {
+	if g == other {
+		return True
+	}
+	return False
+}
+
+func (g *Generator) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "next":
+		return &Builtin{
+			name: "generator.next",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("generator.next", 0, len(args))
+				}
+				value, ok, err := g.Resume(ctx)
+				if err != nil {
+					return NewError(err)
+				}
+				if !ok {
+					return Nil
+				}
+				return value
+			},
+		}, true
+	case "entry":
+		return &Builtin{
+			name: "generator.entry",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("generator.entry", 0, len(args))
+				}
+				entry, ok := g.Entry()
+				if !ok {
+					return Nil
+				}
+				return entry
+			},
+		}, true
+	}
+	return nil, false
+}
+
+func (g *Generator) IsTruthy() bool {
+	return !g.done
+}
+
+func (g *Generator) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for generator: %v", opType)
+}
+
+// Resume resumes the generator function and returns the next value it
+// yields, or false once the function has returned. An error raised by the
+// function is returned, as is an error if the generator is already running,
+// such as when it is resumed from within its own function.
+func (g *Generator) Resume(ctx context.Context) (Object, bool, error) {
+	if !g.mutex.TryLock() {
+		return nil, false, errGeneratorRunning
+	}
+	defer g.mutex.Unlock()
+	g.err = nil
+	if g.done {
+		return nil, false, nil
+	}
+	value, ok, err := g.resume(ctx)
+	if err != nil || !ok {
+		g.done = true
+		g.current = nil
+		g.err = err
+		return nil, false, err
+	}
+	g.pos++
+	g.current = value
+	return value, true, nil
+}
+
+// Next resumes the generator function and returns the next value it yields.
+// It returns false once the function has returned, or if it raised an error,
+// which is then available from Err.
+func (g *Generator) Next(ctx context.Context) (Object, bool) {
+	value, ok, _ := g.Resume(ctx)
+	return value, ok
+}
+
+func (g *Generator) Entry() (IteratorEntry, bool) {
+	if g.current == nil {
+		return nil, false
+	}
+	return &Entry{
+		key:     NewInt(g.pos),
+		value:   g.current,
+		primary: g.current,
+	}, true
+}
+
+// Iter returns the generator itself, so that a generator can be used
+// wherever an iterable is expected.
+func (g *Generator) Iter() Iterator {
+	return g
+}
+
+// Err returns the error raised by the generator function during the last
+// call to Next, if any. While the generator is running, it returns an error
+// saying so, since a call to Next at that point fails for that reason.
+func (g *Generator) Err() error {
+	if !g.mutex.TryLock() {
+		return errGeneratorRunning
+	}
+	defer g.mutex.Unlock()
+	return g.err
+}
+
+// Function returns the generator function that was called.
+func (g *Generator) Function() *Function {
+	return g.fn
+}
+
+func (g *Generator) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal generator")
+}
+
+// NewGenerator returns a generator for a call to the given function, which
+// is run by the resume function.
+func NewGenerator(fn *Function, resume ResumeFunc) *Generator {
+	return &Generator{fn: fn, resume: resume, pos: -1}
+}
+
+// IterErr returns the error that ended an iteration, if the iterator stopped
+// because of one rather than running out of items.
+func IterErr(iter Iterator) error {
+	if iter, ok := iter.(interface{ Err() error }); ok {
+		return iter.Err()
+	}
+	return nil
+}
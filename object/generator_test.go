@@ -0,0 +1,97 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	ctx := context.Background()
+	fn := NewFunction(compiler.NewFunction(compiler.FunctionOpts{Name: "count"}))
+	n := int64(0)
+	gen := NewGenerator(fn, func(ctx context.Context) (Object, bool, error) {
+		if n == 2 {
+			return nil, false, nil
+		}
+		n++
+		return NewInt(n * 10), true, nil
+	})
+	require.Equal(t, GENERATOR, gen.Type())
+	require.Equal(t, "generator(count)", gen.Inspect())
+	require.True(t, gen.IsTruthy())
+
+	_, ok := gen.Entry()
+	require.False(t, ok)
+
+	value, ok := gen.Next(ctx)
+	require.True(t, ok)
+	require.Equal(t, NewInt(10), value)
+	entry, ok := gen.Entry()
+	require.True(t, ok)
+	require.Equal(t, NewInt(0), entry.Key())
+	require.Equal(t, NewInt(10), entry.Value())
+
+	value, ok = gen.Next(ctx)
+	require.True(t, ok)
+	require.Equal(t, NewInt(20), value)
+
+	_, ok = gen.Next(ctx)
+	require.False(t, ok)
+	require.Nil(t, gen.Err())
+	require.False(t, gen.IsTruthy())
+	require.Nil(t, IterErr(gen))
+}
+
+func TestGeneratorError(t *testing.T) {
+	fn := NewFunction(compiler.NewFunction(compiler.FunctionOpts{}))
+	gen := NewGenerator(fn, func(ctx context.Context) (Object, bool, error) {
+		return nil, false, errors.New("kaboom")
+	})
+	require.Equal(t, "generator(anonymous)", gen.Inspect())
+	_, ok := gen.Next(context.Background())
+	require.False(t, ok)
+	require.Equal(t, "kaboom", IterErr(gen).Error())
+
+	next, ok := gen.GetAttr("next")
+	require.True(t, ok)
+	require.Equal(t, Nil, next.(*Builtin).Call(context.Background()))
+}
+
+func TestGeneratorAlreadyRunning(t *testing.T) {
+	ctx := context.Background()
+	fn := NewFunction(compiler.NewFunction(compiler.FunctionOpts{Name: "self"}))
+	var gen *Generator
+	var resumeErr, iterErr error
+	gen = NewGenerator(fn, func(ctx context.Context) (Object, bool, error) {
+		_, _, resumeErr = gen.Resume(ctx)
+		_, ok := gen.Next(ctx)
+		require.False(t, ok)
+		iterErr = IterErr(gen)
+		return NewInt(1), true, nil
+	})
+	value, ok, err := gen.Resume(ctx)
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, NewInt(1), value)
+	require.Equal(t, "value error: generator is already running", resumeErr.Error())
+	require.Equal(t, "value error: generator is already running", iterErr.Error())
+
+	// The failed inner calls did not affect the generator itself
+	require.Nil(t, gen.Err())
+	require.True(t, gen.IsTruthy())
+}
+
+func TestGeneratorInterface(t *testing.T) {
+	fn := NewFunction(compiler.NewFunction(compiler.FunctionOpts{}))
+	calls := 0
+	gen := NewGenerator(fn, func(ctx context.Context) (Object, bool, error) {
+		calls++
+		return NewInt(1), true, nil
+	})
+	require.Nil(t, gen.Interface())
+	require.Equal(t, 0, calls)
+}
@@ -163,19 +163,24 @@ func (i *Int) Iter() Iterator {
 }
 
 func NewInt(value int64) *Int {
-	if value >= 0 && value < tableSize {
-		return intCache[value]
+	if value >= minCachedInt && value < maxCachedInt {
+		return intCache[value-minCachedInt]
 	}
 	return &Int{value: value}
 }
 
-const tableSize = 256
+// Integers in the range [minCachedInt, maxCachedInt) are preallocated, so
+// that arithmetic on loop counters and other small values doesn't allocate.
+const (
+	minCachedInt = -128
+	maxCachedInt = 1024
+)
 
 var intCache = []*Int{}
 
 func init() {
-	intCache = make([]*Int, tableSize)
-	for i := 0; i < tableSize; i++ {
-		intCache[i] = &Int{value: int64(i)}
+	intCache = make([]*Int, maxCachedInt-minCachedInt)
+	for i := range intCache {
+		intCache[i] = &Int{value: int64(i) + minCachedInt}
 	}
 }
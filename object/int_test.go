@@ -63,3 +63,13 @@ func TestIntBasics(t *testing.T) {
 	require.Equal(t, "-3", value.Inspect())
 	require.Equal(t, int64(-3), value.Interface())
 }
+
+func TestNewIntCache(t *testing.T) {
+	for _, value := range []int64{minCachedInt, -1, 0, 255, maxCachedInt - 1} {
+		require.Same(t, NewInt(value), NewInt(value))
+		require.Equal(t, value, NewInt(value).Value())
+	}
+	require.NotSame(t, NewInt(minCachedInt-1), NewInt(minCachedInt-1))
+	require.NotSame(t, NewInt(maxCachedInt), NewInt(maxCachedInt))
+	require.Equal(t, int64(maxCachedInt), NewInt(maxCachedInt).Value())
+}
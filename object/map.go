@@ -14,6 +14,11 @@ import (
 type Map struct {
 	items map[string]Object
 
+	// Items keyed by enum members. These are kept apart from the string keys
+	// and compared by identity, so a member never collides with a string or
+	// with a member of another enum that has the same name.
+	members map[*EnumMember]Object
+
 	// Used to avoid the possibility of infinite recursion when inspecting.
 	// Similar to the usage of Py_ReprEnter in CPython.
 	inspectActive bool
@@ -38,6 +43,9 @@ func (m *Map) Inspect() string {
 		v := m.items[k]
 		pairs = append(pairs, fmt.Sprintf("%q: %s", k, v.Inspect()))
 	}
+	for _, k := range m.sortedMembers() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", k.Inspect(), m.members[k].Inspect()))
+	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
 	out.WriteString("}")
@@ -48,6 +56,8 @@ func (m *Map) String() string {
 	return m.Inspect()
 }
 
+// Value returns the items with string keys. Items keyed by enum members are
+// not included.
 func (m *Map) Value() map[string]Object {
 	return m.items
 }
@@ -86,11 +96,10 @@ func (m *Map) GetAttr(name string) (Object, bool) {
 				if len(args) < 1 || len(args) > 2 {
 					return NewArgsRangeError("map.get", 1, 2, len(args))
 				}
-				key, err := AsString(args[0])
+				value, found, err := m.lookup(args[0])
 				if err != nil {
 					return err
 				}
-				value, found := m.items[key]
 				if !found {
 					if len(args) == 2 {
 						return args[1]
@@ -139,14 +148,25 @@ func (m *Map) GetAttr(name string) (Object, bool) {
 				if nArgs < 1 || nArgs > 2 {
 					return NewArgsRangeError("map.pop", 1, 2, len(args))
 				}
-				key, err := AsString(args[0])
-				if err != nil {
-					return err
-				}
 				var def Object
 				if nArgs == 2 {
 					def = args[1]
 				}
+				if member, ok := args[0].(*EnumMember); ok {
+					value, found := m.members[member]
+					if !found {
+						value = def
+					}
+					delete(m.members, member)
+					if value == nil {
+						return Nil
+					}
+					return value
+				}
+				key, err := AsString(args[0])
+				if err != nil {
+					return err
+				}
 				return m.Pop(key, def)
 			},
 		}, true
@@ -157,11 +177,17 @@ func (m *Map) GetAttr(name string) (Object, bool) {
 				if len(args) != 2 {
 					return NewArgsError("map.setdefault", 2, len(args))
 				}
-				key, err := AsString(args[0])
+				value, found, err := m.lookup(args[0])
 				if err != nil {
 					return err
 				}
-				return m.SetDefault(key, args[1])
+				if found {
+					return value
+				}
+				if err := m.SetItem(args[0], args[1]); err != nil {
+					return err
+				}
+				return args[1]
 			},
 		}, true
 	case "update":
@@ -189,11 +215,15 @@ func (m *Map) ListItems() *List {
 	for _, k := range m.SortedKeys() {
 		items = append(items, NewList([]Object{NewString(k), m.items[k]}))
 	}
+	for _, k := range m.sortedMembers() {
+		items = append(items, NewList([]Object{k, m.members[k]}))
+	}
 	return NewList(items)
 }
 
 func (m *Map) Clear() {
 	m.items = map[string]Object{}
+	m.members = nil
 }
 
 func (m *Map) Copy() *Map {
@@ -201,7 +231,11 @@ func (m *Map) Copy() *Map {
 	for k, v := range m.items {
 		items[k] = v
 	}
-	return &Map{items: items}
+	c := &Map{items: items}
+	for k, v := range m.members {
+		c.setMember(k, v)
+	}
+	return c
 }
 
 func (m *Map) Pop(key string, def Object) Object {
@@ -227,8 +261,12 @@ func (m *Map) Update(other *Map) {
 	for k, v := range other.items {
 		m.items[k] = v
 	}
+	for k, v := range other.members {
+		m.setMember(k, v)
+	}
 }
 
+// SortedKeys returns the string keys of the map in sorted order.
 func (m *Map) SortedKeys() []string {
 	keys := make([]string, 0, len(m.items))
 	for k := range m.items {
@@ -238,19 +276,51 @@ func (m *Map) SortedKeys() []string {
 	return keys
 }
 
-func (m *Map) Keys() *List {
-	items := make([]Object, 0, len(m.items))
+// sortedMembers returns the enum member keys of the map, ordered by enum
+// name and then by declaration order.
+func (m *Map) sortedMembers() []*EnumMember {
+	keys := make([]*EnumMember, 0, len(m.members))
+	for k := range m.members {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.typ != b.typ {
+			if a.typ.name != b.typ.name {
+				return a.typ.name < b.typ.name
+			}
+			return a.typ.id < b.typ.id
+		}
+		return a.index < b.index
+	})
+	return keys
+}
+
+// keys returns all keys of the map: the sorted string keys followed by the
+// enum member keys.
+func (m *Map) keys() []Object {
+	keys := make([]Object, 0, m.Size())
 	for _, k := range m.SortedKeys() {
-		items = append(items, NewString(k))
+		keys = append(keys, NewString(k))
 	}
-	return &List{items: items}
+	for _, k := range m.sortedMembers() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (m *Map) Keys() *List {
+	return &List{items: m.keys()}
 }
 
 func (m *Map) Values() *List {
-	items := make([]Object, 0, len(m.items))
+	items := make([]Object, 0, m.Size())
 	for _, k := range m.SortedKeys() {
 		items = append(items, m.items[k])
 	}
+	for _, k := range m.sortedMembers() {
+		items = append(items, m.members[k])
+	}
 	return &List{items: items}
 }
 
@@ -288,11 +358,16 @@ func (m *Map) Set(key string, value Object) {
 }
 
 func (m *Map) Size() int {
-	return len(m.items)
+	return len(m.items) + len(m.members)
 }
 
+// Interface returns the map as a map[string]any. Enum member keys are
+// converted to their qualified names, e.g. "Color.RED".
 func (m *Map) Interface() interface{} {
-	result := make(map[string]any, len(m.items))
+	result := make(map[string]any, m.Size())
+	for k, v := range m.members {
+		result[k.Inspect()] = v.Interface()
+	}
 	for k, v := range m.items {
 		result[k] = v.Interface()
 	}
@@ -304,7 +379,7 @@ func (m *Map) Equals(other Object) Object {
 		return False
 	}
 	otherMap := other.(*Map)
-	if len(m.items) != len(otherMap.items) {
+	if len(m.items) != len(otherMap.items) || len(m.members) != len(otherMap.members) {
 		return False
 	}
 	for k, v := range m.items {
@@ -316,6 +391,15 @@ func (m *Map) Equals(other Object) Object {
 			return False
 		}
 	}
+	for k, v := range m.members {
+		otherValue, found := otherMap.members[k]
+		if !found {
+			return False
+		}
+		if !v.Equals(otherValue).(*Bool).value {
+			return False
+		}
+	}
 	return True
 }
 
@@ -323,14 +407,37 @@ func (m *Map) RunOperation(opType op.BinaryOpType, right Object) Object {
 	return TypeErrorf("type error: unsupported operation for map: %v", opType)
 }
 
+// lookup returns the value for the given key, which must be a string or an
+// enum member.
+func (m *Map) lookup(key Object) (Object, bool, *Error) {
+	switch key := key.(type) {
+	case *String:
+		value, found := m.items[key.value]
+		return value, found, nil
+	case *EnumMember:
+		value, found := m.members[key]
+		return value, found, nil
+	}
+	return nil, false, TypeErrorf("type error: map key must be a string or enum member (got %s)", key.Type())
+}
+
+func (m *Map) setMember(key *EnumMember, value Object) {
+	if m.members == nil {
+		m.members = map[*EnumMember]Object{}
+	}
+	m.members[key] = value
+}
+
 func (m *Map) GetItem(key Object) (Object, *Error) {
-	strObj, ok := key.(*String)
-	if !ok {
-		return nil, TypeErrorf("type error: map key must be a string (got %s)", key.Type())
+	value, found, err := m.lookup(key)
+	if err != nil {
+		return nil, err
 	}
-	value, found := m.items[strObj.value]
 	if !found {
-		return nil, Errorf("key error: %q", strObj.Value())
+		if member, ok := key.(*EnumMember); ok {
+			return nil, Errorf("key error: %s", member.Inspect())
+		}
+		return nil, Errorf("key error: %q", key.(*String).value)
 	}
 	return value, nil
 }
@@ -340,43 +447,46 @@ func (m *Map) GetSlice(s Slice) (Object, *Error) {
 	return nil, TypeErrorf("map does not support slice operations")
 }
 
-// SetItem assigns a value to the given key in the map.
+// SetItem assigns a value to the given key in the map. The key must be a
+// string or an enum member.
 func (m *Map) SetItem(key, value Object) *Error {
-	strObj, ok := key.(*String)
-	if !ok {
-		return TypeErrorf("type error: map key must be a string (got %s)", key.Type())
+	switch key := key.(type) {
+	case *String:
+		m.items[key.value] = value
+	case *EnumMember:
+		m.setMember(key, value)
+	default:
+		return TypeErrorf("type error: map key must be a string or enum member (got %s)", key.Type())
 	}
-	m.items[strObj.value] = value
 	return nil
 }
 
 // DelItem deletes the item with the given key from the map.
 func (m *Map) DelItem(key Object) *Error {
-	strObj, ok := key.(*String)
-	if !ok {
-		return TypeErrorf("type error: map key must be a string (got %s)", key.Type())
+	switch key := key.(type) {
+	case *String:
+		delete(m.items, key.value)
+	case *EnumMember:
+		delete(m.members, key)
+	default:
+		return TypeErrorf("type error: map key must be a string or enum member (got %s)", key.Type())
 	}
-	delete(m.items, strObj.value)
 	return nil
 }
 
 // Contains returns true if the given item is found in this container.
 func (m *Map) Contains(key Object) *Bool {
-	strObj, ok := key.(*String)
-	if !ok {
-		return False
-	}
-	_, found := m.items[strObj.value]
-	return NewBool(found)
+	_, found, err := m.lookup(key)
+	return NewBool(err == nil && found)
 }
 
 func (m *Map) IsTruthy() bool {
-	return len(m.items) > 0
+	return m.Size() > 0
 }
 
 // Len returns the number of items in this container.
 func (m *Map) Len() *Int {
-	return NewInt(int64(len(m.items)))
+	return NewInt(int64(m.Size()))
 }
 
 func (m *Map) Iter() Iterator {
@@ -394,11 +504,23 @@ func (m *Map) StringKeys() []string {
 func (m *Map) Cost() int {
 	// It would be possible to recurse and compute the cost of each item, but
 	// let's avoid that since it would be an expensive op itself.
-	return len(m.items) * 8
+	return m.Size() * 8
 }
 
+// MarshalJSON encodes the map as a JSON object. Enum member keys are encoded
+// as their qualified names, e.g. "Color.RED".
 func (m *Map) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.items)
+	if len(m.members) == 0 {
+		return json.Marshal(m.items)
+	}
+	items := make(map[string]Object, m.Size())
+	for k, v := range m.members {
+		items[k.Inspect()] = v
+	}
+	for k, v := range m.items {
+		items[k] = v
+	}
+	return json.Marshal(items)
 }
 
 func NewMap(m map[string]Object) *Map {
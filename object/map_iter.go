@@ -11,9 +11,9 @@ import (
 type MapIter struct {
 	*base
 	m       *Map
-	keys    []string
+	keys    []Object
 	pos     int64
-	current *String
+	current Object
 }
 
 func (iter *MapIter) Type() Type {
@@ -99,7 +99,7 @@ func (iter *MapIter) Next(ctx context.Context) (Object, bool) {
 		return nil, false
 	}
 	iter.pos++
-	iter.current = NewString(keys[iter.pos])
+	iter.current = keys[iter.pos]
 	return iter.current, true
 }
 
@@ -107,7 +107,7 @@ func (iter *MapIter) Entry() (IteratorEntry, bool) {
 	if iter.current == nil {
 		return nil, false
 	}
-	value, ok := iter.m.items[iter.current.value]
+	value, ok, _ := iter.m.lookup(iter.current)
 	if !ok {
 		iter.current = nil
 		return nil, false
@@ -120,5 +120,5 @@ func (iter *MapIter) MarshalJSON() ([]byte, error) {
 }
 
 func NewMapIter(m *Map) *MapIter {
-	return &MapIter{m: m, keys: m.SortedKeys(), pos: -1}
+	return &MapIter{m: m, keys: m.keys(), pos: -1}
 }
@@ -3,6 +3,7 @@ package object
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/errz"
@@ -19,6 +20,12 @@ type Module struct {
 	callable     BuiltinFunction
 }
 
+// moduleOverrides counts the attributes overridden on any module. It is the
+// version of the cache keys of modules, so that an override invalidates
+// cached attribute lookups. Overrides are rare, so invalidating the lookups
+// on all modules is simpler than tracking a version for each.
+var moduleOverrides atomic.Uint64
+
 func (m *Module) Type() Type {
 	return MODULE
 }
@@ -41,6 +48,24 @@ func (m *Module) GetAttr(name string) (Object, bool) {
 	return nil, false
 }
 
+func (m *Module) AttrCacheKey() AttrCacheKey {
+	return AttrCacheKey{Owner: m, Version: moduleOverrides.Load()}
+}
+
+func (m *Module) AttrGetter(name string) (AttrGetter, bool) {
+	if name == "__name__" {
+		return nil, false
+	}
+	if builtin, found := m.builtins[name]; found {
+		return func(Object) Object { return builtin }, true
+	}
+	if index, found := m.globalsIndex[name]; found {
+		// Globals are read on each access, since they may be reassigned
+		return func(Object) Object { return m.globals[index] }, true
+	}
+	return nil, false
+}
+
 func (m *Module) SetAttr(name string, value Object) error {
 	return errz.TypeErrorf("type error: cannot modify module attributes")
 }
@@ -54,6 +79,7 @@ func (m *Module) Override(name string, value Object) error {
 		return TypeErrorf("type error: cannot override attribute %q", name)
 	}
 	if _, found := m.builtins[name]; found {
+		moduleOverrides.Add(1)
 		if value == nil {
 			delete(m.builtins, name)
 			return nil
@@ -62,6 +88,7 @@ func (m *Module) Override(name string, value Object) error {
 		return nil
 	}
 	if index, found := m.globalsIndex[name]; found {
+		moduleOverrides.Add(1)
 		if value == nil {
 			delete(m.globalsIndex, name)
 			return nil
@@ -32,6 +32,7 @@ type Type string
 // Type constants
 const (
 	BOOL          Type = "bool"
+	BOUND_METHOD  Type = "bound_method"
 	BUFFER        Type = "buffer"
 	BUILTIN       Type = "builtin"
 	BYTE          Type = "byte"
@@ -43,6 +44,8 @@ const (
 	COMPLEX_SLICE Type = "complex_slice"
 	DIR_ENTRY     Type = "dir_entry"
 	DYNAMIC_ATTR  Type = "dynamic_attr"
+	ENUM          Type = "enum"
+	ENUM_MEMBER   Type = "enum_member"
 	ERROR         Type = "error"
 	FILE          Type = "file"
 	FILE_INFO     Type = "file_info"
@@ -51,6 +54,7 @@ const (
 	FLOAT         Type = "float"
 	FLOAT_SLICE   Type = "float_slice"
 	FUNCTION      Type = "function"
+	GENERATOR     Type = "generator"
 	GO_FIELD      Type = "go_field"
 	GO_METHOD     Type = "go_method"
 	GO_TYPE       Type = "go_type"
@@ -71,6 +75,8 @@ const (
 	SLICE_ITER    Type = "slice_iter"
 	STRING        Type = "string"
 	STRING_ITER   Type = "string_iter"
+	STRUCT        Type = "struct"
+	STRUCT_TYPE   Type = "struct_type"
 	THREAD        Type = "thread"
 	TIME          Type = "time"
 )
@@ -173,6 +179,19 @@ type Callable interface {
 	Call(ctx context.Context, args ...Object) Object
 }
 
+// KwargsCallable is an interface for callables that may also receive keyword
+// arguments.
+type KwargsCallable interface {
+	Callable
+
+	// AcceptsKwargs returns true if the callable accepts keyword arguments.
+	AcceptsKwargs() bool
+
+	// CallWithKwargs invokes the callable with the given positional and
+	// keyword arguments and returns the result.
+	CallWithKwargs(ctx context.Context, kwargs map[string]Object, args ...Object) Object
+}
+
 // Hashable types can be hashed and consequently used in a set.
 type Hashable interface {
 	// Hash returns a hash key for the given object.
@@ -219,6 +238,31 @@ type AttrResolver interface {
 
 type ResolveAttrFunc func(ctx context.Context, name string) (Object, error)
 
+// AttrCacher is implemented by objects whose attribute lookups can be cached,
+// as the VM does for each instruction that loads an attribute.
+type AttrCacher interface {
+	// AttrCacheKey returns a key for the way attributes are resolved on this
+	// object. A getter returned by AttrGetter may be used for any object with
+	// an equal key.
+	AttrCacheKey() AttrCacheKey
+
+	// AttrGetter returns a getter for the named attribute, or false if the
+	// attribute does not exist or can't be cached.
+	AttrGetter(name string) (AttrGetter, bool)
+}
+
+// AttrCacheKey identifies how attributes are resolved on an object. Objects
+// with equal keys must have the same Go type and the same attributes. The
+// owner is typically the type of the object, or the object itself, and the
+// version changes whenever the attributes of the owner change.
+type AttrCacheKey struct {
+	Owner   any
+	Version uint64
+}
+
+// AttrGetter gets an attribute that was resolved ahead of time from an object.
+type AttrGetter func(obj Object) Object
+
 // Keys returns the keys of an object map as a sorted slice of strings.
 func Keys(m map[string]Object) []string {
 	var names []string
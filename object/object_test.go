@@ -154,3 +154,24 @@ func TestPrintableValue(t *testing.T) {
 		})
 	}
 }
+
+func TestModuleAttrCacheKey(t *testing.T) {
+	m := NewBuiltinsModule("m", map[string]Object{"a": NewInt(1)})
+	other := NewBuiltinsModule("m", map[string]Object{"a": NewInt(1)})
+	key := m.AttrCacheKey()
+	require.True(t, key == m.AttrCacheKey())
+	require.False(t, key == other.AttrCacheKey())
+
+	getter, ok := m.AttrGetter("a")
+	require.True(t, ok)
+	require.Equal(t, NewInt(1), getter(m))
+	_, ok = m.AttrGetter("__name__")
+	require.False(t, ok)
+
+	// Overriding an attribute changes the key, invalidating the getter
+	require.Nil(t, m.Override("a", NewInt(2)))
+	require.False(t, key == m.AttrCacheKey())
+	getter, ok = m.AttrGetter("a")
+	require.True(t, ok)
+	require.Equal(t, NewInt(2), getter(m))
+}
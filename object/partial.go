@@ -11,8 +11,9 @@ import (
 // Partial is a partially applied function
 type Partial struct {
 	*base
-	fn   Object
-	args []Object
+	fn     Object
+	args   []Object
+	kwargs *Map
 }
 
 func (p *Partial) Function() Object {
@@ -23,6 +24,11 @@ func (p *Partial) Args() []Object {
 	return p.args
 }
 
+// Kwargs returns the keyword arguments of the partial, or nil if it has none.
+func (p *Partial) Kwargs() *Map {
+	return p.kwargs
+}
+
 func (p *Partial) Type() Type {
 	return PARTIAL
 }
@@ -32,6 +38,11 @@ func (p *Partial) Inspect() string {
 	for _, arg := range p.args {
 		args = append(args, arg.Inspect())
 	}
+	if p.kwargs != nil {
+		for _, name := range p.kwargs.SortedKeys() {
+			args = append(args, name+"="+p.kwargs.Get(name).Inspect())
+		}
+	}
 	return fmt.Sprintf("partial(%s, %s)", p.fn.Inspect(), strings.Join(args, ", "))
 }
 
@@ -60,3 +71,12 @@ func NewPartial(fn Object, args []Object) *Partial {
 		args: args,
 	}
 }
+
+// NewPartialWithKwargs returns a partial that also holds keyword arguments.
+func NewPartialWithKwargs(fn Object, args []Object, kwargs *Map) *Partial {
+	return &Partial{
+		fn:     fn,
+		args:   args,
+		kwargs: kwargs,
+	}
+}
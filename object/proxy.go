@@ -65,8 +65,23 @@ func (p *Proxy) GoType() *GoType {
 }
 
 func (p *Proxy) GetAttr(name string) (Object, bool) {
+	getter, found := p.AttrGetter(name)
+	if !found {
+		return nil, false
+	}
+	return getter(p), true
+}
+
+// AttrCacheKey returns the Go type of the proxied object, since the
+// attributes of a Go type are fixed when the type is registered.
+func (p *Proxy) AttrCacheKey() AttrCacheKey {
+	return AttrCacheKey{Owner: p.typ}
+}
+
+func (p *Proxy) AttrGetter(name string) (AttrGetter, bool) {
 	if name == "__type__" {
-		return p.typ, true
+		typ := p.typ
+		return func(Object) Object { return typ }, true
 	}
 	attr, found := p.typ.GetAttribute(name)
 	if !found {
@@ -76,25 +91,33 @@ func (p *Proxy) GetAttr(name string) (Object, bool) {
 	case *GoField:
 		conv, ok := attr.Converter()
 		if !ok {
-			return TypeErrorf("type error: no converter for field %s", name), true
-		}
-		var value interface{}
-		if p.typ.IsPointerType() {
-			value = reflect.ValueOf(p.obj).Elem().FieldByName(name).Interface()
-		} else {
-			value = reflect.ValueOf(p.obj).FieldByName(name).Interface()
+			return func(Object) Object {
+				return TypeErrorf("type error: no converter for field %s", name)
+			}, true
 		}
-		result, err := conv.From(value)
-		if err != nil {
-			return NewError(err), true
-		}
-		return result, true
+		index := attr.field.Index
+		isPointer := p.typ.IsPointerType()
+		return func(obj Object) Object {
+			value := reflect.ValueOf(obj.(*Proxy).obj)
+			if isPointer {
+				value = value.Elem()
+			}
+			result, err := conv.From(value.FieldByIndex(index).Interface())
+			if err != nil {
+				return NewError(err)
+			}
+			return result
+		}, true
 	case *GoMethod:
-		return &Builtin{
-			name: fmt.Sprintf("%s.%s", p.typ.Name(), name),
-			fn: func(ctx context.Context, args ...Object) Object {
-				return p.call(ctx, attr, args...)
-			},
+		fullName := fmt.Sprintf("%s.%s", p.typ.Name(), name)
+		return func(obj Object) Object {
+			proxy := obj.(*Proxy)
+			return &Builtin{
+				name: fullName,
+				fn: func(ctx context.Context, args ...Object) Object {
+					return proxy.call(ctx, attr, args...)
+				},
+			}
 		}, true
 	}
 	return nil, false
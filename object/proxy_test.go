@@ -433,3 +433,26 @@ func TestProxyHasher(t *testing.T) {
 
 	require.Equal(t, expected, byte_slice.Value())
 }
+
+func TestProxyAttrGetter(t *testing.T) {
+	a, err := object.NewProxy(&proxyTestType2{A: 1})
+	require.Nil(t, err)
+	b, err := object.NewProxy(&proxyTestType2{A: 2})
+	require.Nil(t, err)
+	other, err := object.NewProxy(&proxyTestType3{})
+	require.Nil(t, err)
+
+	// Proxies of the same Go type share a cache key
+	require.True(t, a.AttrCacheKey() == b.AttrCacheKey())
+	require.False(t, a.AttrCacheKey() == other.AttrCacheKey())
+
+	getter, ok := a.AttrGetter("A")
+	require.True(t, ok)
+	require.Equal(t, object.NewInt(1), getter(a))
+	require.Equal(t, object.NewInt(2), getter(b))
+	require.Nil(t, b.SetAttr("A", object.NewInt(3)))
+	require.Equal(t, object.NewInt(3), getter(b))
+
+	_, ok = a.AttrGetter("Missing")
+	require.False(t, ok)
+}
@@ -44,169 +44,64 @@ func (s *String) HashKey() HashKey {
 }
 
 func (s *String) GetAttr(name string) (Object, bool) {
-	switch name {
-	case "contains":
-		return &Builtin{
-			name: "string.contains",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.contains", 1, len(args))
-				}
-				return s.Contains(args[0])
-			},
-		}, true
-	case "has_prefix":
-		return &Builtin{
-			name: "string.has_prefix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.has_prefix", 1, len(args))
-				}
-				return s.HasPrefix(args[0])
-			},
-		}, true
-	case "has_suffix":
-		return &Builtin{
-			name: "string.has_suffix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.has_suffix", 1, len(args))
-				}
-				return s.HasSuffix(args[0])
-			},
-		}, true
-	case "count":
-		return &Builtin{
-			name: "string.count",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.count", 1, len(args))
-				}
-				return s.Count(args[0])
-			},
-		}, true
-	case "join":
-		return &Builtin{
-			name: "string.join",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.join", 1, len(args))
-				}
-				return s.Join(args[0])
-			},
-		}, true
-	case "split":
-		return &Builtin{
-			name: "string.split",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.split", 1, len(args))
-				}
-				return s.Split(args[0])
-			},
-		}, true
-	case "fields":
-		return &Builtin{
-			name: "string.fields",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.fields", 0, len(args))
-				}
-				return s.Fields()
-			},
-		}, true
-	case "index":
-		return &Builtin{
-			name: "string.index",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.index", 1, len(args))
-				}
-				return s.Index(args[0])
-			},
-		}, true
-	case "last_index":
-		return &Builtin{
-			name: "string.last_index",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.last_index", 1, len(args))
-				}
-				return s.LastIndex(args[0])
-			},
-		}, true
-	case "replace_all":
-		return &Builtin{
-			name: "string.replace_all",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 2 {
-					return NewArgsError("string.replace_all", 2, len(args))
-				}
-				return s.ReplaceAll(args[0], args[1])
-			},
-		}, true
-	case "to_lower":
-		return &Builtin{
-			name: "string.to_lower",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.to_lower", 0, len(args))
-				}
-				return s.ToLower()
-			},
-		}, true
-	case "to_upper":
-		return &Builtin{
-			name: "string.to_upper",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.to_upper", 0, len(args))
-				}
-				return s.ToUpper()
-			},
-		}, true
-	case "trim":
-		return &Builtin{
-			name: "string.trim",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim", 1, len(args))
-				}
-				return s.Trim(args[0])
-			},
-		}, true
-	case "trim_prefix":
-		return &Builtin{
-			name: "string.trim_prefix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim_prefix", 1, len(args))
-				}
-				return s.TrimPrefix(args[0])
-			},
-		}, true
-	case "trim_space":
-		return &Builtin{
-			name: "string.trim_space",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.trim_space", 0, len(args))
-				}
-				return s.TrimSpace()
-			},
-		}, true
-	case "trim_suffix":
-		return &Builtin{
-			name: "string.trim_suffix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim_suffix", 1, len(args))
-				}
-				return s.TrimSuffix(args[0])
-			},
-		}, true
+	method, ok := stringMethods[name]
+	if !ok {
+		return nil, false
+	}
+	return method.bind(s), true
+}
+
+// AttrCacheKey returns the same key for all strings, since they share the
+// same methods.
+func (s *String) AttrCacheKey() AttrCacheKey {
+	return AttrCacheKey{Owner: STRING}
+}
+
+func (s *String) AttrGetter(name string) (AttrGetter, bool) {
+	method, ok := stringMethods[name]
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	return func(obj Object) Object { return method.bind(obj.(*String)) }, true
+}
+
+// stringMethod is a method of strings that takes a fixed number of arguments.
+type stringMethod struct {
+	name  string
+	nargs int
+	call  func(s *String, args []Object) Object
+}
+
+// bind returns a builtin that calls the method on the given string.
+func (m *stringMethod) bind(s *String) *Builtin {
+	return &Builtin{
+		name: m.name,
+		fn: func(ctx context.Context, args ...Object) Object {
+			if len(args) != m.nargs {
+				return NewArgsError(m.name, m.nargs, len(args))
+			}
+			return m.call(s, args)
+		},
+	}
+}
+
+var stringMethods = map[string]*stringMethod{
+	"contains":    {"string.contains", 1, func(s *String, args []Object) Object { return s.Contains(args[0]) }},
+	"has_prefix":  {"string.has_prefix", 1, func(s *String, args []Object) Object { return s.HasPrefix(args[0]) }},
+	"has_suffix":  {"string.has_suffix", 1, func(s *String, args []Object) Object { return s.HasSuffix(args[0]) }},
+	"count":       {"string.count", 1, func(s *String, args []Object) Object { return s.Count(args[0]) }},
+	"join":        {"string.join", 1, func(s *String, args []Object) Object { return s.Join(args[0]) }},
+	"split":       {"string.split", 1, func(s *String, args []Object) Object { return s.Split(args[0]) }},
+	"fields":      {"string.fields", 0, func(s *String, args []Object) Object { return s.Fields() }},
+	"index":       {"string.index", 1, func(s *String, args []Object) Object { return s.Index(args[0]) }},
+	"last_index":  {"string.last_index", 1, func(s *String, args []Object) Object { return s.LastIndex(args[0]) }},
+	"replace_all": {"string.replace_all", 2, func(s *String, args []Object) Object { return s.ReplaceAll(args[0], args[1]) }},
+	"to_lower":    {"string.to_lower", 0, func(s *String, args []Object) Object { return s.ToLower() }},
+	"to_upper":    {"string.to_upper", 0, func(s *String, args []Object) Object { return s.ToUpper() }},
+	"trim":        {"string.trim", 1, func(s *String, args []Object) Object { return s.Trim(args[0]) }},
+	"trim_prefix": {"string.trim_prefix", 1, func(s *String, args []Object) Object { return s.TrimPrefix(args[0]) }},
+	"trim_space":  {"string.trim_space", 0, func(s *String, args []Object) Object { return s.TrimSpace() }},
+	"trim_suffix": {"string.trim_suffix", 1, func(s *String, args []Object) Object { return s.TrimSuffix(args[0]) }},
 }
 
 func (s *String) Interface() interface{} {
@@ -1,6 +1,7 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -97,3 +98,19 @@ func TestStringHashKey(t *testing.T) {
 
 	require.Equal(t, HashKey{Type: STRING, StrValue: "hello"}, a.HashKey())
 }
+
+func TestStringAttrGetter(t *testing.T) {
+	a, b := NewString(" a "), NewString("b")
+	require.True(t, a.AttrCacheKey() == b.AttrCacheKey())
+
+	getter, ok := a.AttrGetter("trim_space")
+	require.True(t, ok)
+	method, ok := getter(b).(*Builtin)
+	require.True(t, ok)
+	require.Equal(t, "string.trim_space", method.Name())
+	require.Equal(t, NewString("b"), method.Call(context.Background()))
+	require.Equal(t, NewArgsError("string.trim_space", 0, 1), method.Call(context.Background(), b))
+
+	_, ok = a.AttrGetter("missing")
+	require.False(t, ok)
+}
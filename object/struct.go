@@ -0,0 +1,393 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// StructType is a user-defined record type created by a struct declaration.
+// Calling a StructType constructs a new Struct instance.
+type StructType struct {
+	*base
+	name        string
+	fields      []string
+	fieldIndex  map[string]int
+	defaults    []Object
+	methods     map[string]*Function
+	methodNames []string
+}
+
+func (t *StructType) Type() Type {
+	return STRUCT_TYPE
+}
+
+func (t *StructType) Name() string {
+	return t.name
+}
+
+// Fields returns the names of the fields of the struct, in declaration order.
+func (t *StructType) Fields() []string {
+	return t.fields
+}
+
+// Methods returns the names of the methods of the struct, in declaration order.
+func (t *StructType) Methods() []string {
+	return t.methodNames
+}
+
+// Method returns the method with the given name, if it exists.
+func (t *StructType) Method(name string) (*Function, bool) {
+	method, ok := t.methods[name]
+	return method, ok
+}
+
+// RequiredFieldsCount returns the number of fields that must be passed to
+// the constructor. The remaining fields have default values.
+func (t *StructType) RequiredFieldsCount() int {
+	return len(t.fields) - len(t.defaults)
+}
+
+func (t *StructType) Inspect() string {
+	return fmt.Sprintf("struct %s(%s)", t.name, strings.Join(t.fields, ", "))
+}
+
+func (t *StructType) String() string {
+	return t.Inspect()
+}
+
+func (t *StructType) Interface() interface{} {
+	return nil
+}
+
+func (t *StructType) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "__name__":
+		return NewString(t.name), true
+	case "__fields__":
+		return NewStringList(t.fields), true
+	}
+	if method, ok := t.methods[name]; ok {
+		return method, true
+	}
+	return nil, false
+}
+
+func (t *StructType) SetAttr(name string, value Object) error {
+	return TypeErrorf("type error: cannot set attribute %q on struct type %s", name, t.name)
+}
+
+func (t *StructType) Equals(other Object) Object {
+	if t == other {
+		return True
+	}
+	return False
+}
+
+func (t *StructType) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for struct_type: %v", opType)
+}
+
+func (t *StructType) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal struct_type")
+}
+
+// Call constructs a new instance of the struct. Arguments are assigned to
+// fields positionally and omitted trailing fields take their default values.
+func (t *StructType) Call(ctx context.Context, args ...Object) Object {
+	nArgs := len(args)
+	required := t.RequiredFieldsCount()
+	if nArgs < required || nArgs > len(t.fields) {
+		if required == len(t.fields) {
+			return NewArgsError(t.name, required, nArgs)
+		}
+		return NewArgsRangeError(t.name, required, len(t.fields), nArgs)
+	}
+	values := make([]Object, len(t.fields))
+	copy(values, args)
+	for i := nArgs; i < len(t.fields); i++ {
+		values[i] = t.defaults[i-required]
+	}
+	return &Struct{typ: t, values: values}
+}
+
+// AcceptsKwargs returns true, since fields may be passed by name.
+func (t *StructType) AcceptsKwargs() bool {
+	return true
+}
+
+// CallWithKwargs constructs a new instance of the struct, with fields given
+// positionally or by name. Fields that are not given take their default
+// values.
+func (t *StructType) CallWithKwargs(ctx context.Context, kwargs map[string]Object, args ...Object) Object {
+	if len(kwargs) == 0 {
+		return t.Call(ctx, args...)
+	}
+	if len(args) > len(t.fields) {
+		return ArgsErrorf("args error: %s() takes at most %d positional arguments (%d given)",
+			t.name, len(t.fields), len(args))
+	}
+	values := make([]Object, len(t.fields))
+	copy(values, args)
+	names := make([]string, 0, len(kwargs))
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		idx, ok := t.fieldIndex[name]
+		if !ok {
+			return ArgsErrorf("args error: %s() got an unexpected keyword argument %q", t.name, name)
+		}
+		if values[idx] != nil {
+			return ArgsErrorf("args error: %s() got multiple values for argument %q", t.name, name)
+		}
+		values[idx] = kwargs[name]
+	}
+	required := t.RequiredFieldsCount()
+	for i, value := range values {
+		if value != nil {
+			continue
+		}
+		if i < required {
+			return ArgsErrorf("args error: %s() missing argument %q", t.name, t.fields[i])
+		}
+		values[i] = t.defaults[i-required]
+	}
+	return &Struct{typ: t, values: values}
+}
+
+// NewStructType creates a new struct type. The defaults slice holds values
+// for the trailing fields of the struct, which are optional when calling the
+// constructor.
+func NewStructType(
+	name string,
+	fields []string,
+	defaults []Object,
+	methods map[string]*Function,
+	methodNames []string,
+) *StructType {
+	fieldIndex := make(map[string]int, len(fields))
+	for i, field := range fields {
+		fieldIndex[field] = i
+	}
+	if methods == nil {
+		methods = map[string]*Function{}
+	}
+	return &StructType{
+		name:        name,
+		fields:      fields,
+		fieldIndex:  fieldIndex,
+		defaults:    defaults,
+		methods:     methods,
+		methodNames: methodNames,
+	}
+}
+
+// Struct is an instance of a user-defined StructType.
+type Struct struct {
+	*base
+	typ    *StructType
+	values []Object
+}
+
+func (s *Struct) Type() Type {
+	return STRUCT
+}
+
+// StructType returns the type this struct is an instance of.
+func (s *Struct) StructType() *StructType {
+	return s.typ
+}
+
+// Field returns the value of the named field, if it exists.
+func (s *Struct) Field(name string) (Object, bool) {
+	idx, ok := s.typ.fieldIndex[name]
+	if !ok {
+		return nil, false
+	}
+	return s.values[idx], true
+}
+
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+	out.WriteString(s.typ.name + "(")
+	for i, field := range s.typ.fields {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(field + "=" + s.values[i].Inspect())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+func (s *Struct) String() string {
+	return s.Inspect()
+}
+
+func (s *Struct) Interface() interface{} {
+	result := make(map[string]interface{}, len(s.values))
+	for i, field := range s.typ.fields {
+		result[field] = s.values[i].Interface()
+	}
+	return result
+}
+
+func (s *Struct) GetAttr(name string) (Object, bool) {
+	if value, ok := s.Field(name); ok {
+		return value, true
+	}
+	if method, ok := s.typ.methods[name]; ok {
+		return NewBoundMethod(s, method), true
+	}
+	return nil, false
+}
+
+// AttrCacheKey returns the type of the struct, since all structs of a type
+// have the same fields and methods.
+func (s *Struct) AttrCacheKey() AttrCacheKey {
+	return AttrCacheKey{Owner: s.typ}
+}
+
+func (s *Struct) AttrGetter(name string) (AttrGetter, bool) {
+	if idx, ok := s.typ.fieldIndex[name]; ok {
+		return func(obj Object) Object { return obj.(*Struct).values[idx] }, true
+	}
+	if method, ok := s.typ.methods[name]; ok {
+		return func(obj Object) Object { return NewBoundMethod(obj.(*Struct), method) }, true
+	}
+	return nil, false
+}
+
+func (s *Struct) SetAttr(name string, value Object) error {
+	idx, ok := s.typ.fieldIndex[name]
+	if !ok {
+		return TypeErrorf("type error: struct %s has no field %q", s.typ.name, name)
+	}
+	s.values[idx] = value
+	return nil
+}
+
+func (s *Struct) Equals(other Object) Object {
+	otherStruct, ok := other.(*Struct)
+	if !ok || s.typ != otherStruct.typ {
+		return False
+	}
+	for i, value := range s.values {
+		if value.Equals(otherStruct.values[i]) == False {
+			return False
+		}
+	}
+	return True
+}
+
+func (s *Struct) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for struct: %v", opType)
+}
+
+// MarshalJSON encodes the struct as a JSON object with its fields in
+// declaration order.
+func (s *Struct) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, field := range s.typ.fields {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		key, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(s.values[i])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// BoundMethod is a struct method bound to a specific struct instance. When
+// called, the instance is passed as the first argument to the method.
+type BoundMethod struct {
+	*base
+	receiver Object
+	method   *Function
+}
+
+func (m *BoundMethod) Type() Type {
+	return BOUND_METHOD
+}
+
+func (m *BoundMethod) Receiver() Object {
+	return m.receiver
+}
+
+func (m *BoundMethod) Method() *Function {
+	return m.method
+}
+
+func (m *BoundMethod) Inspect() string {
+	return fmt.Sprintf("bound_method(%s)", m.method.Name())
+}
+
+func (m *BoundMethod) String() string {
+	return m.Inspect()
+}
+
+func (m *BoundMethod) Interface() interface{} {
+	return nil
+}
+
+func (m *BoundMethod) Equals(other Object) Object {
+	otherMethod, ok := other.(*BoundMethod)
+	if !ok {
+		return False
+	}
+	if m.method == otherMethod.method && m.receiver == otherMethod.receiver {
+		return True
+	}
+	return False
+}
+
+func (m *BoundMethod) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for bound_method: %v", opType)
+}
+
+func (m *BoundMethod) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal bound_method")
+}
+
+// Args returns the arguments to pass to the underlying method, with the
+// receiver prepended to the given arguments.
+func (m *BoundMethod) Args(args []Object) []Object {
+	result := make([]Object, 0, len(args)+1)
+	result = append(result, m.receiver)
+	return append(result, args...)
+}
+
+func (m *BoundMethod) Call(ctx context.Context, args ...Object) Object {
+	callFunc, found := GetCallFunc(ctx)
+	if !found {
+		return Errorf("eval error: context did not contain a call function")
+	}
+	result, err := callFunc(ctx, m.method, m.Args(args))
+	if err != nil {
+		return NewError(err)
+	}
+	return result
+}
+
+func NewBoundMethod(receiver Object, method *Function) *BoundMethod {
+	return &BoundMethod{receiver: receiver, method: method}
+}
@@ -0,0 +1,102 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructConstructor(t *testing.T) {
+	typ := NewStructType("Point", []string{"x", "y", "z"}, []Object{NewInt(0)}, nil, nil)
+	require.Equal(t, 2, typ.RequiredFieldsCount())
+	require.Equal(t, "struct Point(x, y, z)", typ.Inspect())
+
+	obj := typ.Call(context.Background(), NewInt(1), NewInt(2))
+	s, ok := obj.(*Struct)
+	require.True(t, ok)
+	require.Equal(t, STRUCT, s.Type())
+	require.Equal(t, typ, s.StructType())
+	z, ok := s.GetAttr("z")
+	require.True(t, ok)
+	require.Equal(t, NewInt(0), z)
+	require.Equal(t, "Point(x=1, y=2, z=0)", s.Inspect())
+
+	errObj := typ.Call(context.Background(), NewInt(1))
+	require.Equal(t, ERROR, errObj.Type())
+	require.Equal(t, "args error: Point() takes between 2 and 3 arguments (1 given)",
+		errObj.(*Error).Message().Value())
+}
+
+func TestStructConstructorKwargs(t *testing.T) {
+	ctx := context.Background()
+	typ := NewStructType("Point", []string{"x", "y", "z"}, []Object{NewInt(0), NewInt(9)}, nil, nil)
+	require.True(t, typ.AcceptsKwargs())
+
+	s := typ.CallWithKwargs(ctx, map[string]Object{"z": NewInt(3)}, NewInt(1)).(*Struct)
+	require.Equal(t, "Point(x=1, y=0, z=3)", s.Inspect())
+
+	errObj := typ.CallWithKwargs(ctx, map[string]Object{"y": NewInt(2)})
+	require.Equal(t, ERROR, errObj.Type())
+	require.Equal(t, "args error: Point() missing argument \"x\"", errObj.(*Error).Message().Value())
+}
+
+func TestStructSetAttr(t *testing.T) {
+	typ := NewStructType("Point", []string{"x", "y"}, nil, nil, nil)
+	s := typ.Call(context.Background(), NewInt(1), NewInt(2)).(*Struct)
+	require.Nil(t, s.SetAttr("x", NewInt(5)))
+	x, ok := s.Field("x")
+	require.True(t, ok)
+	require.Equal(t, NewInt(5), x)
+
+	err := s.SetAttr("w", NewInt(1))
+	require.NotNil(t, err)
+	require.Equal(t, "type error: struct Point has no field \"w\"", err.Error())
+	require.NotNil(t, typ.SetAttr("x", NewInt(1)))
+}
+
+func TestStructEquals(t *testing.T) {
+	ctx := context.Background()
+	a := NewStructType("A", []string{"x"}, nil, nil, nil)
+	b := NewStructType("B", []string{"x"}, nil, nil, nil)
+	require.Equal(t, True, a.Call(ctx, NewInt(1)).Equals(a.Call(ctx, NewInt(1))))
+	require.Equal(t, False, a.Call(ctx, NewInt(1)).Equals(a.Call(ctx, NewInt(2))))
+	require.Equal(t, False, a.Call(ctx, NewInt(1)).Equals(b.Call(ctx, NewInt(1))))
+	require.Equal(t, True, a.Equals(a))
+	require.Equal(t, False, a.Equals(b))
+}
+
+func TestStructMarshalJSON(t *testing.T) {
+	typ := NewStructType("Person", []string{"name", "age", "tags"}, nil, nil, nil)
+	s := typ.Call(context.Background(),
+		NewString("joe"), NewInt(42), NewStringList([]string{"a", "b"}))
+	data, err := json.Marshal(s)
+	require.Nil(t, err)
+	require.Equal(t, `{"name":"joe","age":42,"tags":["a","b"]}`, string(data))
+	require.Equal(t, map[string]interface{}{
+		"name": "joe",
+		"age":  int64(42),
+		"tags": []interface{}{"a", "b"},
+	}, s.Interface())
+
+	_, err = json.Marshal(typ)
+	require.NotNil(t, err)
+}
+
+func TestStructAttrGetter(t *testing.T) {
+	typ := NewStructType("Point", []string{"x", "y"}, nil, nil, nil)
+	a, ok := typ.Call(context.Background(), NewInt(1), NewInt(2)).(*Struct)
+	require.True(t, ok)
+	b, ok := typ.Call(context.Background(), NewInt(3), NewInt(4)).(*Struct)
+	require.True(t, ok)
+	require.True(t, a.AttrCacheKey() == b.AttrCacheKey())
+
+	getter, ok := a.AttrGetter("y")
+	require.True(t, ok)
+	require.Equal(t, NewInt(2), getter(a))
+	require.Equal(t, NewInt(4), getter(b))
+
+	_, ok = a.AttrGetter("z")
+	require.False(t, ok)
+}
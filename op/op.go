@@ -14,12 +14,15 @@ const (
 	ReturnValue Code = 4
 	Defer       Code = 5
 	Go          Code = 6
+	Yield       Code = 7
 
 	// Jump
-	JumpBackward          Code = 10
-	JumpForward           Code = 11
-	PopJumpForwardIfFalse Code = 12
-	PopJumpForwardIfTrue  Code = 13
+	JumpBackward           Code = 10
+	JumpForward            Code = 11
+	PopJumpForwardIfFalse  Code = 12
+	PopJumpForwardIfTrue   Code = 13
+	PopJumpForwardIfNil    Code = 14
+	PopJumpForwardIfNotNil Code = 15
 
 	// Load
 	LoadAttr   Code = 20
@@ -45,14 +48,27 @@ const (
 	BuildMap    Code = 51
 	BuildSet    Code = 52
 	BuildString Code = 53
+	BuildStruct Code = 54
+	ListExtend  Code = 55
+	MapMerge    Code = 56
+
+	// Comprehensions. ListAppend, SetAdd, and MapAdd pop an element, or a key
+	// and a value, and add it to the container that is the operand's depth
+	// below the top of the stack. The container is held beneath the iterators
+	// of the comprehension's loops, where no other opcode can reach it.
+	ListAppend Code = 57
+	SetAdd     Code = 58
+	MapAdd     Code = 59
 
 	// Containers
-	BinarySubscr Code = 60
-	StoreSubscr  Code = 61
-	ContainsOp   Code = 62
-	Length       Code = 63
-	Slice        Code = 64
-	Unpack       Code = 65
+	BinarySubscr   Code = 60
+	StoreSubscr    Code = 61
+	ContainsOp     Code = 62
+	Length         Code = 63
+	Slice          Code = 64
+	Unpack         Code = 65
+	UnpackRest     Code = 66
+	OptionalSubscr Code = 67
 
 	// Stack
 	Swap   Code = 70
@@ -76,15 +92,85 @@ const (
 	// Channels
 	Receive Code = 110
 	Send    Code = 111
+	Select  Code = 112
 
 	// Closures
-	LoadClosure Code = 120
-	MakeCell    Code = 121
+	LoadClosure  Code = 120
+	MakeCell     Code = 121
+	LoadFreeCell Code = 122
+	WrapFunction Code = 123
 
 	// Partials
 	Partial Code = 130
+
+	// Exceptions
+	PushExcept Code = 140
+	PopExcept  Code = 141
+	Raise      Code = 142
+
+	// Pattern matching
+	MatchType Code = 150
+	MatchList Code = 151
+	MatchKey  Code = 152
+	MatchAttr Code = 153
+
+	// Enums
+	BuildEnum Code = 160
+
+	// Strings
+	FormatValue Code = 170
+
+	// Specialized operations, used when the operand types are not known at
+	// compile time. BinaryAddFast, BinarySubtractFast, and CompareOpFast take
+	// a fast path when both operands are integers and otherwise behave like
+	// BinaryOp and CompareOp. The FastConst opcodes combine LoadFast,
+	// LoadConst, and an operation.
+	BinaryAddFast      Code = 180
+	BinarySubtractFast Code = 181
+	CompareOpFast      Code = 182
+	BinaryOpFastConst  Code = 183
+	CompareOpFastConst Code = 184
+)
+
+// Flags set in the operand of Call and Partial, above the count of positional
+// arguments, when a call unpacks arguments with "...items" or "**opts".
+const (
+	// CallArgsList indicates that the positional arguments were collected
+	// into a single list, which is pushed in place of the arguments.
+	CallArgsList uint16 = 1 << 8
+
+	// CallKwargs indicates that a map of keyword arguments is pushed after
+	// the positional arguments.
+	CallKwargs uint16 = 1 << 9
+
+	// CallArgsMask selects the count of positional arguments.
+	CallArgsMask uint16 = 0xff
 )
 
+// ForIterItem is set in the name count operand of ForIter to push the item
+// returned by the iterator, rather than the key of its entry, when there is a
+// single name. Comprehensions iterate this way, so that "for x in items" binds
+// the items of a list and the keys of a map.
+const ForIterItem uint16 = 1 << 8
+
+// Conversions applied by FormatValue to a value before it is formatted, as
+// in the f-string "{name!r}".
+const (
+	// FormatNone formats the value itself.
+	FormatNone uint16 = 0
+
+	// FormatRepr formats the representation of the value, as returned by
+	// its Inspect method.
+	FormatRepr uint16 = 1
+
+	// FormatStr formats the string form of the value.
+	FormatStr uint16 = 2
+)
+
+// SelectDefault is set in the operand of Select, above the count of cases,
+// when the select statement has a default case.
+const SelectDefault uint16 = 1 << 8
+
 // BinaryOpType describes a type of binary operation, as in an operation that
 // takes two operands. For example, addition, subtraction, multiplication, etc.
 type BinaryOpType uint16
@@ -190,19 +276,27 @@ func init() {
 		count int
 	}
 	ops := []opInfo{
+		{BinaryAddFast, "BINARY_ADD_FAST", 0},
 		{BinaryOp, "BINARY_OP", 1},
+		{BinaryOpFastConst, "BINARY_OP_FAST_CONST", 3},
 		{BinarySubscr, "BINARY_SUBSCR", 0},
+		{BinarySubtractFast, "BINARY_SUBTRACT_FAST", 0},
+		{BuildEnum, "BUILD_ENUM", 1},
 		{BuildList, "BUILD_LIST", 1},
 		{BuildMap, "BUILD_MAP", 1},
 		{BuildSet, "BUILD_SET", 1},
 		{BuildString, "BUILD_STRING", 1},
+		{BuildStruct, "BUILD_STRUCT", 3},
 		{Call, "CALL", 1},
 		{CompareOp, "COMPARE_OP", 1},
+		{CompareOpFast, "COMPARE_OP_FAST", 1},
+		{CompareOpFastConst, "COMPARE_OP_FAST_CONST", 3},
 		{ContainsOp, "CONTAINS_OP", 1},
 		{Copy, "COPY", 1},
 		{Defer, "DEFER", 0},
 		{False, "FALSE", 0},
 		{ForIter, "FOR_ITER", 2},
+		{FormatValue, "FORMAT_VALUE", 1},
 		{FromImport, "FROM_IMPORT", 2},
 		{GetIter, "GET_ITER", 0},
 		{Go, "GO", 0},
@@ -211,23 +305,40 @@ func init() {
 		{JumpBackward, "JUMP_BACKWARD", 1},
 		{JumpForward, "JUMP_FORWARD", 1},
 		{Length, "LENGTH", 0},
+		{ListExtend, "LIST_EXTEND", 0},
+		{ListAppend, "LIST_APPEND", 1},
 		{LoadAttr, "LOAD_ATTR", 1},
 		{LoadClosure, "LOAD_CLOSURE", 2},
 		{LoadConst, "LOAD_CONST", 1},
 		{LoadFast, "LOAD_FAST", 1},
 		{LoadFree, "LOAD_FREE", 1},
+		{LoadFreeCell, "LOAD_FREE_CELL", 1},
 		{LoadGlobal, "LOAD_GLOBAL", 1},
 		{MakeCell, "MAKE_CELL", 2},
+		{MapAdd, "MAP_ADD", 1},
+		{MapMerge, "MAP_MERGE", 0},
+		{MatchAttr, "MATCH_ATTR", 1},
+		{MatchKey, "MATCH_KEY", 1},
+		{MatchList, "MATCH_LIST", 2},
+		{MatchType, "MATCH_TYPE", 1},
 		{Nil, "NIL", 0},
 		{Nop, "NOP", 0},
+		{OptionalSubscr, "OPTIONAL_SUBSCR", 0},
 		{Partial, "PARTIAL", 1},
+		{PopExcept, "POP_EXCEPT", 0},
 		{PopJumpForwardIfFalse, "POP_JUMP_FORWARD_IF_FALSE", 1},
+		{PopJumpForwardIfNil, "POP_JUMP_FORWARD_IF_NIL", 1},
+		{PopJumpForwardIfNotNil, "POP_JUMP_FORWARD_IF_NOT_NIL", 1},
 		{PopJumpForwardIfTrue, "POP_JUMP_FORWARD_IF_TRUE", 1},
 		{PopTop, "POP_TOP", 0},
+		{PushExcept, "PUSH_EXCEPT", 1},
+		{Raise, "RAISE", 0},
 		{Range, "RANGE", 0},
 		{Receive, "RECEIVE", 0},
 		{ReturnValue, "RETURN_VALUE", 0},
+		{Select, "SELECT", 1},
 		{Send, "SEND", 0},
+		{SetAdd, "SET_ADD", 1},
 		{Slice, "SLICE", 0},
 		{StoreAttr, "STORE_ATTR", 1},
 		{StoreFast, "STORE_FAST", 1},
@@ -239,6 +350,9 @@ func init() {
 		{UnaryNegative, "UNARY_NEGATIVE", 0},
 		{UnaryNot, "UNARY_NOT", 0},
 		{Unpack, "UNPACK", 1},
+		{UnpackRest, "UNPACK_REST", 2},
+		{WrapFunction, "WRAP_FUNCTION", 0},
+		{Yield, "YIELD", 0},
 	}
 	for _, o := range ops {
 		infos[o.op] = Info{
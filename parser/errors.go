@@ -161,6 +161,8 @@ func tokenTypeDescription(t token.Type) string {
 		return "identifier"
 	case token.NEWLINE:
 		return "newline"
+	case token.IN:
+		return "in"
 	default:
 		return string(t)
 	}
@@ -123,7 +123,7 @@ func New(l *lexer.Lexer, options ...Option) *Parser {
 	p.registerPrefix(token.FSTRING, p.parseString)
 	p.registerPrefix(token.FUNC, p.parseFunc)
 	p.registerPrefix(token.GO, p.parseGo)
-	p.registerPrefix(token.IDENT, p.parseIdent)
+	p.registerPrefix(token.IDENT, p.parseIdentOrKeyword)
 	p.registerPrefix(token.IF, p.parseIf)
 	p.registerPrefix(token.ILLEGAL, p.illegalToken)
 	p.registerPrefix(token.IMPORT, p.parseImport)
@@ -167,6 +167,8 @@ func New(l *lexer.Lexer, options ...Option) *Parser {
 	p.registerInfix(token.PLUS, p.parseInfixExpr)
 	p.registerInfix(token.POW, p.parseInfixExpr)
 	p.registerInfix(token.QUESTION, p.parseTernary)
+	p.registerInfix(token.QUESTION_PERIOD, p.parseOptionalChain)
+	p.registerInfix(token.NULLISH, p.parseInfixExpr)
 	p.registerInfix(token.SEND, p.parseSend)
 	p.registerInfix(token.SLASH_EQUALS, p.parseAssign)
 	p.registerInfix(token.SLASH, p.parseInfixExpr)
@@ -229,7 +231,11 @@ func (p *Parser) Parse(ctx context.Context) (*ast.Program, error) {
 			return nil, err
 		}
 	}
-	return ast.NewProgram(statements), p.err
+	var comments []*ast.Comment
+	for _, tok := range p.l.Comments() {
+		comments = append(comments, ast.NewComment(tok))
+	}
+	return ast.NewProgramWithComments(statements, comments), p.err
 }
 
 // registerPrefix registers a function for handling a prefix-based statement.
@@ -309,15 +315,27 @@ func (p *Parser) parseStatement() ast.Node {
 		stmt = p.parseConst()
 	case token.RETURN:
 		stmt = p.parseReturn()
+	case token.YIELD:
+		stmt = p.parseYield()
 	case token.BREAK:
 		stmt = p.parseBreak()
 	case token.CONTINUE:
 		stmt = p.parseContinue()
+	case token.STRUCT:
+		stmt = p.parseStruct()
+	case token.AT:
+		stmt = p.parseDecorated()
 	case token.NEWLINE:
 		stmt = nil
 	case token.IDENT:
-		if p.peekTokenIs(token.DECLARE) || p.peekTokenIs(token.COMMA) {
+		if p.curToken.Literal == "try" && p.peekTokenIs(token.LBRACE) {
+			stmt = p.parseTry()
+		} else if p.curToken.Literal == "enum" && p.peekTokenIs(token.IDENT) {
+			stmt = p.parseEnum()
+		} else if p.peekTokenIs(token.DECLARE) || p.peekTokenIs(token.COMMA) {
 			stmt = p.parseDeclaration()
+		} else if p.peekTokenIs(token.COLON) {
+			stmt = p.parseLabeled()
 		} else {
 			stmt = p.parseExpressionStatement()
 		}
@@ -440,12 +458,87 @@ func (p *Parser) parseReturn() *ast.Return {
 	return ast.NewReturn(returnToken, value)
 }
 
+func (p *Parser) parseYield() *ast.Yield {
+	yieldToken := p.curToken
+	if p.peekTokenIs(token.SEMICOLON) ||
+		p.peekTokenIs(token.NEWLINE) ||
+		p.peekTokenIs(token.RBRACE) ||
+		p.peekTokenIs(token.EOF) {
+		return ast.NewYield(yieldToken, nil)
+	}
+	p.nextToken()
+	value := p.parseExpression(LOWEST)
+	if value == nil {
+		return nil
+	}
+	return ast.NewYield(yieldToken, value)
+}
+
 func (p *Parser) parseBreak() *ast.Control {
-	return ast.NewControl(p.curToken, nil)
+	return p.parseLoopControl()
 }
 
 func (p *Parser) parseContinue() *ast.Control {
-	return ast.NewControl(p.curToken, nil)
+	return p.parseLoopControl()
+}
+
+// parseLoopControl parses a break or continue statement, which may name the
+// label of the loop it applies to.
+func (p *Parser) parseLoopControl() *ast.Control {
+	tok := p.curToken
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		return ast.NewLabeledControl(tok, ast.NewIdent(p.curToken))
+	}
+	return ast.NewControl(tok, nil)
+}
+
+// parseDecorated parses one or more "@decorator" lines followed by the named
+// function they apply to.
+func (p *Parser) parseDecorated() ast.Node {
+	atToken := p.curToken
+	var decorators []ast.Expression
+	for p.curTokenIs(token.AT) {
+		p.nextToken() // move past the "@"
+		decorator := p.parseExpression(LOWEST)
+		if decorator == nil {
+			return nil
+		}
+		decorators = append(decorators, decorator)
+		if !p.peekTokenIs(token.NEWLINE) && !p.peekTokenIs(token.EOF) {
+			p.setTokenError(p.peekToken, "unexpected %s after decorator", p.peekToken.Literal)
+			return nil
+		}
+		p.nextToken()
+		p.eatNewlines()
+	}
+	if !p.curTokenIs(token.FUNC) || !p.peekTokenIs(token.IDENT) {
+		p.setTokenError(atToken, "decorators must be followed by a named function")
+		return nil
+	}
+	fn, ok := p.parseFunc().(*ast.Func)
+	if !ok {
+		return nil
+	}
+	return ast.NewDecorated(atToken, decorators, fn)
+}
+
+// parseLabeled parses a label followed by the for loop it names, as in
+// "outer: for ...".
+func (p *Parser) parseLabeled() ast.Node {
+	label := ast.NewIdent(p.curToken)
+	p.nextToken() // move to the colon
+	p.nextToken()
+	p.eatNewlines()
+	if !p.curTokenIs(token.FOR) {
+		p.setTokenError(label.Token(), "label %s must be followed by a for loop", label.Literal())
+		return nil
+	}
+	loop, ok := p.parseFor().(*ast.For)
+	if !ok {
+		return nil
+	}
+	return ast.NewLabeled(label, loop)
 }
 
 func (p *Parser) parseExpressionStatement() ast.Node {
@@ -627,66 +720,435 @@ func (p *Parser) parseSwitch() ast.Node {
 		// Now we are at the block of code to be executed for this case
 		p.nextToken()
 		p.eatNewlines()
-		// An empty case statement is valid
-		if p.curTokenIs(token.CASE) || p.curTokenIs(token.DEFAULT) || p.curTokenIs(token.RBRACE) {
-			if isDefaultCase {
-				defaultCaseCount++
-				if defaultCaseCount > 1 {
-					p.setTokenError(caseToken, "switch statement has multiple default blocks")
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		if isDefaultCase {
+			defaultCaseCount++
+			if defaultCaseCount > 1 {
+				p.setTokenError(caseToken, "switch statement has multiple default blocks")
+				return nil
+			}
+			cases = append(cases, ast.NewDefaultCase(caseToken, block))
+		} else {
+			cases = append(cases, ast.NewCase(caseToken, caseExprs, block))
+		}
+	}
+	return ast.NewSwitch(switchToken, switchValue, cases)
+}
+
+// parseCaseBlock parses the statements of a switch or match case, starting
+// at the current token and ending before the next case, default, or closing
+// brace. An empty case is valid and results in a nil block.
+func (p *Parser) parseCaseBlock() (*ast.Block, bool) {
+	if p.curTokenIs(token.CASE) || p.curTokenIs(token.DEFAULT) || p.curTokenIs(token.RBRACE) {
+		return nil, true
+	}
+	blockFirstToken := p.curToken
+	var blockStatements []ast.Node
+	for {
+		// Skip over newlines and semicolons
+		for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) {
+			if err := p.nextToken(); err != nil {
+				return nil, false
+			}
+		}
+		// Any of these tokens indicate the end of the current case
+		if p.curTokenIs(token.CASE) ||
+			p.curTokenIs(token.DEFAULT) ||
+			p.curTokenIs(token.RBRACE) ||
+			p.curTokenIs(token.EOF) {
+			break
+		}
+		// Parse one statement
+		if s := p.parseStatement(); s != nil {
+			blockStatements = append(blockStatements, s)
+		}
+		if !p.curTokenIs(token.SEMICOLON) &&
+			!statementTerminators[p.peekToken.Type] &&
+			!p.peekTokenIs(token.CASE) &&
+			!p.peekTokenIs(token.DEFAULT) &&
+			!p.peekTokenIs(token.RBRACE) {
+			p.peekError("case statement", token.SEMICOLON, p.peekToken)
+			return nil, false
+		}
+		// Move to the token just beyond the statement
+		if err := p.nextToken(); err != nil {
+			return nil, false
+		}
+	}
+	return ast.NewBlock(blockFirstToken, blockStatements), true
+}
+
+// parseIdentOrKeyword parses an identifier, or a match expression if the
+// identifier is "match" and is followed by the value to match, or a select
+// expression if the identifier is "select" and is followed by a brace. Since
+// "match" and "select" are also common function and attribute names, they
+// are only keywords where an identifier could not appear.
+func (p *Parser) parseIdentOrKeyword() ast.Node {
+	if p.curToken.Literal == "match" && p.isMatchStart() {
+		return p.parseMatch()
+	}
+	if p.curToken.Literal == "select" && p.peekTokenIs(token.LBRACE) {
+		return p.parseSelect()
+	}
+	return p.parseIdent()
+}
+
+// isMatchStart returns true if the peek token begins the value of a match
+// expression. A parenthesis or bracket directly following "match" is treated
+// as a call or index expression, as in "match(x)" and "match[0]".
+func (p *Parser) isMatchStart() bool {
+	switch p.peekToken.Type {
+	case token.IDENT, token.INT, token.FLOAT, token.STRING, token.BACKTICK,
+		token.FSTRING, token.TRUE, token.FALSE, token.NIL, token.BANG, token.FUNC:
+		return true
+	case token.LPAREN, token.LBRACKET:
+		return p.peekToken.StartPosition.Char > p.curToken.EndPosition.Char+1
+	}
+	return false
+}
+
+func (p *Parser) parseMatch() ast.Node {
+	matchToken := p.curToken
+	p.nextToken()
+	matchValue := p.parseExpression(LOWEST)
+	if matchValue == nil {
+		return nil
+	}
+	if !p.expectPeek("match expression", token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+	p.eatNewlines()
+	var cases []*ast.MatchCase
+	var defaultCaseCount int
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.setTokenError(p.prevToken, "unterminated match expression")
+			return nil
+		}
+		caseToken := p.curToken
+		var patterns []ast.Pattern
+		var guard ast.Expression
+		switch p.curToken.Type {
+		case token.DEFAULT:
+			defaultCaseCount++
+			if defaultCaseCount > 1 {
+				p.setTokenError(caseToken, "match expression has multiple default blocks")
+				return nil
+			}
+		case token.CASE:
+			for {
+				p.nextToken() // move to the pattern
+				pattern := p.parsePattern()
+				if pattern == nil {
 					return nil
 				}
-				cases = append(cases, ast.NewDefaultCase(caseToken, nil))
-			} else {
-				cases = append(cases, ast.NewCase(caseToken, caseExprs, nil))
+				patterns = append(patterns, pattern)
+				if !p.peekTokenIs(token.COMMA) {
+					break
+				}
+				p.nextToken() // move to the comma
 			}
-			continue
-		}
-		blockFirstToken := p.curToken
-		var blockStatements []ast.Node
-		for {
-			// Skip over newlines and semicolons
-			for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) {
-				if err := p.nextToken(); err != nil {
+			if p.peekTokenIs(token.IF) {
+				p.nextToken() // move to "if"
+				p.nextToken() // move to the guard expression
+				guard = p.parseExpression(LOWEST)
+				if guard == nil {
 					return nil
 				}
 			}
-			// Any of these tokens indicate the end of the current case
-			if p.curTokenIs(token.CASE) ||
-				p.curTokenIs(token.DEFAULT) ||
-				p.curTokenIs(token.RBRACE) ||
-				p.curTokenIs(token.EOF) {
-				break
+		default:
+			p.setTokenError(p.curToken, "expected 'case' or 'default' (got %s)", p.curToken.Literal)
+			return nil
+		}
+		if !p.expectPeek("match expression", token.COLON) {
+			return nil
+		}
+		// Now we are at the block of code to be executed for this case
+		p.nextToken()
+		p.eatNewlines()
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		if caseToken.Type == token.DEFAULT {
+			cases = append(cases, ast.NewDefaultMatchCase(caseToken, block))
+		} else {
+			cases = append(cases, ast.NewMatchCase(caseToken, patterns, guard, block))
+		}
+	}
+	return ast.NewMatch(matchToken, matchValue, cases)
+}
+
+// parsePattern parses one pattern of a match case, starting at the current
+// token and ending on the last token of the pattern.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.peekTokenIs(token.LPAREN) {
+			return p.parseTypePattern()
+		}
+		if p.peekTokenIs(token.PERIOD) {
+			return p.parseDottedPattern()
+		}
+		if p.curToken.Literal == "_" {
+			return ast.NewWildcardPattern(p.curToken)
+		}
+		return ast.NewCapturePattern(ast.NewIdent(p.curToken))
+	case token.INT, token.FLOAT, token.STRING, token.BACKTICK, token.FSTRING,
+		token.TRUE, token.FALSE, token.NIL:
+		value, ok := p.prefixParseFns[p.curToken.Type]().(ast.Expression)
+		if !ok {
+			return nil
+		}
+		return ast.NewValuePattern(value)
+	case token.MINUS:
+		// Only negative numbers are allowed, not arbitrary expressions
+		minus := p.curToken
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			p.setTokenError(minus, "invalid pattern (expected a number after %q)", "-")
+			return nil
+		}
+		p.nextToken()
+		value, ok := p.prefixParseFns[p.curToken.Type]().(ast.Expression)
+		if !ok {
+			return nil
+		}
+		return ast.NewValuePattern(ast.NewPrefix(minus, value))
+	case token.LBRACKET:
+		return p.parseListPattern()
+	case token.LBRACE:
+		return p.parseMapPattern()
+	}
+	p.setTokenError(p.curToken, "invalid pattern (unexpected %q)", p.curToken.Literal)
+	return nil
+}
+
+// parseDottedPattern parses a value pattern that refers to a value by a
+// dotted name, such as "http.StatusOK".
+func (p *Parser) parseDottedPattern() ast.Pattern {
+	var value ast.Expression = ast.NewIdent(p.curToken)
+	for p.peekTokenIs(token.PERIOD) {
+		p.nextToken()
+		period := p.curToken
+		if !p.expectPeek("pattern", token.IDENT) {
+			return nil
+		}
+		value = ast.NewGetAttr(period, value, ast.NewIdent(p.curToken))
+	}
+	return ast.NewValuePattern(value)
+}
+
+// parsePatternItems parses the comma separated items of a list, map, or type
+// pattern, calling parseItem with the current token on the first token of
+// each item. It ends with the current token on the closing token.
+func (p *Parser) parsePatternItems(context string, end token.Type, parseItem func() bool) bool {
+	p.nextToken()
+	p.eatNewlines()
+	for !p.curTokenIs(end) {
+		if !parseItem() {
+			return false
+		}
+		p.nextToken()
+		p.eatNewlines()
+		if p.curTokenIs(token.COMMA) {
+			p.nextToken()
+			p.eatNewlines()
+		} else if !p.curTokenIs(end) {
+			p.setTokenError(p.curToken, "unexpected %q in %s", p.curToken.Literal, context)
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parser) parseListPattern() ast.Pattern {
+	listToken := p.curToken
+	var items []ast.Pattern
+	var hasRest bool
+	ok := p.parsePatternItems("list pattern", token.RBRACKET, func() bool {
+		if !p.curTokenIs(token.ELLIPSIS) {
+			item := p.parsePattern()
+			if item == nil {
+				return false
 			}
-			// Parse one statement
-			if s := p.parseStatement(); s != nil {
-				blockStatements = append(blockStatements, s)
+			items = append(items, item)
+			return true
+		}
+		if hasRest {
+			p.setTokenError(p.curToken, "list pattern has multiple rest patterns")
+			return false
+		}
+		hasRest = true
+		restToken := p.curToken
+		var name *ast.Ident
+		if p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+			if p.curToken.Literal != "_" {
+				name = ast.NewIdent(p.curToken)
 			}
-			if !p.curTokenIs(token.SEMICOLON) &&
-				!statementTerminators[p.peekToken.Type] &&
-				!p.peekTokenIs(token.CASE) &&
-				!p.peekTokenIs(token.DEFAULT) &&
-				!p.peekTokenIs(token.RBRACE) {
-				p.peekError("case statement", token.SEMICOLON, p.peekToken)
-				return nil
+		}
+		items = append(items, ast.NewRestPattern(restToken, name))
+		return true
+	})
+	if !ok {
+		return nil
+	}
+	return ast.NewListPattern(listToken, items)
+}
+
+func (p *Parser) parseMapPattern() ast.Pattern {
+	mapToken := p.curToken
+	var keys []ast.Expression
+	var values []ast.Pattern
+	seen := map[string]bool{}
+	ok := p.parsePatternItems("map pattern", token.RBRACE, func() bool {
+		var key ast.Expression
+		var name string
+		switch p.curToken.Type {
+		case token.STRING, token.BACKTICK:
+			key, name = ast.NewString(p.curToken), p.curToken.Literal
+		case token.IDENT:
+			key, name = ast.NewIdent(p.curToken), p.curToken.Literal
+		default:
+			p.setTokenError(p.curToken, "invalid map pattern key (got %s)", p.curToken.Literal)
+			return false
+		}
+		if seen[name] {
+			p.setTokenError(p.curToken, "duplicate key %q in map pattern", name)
+			return false
+		}
+		seen[name] = true
+		if !p.expectPeek("map pattern", token.COLON) {
+			return false
+		}
+		p.nextToken()
+		p.eatNewlines()
+		value := p.parsePattern()
+		if value == nil {
+			return false
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if !ok {
+		return nil
+	}
+	return ast.NewMapPattern(mapToken, keys, values)
+}
+
+func (p *Parser) parseTypePattern() ast.Pattern {
+	name := ast.NewIdent(p.curToken)
+	p.nextToken() // move to the opening parenthesis
+	var value ast.Pattern
+	var attrNames []*ast.Ident
+	var attrs []ast.Pattern
+	seen := map[string]bool{}
+	ok := p.parsePatternItems("type pattern", token.RPAREN, func() bool {
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+			attrName := ast.NewIdent(p.curToken)
+			if seen[attrName.Literal()] {
+				p.setTokenError(p.curToken, "duplicate attribute %q in type pattern", attrName.Literal())
+				return false
 			}
-			// Move to the token just beyond the statement
-			if err := p.nextToken(); err != nil {
-				return nil
+			seen[attrName.Literal()] = true
+			p.nextToken() // move to the "="
+			p.nextToken() // move to the pattern
+			attr := p.parsePattern()
+			if attr == nil {
+				return false
 			}
+			attrNames = append(attrNames, attrName)
+			attrs = append(attrs, attr)
+			return true
 		}
-		block := ast.NewBlock(blockFirstToken, blockStatements)
-		if isDefaultCase {
+		if value != nil || len(attrs) > 0 {
+			p.setTokenError(p.curToken, "type pattern accepts one positional pattern, before any attribute patterns")
+			return false
+		}
+		value = p.parsePattern()
+		return value != nil
+	})
+	if !ok {
+		return nil
+	}
+	return ast.NewTypePattern(name, value, attrNames, attrs)
+}
+
+func (p *Parser) parseSelect() ast.Node {
+	selectToken := p.curToken
+	p.nextToken() // move to the opening brace
+	p.nextToken()
+	p.eatNewlines()
+	var cases []*ast.SelectCase
+	var defaultCaseCount int
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.setTokenError(p.prevToken, "unterminated select statement")
+			return nil
+		}
+		caseToken := p.curToken
+		var names []*ast.Ident
+		var comm ast.Node
+		switch p.curToken.Type {
+		case token.DEFAULT:
 			defaultCaseCount++
 			if defaultCaseCount > 1 {
-				p.setTokenError(caseToken, "switch statement has multiple default blocks")
+				p.setTokenError(caseToken, "select statement has multiple default blocks")
 				return nil
 			}
-			cases = append(cases, ast.NewDefaultCase(caseToken, block))
+		case token.CASE:
+			p.nextToken() // move to the token following "case"
+			if p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.DECLARE) || p.peekTokenIs(token.COMMA)) {
+				names = append(names, ast.NewIdent(p.curToken))
+				if p.peekTokenIs(token.COMMA) {
+					p.nextToken()
+					if !p.expectPeek("select case", token.IDENT) {
+						return nil
+					}
+					names = append(names, ast.NewIdent(p.curToken))
+				}
+				if !p.expectPeek("select case", token.DECLARE) {
+					return nil
+				}
+				p.nextToken()
+			}
+			comm = p.parseNode(LOWEST)
+			switch comm.(type) {
+			case *ast.Receive:
+			case *ast.Send:
+				if len(names) > 0 {
+					p.setTokenError(caseToken, "select case cannot assign the result of a send")
+					return nil
+				}
+			default:
+				p.setTokenError(caseToken, "select case must receive from or send to a channel")
+				return nil
+			}
+		default:
+			p.setTokenError(p.curToken, "expected 'case' or 'default' (got %s)", p.curToken.Literal)
+			return nil
+		}
+		if !p.expectPeek("select statement", token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		p.eatNewlines()
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		if caseToken.Type == token.DEFAULT {
+			cases = append(cases, ast.NewDefaultSelectCase(caseToken, block))
 		} else {
-			cases = append(cases, ast.NewCase(caseToken, caseExprs, block))
+			cases = append(cases, ast.NewSelectCase(caseToken, names, comm, block))
 		}
 	}
-	return ast.NewSwitch(switchToken, switchValue, cases)
+	return ast.NewSelect(selectToken, cases)
 }
 
 func (p *Parser) parseImport() ast.Node {
@@ -1062,42 +1524,66 @@ func (p *Parser) parseFunc() ast.Node {
 	if !p.expectPeek("function", token.LPAREN) { // Move to the "("
 		return nil
 	}
-	defaults, params := p.parseFuncParams()
+	defaults, params, rest := p.parseFuncParams()
 	if !p.expectPeek("function", token.LBRACE) { // move to the "{"
 		return nil
 	}
+	if rest != nil {
+		return ast.NewVariadicFunc(funcToken, ident, params, rest, defaults, p.parseBlock())
+	}
 	return ast.NewFunc(funcToken, ident, params, defaults, p.parseBlock())
 }
 
-func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident) {
+func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident, *ast.Ident) {
 	// If the next parameter is ")", then there are no parameters
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return map[string]ast.Expression{}, nil
+		return map[string]ast.Expression{}, nil, nil
 	}
 	defaults := map[string]ast.Expression{}
 	params := make([]*ast.Ident, 0)
+	var rest *ast.Ident
 	p.nextToken()
 	for !p.curTokenIs(token.RPAREN) { // Keep going until we find a ")"
 		if p.curTokenIs(token.EOF) {
 			p.setTokenError(p.prevToken, "unterminated function parameters")
-			return nil, nil
+			return nil, nil, nil
+		}
+		// A "...name" rest parameter must be the last parameter
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek("function", token.IDENT) {
+				return nil, nil, nil
+			}
+			rest = ast.NewIdent(p.curToken)
+			if p.peekTokenIs(token.ASSIGN) {
+				p.setTokenError(rest.Token(), "rest parameter cannot have a default value")
+				return nil, nil, nil
+			}
+			if p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+			}
+			if !p.peekTokenIs(token.RPAREN) {
+				p.setTokenError(rest.Token(), "the rest parameter must be the last parameter")
+				return nil, nil, nil
+			}
+			p.nextToken()
+			break
 		}
 		if !p.curTokenIs(token.IDENT) {
 			p.setTokenError(p.curToken, "expected an identifier (got %s)", p.curToken.Literal)
-			return nil, nil
+			return nil, nil, nil
 		}
 		ident := ast.NewIdent(p.curToken)
 		params = append(params, ident)
 		if err := p.nextToken(); err != nil {
-			return nil, nil
+			return nil, nil, nil
 		}
 		// If there is "=expr" after the name then expr is a default value
 		if p.curTokenIs(token.ASSIGN) {
 			p.nextToken()
 			expr := p.parseExpression(LOWEST)
 			if expr == nil {
-				return nil, nil
+				return nil, nil, nil
 			}
 			defaults[ident.String()] = expr
 			p.nextToken()
@@ -1106,7 +1592,188 @@ func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident) {
 			p.nextToken()
 		}
 	}
-	return defaults, params
+	return defaults, params, rest
+}
+
+func (p *Parser) parseStruct() ast.Node {
+	structToken := p.curToken
+	if !p.expectPeek("struct", token.IDENT) {
+		return nil
+	}
+	name := ast.NewIdent(p.curToken)
+	if !p.expectPeek("struct", token.LBRACE) {
+		return nil
+	}
+	var fields []*ast.Ident
+	var methods []*ast.Func
+	defaults := map[string]ast.Expression{}
+	seen := map[string]bool{}
+	for {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		// Members may be separated by newlines, semicolons, or commas
+		for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) || p.curTokenIs(token.COMMA) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		if p.curTokenIs(token.RBRACE) {
+			break
+		}
+		var member *ast.Ident
+		switch p.curToken.Type {
+		case token.EOF:
+			p.setTokenError(structToken, "unterminated struct declaration")
+			return nil
+		case token.FUNC:
+			if !p.peekTokenIs(token.IDENT) {
+				p.setTokenError(p.peekToken, "expected a method name (got %s)", p.peekToken.Literal)
+				return nil
+			}
+			method, ok := p.parseFunc().(*ast.Func)
+			if !ok {
+				return nil
+			}
+			member = method.Name()
+			methods = append(methods, method)
+		case token.IDENT:
+			member = ast.NewIdent(p.curToken)
+			fields = append(fields, member)
+			// If there is "=expr" after the name then expr is a default value
+			if p.peekTokenIs(token.ASSIGN) {
+				p.nextToken()
+				p.nextToken()
+				expr := p.parseExpression(LOWEST)
+				if expr == nil {
+					return nil
+				}
+				defaults[member.Literal()] = expr
+			}
+		default:
+			p.setTokenError(p.curToken, "unexpected token %q in struct declaration", p.curToken.Literal)
+			return nil
+		}
+		if seen[member.Literal()] {
+			p.setTokenError(member.Token(), "duplicate struct member %q", member.Literal())
+			return nil
+		}
+		seen[member.Literal()] = true
+		switch p.peekToken.Type {
+		case token.NEWLINE, token.SEMICOLON, token.COMMA, token.RBRACE:
+		case token.EOF:
+			p.setTokenError(structToken, "unterminated struct declaration")
+			return nil
+		default:
+			p.setTokenError(p.peekToken, "unexpected token %q in struct declaration", p.peekToken.Literal)
+			return nil
+		}
+	}
+	return ast.NewStruct(structToken, name, fields, defaults, methods)
+}
+
+// parseEnum parses an enum declaration. The "enum" word is not a reserved
+// keyword, so it is only recognized when followed by the name of the enum.
+func (p *Parser) parseEnum() ast.Node {
+	enumToken := p.curToken
+	p.nextToken()
+	name := ast.NewIdent(p.curToken)
+	if !p.expectPeek("enum", token.LBRACE) {
+		return nil
+	}
+	var members []*ast.Ident
+	values := map[string]ast.Expression{}
+	seen := map[string]bool{}
+	for {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		// Members may be separated by newlines, semicolons, or commas
+		for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) || p.curTokenIs(token.COMMA) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		if p.curTokenIs(token.RBRACE) {
+			break
+		}
+		switch p.curToken.Type {
+		case token.EOF:
+			p.setTokenError(enumToken, "unterminated enum declaration")
+			return nil
+		case token.IDENT:
+		default:
+			p.setTokenError(p.curToken, "unexpected token %q in enum declaration", p.curToken.Literal)
+			return nil
+		}
+		member := ast.NewIdent(p.curToken)
+		if seen[member.Literal()] {
+			p.setTokenError(member.Token(), "duplicate enum member %q", member.Literal())
+			return nil
+		}
+		seen[member.Literal()] = true
+		members = append(members, member)
+		// If there is "=expr" after the name then expr is the member's value
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			expr := p.parseExpression(LOWEST)
+			if expr == nil {
+				return nil
+			}
+			values[member.Literal()] = expr
+		}
+		switch p.peekToken.Type {
+		case token.NEWLINE, token.SEMICOLON, token.COMMA, token.RBRACE:
+		case token.EOF:
+			p.setTokenError(enumToken, "unterminated enum declaration")
+			return nil
+		default:
+			p.setTokenError(p.peekToken, "unexpected token %q in enum declaration", p.peekToken.Literal)
+			return nil
+		}
+	}
+	return ast.NewEnum(enumToken, name, members, values)
+}
+
+// parseTry parses a try statement. The "try", "catch", and "finally" words are
+// not reserved keywords, so that the try builtin remains available.
+func (p *Parser) parseTry() ast.Node {
+	tryToken := p.curToken
+	p.nextToken() // move to the "{"
+	body := p.parseBlock()
+	if body == nil {
+		return nil
+	}
+	var catchIdent *ast.Ident
+	var catchBlock, finallyBlock *ast.Block
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "catch" {
+		p.nextToken() // move to the "catch"
+		if p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+			catchIdent = ast.NewIdent(p.curToken)
+		}
+		if !p.expectPeek("catch block", token.LBRACE) {
+			return nil
+		}
+		if catchBlock = p.parseBlock(); catchBlock == nil {
+			return nil
+		}
+	}
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "finally" {
+		p.nextToken() // move to the "finally"
+		if !p.expectPeek("finally block", token.LBRACE) {
+			return nil
+		}
+		if finallyBlock = p.parseBlock(); finallyBlock == nil {
+			return nil
+		}
+	}
+	if catchBlock == nil && finallyBlock == nil {
+		p.setTokenError(tryToken, "try statement requires a catch or finally block")
+		return nil
+	}
+	return ast.NewTry(tryToken, body, catchIdent, catchBlock, finallyBlock)
 }
 
 func (p *Parser) parseGo() ast.Node {
@@ -1204,10 +1871,97 @@ func (p *Parser) parseString() ast.Node {
 
 func (p *Parser) parseList() ast.Node {
 	bracket := p.curToken
-	items := p.parseExprList(token.RBRACKET)
+	for p.peekTokenIs(token.NEWLINE) {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+	}
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		return ast.NewList(bracket, make([]ast.Expression, 0))
+	}
+	p.nextToken()
+	first := p.parseExpression(LOWEST)
+	if first == nil {
+		p.setTokenError(p.curToken, "invalid syntax in list expression")
+		return nil
+	}
+	for p.peekTokenIs(token.NEWLINE) {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+	}
+	if p.peekTokenIs(token.FOR) {
+		clauses := p.parseForClauses("list comprehension", token.RBRACKET)
+		if clauses == nil {
+			return nil
+		}
+		return ast.NewListComprehension(bracket, first, clauses)
+	}
+	items := p.parseExprListAfter([]ast.Expression{first}, token.RBRACKET)
+	if items == nil {
+		return nil
+	}
 	return ast.NewList(bracket, items)
 }
 
+// parseForClauses parses the for clauses of a comprehension, along with
+// their conditions, and then the end token of the comprehension. The peek
+// token is the "for" token of the first clause.
+func (p *Parser) parseForClauses(context string, end token.Type) []*ast.ForClause {
+	var clauses []*ast.ForClause
+	for p.peekTokenIs(token.FOR) {
+		p.nextToken() // move to "for"
+		forToken := p.curToken
+		var names []*ast.Ident
+		for {
+			if !p.expectPeek(context, token.IDENT) {
+				return nil
+			}
+			names = append(names, ast.NewIdent(p.curToken))
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken() // move to the comma
+		}
+		if len(names) > 2 {
+			p.setTokenError(forToken, "%s accepts one or two names per for clause (got %d)", context, len(names))
+			return nil
+		}
+		if !p.expectPeek(context, token.IN) {
+			return nil
+		}
+		p.nextToken() // move to the iterable
+		iterable := p.parseExpression(LOWEST)
+		if iterable == nil {
+			return nil
+		}
+		var conditions []ast.Expression
+		for {
+			for p.peekTokenIs(token.NEWLINE) {
+				if err := p.nextToken(); err != nil {
+					return nil
+				}
+			}
+			if !p.peekTokenIs(token.IF) {
+				break
+			}
+			p.nextToken() // move to "if"
+			p.nextToken() // move to the condition
+			condition := p.parseExpression(LOWEST)
+			if condition == nil {
+				return nil
+			}
+			conditions = append(conditions, condition)
+		}
+		clauses = append(clauses, ast.NewForClause(forToken, names, iterable, conditions))
+	}
+	if !p.expectPeek(context, end) {
+		return nil
+	}
+	return clauses
+}
+
 func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 	list := make([]ast.Expression, 0)
 	if p.peekTokenIs(end) {
@@ -1225,7 +1979,12 @@ func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 		p.setTokenError(p.curToken, "invalid syntax in list expression")
 		return nil
 	}
-	list = append(list, expr)
+	return p.parseExprListAfter(append(list, expr), end)
+}
+
+// parseExprListAfter parses the remaining items of an expression list, after
+// the first item, and then the end token of the list.
+func (p *Parser) parseExprListAfter(list []ast.Expression, end token.Type) []ast.Expression {
 	for p.peekTokenIs(token.COMMA) {
 		// move to the comma
 		if err := p.nextToken(); err != nil {
@@ -1379,13 +2138,119 @@ func (p *Parser) parseCall(functionNode ast.Node) ast.Node {
 		return nil
 	}
 	callToken := p.curToken
-	arguments := p.parseNodeList(token.RPAREN)
+	arguments := p.parseCallArgs()
 	if arguments == nil {
 		return nil
 	}
 	return ast.NewCall(callToken, function, arguments)
 }
 
+// parseCallArgs parses the arguments of a call up to the closing ")". Any
+// argument may be a "...items" list spread or a "**opts" map spread, but
+// positional arguments may not follow a map spread.
+func (p *Parser) parseCallArgs() []ast.Node {
+	args := make([]ast.Node, 0)
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+	var mapSpread bool
+	var keywords map[string]bool
+	for {
+		// advance across any extra newlines
+		for p.peekTokenIs(token.NEWLINE) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		// move to the next argument
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		var arg ast.Node
+		if p.curTokenIs(token.ELLIPSIS) || p.curTokenIs(token.POW) {
+			spreadToken := p.curToken
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+			value := p.parseExpression(LOWEST)
+			if value == nil {
+				return nil
+			}
+			spread := ast.NewSpread(spreadToken, value)
+			if !spread.IsMap() && mapSpread {
+				p.setTokenError(spreadToken, "positional argument follows keyword argument unpacking")
+				return nil
+			}
+			if !spread.IsMap() && keywords != nil {
+				p.setTokenError(spreadToken, "positional argument follows keyword argument")
+				return nil
+			}
+			mapSpread = mapSpread || spread.IsMap()
+			arg = spread
+		} else if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+			name := ast.NewIdent(p.curToken)
+			if keywords[name.Literal()] {
+				p.setTokenError(p.curToken, "duplicate keyword argument %q", name.Literal())
+				return nil
+			}
+			p.nextToken()
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+			value := p.parseExpression(LOWEST)
+			if value == nil {
+				return nil
+			}
+			if keywords == nil {
+				keywords = map[string]bool{}
+			}
+			keywords[name.Literal()] = true
+			arg = ast.NewKeywordArg(name, value)
+		} else {
+			arg = p.parseNode(LOWEST)
+			if arg == nil {
+				p.setTokenError(p.curToken, "invalid syntax in list expression")
+				return nil
+			}
+			if mapSpread {
+				p.setTokenError(arg.Token(), "positional argument follows keyword argument unpacking")
+				return nil
+			}
+			if keywords != nil {
+				p.setTokenError(arg.Token(), "positional argument follows keyword argument")
+				return nil
+			}
+		}
+		args = append(args, arg)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		// move to the comma
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		// check if the list has ended after any newlines
+		for p.peekTokenIs(token.NEWLINE) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+	}
+	for p.peekTokenIs(token.NEWLINE) {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+	}
+	if !p.expectPeek("a node list", token.RPAREN) {
+		return nil
+	}
+	return args
+}
+
 func (p *Parser) parsePipe(firstNode ast.Node) ast.Node {
 	first, ok := firstNode.(ast.Expression)
 	if !ok {
@@ -1473,8 +2338,20 @@ func (p *Parser) parseMapOrSet() ast.Node {
 		p.nextToken() // move to the ":"
 		p.nextToken() // move to the first value
 		firstValue := p.parseExpression(LOWEST)
+		for p.peekTokenIs(token.NEWLINE) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		if p.peekTokenIs(token.FOR) {
+			clauses := p.parseForClauses("map comprehension", token.RBRACE)
+			if clauses == nil {
+				return nil
+			}
+			return ast.NewMapComprehension(firstToken, firstKey, firstValue, clauses)
+		}
 		pairs := map[ast.Expression]ast.Expression{firstKey: firstValue}
-		for !p.peekTokenIs(token.RBRACE) {
+		for !p.curTokenIs(token.NEWLINE) && !p.peekTokenIs(token.RBRACE) {
 			if p.peekTokenIs(token.NEWLINE) {
 				p.nextToken()
 				break
@@ -1509,6 +2386,18 @@ func (p *Parser) parseMapOrSet() ast.Node {
 		}
 		return ast.NewMap(firstToken, pairs)
 	} else { // This is a set
+		for p.peekTokenIs(token.NEWLINE) {
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+		}
+		if p.peekTokenIs(token.FOR) {
+			clauses := p.parseForClauses("set comprehension", token.RBRACE)
+			if clauses == nil {
+				return nil
+			}
+			return ast.NewSetComprehension(firstToken, firstKey, clauses)
+		}
 		items := []ast.Expression{firstKey}
 		if p.peekTokenIs(token.COMMA) {
 			p.nextToken()
@@ -1594,6 +2483,78 @@ func (p *Parser) parseGetAttr(objNode ast.Node) ast.Node {
 	return ast.NewGetAttr(period, obj, name)
 }
 
+// parseOptionalChain parses a chain of attribute accesses, index operations
+// and calls that begins with a "?." access on the given object. The chain
+// continues for as long as another access, index operation or call follows.
+func (p *Parser) parseOptionalChain(objNode ast.Node) ast.Node {
+	obj, ok := objNode.(ast.Expression)
+	if !ok {
+		p.setTokenError(p.curToken, "invalid optional chain")
+		return nil
+	}
+	chainToken := p.curToken
+	expr := p.parseOptionalLink(obj)
+	for expr != nil {
+		var node ast.Node
+		switch p.peekToken.Type {
+		case token.QUESTION_PERIOD:
+			p.nextToken()
+			expr = p.parseOptionalLink(expr)
+			continue
+		case token.PERIOD:
+			p.nextToken()
+			node = p.parseGetAttr(expr)
+		case token.LBRACKET:
+			p.nextToken()
+			node = p.parseIndex(expr)
+		case token.LPAREN:
+			p.nextToken()
+			node = p.parseCall(expr)
+		default:
+			return ast.NewOptionalChain(chainToken, expr)
+		}
+		if node == nil {
+			return nil
+		}
+		if expr, ok = node.(ast.Expression); !ok {
+			p.setTokenError(chainToken, "cannot assign to an optional chain")
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseOptionalLink parses the "?." access that follows the given object,
+// with the current token being the "?.".
+func (p *Parser) parseOptionalLink(obj ast.Expression) ast.Expression {
+	optToken := p.curToken
+	switch p.peekToken.Type {
+	case token.IDENT:
+		p.nextToken()
+		name := p.parseIdent().(*ast.Ident)
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			call, ok := p.parseCall(name).(ast.Expression)
+			if !ok {
+				return nil
+			}
+			return ast.NewOptionalObjectCall(optToken, obj, call)
+		}
+		return ast.NewOptionalGetAttr(optToken, obj, name)
+	case token.LBRACKET:
+		p.nextToken()
+		switch node := p.parseIndex(obj).(type) {
+		case *ast.Index:
+			return ast.NewOptionalIndex(node.Token(), node.Left(), node.Index())
+		case *ast.Slice:
+			return ast.NewOptionalSlice(node.Token(), node.Left(), node.FromIndex(), node.ToIndex())
+		}
+		return nil
+	}
+	p.setTokenError(p.peekToken, "expected an identifier or \"[\" after %q", "?.")
+	return nil
+}
+
 func (p *Parser) parseSend(channel ast.Node) ast.Node {
 	chanExpr, ok := channel.(ast.Expression)
 	if !ok {
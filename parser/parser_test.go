@@ -201,6 +201,24 @@ func TestReturn(t *testing.T) {
 	}
 }
 
+func TestYield(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`func gen() { yield 1 }`, "func gen() { yield 1 }"},
+		{`func gen() { yield x + 1; yield }`, "func gen() { yield (x + 1)\nyield }"},
+		{`func gen() {
+			yield
+		}`, "func gen() { yield }"},
+	}
+	for _, tt := range tests {
+		result, err := Parse(context.Background(), tt.input)
+		require.Nil(t, err)
+		require.Equal(t, tt.expected, result.String())
+	}
+}
+
 func TestIdent(t *testing.T) {
 	program, err := Parse(context.Background(), "foobar;")
 	require.Nil(t, err)
@@ -722,10 +740,10 @@ func TestCallExpression(t *testing.T) {
 	require.Equal(t, "foo", call.Function().String())
 	args := call.Arguments()
 	require.Len(t, args, 2)
-	arg0 := args[0].(*ast.Assign)
-	require.Equal(t, "a = 1", arg0.String())
-	arg1 := args[1].(*ast.Assign)
-	require.Equal(t, "b = 2", arg1.String())
+	arg0 := args[0].(*ast.KeywordArg)
+	require.Equal(t, "a=1", arg0.String())
+	arg1 := args[1].(*ast.KeywordArg)
+	require.Equal(t, "b=2", arg1.String())
 }
 
 func TestGetAttr(t *testing.T) {
@@ -1182,3 +1200,620 @@ func TestInvalidMultipleExpressions2(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, "parse error: unexpected token \"oops\" following statement", err.Error())
 }
+
+func TestStruct(t *testing.T) {
+	input := `struct Point {
+		x
+		y = 1
+		func sum() { return self.x + self.y }
+	}`
+	result, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, result.Statements(), 1)
+	stmt, ok := result.Statements()[0].(*ast.Struct)
+	require.True(t, ok)
+	require.Equal(t, "Point", stmt.Name().Literal())
+	require.Equal(t, []string{"x", "y"}, stmt.FieldNames())
+	require.Len(t, stmt.Defaults(), 1)
+	require.Equal(t, "1", stmt.Defaults()["y"].String())
+	require.Len(t, stmt.Methods(), 1)
+	require.Equal(t, "sum", stmt.Methods()[0].Name().Literal())
+	require.Equal(t, "struct Point { x; y = 1; func sum() { return (self.x + self.y) }; }", stmt.String())
+}
+
+func TestStructSeparators(t *testing.T) {
+	tests := []string{
+		"struct Point { x, y }",
+		"struct Point { x; y; }",
+		"struct Point {\nx\ny\n}",
+		"struct Point {\n  x,\n  y,\n}",
+	}
+	for _, tt := range tests {
+		result, err := Parse(context.Background(), tt)
+		require.Nil(t, err)
+		stmt, ok := result.Statements()[0].(*ast.Struct)
+		require.True(t, ok)
+		require.Equal(t, []string{"x", "y"}, stmt.FieldNames())
+	}
+}
+
+func TestInvalidStructs(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"struct { x }", "parse error: unexpected { while parsing struct (expected identifier)"},
+		{"struct Point { x y }", "parse error: unexpected token \"y\" in struct declaration"},
+		{"struct Point { x; x }", "parse error: duplicate struct member \"x\""},
+		{"struct Point { x; func x() {} }", "parse error: duplicate struct member \"x\""},
+		{"struct Point { func() {} }", "parse error: expected a method name (got ()"},
+		{"struct Point { 42 }", "parse error: unexpected token \"42\" in struct declaration"},
+		{"struct Point { x", "parse error: unterminated struct declaration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestEnum(t *testing.T) {
+	input := `enum Status {
+		PENDING
+		RUNNING = 5
+		FAILED
+	}`
+	result, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, result.Statements(), 1)
+	stmt, ok := result.Statements()[0].(*ast.Enum)
+	require.True(t, ok)
+	require.Equal(t, "Status", stmt.Name().Literal())
+	require.Len(t, stmt.Members(), 3)
+	require.Equal(t, "FAILED", stmt.Members()[2].Literal())
+	require.Len(t, stmt.Values(), 1)
+	require.Equal(t, "5", stmt.Values()["RUNNING"].String())
+	require.Equal(t, "enum Status { PENDING, RUNNING = 5, FAILED }", stmt.String())
+}
+
+func TestEnumIsSoftKeyword(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`enum := 1`, "enum := 1"},
+		{`enum(x)`, "enum(x)"},
+		{`enum Color { RED, GREEN }`, "enum Color { RED, GREEN }"},
+		{`enum Color {}`, "enum Color { }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result.String())
+		})
+	}
+}
+
+func TestInvalidEnums(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"enum Color ( RED )", "parse error: unexpected ( while parsing enum (expected {)"},
+		{"enum Color { RED GREEN }", "parse error: unexpected token \"GREEN\" in enum declaration"},
+		{"enum Color { RED, RED }", "parse error: duplicate enum member \"RED\""},
+		{"enum Color { 42 }", "parse error: unexpected token \"42\" in enum declaration"},
+		{"enum Color { RED", "parse error: unterminated enum declaration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestTry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { a() } catch err { b(err) }", "try { a() } catch err { b(err) }"},
+		{"try { a() } catch { b() }", "try { a() } catch { b() }"},
+		{"try { a() } finally { c() }", "try { a() } finally { c() }"},
+		{"try { a() } catch e { b() } finally { c() }", "try { a() } catch e { b() } finally { c() }"},
+		{"try {\n  a()\n} catch e {\n  b()\n} finally {\n  c()\n}", "try { a() } catch e { b() } finally { c() }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, result.Statements(), 1)
+			stmt, ok := result.Statements()[0].(*ast.Try)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, stmt.String())
+		})
+	}
+}
+
+func TestTryBuiltinStillCallable(t *testing.T) {
+	result, err := Parse(context.Background(), "try(func() { 1 }, 2)")
+	require.Nil(t, err)
+	require.Len(t, result.Statements(), 1)
+	_, ok := result.Statements()[0].(*ast.Call)
+	require.True(t, ok)
+}
+
+func TestInvalidTry(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"try { a() }", "parse error: try statement requires a catch or finally block"},
+		{"try { a() } catch err", "parse error: unexpected end of file while parsing catch block (expected {)"},
+		{"try { a() } finally x", "parse error: unexpected x while parsing finally block (expected {)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestRestParameter(t *testing.T) {
+	program, err := Parse(context.Background(), "func log(level, ...parts) { parts }")
+	require.Nil(t, err)
+	function, ok := program.First().(*ast.Func)
+	require.True(t, ok)
+	require.Len(t, function.Parameters(), 1)
+	testLiteralExpression(t, function.Parameters()[0], "level")
+	require.NotNil(t, function.RestParameter())
+	require.Equal(t, "parts", function.RestParameter().Literal())
+	require.Equal(t, "func log(level, ...parts) { parts }", function.String())
+}
+
+func TestSpreadArguments(t *testing.T) {
+	program, err := Parse(context.Background(), "f(1, ...items, ...more, **opts)")
+	require.Nil(t, err)
+	call, ok := program.First().(*ast.Call)
+	require.True(t, ok)
+	args := call.Arguments()
+	require.Len(t, args, 4)
+	testLiteralExpression(t, args[0].(ast.Expression), 1)
+	spread, ok := args[1].(*ast.Spread)
+	require.True(t, ok)
+	require.False(t, spread.IsMap())
+	testIdentifier(t, spread.Value(), "items")
+	spread, ok = args[3].(*ast.Spread)
+	require.True(t, ok)
+	require.True(t, spread.IsMap())
+	testIdentifier(t, spread.Value(), "opts")
+	require.Equal(t, "f(1, ...items, ...more, **opts)", call.String())
+}
+
+func TestKeywordArguments(t *testing.T) {
+	program, err := Parse(context.Background(), "f(1, timeout=5, **opts, retries=x + 1)")
+	require.Nil(t, err)
+	call, ok := program.First().(*ast.Call)
+	require.True(t, ok)
+	args := call.Arguments()
+	require.Len(t, args, 4)
+	kwarg, ok := args[1].(*ast.KeywordArg)
+	require.True(t, ok)
+	require.Equal(t, "timeout", kwarg.Name().Literal())
+	testLiteralExpression(t, kwarg.Value(), 5)
+	kwarg, ok = args[3].(*ast.KeywordArg)
+	require.True(t, ok)
+	require.Equal(t, "retries", kwarg.Name().Literal())
+	require.Equal(t, "f(1, timeout=5, **opts, retries=(x + 1))", call.String())
+}
+
+func TestInvalidKeywordArguments(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"f(a=1, 2)", "parse error: positional argument follows keyword argument"},
+		{"f(a=1, ...items)", "parse error: positional argument follows keyword argument"},
+		{"f(a=1, b=2, a=3)", "parse error: duplicate keyword argument \"a\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestInvalidVariadics(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"func f(...a, b) {}", "parse error: the rest parameter must be the last parameter"},
+		{"func f(...) {}", "parse error: unexpected ) while parsing function (expected identifier)"},
+		{"func f(...b=1) {}", "parse error: rest parameter cannot have a default value"},
+		{"func f(a, ...b=[]) {}", "parse error: rest parameter cannot have a default value"},
+		{"f(**opts, 1)", "parse error: positional argument follows keyword argument unpacking"},
+		{"f(**opts, ...items)", "parse error: positional argument follows keyword argument unpacking"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	input := `match event {
+	case {"kind": "pod", "name": n} if n != "":
+		n
+	case [first, ...rest], [first, ...rest, _]:
+	case int(n), -1, http.StatusOK:
+		1
+	case Point(x=0, y=y):
+		y
+	case _:
+	default:
+		nil
+}`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	match, ok := program.First().(*ast.Match)
+	require.True(t, ok)
+	require.Equal(t, "event", match.Value().String())
+	cases := match.Cases()
+	require.Len(t, cases, 6)
+
+	require.Len(t, cases[0].Patterns(), 1)
+	mapPattern, ok := cases[0].Patterns()[0].(*ast.MapPattern)
+	require.True(t, ok)
+	require.Len(t, mapPattern.Keys(), 2)
+	require.IsType(t, &ast.ValuePattern{}, mapPattern.Values()[0])
+	require.IsType(t, &ast.CapturePattern{}, mapPattern.Values()[1])
+	require.Equal(t, `(n != "")`, cases[0].Guard().String())
+
+	require.Len(t, cases[1].Patterns(), 2)
+	listPattern, ok := cases[1].Patterns()[1].(*ast.ListPattern)
+	require.True(t, ok)
+	require.IsType(t, &ast.RestPattern{}, listPattern.Items()[1])
+	require.IsType(t, &ast.WildcardPattern{}, listPattern.Items()[2])
+	require.Nil(t, cases[1].Block())
+
+	require.Equal(t, "int(n)", cases[2].Patterns()[0].String())
+	require.Equal(t, "(-1)", cases[2].Patterns()[1].String())
+	require.Equal(t, "http.StatusOK", cases[2].Patterns()[2].String())
+	require.Equal(t, "Point(x=0, y=y)", cases[3].Patterns()[0].String())
+	require.IsType(t, &ast.WildcardPattern{}, cases[4].Patterns()[0])
+	require.True(t, cases[5].IsDefault())
+}
+
+func TestMatchIsSoftKeyword(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match(x)`, "match(x)"},
+		{`regexp.match("a", s)`, `regexp.match("a", s)`},
+		{`match[0]`, "(match[0])"},
+		{`match := 1`, "match := 1"},
+		{`match + 1`, "(match + 1)"},
+		{`x := match (y) { case 1: 2 }`, "x := match y {\ncase 1:\n\t2\n}"},
+		{`match [a, b] { case [1, _]: a }`, "match [a, b] {\ncase [1, _]:\n\ta\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result.String())
+		})
+	}
+}
+
+func TestInvalidMatch(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"match x { case 1: 1", "parse error: unterminated match expression"},
+		{"match x { default: 1\ndefault: 2 }", "parse error: match expression has multiple default blocks"},
+		{"match x { case a + 1: 1 }", "parse error: unexpected + while parsing match expression (expected :)"},
+		{"match x { case -a: 1 }", "parse error: invalid pattern (expected a number after \"-\")"},
+		{"match x { case (a): 1 }", "parse error: invalid pattern (unexpected \"(\")"},
+		{"match x { case [...a, ...b]: 1 }", "parse error: list pattern has multiple rest patterns"},
+		{"match x { case {1: a}: 1 }", "parse error: invalid map pattern key (got 1)"},
+		{"match x { case {a: 1, a: 2}: 1 }", "parse error: duplicate key \"a\" in map pattern"},
+		{"match x { case T(a=1, a=2): 1 }", "parse error: duplicate attribute \"a\" in type pattern"},
+		{"match x { case T(a=1, b): 1 }", "parse error: type pattern accepts one positional pattern, before any attribute patterns"},
+		{"match x { case [a b]: 1 }", "parse error: unexpected \"b\" in list pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in items]", "[(x * 2) for x in items]"},
+		{"[x for x in items if x > 0 if x < 9]", "[x for x in items if (x > 0) if (x < 9)]"},
+		{"[[i, j] for i in a for j in b if i != j]", "[[i, j] for i in a for j in b if (i != j)]"},
+		{"{k: v for k, v in m}", "{k: v for k, v in m}"},
+		{"{x for x in s}", "{x for x in s}"},
+		{"[\n  x\n  for x in items\n  if x\n]", "[x for x in items if x]"},
+		{"{\n  x: 1\n  for x in items\n}", "{x: 1 for x in items}"},
+		{"[x for x in range(3)]", "[x for x in range 3]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestComprehensionNodes(t *testing.T) {
+	program, err := Parse(context.Background(), "{k: v for k, v in m if v for x in v}")
+	require.Nil(t, err)
+	comp, ok := program.First().(*ast.MapComprehension)
+	require.True(t, ok)
+	require.Equal(t, "k", comp.Key().String())
+	require.Equal(t, "v", comp.Value().String())
+	clauses := comp.Clauses()
+	require.Len(t, clauses, 2)
+	require.Len(t, clauses[0].Names(), 2)
+	require.Equal(t, "m", clauses[0].Iterable().String())
+	require.Len(t, clauses[0].Conditions(), 1)
+	require.Len(t, clauses[1].Names(), 1)
+	require.Len(t, clauses[1].Conditions(), 0)
+}
+
+func TestInvalidComprehensions(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"[x for in items]", "parse error: unexpected in while parsing list comprehension (expected identifier)"},
+		{"[x for a, b, c in items]", "parse error: list comprehension accepts one or two names per for clause (got 3)"},
+		{"[x for x items]", "parse error: unexpected items while parsing list comprehension (expected in)"},
+		{"{x for x in items]", "parse error: unexpected ] while parsing set comprehension (expected })"},
+		{"{k: v for k in items, 1}", "parse error: unexpected , while parsing map comprehension (expected })"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a?.b", "a?.b"},
+		{"a?.b.c", "a?.b.c"},
+		{"a.b?.c", "a.b?.c"},
+		{"a?.[k]", "(a?.[k])"},
+		{"a?.[1:2]", "(a?.[1:2])"},
+		{"a?.f(x)", "a?.f(x)"},
+		{"a?.b[0]?.c(1).d", "(a?.b[0])?.c(1).d"},
+		{"-a?.b", "(-a?.b)"},
+		{"a ?? b", "(a ?? b)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a ?? b + 1", "(a ?? (b + 1))"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a ?? b ? c : d", "((a ?? b) ? c : d)"},
+		{"a || b ?? c", "(a || (b ?? c))"},
+		{"a?.b ?? c", "(a?.b ?? c)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestOptionalChainNodes(t *testing.T) {
+	program, err := Parse(context.Background(), "a?.b.c?.[0]")
+	require.Nil(t, err)
+	chain, ok := program.First().(*ast.OptionalChain)
+	require.True(t, ok)
+	index, ok := chain.Expression().(*ast.Index)
+	require.True(t, ok)
+	require.True(t, index.IsOptional())
+	attr, ok := index.Left().(*ast.GetAttr)
+	require.True(t, ok)
+	require.False(t, attr.IsOptional())
+	attr, ok = attr.Object().(*ast.GetAttr)
+	require.True(t, ok)
+	require.True(t, attr.IsOptional())
+	require.Equal(t, "a", attr.Object().String())
+}
+
+func TestInvalidOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"a?.(1)", "parse error: expected an identifier or \"[\" after \"?.\""},
+		{"a?.b.c = 1", "parse error: cannot assign to an optional chain"},
+		{"a ??", "parse error: invalid expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	input := `outer:
+for _, row := range rows {
+	for x := range row {
+		if x { continue outer }
+		break outer
+	}
+	break
+}`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	labeled, ok := program.First().(*ast.Labeled)
+	require.True(t, ok)
+	require.Equal(t, "outer", labeled.Label().Literal())
+	body := labeled.Loop().Consequence().Statements()
+	require.Len(t, body, 2)
+	inner := body[0].(*ast.For).Consequence().Statements()
+	require.Equal(t, "continue outer", inner[0].(*ast.If).Consequence().Statements()[0].String())
+	control, ok := inner[1].(*ast.Control)
+	require.True(t, ok)
+	require.Equal(t, "outer", control.Label().Literal())
+	require.Nil(t, body[1].(*ast.Control).Label())
+}
+
+func TestInvalidLabel(t *testing.T) {
+	_, err := Parse(context.Background(), "outer: x := 1")
+	require.NotNil(t, err)
+	require.Equal(t, "parse error: label outer must be followed by a for loop", err.Error())
+}
+
+func TestSelect(t *testing.T) {
+	input := `select {
+	case v := <-a:
+		v
+	case v, ok := <-b:
+	case <-time.after(1):
+		"timeout"
+	case c <- 42:
+	default:
+		nil
+}`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	sel, ok := program.First().(*ast.Select)
+	require.True(t, ok)
+	cases := sel.Cases()
+	require.Len(t, cases, 5)
+
+	require.Len(t, cases[0].Names(), 1)
+	require.Equal(t, "v", cases[0].Names()[0].Literal())
+	require.IsType(t, &ast.Receive{}, cases[0].Comm())
+
+	require.Len(t, cases[1].Names(), 2)
+	require.Equal(t, "ok", cases[1].Names()[1].Literal())
+	require.Nil(t, cases[1].Block())
+
+	require.Empty(t, cases[2].Names())
+	require.Equal(t, "<- time.after(1)", cases[2].Comm().String())
+
+	send, ok := cases[3].Comm().(*ast.Send)
+	require.True(t, ok)
+	require.Equal(t, "c", send.Channel().String())
+	require.True(t, cases[4].IsDefault())
+}
+
+func TestSelectIsSoftKeyword(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`select(x)`, "select(x)"},
+		{`db.select("a")`, `db.select("a")`},
+		{`select := 1`, "select := 1"},
+		{`x := select { case <-a: 1 }`, "x := select {\ncase <- a:\n\t1\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result.String())
+		})
+	}
+}
+
+func TestInvalidSelect(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"select { case <-a: 1", "parse error: unterminated select statement"},
+		{"select { default: 1\ndefault: 2 }", "parse error: select statement has multiple default blocks"},
+		{"select { case x: 1 }", "parse error: select case must receive from or send to a channel"},
+		{"select { case v := a <- 1: 1 }", "parse error: select case cannot assign the result of a send"},
+		{"select { case a, b, c := <-x: 1 }", "parse error: unexpected , while parsing select case (expected :=)"},
+		{"select { x }", "parse error: expected 'case' or 'default' (got x)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestDecorated(t *testing.T) {
+	input := `@retry(3)
+
+@timed
+@lib.cache
+func fetch(url, timeout=5) { return url }`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	stmt, ok := program.First().(*ast.Decorated)
+	require.True(t, ok)
+	decorators := stmt.Decorators()
+	require.Len(t, decorators, 3)
+	require.Equal(t, "retry(3)", decorators[0].String())
+	require.Equal(t, "timed", decorators[1].String())
+	require.Equal(t, "lib.cache", decorators[2].String())
+	require.Equal(t, "fetch", stmt.Func().Name().Literal())
+	require.Equal(t, "@retry(3)\n@timed\n@lib.cache\nfunc fetch(url, timeout) { return url }", stmt.String())
+}
+
+func TestInvalidDecorated(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"@timed", "parse error: decorators must be followed by a named function"},
+		{"@timed\nx := 1", "parse error: decorators must be followed by a named function"},
+		{"@timed\nfunc() {}", "parse error: decorators must be followed by a named function"},
+		{"@timed func f() {}", "parse error: unexpected func after decorator"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.err, err.Error())
+		})
+	}
+}
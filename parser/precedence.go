@@ -11,6 +11,7 @@ const (
 	ASSIGN      // =
 	DECLARE     // :=
 	TERNARY     // ? :
+	NULLISH     // ??
 	EQUALS      // == or !=
 	LESSGREATER // > or <
 	SUM         // + or -
@@ -26,6 +27,7 @@ const (
 // Precedences for each token type
 var precedences = map[token.Type]int{
 	token.QUESTION:        TERNARY,
+	token.NULLISH:         NULLISH,
 	token.ASSIGN:          ASSIGN,
 	token.DECLARE:         DECLARE,
 	token.EQ:              EQUALS,
@@ -51,8 +53,18 @@ var precedences = map[token.Type]int{
 	token.PIPE:            PIPE,
 	token.LPAREN:          CALL,
 	token.PERIOD:          INDEX,
+	token.QUESTION_PERIOD: INDEX,
 	token.LBRACKET:        INDEX,
 	token.IN:              PREFIX,
 	token.RANGE:           PREFIX,
 	token.SEND:            CALL,
 }
+
+// Precedence returns the precedence of the given operator token type, or
+// LOWEST if the token type is not an operator.
+func Precedence(t token.Type) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
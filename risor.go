@@ -16,7 +16,7 @@ import (
 func Eval(ctx context.Context, source string, options ...Option) (object.Object, error) {
 	cfg := NewConfig(options...)
 	// Parse the source code to create the AST
-	ast, err := parser.Parse(ctx, source)
+	ast, err := parser.Parse(ctx, source, cfg.ParserOpts()...)
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +26,7 @@ import (
 	modTime "github.com/risor-io/risor/modules/time"
 	modYAML "github.com/risor-io/risor/modules/yaml"
 	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
 	"github.com/risor-io/risor/vm"
 )
 
@@ -36,6 +37,13 @@ type Config struct {
 	denylist              map[string]bool
 	importer              importer.Importer
 	localImportPath       string
+	filename              string
+	coverage              *vm.Coverage
+	profiler              *vm.Profiler
+	maxFrameDepth         int
+	maxStackSize          int
+	optimize              bool
+	withoutStackTraces    bool
 	withoutDefaultGlobals bool
 	withConcurrency       bool
 	listenersAllowed      bool
@@ -192,6 +200,15 @@ func (cfg *Config) applyOverrides() error {
 	return nil
 }
 
+// ParserOpts returns parser options derived from this configuration.
+func (cfg *Config) ParserOpts() []parser.Option {
+	var opts []parser.Option
+	if cfg.filename != "" {
+		opts = append(opts, parser.WithFile(cfg.filename))
+	}
+	return opts
+}
+
 // CompilerOpts returns compiler options derived from this configuration.
 func (cfg *Config) CompilerOpts() []compiler.Option {
 	cfg.init()
@@ -200,6 +217,9 @@ func (cfg *Config) CompilerOpts() []compiler.Option {
 	if len(globalNames) > 0 {
 		opts = append(opts, compiler.WithGlobalNames(globalNames))
 	}
+	if cfg.optimize {
+		opts = append(opts, compiler.WithOptimization())
+	}
 	return opts
 }
 
@@ -225,6 +245,21 @@ func (cfg *Config) VMOpts() []vm.Option {
 	if cfg.withConcurrency {
 		opts = append(opts, vm.WithConcurrency())
 	}
+	if cfg.coverage != nil {
+		opts = append(opts, vm.WithCoverage(cfg.coverage))
+	}
+	if cfg.profiler != nil {
+		opts = append(opts, vm.WithProfiler(cfg.profiler))
+	}
+	if cfg.maxFrameDepth > 0 {
+		opts = append(opts, vm.WithMaxFrameDepth(cfg.maxFrameDepth))
+	}
+	if cfg.maxStackSize > 0 {
+		opts = append(opts, vm.WithMaxStackSize(cfg.maxStackSize))
+	}
+	if cfg.withoutStackTraces {
+		opts = append(opts, vm.WithoutStackTraces())
+	}
 	return opts
 }
 
@@ -1,6 +1,9 @@
 package risor
 
-import "github.com/risor-io/risor/importer"
+import (
+	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/vm"
+)
 
 // Option describes a function used to configure a Risor evaluation.
 type Option func(*Config)
@@ -69,6 +72,62 @@ func WithLocalImporter(path string) Option {
 	}
 }
 
+// WithFilename sets the name of the file the source code was read from. This
+// name is reported in the locations of runtime stack traces.
+func WithFilename(filename string) Option {
+	return func(cfg *Config) {
+		cfg.filename = filename
+	}
+}
+
+// WithCoverage records the code executed during evaluation in the given
+// Coverage.
+func WithCoverage(coverage *vm.Coverage) Option {
+	return func(cfg *Config) {
+		cfg.coverage = coverage
+	}
+}
+
+// WithProfiler samples the evaluation using the given Profiler. Samples are
+// only recorded while the profiler is started.
+func WithProfiler(profiler *vm.Profiler) Option {
+	return func(cfg *Config) {
+		cfg.profiler = profiler
+	}
+}
+
+// WithOptimization enables the compiler's optimization pass, which simplifies
+// the compiled code, for example by folding operations on constants.
+func WithOptimization() Option {
+	return func(cfg *Config) {
+		cfg.optimize = true
+	}
+}
+
+// WithMaxFrameDepth sets the maximum depth of nested function calls, which
+// limits recursion. Deeper calls raise a stack overflow error.
+func WithMaxFrameDepth(depth int) Option {
+	return func(cfg *Config) {
+		cfg.maxFrameDepth = depth
+	}
+}
+
+// WithMaxStackSize sets the maximum number of values on the VM data stack.
+func WithMaxStackSize(size int) Option {
+	return func(cfg *Config) {
+		cfg.maxStackSize = size
+	}
+}
+
+// WithoutStackTraces returns runtime errors with their original type, rather
+// than wrapped in an *errz.TracedError that carries the Risor stack trace of
+// the error.
+func WithoutStackTraces() Option {
+	return func(cfg *Config) {
+		cfg.withoutStackTraces = true
+	}
+}
+
 // WithConcurrency enables the use of concurrency in Risor evaluations.
 func WithConcurrency() Option {
 	return func(cfg *Config) {
@@ -6,9 +6,11 @@ import (
 	"testing"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/object"
 	ros "github.com/risor-io/risor/os"
 	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
 	"github.com/stretchr/testify/require"
 )
 
@@ -364,3 +366,36 @@ func TestWithConcurrency(t *testing.T) {
 	require.NotNil(t, err)
 	require.Equal(t, "eval error: context did not contain a spawn function", err.Error())
 }
+
+func TestWithFilename(t *testing.T) {
+	_, err := Eval(context.Background(), "x := 1\nx.foo", WithFilename("example.risor"))
+	require.NotNil(t, err)
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "__main__", File: "example.risor", Line: 2, Column: 2},
+	}, traced.StackTrace())
+
+	_, err = Eval(context.Background(), "x := 1\nx.foo", WithFilename("example.risor"), WithoutStackTraces())
+	require.NotNil(t, err)
+	_, ok := err.(*errz.TypeError)
+	require.True(t, ok)
+}
+
+func TestWithMaxFrameDepth(t *testing.T) {
+	script := `func depth(n) { if n == 0 { return 0 }; return 1 + depth(n - 1) }
+	c := chan()
+	go func() { c <- (depth(2000)) }()
+	[depth(2000), <-c]`
+
+	result, err := Eval(context.Background(), script, WithConcurrency(), WithMaxFrameDepth(5000))
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewInt(2000), object.NewInt(2000),
+	}), result)
+
+	_, err = Eval(context.Background(), script, WithConcurrency())
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, vm.ErrStackOverflow))
+	require.Equal(t, "stack overflow: maximum call depth of 1024 exceeded", err.Error())
+}
@@ -38,17 +38,20 @@ const (
 	ASSIGN          = "="
 	ASTERISK        = "*"
 	ASTERISK_EQUALS = "*="
+	AT              = "@"
 	BACKTICK        = "`"
 	FSTRING         = "'"
 	BANG            = "!"
 	CASE            = "case"
 	COLON           = ":"
 	COMMA           = ","
+	COMMENT         = "COMMENT"
 	CONST           = "CONST"
 	DECLARE         = ":="
 	DEFAULT         = "DEFAULT"
 	DEFER           = "DEFER"
 	FUNC            = "FUNC"
+	ELLIPSIS        = "..."
 	ELSE            = "ELSE"
 	EOF             = "EOF"
 	EQ              = "=="
@@ -83,6 +86,8 @@ const (
 	PLUS_PLUS       = "++"
 	POW             = "**"
 	QUESTION        = "?"
+	QUESTION_PERIOD = "?."
+	NULLISH         = "??"
 	RBRACE          = "}"
 	RBRACKET        = "]"
 	RETURN          = "RETURN"
@@ -104,6 +109,7 @@ const (
 	RANGE           = "RANGE"
 	FROM            = "FROM"
 	AS              = "AS"
+	YIELD           = "YIELD"
 )
 
 // Reserved keywords
@@ -131,6 +137,7 @@ var keywords = map[string]Type{
 	"switch":   SWITCH,
 	"true":     TRUE,
 	"var":      VAR,
+	"yield":    YIELD,
 }
 
 // LookupIdentifier used to determinate whether identifier is keyword nor not
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/object"
@@ -14,6 +15,21 @@ type code struct {
 	Constants    []object.Object
 	Globals      []object.Object
 	Names        []string
+
+	// Coverage counters, which are only set when coverage is enabled
+	calls *uint32
+	hits  []uint32
+
+	// Inline caches for LoadAttr instructions, indexed by the offset of the
+	// instruction. This is nil if the code has no LoadAttr instructions.
+	attrCaches []atomic.Pointer[attrCache]
+}
+
+// attrCache holds the getter for an attribute loaded by an instruction, which
+// is valid for objects with the same cache key.
+type attrCache struct {
+	key    object.AttrCacheKey
+	getter object.AttrGetter
 }
 
 func wrapCode(cc *compiler.Code) *code {
@@ -30,6 +46,12 @@ func wrapCode(cc *compiler.Code) *code {
 	for i := 0; i < cc.NameCount(); i++ {
 		c.Names[i] = cc.Name(i)
 	}
+	for ip := 0; ip < len(c.Instructions); ip += 1 + op.GetInfo(c.Instructions[ip]).OperandCount {
+		if c.Instructions[ip] == op.LoadAttr {
+			c.attrCaches = make([]atomic.Pointer[attrCache], len(c.Instructions))
+			break
+		}
+	}
 	for i := 0; i < cc.ConstantsCount(); i++ {
 		constant := cc.Constant(i)
 		switch constant := constant.(type) {
@@ -54,6 +76,28 @@ func wrapCode(cc *compiler.Code) *code {
 	return c
 }
 
+// getAttr gets the named attribute of an object for the LoadAttr instruction
+// at the given offset. The attribute getter is cached for the instruction, so
+// that later loads from objects with the same cache key skip the lookup. The
+// cache holds one entry, which is replaced when the key changes.
+func (c *code) getAttr(ip int, obj object.Object, name string) (object.Object, bool) {
+	cacher, ok := obj.(object.AttrCacher)
+	if !ok || c.attrCaches == nil {
+		return obj.GetAttr(name)
+	}
+	key := cacher.AttrCacheKey()
+	entry := &c.attrCaches[ip]
+	if cached := entry.Load(); cached != nil && cached.key == key {
+		return cached.getter(obj), true
+	}
+	getter, ok := cacher.AttrGetter(name)
+	if !ok {
+		return obj.GetAttr(name)
+	}
+	entry.Store(&attrCache{key: key, getter: getter})
+	return getter(obj), true
+}
+
 func (c *code) GlobalsCount() int {
 	return len(c.Globals)
 }
@@ -0,0 +1,228 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/risor-io/risor/compiler"
+)
+
+// Coverage records how many times each instruction is executed. It is
+// attached to a VM using the WithCoverage option. A single Coverage may be
+// shared by any number of VMs, including VMs cloned for concurrent calls, and
+// the counts from all of them are combined. Counts are keyed by the compiled
+// code, so VMs must run the same *compiler.Code for their counts to combine.
+type Coverage struct {
+	mutex    sync.Mutex
+	counters map[*compiler.Code]*codeCounters
+	roots    []*compiler.Code
+}
+
+// codeCounters holds the counts for one compiled code object. The counts of
+// the instructions are split across slices that are never resized, since
+// VMs add to them without holding the mutex. When the code grows, a longer
+// slice is added and the count of an instruction is the sum of its counts in
+// each slice.
+type codeCounters struct {
+	calls uint32
+	hits  [][]uint32
+}
+
+// count returns the number of times the instruction at the given offset was
+// executed.
+func (c *codeCounters) count(offset int) uint32 {
+	var total uint32
+	for _, hits := range c.hits {
+		if offset < len(hits) {
+			total += atomic.LoadUint32(&hits[offset])
+		}
+	}
+	return total
+}
+
+// latest returns the counters for the most recent version of the code.
+func (c *codeCounters) latest() []uint32 {
+	if len(c.hits) == 0 {
+		return nil
+	}
+	return c.hits[len(c.hits)-1]
+}
+
+// NewCoverage returns an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{counters: map[*compiler.Code]*codeCounters{}}
+}
+
+// FileCoverage summarizes the coverage of one source file.
+type FileCoverage struct {
+	Filename  string
+	Lines     []LineCoverage
+	Functions []FunctionCoverage
+}
+
+// LineCoverage is the execution count of one source line, which is the
+// largest execution count of any instruction compiled from the line.
+type LineCoverage struct {
+	Line int
+	Hits int
+}
+
+// FunctionCoverage is the number of times a function was called.
+type FunctionCoverage struct {
+	Name string
+	Line int
+	Hits int
+}
+
+// LinesCovered returns the number of lines that were executed.
+func (f *FileCoverage) LinesCovered() int {
+	var count int
+	for _, line := range f.Lines {
+		if line.Hits > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Percent returns the percentage of lines that were executed.
+func (f *FileCoverage) Percent() float64 {
+	if len(f.Lines) == 0 {
+		return 100
+	}
+	return 100 * float64(f.LinesCovered()) / float64(len(f.Lines))
+}
+
+// register returns the call counter and instruction counters for the given
+// code, creating counters for it and all the code compiled with it if
+// necessary. Creating counters for the functions up front means functions
+// that never run are reported.
+func (c *Coverage) register(cc *compiler.Code) (*uint32, []uint32) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if counters, ok := c.counters[cc]; ok && len(counters.latest()) == cc.InstructionCount() {
+		return &counters.calls, counters.latest()
+	}
+	root := cc.Root()
+	if _, ok := c.counters[root]; !ok {
+		c.roots = append(c.roots, root)
+	}
+	// In a REPL, the main code grows with each input, so new counters are
+	// added to cover the new instructions. VMs that loaded an earlier version
+	// of the code keep using the existing counters.
+	for _, code := range root.Flatten() {
+		counters, ok := c.counters[code]
+		if !ok {
+			counters = &codeCounters{}
+			c.counters[code] = counters
+		}
+		if len(counters.latest()) < code.InstructionCount() {
+			counters.hits = append(counters.hits, make([]uint32, code.InstructionCount()))
+		}
+	}
+	counters := c.counters[cc]
+	return &counters.calls, counters.latest()
+}
+
+// Merge adds the counts recorded by other to this Coverage.
+func (c *Coverage) Merge(other *Coverage) {
+	if other == c {
+		return
+	}
+	other.mutex.Lock()
+	snapshots := make(map[*compiler.Code]*codeCounters, len(other.counters))
+	for cc, counters := range other.counters {
+		snapshots[cc] = &codeCounters{
+			calls: atomic.LoadUint32(&counters.calls),
+			hits:  append([][]uint32(nil), counters.hits...),
+		}
+	}
+	other.mutex.Unlock()
+	for cc, src := range snapshots {
+		calls, hits := c.register(cc)
+		atomic.AddUint32(calls, src.calls)
+		for i := range hits {
+			atomic.AddUint32(&hits[i], src.count(i))
+		}
+	}
+}
+
+// Files returns the line coverage of each source file, sorted by filename.
+// Code compiled without a filename is reported with an empty filename. If
+// the same file was compiled more than once, as happens when separate VMs
+// import the same module, the counts from each compilation are added.
+func (c *Coverage) Files() []FileCoverage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	type fileState struct {
+		lines     map[int]int
+		functions []FunctionCoverage
+	}
+	files := map[string]*fileState{}
+	for _, root := range c.roots {
+		file, ok := files[root.Filename()]
+		if !ok {
+			file = &fileState{lines: map[int]int{}}
+			files[root.Filename()] = file
+		}
+		rootLines := map[int]int{}
+		for i, cc := range root.Flatten() {
+			counters, ok := c.counters[cc]
+			if !ok {
+				continue
+			}
+			for offset := range counters.latest() {
+				loc := cc.Location(offset)
+				if loc.Line <= 0 {
+					continue
+				}
+				hits := int(counters.count(offset))
+				if current, ok := rootLines[loc.Line]; !ok || hits > current {
+					rootLines[loc.Line] = hits
+				}
+			}
+			if i > 0 {
+				file.functions = addFunction(file.functions, cc, int(atomic.LoadUint32(&counters.calls)))
+			}
+		}
+		for line, hits := range rootLines {
+			file.lines[line] += hits
+		}
+	}
+	result := make([]FileCoverage, 0, len(files))
+	for filename, file := range files {
+		fc := FileCoverage{Filename: filename, Functions: file.functions}
+		for line, hits := range file.lines {
+			fc.Lines = append(fc.Lines, LineCoverage{Line: line, Hits: hits})
+		}
+		sort.Slice(fc.Lines, func(i, j int) bool {
+			return fc.Lines[i].Line < fc.Lines[j].Line
+		})
+		sort.SliceStable(fc.Functions, func(i, j int) bool {
+			return fc.Functions[i].Line < fc.Functions[j].Line
+		})
+		result = append(result, fc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Filename < result[j].Filename
+	})
+	return result
+}
+
+// addFunction adds the calls of the function compiled as cc to the list.
+func addFunction(functions []FunctionCoverage, cc *compiler.Code, calls int) []FunctionCoverage {
+	line := cc.Location(0).Line
+	name := cc.CodeName()
+	if name == "" {
+		name = fmt.Sprintf("<anonymous:%d>", line)
+	}
+	for i, fn := range functions {
+		if fn.Name == name && fn.Line == line {
+			functions[i].Hits += calls
+			return functions
+		}
+	}
+	return append(functions, FunctionCoverage{Name: name, Line: line, Hits: calls})
+}
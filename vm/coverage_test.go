@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+const coverageSource = `func square(x) {
+  return x * x
+}
+func unused() {
+  return 1
+}
+threads := []
+for i := 0; i < 4; i++ {
+  threads.append(spawn(square, i))
+}
+if false {
+  unused()
+}
+threads.map(func(t) { t.wait() })
+`
+
+func runCoverage(t *testing.T, main *compiler.Code, cov *Coverage) {
+	t.Helper()
+	vm := New(main, WithCoverage(cov), WithConcurrency(), WithGlobals(basicBuiltins()))
+	require.Nil(t, vm.Run(context.Background()))
+}
+
+func compileCoverage(t *testing.T, source string) *compiler.Code {
+	t.Helper()
+	ast, err := parser.Parse(context.Background(), source, parser.WithFile("cover.risor"))
+	require.Nil(t, err)
+	var globalNames []string
+	for name := range basicBuiltins() {
+		globalNames = append(globalNames, name)
+	}
+	main, err := compiler.Compile(ast, compiler.WithGlobalNames(globalNames))
+	require.Nil(t, err)
+	return main
+}
+
+func lineHits(file FileCoverage) map[int]int {
+	hits := map[int]int{}
+	for _, line := range file.Lines {
+		hits[line.Line] = line.Hits
+	}
+	return hits
+}
+
+func TestCoverage(t *testing.T) {
+	main := compileCoverage(t, coverageSource)
+	cov := NewCoverage()
+	runCoverage(t, main, cov)
+
+	files := cov.Files()
+	require.Len(t, files, 1)
+	file := files[0]
+	require.Equal(t, "cover.risor", file.Filename)
+
+	hits := lineHits(file)
+	// Calls made in cloned VMs by spawn are counted
+	require.Equal(t, 4, hits[2])
+	require.Equal(t, 0, hits[5])
+	require.Equal(t, 4, hits[9])
+	require.Equal(t, 0, hits[12])
+	require.Greater(t, hits[14], 0)
+	require.Less(t, file.LinesCovered(), len(file.Lines))
+
+	require.Equal(t, []FunctionCoverage{
+		{Name: "square", Line: 2, Hits: 4},
+		{Name: "unused", Line: 5, Hits: 0},
+		{Name: "<anonymous:14>", Line: 14, Hits: 4},
+	}, file.Functions)
+}
+
+func TestCoverageMerge(t *testing.T) {
+	main := compileCoverage(t, coverageSource)
+	first := NewCoverage()
+	second := NewCoverage()
+	runCoverage(t, main, first)
+	runCoverage(t, main, second)
+	first.Merge(second)
+	hits := lineHits(first.Files()[0])
+	require.Equal(t, 8, hits[2])
+	require.Equal(t, 0, hits[5])
+}
+
+func TestCoverageGrowingCode(t *testing.T) {
+	// In a REPL the main code grows, while VMs that loaded it earlier may
+	// still be counting instructions in the existing counters
+	ctx := context.Background()
+	comp, err := compiler.New()
+	require.Nil(t, err)
+	ast, err := parser.Parse(ctx, "x := 1", parser.WithFile("repl.risor"))
+	require.Nil(t, err)
+	main, err := comp.Compile(ast)
+	require.Nil(t, err)
+
+	cov := NewCoverage()
+	_, before := cov.register(main)
+	require.Len(t, before, main.InstructionCount())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			atomic.AddUint32(&before[0], 1)
+		}
+	}()
+	ast, err = parser.Parse(ctx, "x = 2", parser.WithFile("repl.risor"))
+	require.Nil(t, err)
+	_, err = comp.Compile(ast)
+	require.Nil(t, err)
+	_, after := cov.register(main)
+	require.Greater(t, len(after), len(before))
+	atomic.AddUint32(&after[0], 1)
+	<-done
+
+	hits := lineHits(cov.Files()[0])
+	require.Equal(t, 1001, hits[1])
+}
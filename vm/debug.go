@@ -0,0 +1,296 @@
+package vm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+)
+
+// StopReason describes why a Debugger paused execution.
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"
+	StopBreakpoint StopReason = "breakpoint"
+	StopStep       StopReason = "step"
+	StopPause      StopReason = "pause"
+)
+
+type stepMode int
+
+const (
+	stepNone stepMode = iota
+	stepIn
+	stepOver
+	stepOut
+)
+
+// Variable is a named value visible in a paused frame.
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+// DebugFrame is a snapshot of one call frame in a paused VM.
+type DebugFrame struct {
+	Function string
+	Location compiler.SourceLocation
+	Locals   []Variable
+	FreeVars []Variable
+	Globals  []Variable
+}
+
+// StopEvent is delivered to the Debugger's stop handler when the VM pauses.
+// Frames holds the call stack with the innermost frame first.
+type StopEvent struct {
+	Reason StopReason
+	Frames []DebugFrame
+}
+
+// Debugger controls the execution of a VM for interactive debugging. It is
+// attached to a VM using the WithDebugger option. When the VM reaches a
+// breakpoint, completes a step, or is asked to pause, it calls the stop
+// handler and then blocks until one of Continue, StepIn, StepOver, or StepOut
+// is called. The remaining methods are safe to call from any goroutine.
+//
+// Breakpoints are matched against the filename given to the parser, so the
+// same form of the path must be used for both.
+//
+// The debugger is shared with the VMs that run functions concurrently, such
+// as those started by go statements. Breakpoints and pauses apply to all of
+// them, but only one is paused at a time: a VM that reaches a breakpoint
+// while another is paused waits until it is resumed. Steps apply to the VM
+// that was paused last. The globals in a stop event are shared with the other
+// VMs, which may change them while it is handled.
+type Debugger struct {
+	mutex       sync.Mutex
+	breakpoints map[string]map[int]bool
+	mode        stepMode
+	stepFP      int
+	stepVM      *VirtualMachine
+	pause       int32
+	paused      bool
+	resume      chan stepMode
+	onStop      func(*StopEvent)
+	// stopMutex is held while a VM is paused
+	stopMutex sync.Mutex
+}
+
+// NewDebugger returns a Debugger that calls onStop each time the VM pauses.
+// The handler is called on the goroutine running the VM.
+func NewDebugger(onStop func(*StopEvent)) *Debugger {
+	return &Debugger{
+		breakpoints: map[string]map[int]bool{},
+		resume:      make(chan stepMode, 1),
+		onStop:      onStop,
+	}
+}
+
+// SetBreakpoints replaces the breakpoints for the given file with the given
+// line numbers.
+func (d *Debugger) SetBreakpoints(filename string, lines []int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(lines) == 0 {
+		delete(d.breakpoints, filename)
+		return
+	}
+	fileBreakpoints := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		fileBreakpoints[line] = true
+	}
+	d.breakpoints[filename] = fileBreakpoints
+}
+
+// StopOnEntry causes the VM to pause before running the first line of code.
+// This must be called before the VM is started.
+func (d *Debugger) StopOnEntry() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.mode = stepIn
+	d.stepFP = -1
+	d.stepVM = nil
+}
+
+// Pause asks a running VM to pause at the next instruction.
+func (d *Debugger) Pause() {
+	atomic.StoreInt32(&d.pause, 1)
+}
+
+// Continue resumes a paused VM until the next breakpoint.
+func (d *Debugger) Continue() {
+	d.resumeWith(stepNone)
+}
+
+// StepIn resumes a paused VM until it reaches a new line, including lines in
+// functions called from the current line.
+func (d *Debugger) StepIn() {
+	d.resumeWith(stepIn)
+}
+
+// StepOver resumes a paused VM until it reaches a new line in the current
+// function or returns from it.
+func (d *Debugger) StepOver() {
+	d.resumeWith(stepOver)
+}
+
+// StepOut resumes a paused VM until the current function returns.
+func (d *Debugger) StepOut() {
+	d.resumeWith(stepOut)
+}
+
+func (d *Debugger) resumeWith(mode stepMode) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	// A resume has no effect unless the VM is paused
+	if !d.paused {
+		return
+	}
+	select {
+	case d.resume <- mode:
+	default:
+	}
+}
+
+// check is called by the VM before each instruction when a debugger is
+// attached. If execution should pause, it calls the stop handler and blocks
+// until it is resumed or the context is cancelled.
+func (d *Debugger) check(ctx context.Context, vm *VirtualMachine) error {
+	f := vm.activeFrame
+	loc := f.code.Location(vm.ip)
+	if !loc.IsValid() {
+		return nil
+	}
+	newLine := loc.Line != f.debugLine
+	f.debugLine = loc.Line
+	reason, stop := d.shouldStop(vm, loc, newLine)
+	if !stop {
+		return nil
+	}
+	d.stopMutex.Lock()
+	defer d.stopMutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.mutex.Lock()
+	d.paused = true
+	d.mutex.Unlock()
+	d.onStop(vm.debugStopEvent(reason))
+	select {
+	case mode := <-d.resume:
+		d.mutex.Lock()
+		d.paused = false
+		d.mode = mode
+		d.stepFP = vm.fp
+		d.stepVM = vm
+		d.mutex.Unlock()
+		return nil
+	case <-ctx.Done():
+		d.mutex.Lock()
+		d.paused = false
+		d.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (d *Debugger) shouldStop(vm *VirtualMachine, loc compiler.SourceLocation, newLine bool) (StopReason, bool) {
+	if atomic.CompareAndSwapInt32(&d.pause, 1, 0) {
+		return StopPause, true
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	fp := vm.fp
+	if d.stepVM != nil && d.stepVM != vm {
+		// Only breakpoints apply to VMs other than the one being stepped
+		if newLine && d.breakpoints[loc.Filename][loc.Line] {
+			return StopBreakpoint, true
+		}
+		return "", false
+	}
+	// Stepping out stops as soon as control is back in the caller, which is
+	// usually partway through the line that made the call.
+	if d.mode == stepOut && fp < d.stepFP {
+		return StopStep, true
+	}
+	if !newLine {
+		return "", false
+	}
+	if d.breakpoints[loc.Filename][loc.Line] {
+		return StopBreakpoint, true
+	}
+	switch d.mode {
+	case stepIn:
+		if d.stepFP < 0 {
+			return StopEntry, true
+		}
+		return StopStep, true
+	case stepOver:
+		return StopStep, fp <= d.stepFP
+	}
+	return "", false
+}
+
+// debugStopEvent captures the frames of the paused VM. The VM is about to
+// execute the instruction at vm.ip in the active frame.
+func (vm *VirtualMachine) debugStopEvent(reason StopReason) *StopEvent {
+	event := &StopEvent{Reason: reason}
+	ip := vm.ip + 1
+	for fp := vm.fp; fp >= 0; fp-- {
+		f := vm.frames[fp]
+		if f.code == nil {
+			break
+		}
+		event.Frames = append(event.Frames, DebugFrame{
+			Function: frameName(f),
+			Location: f.code.Location(ip - 1),
+			Locals:   frameLocals(f),
+			FreeVars: frameFreeVars(f),
+			Globals:  frameGlobals(f),
+		})
+		ip = f.callerIP
+	}
+	return event
+}
+
+func frameLocals(f *frame) []Variable {
+	var vars []Variable
+	for i, value := range f.Locals() {
+		if value == nil || i >= f.code.LocalsCount() {
+			continue
+		}
+		vars = append(vars, Variable{Name: f.code.Local(i).Name(), Value: value})
+	}
+	return vars
+}
+
+func frameFreeVars(f *frame) []Variable {
+	if f.fn == nil {
+		return nil
+	}
+	var vars []Variable
+	for i, cell := range f.fn.FreeVars() {
+		if i >= f.code.FreeCount() {
+			break
+		}
+		value := cell.Value()
+		if value == nil {
+			continue
+		}
+		vars = append(vars, Variable{Name: f.code.Free(i).Symbol().Name(), Value: value})
+	}
+	return vars
+}
+
+func frameGlobals(f *frame) []Variable {
+	var vars []Variable
+	for i, value := range f.code.Globals {
+		if value == nil || i >= f.code.Code.GlobalsCount() {
+			continue
+		}
+		vars = append(vars, Variable{Name: f.code.Global(i).Name(), Value: value})
+	}
+	return vars
+}
@@ -0,0 +1,198 @@
+package vm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+const debugSource = `x := 1
+func add(a, b) {
+  sum := a + b
+  return sum
+}
+func outer() {
+  y := 10
+  inner := func() { return y + x }
+  return add(inner(), 2)
+}
+z := outer()
+z
+`
+
+// startDebugVM runs the source in a VM with the given debugger attached, and
+// returns channels that receive stop events and the final result.
+func startDebugVM(t *testing.T, source string, setup func(d *Debugger), opts ...Option) (*Debugger, chan *StopEvent, chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ast, err := parser.Parse(ctx, source, parser.WithFile("debug.risor"))
+	require.Nil(t, err)
+	globals := basicBuiltins()
+	var globalNames []string
+	for name := range globals {
+		globalNames = append(globalNames, name)
+	}
+	main, err := compiler.Compile(ast, compiler.WithGlobalNames(globalNames))
+	require.Nil(t, err)
+	events := make(chan *StopEvent, 1)
+	done := make(chan error, 1)
+	d := NewDebugger(func(event *StopEvent) { events <- event })
+	setup(d)
+	opts = append(opts, WithDebugger(d), WithGlobals(globals))
+	go func() { done <- New(main, opts...).Run(ctx) }()
+	return d, events, done
+}
+
+func nextStop(t *testing.T, events chan *StopEvent) *StopEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the debugger to stop")
+		return nil
+	}
+}
+
+func variable(vars []Variable, name string) object.Object {
+	for _, v := range vars {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return nil
+}
+
+func TestDebuggerBreakpoint(t *testing.T) {
+	d, events, done := startDebugVM(t, debugSource, func(d *Debugger) {
+		d.SetBreakpoints("debug.risor", []int{4})
+	})
+	event := nextStop(t, events)
+	require.Equal(t, StopBreakpoint, event.Reason)
+	require.Len(t, event.Frames, 3)
+	require.Equal(t, "add", event.Frames[0].Function)
+	require.Equal(t, 4, event.Frames[0].Location.Line)
+	require.Equal(t, "debug.risor", event.Frames[0].Location.Filename)
+	require.Equal(t, object.NewInt(13), variable(event.Frames[0].Locals, "sum"))
+	require.Equal(t, object.NewInt(11), variable(event.Frames[0].Locals, "a"))
+	require.Equal(t, "outer", event.Frames[1].Function)
+	require.Equal(t, 9, event.Frames[1].Location.Line)
+	require.Equal(t, object.NewInt(10), variable(event.Frames[1].Locals, "y"))
+	require.Equal(t, "__main__", event.Frames[2].Function)
+	require.Equal(t, 11, event.Frames[2].Location.Line)
+	require.Equal(t, object.NewInt(1), variable(event.Frames[2].Globals, "x"))
+	d.Continue()
+	require.Nil(t, <-done)
+}
+
+func TestDebuggerFreeVars(t *testing.T) {
+	d, events, done := startDebugVM(t, debugSource, func(d *Debugger) {
+		d.SetBreakpoints("debug.risor", []int{8})
+	})
+	event := nextStop(t, events)
+	require.Equal(t, 8, event.Frames[0].Location.Line)
+	require.Equal(t, "outer", event.Frames[0].Function)
+	d.StepIn()
+	event = nextStop(t, events)
+	require.Equal(t, 9, event.Frames[0].Location.Line)
+	d.StepIn()
+	event = nextStop(t, events)
+	require.Equal(t, "<anonymous>", event.Frames[0].Function)
+	require.Equal(t, 8, event.Frames[0].Location.Line)
+	require.Equal(t, object.NewInt(10), variable(event.Frames[0].FreeVars, "y"))
+	d.Continue()
+	require.Nil(t, <-done)
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	d, events, done := startDebugVM(t, debugSource, func(d *Debugger) {
+		d.StopOnEntry()
+	})
+	var lines []int
+	event := nextStop(t, events)
+	require.Equal(t, StopEntry, event.Reason)
+	lines = append(lines, event.Frames[0].Location.Line)
+	for _, step := range []func(){d.StepOver, d.StepOver, d.StepIn, d.StepIn, d.StepOut, d.StepOver} {
+		step()
+		event = nextStop(t, events)
+		require.Equal(t, StopStep, event.Reason)
+		lines = append(lines, event.Frames[0].Location.Line)
+	}
+	// Entry at 1, over the func definitions at 2 and 6, into outer at 11
+	// then to line 7, out to line 11, and then over to line 12.
+	require.Equal(t, []int{1, 2, 6, 11, 7, 11, 12}, lines)
+	d.Continue()
+	require.Nil(t, <-done)
+}
+
+func TestDebuggerPause(t *testing.T) {
+	source := `func tick(n) {
+  return n + 1
+}
+i := 0
+for {
+  i = tick(i)
+}`
+	d, events, done := startDebugVM(t, source, func(d *Debugger) {})
+	d.Pause()
+	event := nextStop(t, events)
+	require.Equal(t, StopPause, event.Reason)
+	d.SetBreakpoints("debug.risor", []int{2})
+	d.Continue()
+	event = nextStop(t, events)
+	require.Equal(t, StopBreakpoint, event.Reason)
+	n := variable(event.Frames[0].Locals, "n").(*object.Int).Value()
+	d.Continue()
+	event = nextStop(t, events)
+	require.Equal(t, n+1, variable(event.Frames[0].Locals, "n").(*object.Int).Value())
+	d.SetBreakpoints("debug.risor", nil)
+	d.Pause()
+	d.Continue()
+	event = nextStop(t, events)
+	require.Equal(t, StopPause, event.Reason)
+	select {
+	case err := <-done:
+		t.Fatalf("unexpected exit: %v", err)
+	default:
+	}
+}
+
+func TestDebuggerGoroutines(t *testing.T) {
+	source := `func work(ch, n) {
+  ch <- n
+}
+func main() {
+  ch := chan(2)
+  go work(ch, 1)
+  go work(ch, 2)
+  a := <-ch
+  b := <-ch
+  return a + b
+}
+main()`
+	d, events, done := startDebugVM(t, source, func(d *Debugger) {
+		d.SetBreakpoints("debug.risor", []int{2})
+	}, WithConcurrency())
+	var values []int64
+	for i := 0; i < 2; i++ {
+		event := nextStop(t, events)
+		require.Equal(t, StopBreakpoint, event.Reason)
+		require.Equal(t, "work", event.Frames[0].Function)
+		values = append(values, variable(event.Frames[0].Locals, "n").(*object.Int).Value())
+		// The other goroutine waits while this one is paused
+		select {
+		case event := <-events:
+			t.Fatalf("unexpected stop while paused: %v", event.Reason)
+		case <-time.After(50 * time.Millisecond):
+		}
+		d.Continue()
+	}
+	require.ElementsMatch(t, []int64{1, 2}, values)
+	require.Nil(t, <-done)
+}
@@ -8,6 +8,7 @@ const DefaultFrameLocals = 8
 
 type frame struct {
 	returnAddr     int
+	callerIP       int
 	returnSp       int
 	localsCount    uint16
 	fn             *object.Function
@@ -17,15 +18,29 @@ type frame struct {
 	extendedLocals []object.Object
 	capturedLocals []object.Object
 	defers         []*object.Partial
+	handlers       []handler
+	debugLine      int
+	generator      *generatorState
+}
+
+// handler is an exception handler installed by a try statement. When an error
+// is raised, the stack is restored to sp and execution resumes at ip.
+type handler struct {
+	ip int
+	sp int
 }
 
 func (f *frame) ActivateCode(code *code) {
 	f.code = code
 	f.fn = nil
 	f.returnAddr = 0
+	f.callerIP = 0
 	f.localsCount = uint16(code.LocalsCount())
 	f.capturedLocals = nil
 	f.defers = nil
+	f.handlers = f.handlers[:0]
+	f.debugLine = 0
+	f.generator = nil
 	for i := 0; i < DefaultFrameLocals; i++ {
 		f.storage[i] = nil
 	}
@@ -47,6 +62,7 @@ func (f *frame) ActivateFunction(fn *object.Function, code *code, returnAddr, re
 	f.fn = fn
 	// Save the instruction and stack pointers of the caller
 	f.returnAddr = returnAddr
+	f.callerIP = returnAddr
 	f.returnSp = returnSp
 	// Initialize any local variables that were provided
 	for i := 0; i < len(localValues); i++ {
@@ -54,6 +70,17 @@ func (f *frame) ActivateFunction(fn *object.Function, code *code, returnAddr, re
 	} //lint:ignore S1001 - this loop is faster than using copy
 }
 
+// ResumeGenerator makes a frame activated for a generator function use the
+// generator's locals, so that any closures over them stay valid after the
+// generator is suspended.
+func (f *frame) ResumeGenerator(gen *generatorState) {
+	f.generator = gen
+	f.locals = gen.locals
+	f.extendedLocals = gen.locals
+	f.capturedLocals = gen.locals
+	f.defers = gen.defers
+}
+
 func (f *frame) Locals() []object.Object {
 	return f.locals
 }
@@ -76,3 +103,17 @@ func (f *frame) CaptureLocals() []object.Object {
 func (f *frame) Defer(p *object.Partial) {
 	f.defers = append([]*object.Partial{p}, f.defers...)
 }
+
+func (f *frame) PushHandler(ip, sp int) {
+	f.handlers = append(f.handlers, handler{ip: ip, sp: sp})
+}
+
+func (f *frame) PopHandler() (handler, bool) {
+	n := len(f.handlers)
+	if n == 0 {
+		return handler{}, false
+	}
+	h := f.handlers[n-1]
+	f.handlers = f.handlers[:n-1]
+	return h, true
+}
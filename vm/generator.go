@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+)
+
+// vmKey is the context key under which a VM stores itself, so that a
+// generator is resumed by the VM that is iterating over it, which may not be
+// the VM that created it.
+type vmKey struct{}
+
+// generatorState holds the frame of a suspended generator function: its
+// locals and instruction pointer, along with the values it had on the stack
+// and its exception handlers and deferred calls.
+type generatorState struct {
+	fn       *object.Function
+	locals   []object.Object
+	ip       int
+	stack    []object.Object
+	handlers []handler
+	defers   []*object.Partial
+	yielded  bool
+}
+
+// newGenerator returns a generator for a call to a generator function, with
+// the given locals holding the arguments of the call.
+func (vm *VirtualMachine) newGenerator(fn *object.Function, args []object.Object) *object.Generator {
+	code := vm.loadCode(fn.Code())
+	gen := &generatorState{
+		fn:     fn,
+		locals: make([]object.Object, code.LocalsCount()),
+	}
+	copy(gen.locals, args)
+	return object.NewGenerator(fn, func(ctx context.Context) (object.Object, bool, error) {
+		vm, ok := ctx.Value(vmKey{}).(*VirtualMachine)
+		if !ok {
+			return nil, false, errz.EvalErrorf("eval error: generator resumed outside of a running vm")
+		}
+		return vm.resumeGenerator(ctx, gen)
+	})
+}
+
+// resumeGenerator runs a suspended generator function in a new frame until
+// it yields a value or returns. Once it returns or raises an error, its
+// deferred calls are run.
+func (vm *VirtualMachine) resumeGenerator(
+	ctx context.Context,
+	gen *generatorState,
+) (result object.Object, yielded bool, resultErr error) {
+	baseFP := vm.fp
+	baseIP := vm.ip
+	baseSP := vm.sp
+
+	// Restore the previous frame when done
+	defer vm.resumeFrame(baseFP, baseIP, baseSP)
+
+	frame, err := vm.activateFunction(vm.fp+1, gen.ip, gen.fn, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	frame.ResumeGenerator(gen)
+	frame.returnAddr = StopSignal
+
+	// The stack pointers of the exception handlers are relative to the base
+	// of the frame, which may have moved since the generator was suspended
+	for _, obj := range gen.stack {
+		vm.push(obj)
+	}
+	for _, h := range gen.handlers {
+		frame.PushHandler(h.ip, baseSP+h.sp)
+	}
+	gen.stack = nil
+	gen.handlers = nil
+	gen.yielded = false
+
+	err = vm.eval(ctx)
+	if err == nil && gen.yielded {
+		return vm.pop(), true, nil
+	}
+	if vm.ip == StopSignal {
+		vm.ip = baseIP
+	}
+	if err != nil {
+		vm.captureTrace(err)
+	} else {
+		// Discard the return value of the generator function
+		vm.pop()
+	}
+	for _, partial := range frame.defers {
+		if deferErr := vm.callObject(ctx, partial, nil, nil); deferErr != nil {
+			err = deferErr
+		} else {
+			vm.pop()
+		}
+	}
+	return nil, false, err
+}
+
+// suspendGenerator saves the state of the active generator frame and returns
+// control to the caller that resumed it, with the yielded value on the top
+// of the stack.
+func (vm *VirtualMachine) suspendGenerator(value object.Object) error {
+	frame := vm.activeFrame
+	gen := frame.generator
+	if gen == nil {
+		return errz.EvalErrorf("eval error: yield outside of a generator")
+	}
+	base := frame.returnSp
+	gen.ip = vm.ip
+	gen.stack = append([]object.Object(nil), vm.stack[base+1:vm.sp+1]...)
+	for _, h := range frame.handlers {
+		gen.handlers = append(gen.handlers, handler{ip: h.ip, sp: h.sp - base})
+	}
+	gen.defers = frame.defers
+	gen.yielded = true
+	vm.push(value)
+	vm.resumeFrame(vm.fp-1, frame.returnAddr, base)
+	return nil
+}
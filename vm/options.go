@@ -36,3 +36,60 @@ func WithConcurrency() Option {
 		vm.concAllowed = true
 	}
 }
+
+// WithDebugger attaches a Debugger that can pause execution at breakpoints
+// and step through the code. VMs cloned for concurrent calls do not inherit
+// the debugger.
+func WithDebugger(d *Debugger) Option {
+	return func(vm *VirtualMachine) {
+		vm.debugger = d
+	}
+}
+
+// WithCoverage records the instructions executed by the VM in the given
+// Coverage. VMs cloned for concurrent calls record to the same Coverage.
+func WithCoverage(c *Coverage) Option {
+	return func(vm *VirtualMachine) {
+		vm.coverage = c
+	}
+}
+
+// WithProfiler samples the call stacks of the VM using the given Profiler.
+// VMs cloned for concurrent calls are sampled by the same Profiler.
+func WithProfiler(p *Profiler) Option {
+	return func(vm *VirtualMachine) {
+		vm.profiler = p
+	}
+}
+
+// WithoutStackTraces returns errors from Run and Call as-is, rather than
+// wrapped in an *errz.TracedError that carries the Risor stack trace of the
+// error. The trace remains available from LastTrace.
+func WithoutStackTraces() Option {
+	return func(vm *VirtualMachine) {
+		vm.noTraces = true
+	}
+}
+
+// WithMaxFrameDepth sets the maximum number of nested function calls. Calls
+// beyond this depth raise a stack overflow error. Frames are allocated as
+// needed, so a high limit only costs memory when the calls are made. Values
+// less than one are ignored.
+func WithMaxFrameDepth(depth int) Option {
+	return func(vm *VirtualMachine) {
+		if depth > 0 {
+			vm.maxFrames = depth
+		}
+	}
+}
+
+// WithMaxStackSize sets the maximum number of values on the data stack. The
+// stack grows as needed up to this size, beyond which a stack overflow error
+// is raised. Values less than one are ignored.
+func WithMaxStackSize(size int) Option {
+	return func(vm *VirtualMachine) {
+		if size > 0 {
+			vm.maxStack = size
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package vm
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+)
+
+// DefaultProfileInterval is the sampling interval used by NewProfiler when no
+// interval is given.
+const DefaultProfileInterval = 10 * time.Millisecond
+
+// Profiler samples the Risor call stacks of running VMs and attributes time
+// and memory allocations to Risor functions and source lines. It is attached
+// to VMs using the WithProfiler option, and may be shared by any number of
+// VMs, including VMs cloned for concurrent calls.
+//
+// Samples are only taken between calls to Start and Stop. On each tick of the
+// sampling interval, every running VM records its call stack once, before the
+// next instruction it executes. Allocations are measured for the whole Go
+// process, so allocations made by other goroutines are attributed to the VM
+// stacks sampled at the time.
+type Profiler struct {
+	interval  time.Duration
+	tick      uint32
+	mutex     sync.Mutex
+	locations map[profileLocation]uint64
+	samples   map[string]*profileSample
+	order     []string
+	allocs    allocCounts
+	started   time.Time
+	duration  time.Duration
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// profileLocation identifies a source line within compiled code.
+type profileLocation struct {
+	code *compiler.Code
+	name string
+	line int
+}
+
+type profileSample struct {
+	locations []uint64
+	count     int64
+	objects   int64
+	bytes     int64
+}
+
+type allocCounts struct {
+	objects uint64
+	bytes   uint64
+}
+
+// NewProfiler returns a Profiler that samples at the given interval. If the
+// interval is not positive, DefaultProfileInterval is used.
+func NewProfiler(interval time.Duration) *Profiler {
+	if interval <= 0 {
+		interval = DefaultProfileInterval
+	}
+	return &Profiler{
+		interval:  interval,
+		locations: map[profileLocation]uint64{},
+		samples:   map[string]*profileSample{},
+	}
+}
+
+// Start begins sampling. It has no effect if the profiler is already started.
+func (p *Profiler) Start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.done != nil {
+		return
+	}
+	p.done = make(chan struct{})
+	p.started = time.Now()
+	p.allocs = readAllocCounts()
+	p.wg.Add(1)
+	go p.run(p.done)
+}
+
+// Stop ends sampling. It has no effect if the profiler is not started.
+func (p *Profiler) Stop() {
+	p.mutex.Lock()
+	done := p.done
+	p.done = nil
+	if done != nil {
+		p.duration += time.Since(p.started)
+	}
+	p.mutex.Unlock()
+	if done != nil {
+		close(done)
+		p.wg.Wait()
+	}
+}
+
+func (p *Profiler) run(done chan struct{}) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			atomic.AddUint32(&p.tick, 1)
+		case <-done:
+			return
+		}
+	}
+}
+
+// check is called by the VM before each instruction when a profiler is
+// attached. It records a sample once per tick.
+func (p *Profiler) check(vm *VirtualMachine) {
+	tick := atomic.LoadUint32(&p.tick)
+	if tick == vm.profileTick {
+		return
+	}
+	vm.profileTick = tick
+	p.sample(vm)
+}
+
+func (p *Profiler) sample(vm *VirtualMachine) {
+	stack := vm.profileStack()
+	allocs := readAllocCounts()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	ids := make([]uint64, len(stack))
+	var key []byte
+	for i, loc := range stack {
+		id, ok := p.locations[loc]
+		if !ok {
+			id = uint64(len(p.locations) + 1)
+			p.locations[loc] = id
+		}
+		ids[i] = id
+		key = binary.AppendUvarint(key, id)
+	}
+	s, ok := p.samples[string(key)]
+	if !ok {
+		s = &profileSample{locations: ids}
+		p.samples[string(key)] = s
+		p.order = append(p.order, string(key))
+	}
+	s.count++
+	if allocs.objects > p.allocs.objects {
+		s.objects += int64(allocs.objects - p.allocs.objects)
+	}
+	if allocs.bytes > p.allocs.bytes {
+		s.bytes += int64(allocs.bytes - p.allocs.bytes)
+	}
+	p.allocs = allocs
+}
+
+// profileStack returns the source location of each active frame, innermost
+// first. The VM is about to execute the instruction at vm.ip.
+func (vm *VirtualMachine) profileStack() []profileLocation {
+	stack := make([]profileLocation, 0, vm.fp+1)
+	ip := vm.ip + 1
+	for fp := vm.fp; fp >= 0; fp-- {
+		f := vm.frames[fp]
+		if f.code == nil {
+			break
+		}
+		stack = append(stack, profileLocation{
+			code: f.code.Code,
+			name: profileName(f),
+			line: f.code.Location(ip - 1).Line,
+		})
+		ip = f.callerIP
+	}
+	return stack
+}
+
+// profileName returns the name of the function running in the frame. Unlike
+// stack traces, anonymous functions are named after the enclosing function
+// and their line, like "__main__.func12", since pprof strips names in angle
+// brackets.
+func profileName(f *frame) string {
+	if f.fn == nil || f.fn.Name() != "" {
+		return frameName(f)
+	}
+	parent := f.code.Parent()
+	for parent != nil && parent.CodeName() == "" {
+		parent = parent.Parent()
+	}
+	name := "func"
+	if parent != nil {
+		name = parent.CodeName() + ".func"
+	}
+	return fmt.Sprintf("%s%d", name, f.code.Location(0).Line)
+}
+
+func readAllocCounts() allocCounts {
+	samples := []metrics.Sample{
+		{Name: "/gc/heap/allocs:objects"},
+		{Name: "/gc/heap/allocs:bytes"},
+	}
+	metrics.Read(samples)
+	var counts allocCounts
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		counts.objects = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		counts.bytes = samples[1].Value.Uint64()
+	}
+	return counts
+}
+
+// WriteProfile writes the samples recorded so far as a gzip-compressed pprof
+// protocol buffer, which can be read by "go tool pprof".
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	p.mutex.Lock()
+	duration := p.duration
+	if p.done != nil {
+		duration += time.Since(p.started)
+	}
+	data := p.encode(duration)
+	p.mutex.Unlock()
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// encode builds the profile.proto message described at
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+func (p *Profiler) encode(duration time.Duration) []byte {
+	strs := newStringTable()
+	var b protoBuffer
+	valueType := func(typ, unit string) func(*protoBuffer) {
+		return func(b *protoBuffer) {
+			b.int64Field(1, strs.index(typ))
+			b.int64Field(2, strs.index(unit))
+		}
+	}
+	b.messageField(1, valueType("samples", "count"))
+	b.messageField(1, valueType("cpu", "nanoseconds"))
+	b.messageField(1, valueType("alloc_objects", "count"))
+	b.messageField(1, valueType("alloc_space", "bytes"))
+
+	period := p.interval.Nanoseconds()
+	for _, key := range p.order {
+		s := p.samples[key]
+		b.messageField(2, func(b *protoBuffer) {
+			b.packedUint64Field(1, s.locations)
+			b.packedInt64Field(2, []int64{s.count, s.count * period, s.objects, s.bytes})
+		})
+	}
+
+	// Each distinct function is identified by its code and name
+	type functionKey struct {
+		code *compiler.Code
+		name string
+	}
+	functions := map[functionKey]uint64{}
+	var functionOrder []functionKey
+	locations := make([]profileLocation, len(p.locations))
+	for loc, id := range p.locations {
+		locations[id-1] = loc
+	}
+	for i, loc := range locations {
+		key := functionKey{code: loc.code, name: loc.name}
+		fnID, ok := functions[key]
+		if !ok {
+			fnID = uint64(len(functions) + 1)
+			functions[key] = fnID
+			functionOrder = append(functionOrder, key)
+		}
+		b.messageField(4, func(b *protoBuffer) {
+			b.uint64Field(1, uint64(i+1))
+			b.messageField(4, func(b *protoBuffer) {
+				b.uint64Field(1, fnID)
+				b.int64Field(2, int64(loc.line))
+			})
+		})
+	}
+	for i, key := range functionOrder {
+		b.messageField(5, func(b *protoBuffer) {
+			b.uint64Field(1, uint64(i+1))
+			b.int64Field(2, strs.index(key.name))
+			b.int64Field(3, strs.index(key.name))
+			b.int64Field(4, strs.index(key.code.Filename()))
+			b.int64Field(5, int64(key.code.Location(0).Line))
+		})
+	}
+	b.int64Field(9, p.started.UnixNano())
+	b.int64Field(10, duration.Nanoseconds())
+	b.messageField(11, valueType("cpu", "nanoseconds"))
+	b.int64Field(12, period)
+	b.int64Field(14, strs.index("cpu"))
+	// The string table is written last, once every string has been indexed
+	for _, s := range strs.strings {
+		b.stringField(6, s)
+	}
+	return b.data
+}
+
+type stringTable struct {
+	strings []string
+	indexes map[string]int64
+}
+
+func newStringTable() *stringTable {
+	// The first entry of a pprof string table must be the empty string
+	return &stringTable{strings: []string{""}, indexes: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) index(s string) int64 {
+	if i, ok := t.indexes[s]; ok {
+		return i
+	}
+	i := int64(len(t.strings))
+	t.strings = append(t.strings, s)
+	t.indexes[s] = i
+	return i
+}
+
+// protoBuffer encodes protocol buffer fields. Fields with zero values are
+// omitted, as in proto3.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) key(tag, wireType int) {
+	b.varint(uint64(tag)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uint64Field(tag int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(tag, 0)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(tag int, x int64) {
+	b.uint64Field(tag, uint64(x))
+}
+
+func (b *protoBuffer) bytesField(tag int, data []byte) {
+	b.key(tag, 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) stringField(tag int, s string) {
+	b.bytesField(tag, []byte(s))
+}
+
+func (b *protoBuffer) messageField(tag int, fn func(*protoBuffer)) {
+	var msg protoBuffer
+	fn(&msg)
+	b.bytesField(tag, msg.data)
+}
+
+func (b *protoBuffer) packedUint64Field(tag int, xs []uint64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytesField(tag, packed.data)
+}
+
+func (b *protoBuffer) packedInt64Field(tag int, xs []int64) {
+	var packed protoBuffer
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	b.bytesField(tag, packed.data)
+}
@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const profileSource = `func busy(n) {
+  total := 0
+  for i := 0; i < n; i++ {
+    total += i
+  }
+  return total
+}
+threads := []
+for i := 0; i < 2; i++ {
+  threads.append(spawn(busy, 200000))
+}
+threads.map(func(t) { t.wait() })
+busy(200000)
+`
+
+func TestProfiler(t *testing.T) {
+	main := compileCoverage(t, profileSource)
+	p := NewProfiler(time.Millisecond)
+	p.Start()
+	vm := New(main, WithProfiler(p), WithConcurrency(), WithGlobals(basicBuiltins()))
+	require.Nil(t, vm.Run(context.Background()))
+	p.Stop()
+
+	p.mutex.Lock()
+	var busySamples, total int64
+	for _, s := range p.samples {
+		total += s.count
+		for loc, id := range p.locations {
+			if id == s.locations[0] && loc.name == "busy" {
+				require.Contains(t, []int{2, 3, 4, 6}, loc.line)
+				busySamples += s.count
+			}
+		}
+	}
+	p.mutex.Unlock()
+	require.Greater(t, total, int64(0))
+	require.Greater(t, busySamples, int64(0))
+
+	var buf bytes.Buffer
+	require.Nil(t, p.WriteProfile(&buf))
+	zr, err := gzip.NewReader(&buf)
+	require.Nil(t, err)
+	data, err := io.ReadAll(zr)
+	require.Nil(t, err)
+	require.Contains(t, string(data), "busy")
+	require.Contains(t, string(data), "cover.risor")
+	require.Contains(t, string(data), "alloc_space")
+}
+
+func TestProtoBuffer(t *testing.T) {
+	var b protoBuffer
+	b.uint64Field(1, 150)
+	b.uint64Field(2, 0)
+	b.stringField(3, "hi")
+	b.packedInt64Field(4, []int64{1, 300})
+	require.Equal(t, []byte{0x08, 0x96, 0x01, 0x1a, 0x02, 'h', 'i', 0x22, 0x03, 0x01, 0xac, 0x02}, b.data)
+}
@@ -34,7 +34,8 @@ func Run(ctx context.Context, main *compiler.Code, options ...Option) (object.Ob
 }
 
 type runOpts struct {
-	Globals map[string]interface{}
+	Globals  map[string]interface{}
+	Optimize bool
 }
 
 // Run the given source code in a new VM. Used for testing.
@@ -59,16 +60,22 @@ func newVM(ctx context.Context, source string, opts ...runOpts) (*VirtualMachine
 		return nil, err
 	}
 	globals := basicBuiltins()
+	var optimize bool
 	if len(opts) > 0 {
 		for k, v := range opts[0].Globals {
 			globals[k] = v
 		}
+		optimize = opts[0].Optimize
 	}
 	var globalNames []string
 	for k := range globals {
 		globalNames = append(globalNames, k)
 	}
-	main, err := compiler.Compile(ast, compiler.WithGlobalNames(globalNames))
+	compilerOpts := []compiler.Option{compiler.WithGlobalNames(globalNames)}
+	if optimize {
+		compilerOpts = append(compilerOpts, compiler.WithOptimization())
+	}
+	main, err := compiler.Compile(ast, compilerOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/risor-io/risor/compiler"
@@ -42,7 +43,7 @@ func TestRunError(t *testing.T) {
 	_, err = Run(ctx, code)
 	require.NotNil(t, err)
 	require.Equal(t, "type error: attribute \"bar\" not found on int object", err.Error())
-	errValue, ok := err.(*errz.TypeError)
-	require.True(t, ok)
+	var errValue *errz.TypeError
+	require.True(t, errors.As(err, &errValue))
 	require.Equal(t, "type error: attribute \"bar\" not found on int object", errValue.Error())
 }
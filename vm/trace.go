@@ -0,0 +1,90 @@
+package vm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/risor-io/risor/errz"
+)
+
+// StackTrace returns the Risor call stack of the VM, with the innermost
+// frame first. Each frame reports the function name and the source location
+// of the instruction being executed in that frame.
+func (vm *VirtualMachine) StackTrace() []errz.StackFrame {
+	if vm.activeFrame == nil {
+		return nil
+	}
+	trace := make([]errz.StackFrame, 0, vm.fp+1)
+	ip := vm.ip
+	for fp := vm.fp; fp >= 0; fp-- {
+		f := vm.frames[fp]
+		if f.code == nil {
+			break
+		}
+		// The instruction pointer has already advanced past the instruction
+		// being executed, so step back one to land within it.
+		loc := f.code.Location(ip - 1)
+		trace = append(trace, errz.StackFrame{
+			Function: frameName(f),
+			File:     loc.Filename,
+			Line:     loc.Line,
+			Column:   loc.Column,
+		})
+		ip = f.callerIP
+	}
+	return trace
+}
+
+// captureTrace records the current stack trace as the trace for the given
+// error. This is called as soon as an error escapes a frame, before the frame
+// stack is unwound. If the error wraps an error whose trace was already
+// captured by a more deeply nested call, the deeper trace is kept.
+func (vm *VirtualMachine) captureTrace(err error) {
+	if vm.errTraced != nil && errors.Is(err, vm.errTraced) {
+		return
+	}
+	vm.errTraced = err
+	vm.errTrace = vm.StackTrace()
+}
+
+// LastTrace returns the Risor stack trace of the error most recently
+// returned by Run or Call, with the innermost frame first. It returns nil if
+// the last call succeeded or was halted by its context.
+func (vm *VirtualMachine) LastTrace() []errz.StackFrame {
+	return vm.lastTrace
+}
+
+// tracedError records the captured stack trace of an error being returned
+// from the VM, so that it is available from LastTrace, and wraps the error in
+// an *errz.TracedError unless the WithoutStackTraces option is set. Context
+// cancellation errors have no trace, since they indicate the VM was halted
+// rather than a failure in the Risor code.
+func (vm *VirtualMachine) tracedError(err error) error {
+	var trace []errz.StackFrame
+	var traced *errz.TracedError
+	if errors.As(err, &traced) {
+		trace = traced.StackTrace()
+	} else if vm.errTraced != nil && errors.Is(err, vm.errTraced) {
+		trace = vm.errTrace
+	}
+	vm.errTraced = nil
+	vm.errTrace = nil
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	vm.lastTrace = trace
+	if vm.noTraces || traced != nil {
+		return err
+	}
+	return errz.NewTracedError(err, trace)
+}
+
+func frameName(f *frame) string {
+	if f.fn != nil {
+		if name := f.fn.Name(); name != "" {
+			return name
+		}
+		return "<anonymous>"
+	}
+	return f.code.CodeName()
+}
@@ -0,0 +1,227 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func runFile(ctx context.Context, source, filename string, opts ...Option) error {
+	ast, err := parser.Parse(ctx, source, parser.WithFile(filename))
+	if err != nil {
+		return err
+	}
+	main, err := compiler.Compile(ast)
+	if err != nil {
+		return err
+	}
+	return New(main, opts...).Run(ctx)
+}
+
+func TestStackTrace(t *testing.T) {
+	source := `x := 1
+func inner(v) {
+  return v.missing
+}
+func outer() {
+  return inner(x)
+}
+outer()`
+	err := runFile(context.Background(), source, "trace.risor")
+	require.NotNil(t, err)
+	require.Equal(t, "type error: attribute \"missing\" not found on int object", err.Error())
+
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "inner", File: "trace.risor", Line: 3, Column: 11},
+		{Function: "outer", File: "trace.risor", Line: 6, Column: 15},
+		{Function: "__main__", File: "trace.risor", Line: 8, Column: 6},
+	}, traced.StackTrace())
+	require.Equal(t, `type error: attribute "missing" not found on int object
+    at inner (trace.risor:3:11)
+    at outer (trace.risor:6:15)
+    at __main__ (trace.risor:8:6)`, traced.FriendlyErrorMessage())
+
+	// The original error type is still reachable
+	var typeErr *errz.TypeError
+	require.True(t, errors.As(err, &typeErr))
+}
+
+func TestStackTraceUnwrapped(t *testing.T) {
+	// With WithoutStackTraces, errors keep their type and the trace is
+	// available from the VM
+	ctx := context.Background()
+	source := "x := 1\nfunc ok() { return x }\nx.missing"
+	ast, err := parser.Parse(ctx, source, parser.WithFile("plain.risor"))
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	machine := New(main, WithoutStackTraces())
+	err = machine.Run(ctx)
+	require.NotNil(t, err)
+	_, ok := err.(*errz.TypeError)
+	require.True(t, ok)
+	require.Equal(t, []errz.StackFrame{
+		{Function: "__main__", File: "plain.risor", Line: 3, Column: 2},
+	}, machine.LastTrace())
+
+	// A later successful call clears the trace
+	fn, err := machine.Get("ok")
+	require.Nil(t, err)
+	result, err := machine.Call(ctx, fn.(*object.Function), nil)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(1), result)
+	require.Nil(t, machine.LastTrace())
+}
+
+func TestStackTraceThroughBuiltin(t *testing.T) {
+	source := `[1, 2].map(func(x) {
+  return x.missing
+})`
+	err := runFile(context.Background(), source, "map.risor")
+	require.NotNil(t, err)
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	trace := traced.StackTrace()
+	require.Len(t, trace, 2)
+	require.Equal(t, errz.StackFrame{
+		Function: "<anonymous>", File: "map.risor", Line: 2, Column: 11,
+	}, trace[0])
+	require.Equal(t, "__main__", trace[1].Function)
+	require.Equal(t, 1, trace[1].Line)
+}
+
+func TestStackTraceFromBinaryCode(t *testing.T) {
+	source := `func inner(v) {
+  return v.missing
+}
+inner(1)`
+	ctx := context.Background()
+	ast, err := parser.Parse(ctx, source, parser.WithFile("stored.risor"))
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	data, err := compiler.MarshalCodeBinary(main)
+	require.Nil(t, err)
+	loaded, err := compiler.UnmarshalCodeBinary(data)
+	require.Nil(t, err)
+
+	machine := New(loaded)
+	require.NotNil(t, machine.Run(ctx))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "inner", File: "stored.risor", Line: 2, Column: 11},
+		{Function: "__main__", File: "stored.risor", Line: 4, Column: 6},
+	}, machine.LastTrace())
+}
+
+func TestStackTraceCall(t *testing.T) {
+	ctx := context.Background()
+	ast, err := parser.Parse(ctx, "func fail() {\n  x := 1\n  x.boom\n}")
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	vm := New(main)
+	require.Nil(t, vm.Run(ctx))
+	fn, err := vm.Get("fail")
+	require.Nil(t, err)
+
+	_, err = vm.Call(ctx, fn.(*object.Function), nil)
+	require.NotNil(t, err)
+	require.Equal(t, "type error: attribute \"boom\" not found on int object", err.Error())
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	trace := traced.StackTrace()
+	require.Equal(t, trace, vm.LastTrace())
+	require.NotEmpty(t, trace)
+	require.Equal(t, errz.StackFrame{Function: "fail", Line: 3, Column: 4}, trace[0])
+}
+
+func TestStackOverflow(t *testing.T) {
+	source := `func recurse(n) {
+  return recurse(n + 1)
+}
+recurse(0)`
+	err := runFile(context.Background(), source, "overflow.risor")
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrStackOverflow))
+	require.Equal(t, "stack overflow: maximum call depth of 1024 exceeded", err.Error())
+
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	trace := traced.StackTrace()
+	require.Len(t, trace, MaxFrameDepth)
+	require.Equal(t, errz.StackFrame{
+		Function: "recurse", File: "overflow.risor", Line: 2, Column: 17,
+	}, trace[0])
+	require.Equal(t, `stack overflow: maximum call depth of 1024 exceeded
+    at recurse (overflow.risor:2:17)
+    at recurse (overflow.risor:2:17)
+    at recurse (overflow.risor:2:17)
+    ... previous frame repeated 1020 more times
+    at __main__ (overflow.risor:4:8)`, traced.FriendlyErrorMessage())
+}
+
+func TestMaxFrameDepth(t *testing.T) {
+	source := `func depth(n) {
+  if n == 0 { return 0 }
+  return 1 + depth(n - 1)
+}
+depth(5000)`
+	ctx := context.Background()
+	require.Nil(t, runFile(ctx, source, "depth.risor", WithMaxFrameDepth(10000)))
+
+	err := runFile(ctx, source, "depth.risor", WithMaxFrameDepth(100))
+	require.NotNil(t, err)
+	require.Equal(t, "stack overflow: maximum call depth of 100 exceeded", err.Error())
+}
+
+func TestMaxStackSize(t *testing.T) {
+	ctx := context.Background()
+	source := `[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20]`
+	require.Nil(t, runFile(ctx, source, "stack.risor", WithMaxStackSize(32)))
+
+	err := runFile(ctx, source, "stack.risor", WithMaxStackSize(16))
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrStackOverflow))
+	require.Equal(t, "stack overflow: maximum stack size of 16 exceeded", err.Error())
+}
+
+func TestCatchStackOverflow(t *testing.T) {
+	result, err := run(context.Background(), `
+	func recurse(n) { return recurse(n + 1) }
+	msg := nil
+	try { recurse(0) } catch err { msg = err.message() }
+	func sum(n) { if n == 0 { return 0 }; return n + sum(n - 1) }
+	[msg, sum(100)]
+	`)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewString("stack overflow: maximum call depth of 1024 exceeded"),
+		object.NewInt(5050),
+	}), result)
+}
+
+func TestCloneStackLimits(t *testing.T) {
+	ctx := context.Background()
+	ast, err := parser.Parse(ctx, "func recurse(n) { return recurse(n + 1) }")
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	vm := New(main, WithMaxFrameDepth(50))
+	require.Nil(t, vm.Run(ctx))
+	fn, err := vm.Get("recurse")
+	require.Nil(t, err)
+
+	clone, err := vm.Clone()
+	require.Nil(t, err)
+	_, err = clone.Call(ctx, fn.(*object.Function), []object.Object{object.NewInt(0)})
+	require.NotNil(t, err)
+	require.Equal(t, "stack overflow: maximum call depth of 50 exceeded", err.Error())
+}
@@ -1,10 +1,12 @@
 package vm
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
 )
 
 func checkCallArgs(fn *object.Function, argc int) error {
@@ -14,12 +16,22 @@ func checkCallArgs(fn *object.Function, argc int) error {
 	// Number of required args when the function is called (those without defaults)
 	requiredArgsCount := fn.RequiredArgsCount()
 
+	// A function with a rest parameter accepts any number of additional
+	// arguments
+	if fn.RestParameter() != "" {
+		if argc >= requiredArgsCount {
+			return nil
+		}
+		msg := functionDescription(fn)
+		if requiredArgsCount == 1 {
+			return errz.ArgsErrorf("%s takes at least 1 argument (%d given)", msg, argc)
+		}
+		return errz.ArgsErrorf("%s takes at least %d arguments (%d given)", msg, requiredArgsCount, argc)
+	}
+
 	// Check if too many or too few arguments were passed
 	if argc > paramsCount || argc < requiredArgsCount {
-		msg := "args error: function"
-		if name := fn.Name(); name != "" {
-			msg = fmt.Sprintf("%s %q", msg, name)
-		}
+		msg := functionDescription(fn)
 		switch paramsCount {
 		case 0:
 			msg = fmt.Sprintf("%s takes 0 arguments (%d given)", msg, argc)
@@ -32,3 +44,229 @@ func checkCallArgs(fn *object.Function, argc int) error {
 	}
 	return nil
 }
+
+// functionDescription returns the start of an error message about a call to
+// the given function.
+func functionDescription(fn *object.Function) string {
+	if name := fn.Name(); name != "" {
+		return fmt.Sprintf("args error: function %q", name)
+	}
+	return "args error: function"
+}
+
+// callee returns the function that runs when fn is called. For a decorated
+// function this is the wrapper returned by the decorator, rather than the
+// function whose signature it presents. Arguments are checked and bound
+// against the presented signature before they are passed to the wrapper.
+func callee(fn *object.Function) *object.Function {
+	for fn.Wrapper() != nil {
+		fn = fn.Wrapper()
+	}
+	return fn
+}
+
+// bindKwargs returns the positional arguments of a call to a function that
+// also passes keyword arguments. Each keyword argument takes the position of
+// the parameter with the same name, and parameters left without a value take
+// their default values.
+func bindKwargs(fn *object.Function, args []object.Object, kwargs *object.Map) ([]object.Object, error) {
+	if kwargs.Size() == 0 {
+		return args, nil
+	}
+	params := fn.Parameters()
+	bound := make([]object.Object, len(params))
+	copy(bound, args)
+	for _, name := range kwargs.SortedKeys() {
+		index := -1
+		for i, param := range params {
+			if param == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, errz.ArgsErrorf("%s got an unexpected keyword argument %q",
+				functionDescription(fn), name)
+		}
+		if index < len(args) {
+			return nil, errz.ArgsErrorf("%s got multiple values for argument %q",
+				functionDescription(fn), name)
+		}
+		bound[index] = kwargs.Get(name)
+	}
+	defaults := fn.Defaults()
+	required := fn.RequiredArgsCount()
+	for i := len(args); i < len(params); i++ {
+		if bound[i] != nil {
+			continue
+		}
+		if i < required {
+			return nil, errz.ArgsErrorf("%s missing required argument %q",
+				functionDescription(fn), params[i])
+		}
+		if bound[i] = defaults[i]; bound[i] == nil {
+			bound[i] = object.Nil
+		}
+	}
+	if len(args) > len(params) {
+		bound = append(bound, args[len(params):]...)
+	}
+	return bound, nil
+}
+
+// bindCallKwargs binds keyword arguments for a call to any callable object,
+// returning the object to call with the complete positional arguments.
+// Functions and methods written in Risor bind keyword arguments to their
+// parameters, while a builtin that accepts keyword arguments is wrapped in
+// one that passes them along.
+func bindCallKwargs(fn object.Object, args []object.Object, kwargs *object.Map) (object.Object, []object.Object, error) {
+	switch fn := fn.(type) {
+	case *object.Function:
+		bound, err := bindKwargs(fn, args, kwargs)
+		return fn, bound, err
+	case *object.BoundMethod:
+		bound, err := bindKwargs(fn.Method(), fn.Args(args), kwargs)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The receiver is added again when the method is called
+		return fn, bound[1:], nil
+	}
+	if kwargs.Size() == 0 {
+		return fn, args, nil
+	}
+	kwFn, ok := kwargsCallable(fn)
+	if !ok {
+		return nil, nil, errz.ArgsErrorf("args error: %s does not accept keyword arguments", callableName(fn))
+	}
+	name := "builtin"
+	if builtin, ok := fn.(*object.Builtin); ok {
+		name = builtin.Key()
+	}
+	values := kwargs.Value()
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		return kwFn.CallWithKwargs(ctx, values, args...)
+	}), args, nil
+}
+
+// kwargsCallable returns the object as a KwargsCallable if it accepts keyword
+// arguments.
+func kwargsCallable(fn object.Object) (object.KwargsCallable, bool) {
+	kwFn, ok := fn.(object.KwargsCallable)
+	if !ok || !kwFn.AcceptsKwargs() {
+		return nil, false
+	}
+	return kwFn, true
+}
+
+// callableName describes a callable object in an error message.
+func callableName(fn object.Object) string {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		return fmt.Sprintf("builtin function %q", builtin.Key())
+	}
+	return fmt.Sprintf("object of type %s", fn.Type())
+}
+
+// mergeKwargs adds keyword arguments to those collected so far, failing if
+// a name is given more than once.
+func mergeKwargs(kwargs, other *object.Map) error {
+	for _, name := range other.SortedKeys() {
+		if _, found := kwargs.Value()[name]; found {
+			return errz.ArgsErrorf("args error: got multiple values for keyword argument %q", name)
+		}
+		kwargs.Set(name, other.Get(name))
+	}
+	return nil
+}
+
+// spreadItems returns the items of a value unpacked into call arguments with
+// "...items".
+func spreadItems(ctx context.Context, obj object.Object) ([]object.Object, error) {
+	if list, ok := obj.(*object.List); ok {
+		return list.Value(), nil
+	}
+	var iter object.Iterator
+	switch obj := obj.(type) {
+	case object.Iterator:
+		iter = obj
+	case object.Iterable:
+		iter = obj.Iter()
+	default:
+		return nil, errz.TypeErrorf("type error: argument after ... must be iterable (got %s)", obj.Type())
+	}
+	var items []object.Object
+	for {
+		item, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	if err := object.IterErr(iter); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// matchesType returns true if the object is of the named type, as reported
+// by the type builtin, or is an instance of a struct with that name.
+func matchesType(obj object.Object, name string) bool {
+	if string(obj.Type()) == name {
+		return true
+	}
+	if s, ok := obj.(*object.Struct); ok {
+		return s.StructType().Name() == name
+	}
+	return false
+}
+
+// binaryOp runs a binary operation, with a fast path for integer arithmetic
+// that avoids the dynamic dispatch of object.BinaryOp. Other operations and
+// types, and division by zero, use object.BinaryOp.
+func binaryOp(opType op.BinaryOpType, a, b object.Object) (object.Object, error) {
+	if x, ok := a.(*object.Int); ok {
+		if y, ok := b.(*object.Int); ok {
+			switch opType {
+			case op.Add:
+				return object.NewInt(x.Value() + y.Value()), nil
+			case op.Subtract:
+				return object.NewInt(x.Value() - y.Value()), nil
+			case op.Multiply:
+				return object.NewInt(x.Value() * y.Value()), nil
+			case op.Divide:
+				if y.Value() != 0 {
+					return object.NewInt(x.Value() / y.Value()), nil
+				}
+			case op.Modulo:
+				if y.Value() != 0 {
+					return object.NewInt(x.Value() % y.Value()), nil
+				}
+			}
+		}
+	}
+	return object.BinaryOp(opType, a, b)
+}
+
+// compareOp runs a comparison, with a fast path for comparing integers.
+// Other types use object.Compare.
+func compareOp(opType op.CompareOpType, a, b object.Object) (object.Object, error) {
+	if x, ok := a.(*object.Int); ok {
+		if y, ok := b.(*object.Int); ok {
+			switch opType {
+			case op.LessThan:
+				return object.NewBool(x.Value() < y.Value()), nil
+			case op.LessThanOrEqual:
+				return object.NewBool(x.Value() <= y.Value()), nil
+			case op.Equal:
+				return object.NewBool(x.Value() == y.Value()), nil
+			case op.NotEqual:
+				return object.NewBool(x.Value() != y.Value()), nil
+			case op.GreaterThan:
+				return object.NewBool(x.Value() > y.Value()), nil
+			case op.GreaterThanOrEqual:
+				return object.NewBool(x.Value() >= y.Value()), nil
+			}
+		}
+	}
+	return object.Compare(opType, a, b)
+}
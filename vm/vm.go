@@ -18,13 +18,28 @@ import (
 )
 
 const (
-	MaxArgs       = 256
+	MaxArgs = 256
+	// MaxFrameDepth is the default maximum number of nested function calls.
 	MaxFrameDepth = 1024
-	MaxStackDepth = 1024
+	// MaxStackDepth is the default maximum size of the data stack.
+	MaxStackDepth = 16 * 1024
 	StopSignal    = -1
 	MB            = 1024 * 1024
 )
 
+// The data stack starts small and doubles in size as needed.
+const initialStackSize = 64
+
+// ErrStackOverflow is wrapped by the error raised when a call would exceed
+// the maximum frame depth or the data stack would exceed its maximum size.
+var ErrStackOverflow = errors.New("stack overflow")
+
+// stackOverflow is the value push panics with when the data stack is full.
+// The panic is recovered by evalInstructions and raised as its error.
+type stackOverflow struct {
+	err error
+}
+
 type VirtualMachine struct {
 	ip           int // instruction pointer
 	sp           int // stack pointer
@@ -42,9 +57,19 @@ type VirtualMachine struct {
 	concAllowed  bool
 	runMutex     sync.Mutex
 	cloneMutex   sync.Mutex
+	errTrace     []errz.StackFrame
+	errTraced    error
+	lastTrace    []errz.StackFrame
+	noTraces     bool
+	debugger     *Debugger
+	coverage     *Coverage
+	profiler     *Profiler
+	profileTick  uint32
+	maxFrames    int
+	maxStack     int
 	tmp          [MaxArgs]object.Object
-	stack        [MaxStackDepth]object.Object
-	frames       [MaxFrameDepth]frame
+	stack        []object.Object
+	frames       []*frame
 }
 
 // New creates a new Virtual Machine.
@@ -59,6 +84,8 @@ func New(main *compiler.Code, options ...Option) *VirtualMachine {
 		inputGlobals: map[string]any{},
 		globals:      map[string]object.Object{},
 		loadedCode:   map[*compiler.Code]*code{},
+		maxFrames:    MaxFrameDepth,
+		maxStack:     MaxStackDepth,
 	}
 	for _, opt := range options {
 		opt(vm)
@@ -86,6 +113,7 @@ func (vm *VirtualMachine) start(ctx context.Context) error {
 		return fmt.Errorf("vm is already running")
 	}
 	vm.running = true
+	vm.lastTrace = nil
 	// Halt execution when the context is cancelled
 	vm.halt = 0
 	if doneChan := ctx.Done(); doneChan != nil {
@@ -133,10 +161,16 @@ func (vm *VirtualMachine) Run(ctx context.Context) (err error) {
 	}
 
 	// Activate the entrypoint code in frame zero
-	vm.activateCode(0, vm.ip, main)
+	if _, err := vm.activateCode(0, vm.ip, main); err != nil {
+		return err
+	}
 
 	// Run the entrypoint until completion
-	return vm.eval(vm.initContext(ctx))
+	if err := vm.eval(vm.initContext(ctx)); err != nil {
+		vm.captureTrace(err)
+		return vm.tracedError(err)
+	}
+	return nil
 }
 
 // Get a global variable by name as a Risor Object.
@@ -176,7 +210,30 @@ func (vm *VirtualMachine) GlobalNames() []string {
 //
 // Assuming this function returns without error, the result of the evaluation
 // will be on the top of the stack.
+//
+// If an error is raised while a try statement in the active frame has an
+// exception handler installed, execution continues in that handler.
 func (vm *VirtualMachine) eval(ctx context.Context) error {
+	for {
+		err := vm.evalInstructions(ctx)
+		if err == nil || !vm.handleError(err) {
+			return err
+		}
+	}
+}
+
+func (vm *VirtualMachine) evalInstructions(ctx context.Context) (err error) {
+	// Raise a stack overflow from push as an error
+	defer func() {
+		if r := recover(); r != nil {
+			overflow, ok := r.(stackOverflow)
+			if !ok {
+				panic(r)
+			}
+			err = overflow.err
+		}
+	}()
+
 	// Run to the end of the active code
 	for vm.ip < len(vm.activeCode.Instructions) {
 
@@ -184,6 +241,20 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			return ctx.Err()
 		}
 
+		if vm.debugger != nil {
+			if err := vm.debugger.check(ctx, vm); err != nil {
+				return err
+			}
+		}
+
+		if vm.profiler != nil {
+			vm.profiler.check(vm)
+		}
+
+		if hits := vm.activeCode.hits; hits != nil {
+			atomic.AddUint32(&hits[vm.ip], 1)
+		}
+
 		// The current instruction opcode
 		opcode := vm.activeCode.Instructions[vm.ip]
 
@@ -198,9 +269,10 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 		switch opcode {
 		case op.Nop:
 		case op.LoadAttr:
+			ip := vm.ip - 1
 			obj := vm.pop()
 			name := vm.activeCode.Names[vm.fetch()]
-			value, found := obj.GetAttr(name)
+			value, found := vm.activeCode.getAttr(ip, obj, name)
 			if !found {
 				return errz.TypeErrorf("type error: attribute %q not found on %s object",
 					name, obj.Type())
@@ -267,9 +339,19 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if frameIndex < 0 {
 				return errz.EvalErrorf("eval error: no frame at depth %d", framesBack)
 			}
-			frame := &vm.frames[frameIndex]
+			frame := vm.frames[frameIndex]
 			locals := frame.CaptureLocals()
 			vm.push(object.NewCell(&locals[symbolIndex]))
+		case op.LoadFreeCell:
+			idx := vm.fetch()
+			vm.push(vm.activeFrame.fn.FreeVars()[idx])
+		case op.WrapFunction:
+			decorated := vm.pop()
+			fn, ok := vm.pop().(*object.Function)
+			if wrapper, isFunc := decorated.(*object.Function); ok && isFunc && wrapper != fn {
+				decorated = wrapper.Wraps(fn)
+			}
+			vm.push(decorated)
 		case op.Nil:
 			vm.push(object.Nil)
 		case op.True:
@@ -294,29 +376,61 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				return err
 			}
 			vm.push(result)
-		case op.Call:
-			argc := int(vm.fetch())
-			if argc > MaxArgs {
-				return errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
-					MaxArgs, argc)
+		case op.BinaryAddFast:
+			b := vm.pop()
+			a := vm.pop()
+			result, err := binaryOp(op.Add, a, b)
+			if err != nil {
+				return err
 			}
-			args := make([]object.Object, argc)
-			for argIndex := argc - 1; argIndex >= 0; argIndex-- {
-				args[argIndex] = vm.pop()
+			vm.push(result)
+		case op.BinarySubtractFast:
+			b := vm.pop()
+			a := vm.pop()
+			result, err := binaryOp(op.Subtract, a, b)
+			if err != nil {
+				return err
 			}
+			vm.push(result)
+		case op.CompareOpFast:
+			opType := op.CompareOpType(vm.fetch())
+			b := vm.pop()
+			a := vm.pop()
+			result, err := compareOp(opType, a, b)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
+		case op.BinaryOpFastConst:
+			a := vm.activeFrame.Locals()[vm.fetch()]
+			b := vm.activeCode.Constants[vm.fetch()]
+			result, err := binaryOp(op.BinaryOpType(vm.fetch()), a, b)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
+		case op.CompareOpFastConst:
+			a := vm.activeFrame.Locals()[vm.fetch()]
+			b := vm.activeCode.Constants[vm.fetch()]
+			result, err := compareOp(op.CompareOpType(vm.fetch()), a, b)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
+		case op.Call:
+			args, kwargs := vm.popArgs(vm.fetch())
 			obj := vm.pop()
-			if err := vm.callObject(ctx, obj, args); err != nil {
+			if err := vm.callObject(ctx, obj, args, kwargs); err != nil {
 				return err
 			}
 		case op.Partial:
-			argc := int(vm.fetch())
-			args := make([]object.Object, argc)
-			for i := argc - 1; i >= 0; i-- {
-				args[i] = vm.pop()
-			}
+			args, kwargs := vm.popArgs(vm.fetch())
 			obj := vm.pop()
-			partial := object.NewPartial(obj, args)
-			vm.push(partial)
+			if kwargs != nil {
+				vm.push(object.NewPartialWithKwargs(obj, args, kwargs))
+			} else {
+				vm.push(object.NewPartial(obj, args))
+			}
 		case op.ReturnValue:
 			activeFrame := vm.activeFrame
 			returnAddr := activeFrame.returnAddr
@@ -328,6 +442,11 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				// current eval call should stop.
 				return nil
 			}
+		case op.Yield:
+			if err := vm.suspendGenerator(vm.pop()); err != nil {
+				return err
+			}
+			return nil
 		case op.PopJumpForwardIfTrue:
 			tos := vm.pop()
 			delta := int(vm.fetch()) - 2
@@ -340,6 +459,18 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if !tos.IsTruthy() {
 				vm.ip += delta
 			}
+		case op.PopJumpForwardIfNil:
+			tos := vm.pop()
+			delta := int(vm.fetch()) - 2
+			if tos == object.Nil {
+				vm.ip += delta
+			}
+		case op.PopJumpForwardIfNotNil:
+			tos := vm.pop()
+			delta := int(vm.fetch()) - 2
+			if tos != object.Nil {
+				vm.ip += delta
+			}
 		case op.JumpForward:
 			base := vm.ip - 1
 			delta := int(vm.fetch())
@@ -357,13 +488,15 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			vm.push(object.NewList(items))
 		case op.BuildMap:
 			count := vm.fetch()
-			items := make(map[string]object.Object, count)
+			m := object.NewMap(make(map[string]object.Object, count))
 			for i := uint16(0); i < count; i++ {
 				v := vm.pop()
 				k := vm.pop()
-				items[k.(*object.String).Value()] = v
+				if err := m.SetItem(k, v); err != nil {
+					return err.Value()
+				}
 			}
-			vm.push(object.NewMap(items))
+			vm.push(m)
 		case op.BuildSet:
 			count := vm.fetch()
 			items := make([]object.Object, count)
@@ -371,6 +504,80 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				items[i] = vm.pop()
 			}
 			vm.push(object.NewSet(items))
+		case op.BuildStruct:
+			fieldCount := int(vm.fetch())
+			defaultsCount := int(vm.fetch())
+			methodCount := int(vm.fetch())
+			methods := make(map[string]*object.Function, methodCount)
+			methodNames := make([]string, methodCount)
+			for i := methodCount - 1; i >= 0; i-- {
+				fn, ok := vm.pop().(*object.Function)
+				if !ok {
+					return errz.EvalErrorf("eval error: expected function")
+				}
+				name := vm.pop().(*object.String).Value()
+				methods[name] = fn
+				methodNames[i] = name
+			}
+			defaults := make([]object.Object, defaultsCount)
+			for i := defaultsCount - 1; i >= 0; i-- {
+				defaults[i] = vm.pop()
+			}
+			fields := make([]string, fieldCount)
+			for i := fieldCount - 1; i >= 0; i-- {
+				fields[i] = vm.pop().(*object.String).Value()
+			}
+			name := vm.pop().(*object.String).Value()
+			vm.push(object.NewStructType(name, fields, defaults, methods, methodNames))
+		case op.BuildEnum:
+			count := int(vm.fetch())
+			names := make([]string, count)
+			values := make([]object.Object, count)
+			for i := count - 1; i >= 0; i-- {
+				values[i] = vm.pop()
+				names[i] = vm.pop().(*object.String).Value()
+			}
+			name := vm.pop().(*object.String).Value()
+			vm.push(object.NewEnumType(name, names, values))
+		case op.ListExtend:
+			obj := vm.pop()
+			list := vm.pop().(*object.List)
+			items, err := spreadItems(ctx, obj)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				list.Append(item)
+			}
+			vm.push(list)
+		case op.MapMerge:
+			obj := vm.pop()
+			kwargs := vm.pop().(*object.Map)
+			m, ok := obj.(*object.Map)
+			if !ok {
+				return errz.TypeErrorf("type error: argument after ** must be a map (got %s)", obj.Type())
+			}
+			if err := mergeKwargs(kwargs, m); err != nil {
+				return err
+			}
+			vm.push(kwargs)
+		case op.ListAppend:
+			depth := int(vm.fetch())
+			obj := vm.pop()
+			vm.stack[vm.sp-depth].(*object.List).Append(obj)
+		case op.SetAdd:
+			depth := int(vm.fetch())
+			obj := vm.pop()
+			if err, ok := vm.stack[vm.sp-depth].(*object.Set).Add(obj).(*object.Error); ok {
+				return err.Value()
+			}
+		case op.MapAdd:
+			depth := int(vm.fetch())
+			value := vm.pop()
+			key := vm.pop()
+			if err := vm.stack[vm.sp-depth].(*object.Map).SetItem(key, value); err != nil {
+				return err.Value()
+			}
 		case op.BinarySubscr:
 			idx := vm.pop()
 			lhs := vm.pop()
@@ -383,6 +590,25 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				return err.Value()
 			}
 			vm.push(result)
+		case op.OptionalSubscr:
+			idx := vm.pop()
+			lhs := vm.pop()
+			// A key that is missing from a map gives nil rather than an error
+			if m, ok := lhs.(*object.Map); ok {
+				if key, ok := idx.(*object.String); ok {
+					vm.push(m.Get(key.Value()))
+					break
+				}
+			}
+			container, ok := lhs.(object.Container)
+			if !ok {
+				return errz.TypeErrorf("type error: object is not a container (got %s)", lhs.Type())
+			}
+			result, err := container.GetItem(idx)
+			if err != nil {
+				return err.Value()
+			}
+			vm.push(result)
 		case op.StoreSubscr:
 			idx := vm.pop()
 			lhs := vm.pop()
@@ -433,19 +659,30 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			for i := uint16(0); i < count; i++ {
 				dst := count - 1 - i
 				obj := vm.pop()
-				switch obj := obj.(type) {
-				case *object.Error:
-					if obj.IsRaised() {
-						return obj.Value()
-					}
-					items[dst] = obj.Value().Error()
-				case *object.String:
-					items[dst] = obj.Value()
-				default:
-					items[dst] = obj.Inspect()
+				if err, ok := obj.(*object.Error); ok && err.IsRaised() {
+					return err.Value()
 				}
+				items[dst] = object.FormatString(obj)
 			}
 			vm.push(object.NewString(strings.Join(items, "")))
+		case op.FormatValue:
+			conversion := vm.fetch()
+			spec := vm.pop().(*object.String).Value()
+			obj := vm.pop()
+			if err, ok := obj.(*object.Error); ok && err.IsRaised() {
+				return err.Value()
+			}
+			switch conversion {
+			case op.FormatRepr:
+				obj = object.NewString(obj.Inspect())
+			case op.FormatStr:
+				obj = object.NewString(object.FormatString(obj))
+			}
+			s, err := object.Format(obj, spec)
+			if err != nil {
+				return err
+			}
+			vm.push(object.NewString(s))
 		case op.Range:
 			iterableObj := vm.pop()
 			iterable, ok := iterableObj.(object.Iterable)
@@ -553,6 +790,69 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				}
 				vm.push(val)
 			}
+		case op.UnpackRest:
+			before := int(vm.fetch())
+			after := int(vm.fetch())
+			listObj := vm.pop()
+			list, ok := listObj.(*object.List)
+			if !ok {
+				return errz.TypeErrorf("type error: object is not a list (got %s)", listObj.Type())
+			}
+			items := list.Value()
+			if len(items) < before+after {
+				return fmt.Errorf("unpack count mismatch: %d < %d", len(items), before+after)
+			}
+			for _, item := range items[:before] {
+				vm.push(item)
+			}
+			rest := make([]object.Object, len(items)-before-after)
+			copy(rest, items[before:len(items)-after])
+			vm.push(object.NewList(rest))
+			for _, item := range items[len(items)-after:] {
+				vm.push(item)
+			}
+		case op.MatchType:
+			name := vm.activeCode.Names[vm.fetch()]
+			vm.push(object.NewBool(matchesType(vm.stack[vm.sp], name)))
+		case op.MatchList:
+			count := int64(vm.fetch())
+			hasRest := vm.fetch() != 0
+			list, ok := vm.stack[vm.sp].(*object.List)
+			if ok {
+				size := list.Len().Value()
+				ok = size == count || (hasRest && size > count)
+			}
+			vm.push(object.NewBool(ok))
+		case op.MatchKey:
+			key := vm.activeCode.Names[vm.fetch()]
+			var value object.Object
+			if m, ok := vm.stack[vm.sp].(*object.Map); ok {
+				value = m.Value()[key]
+			}
+			if value == nil {
+				vm.push(object.Nil)
+				vm.push(object.False)
+			} else {
+				vm.push(value)
+				vm.push(object.True)
+			}
+		case op.MatchAttr:
+			name := vm.activeCode.Names[vm.fetch()]
+			value, found := vm.stack[vm.sp].GetAttr(name)
+			if resolver, ok := value.(object.AttrResolver); found && ok {
+				attr, err := resolver.ResolveAttr(ctx, name)
+				if err != nil {
+					return err
+				}
+				value = attr
+			}
+			if found {
+				vm.push(value)
+				vm.push(object.True)
+			} else {
+				vm.push(object.Nil)
+				vm.push(object.False)
+			}
 		case op.GetIter:
 			obj := vm.pop()
 			switch obj := obj.(type) {
@@ -568,8 +868,14 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			jumpAmount := vm.fetch()
 			nameCount := vm.fetch()
 			iter := vm.pop().(object.Iterator)
-			if _, ok := iter.Next(ctx); !ok {
+			if item, ok := iter.Next(ctx); !ok {
+				if err := object.IterErr(iter); err != nil {
+					return err
+				}
 				vm.ip = base + int(jumpAmount)
+			} else if nameCount == op.ForIterItem|1 {
+				vm.push(iter)
+				vm.push(item)
 			} else {
 				obj, _ := iter.Entry()
 				vm.push(iter)
@@ -588,7 +894,14 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if !ok {
 				return errz.TypeErrorf("type error: object is not a partial (got %s)", obj.Type())
 			}
-			if _, err := object.Spawn(ctx, partial.Function(), partial.Args()); err != nil {
+			fn, args := partial.Function(), partial.Args()
+			if kwargs := partial.Kwargs(); kwargs != nil {
+				var err error
+				if fn, args, err = bindCallKwargs(fn, args, kwargs); err != nil {
+					return err
+				}
+			}
+			if _, err := object.Spawn(ctx, fn, args); err != nil {
 				return err
 			}
 		case op.Defer:
@@ -619,6 +932,43 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				return err
 			}
 			vm.push(value)
+		case op.Select:
+			operand := vm.fetch()
+			count := int(operand &^ op.SelectDefault)
+			cases := make([]object.SelectCase, count)
+			for i := count - 1; i >= 0; i-- {
+				isSend := vm.pop() == object.True
+				var value object.Object
+				if isSend {
+					value = vm.pop()
+				}
+				channel := vm.pop()
+				ch, ok := channel.(*object.Chan)
+				if !ok {
+					return errz.TypeErrorf("type error: object is not a channel (got %s)", channel.Type())
+				}
+				cases[i] = object.SelectCase{Chan: ch, Value: value}
+			}
+			index, value, ok, err := object.Select(ctx, cases, operand&op.SelectDefault != 0)
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+			vm.push(object.NewBool(ok))
+			vm.push(object.NewInt(int64(index)))
+		case op.PushExcept:
+			base := vm.ip - 1
+			delta := int(vm.fetch())
+			vm.activeFrame.PushHandler(base+delta, vm.sp)
+		case op.PopExcept:
+			vm.activeFrame.PopHandler()
+		case op.Raise:
+			obj := vm.pop()
+			errObj, ok := obj.(*object.Error)
+			if !ok {
+				return errz.TypeErrorf("type error: object is not an error (got %s)", obj.Type())
+			}
+			return errObj.Value()
 		case op.Halt:
 			return nil
 		default:
@@ -628,6 +978,34 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 	return nil
 }
 
+// handleError transfers control to the innermost exception handler in the
+// active frame. The stack is restored to its depth when the handler was
+// installed and the error is pushed for the handler to consume. Returns false
+// if there is no handler or the error is not recoverable, in which case the
+// error should propagate to the caller.
+func (vm *VirtualMachine) handleError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var fatalErr errz.Error
+	if errors.As(err, &fatalErr) && fatalErr.IsFatal() {
+		return false
+	}
+	h, ok := vm.activeFrame.PopHandler()
+	if !ok {
+		return false
+	}
+	for i := vm.sp; i > h.sp; i-- {
+		vm.stack[i] = nil
+	}
+	vm.sp = h.sp
+	vm.ip = h.ip
+	vm.errTraced = nil
+	vm.errTrace = nil
+	vm.push(object.NewError(err).WithRaised(false))
+	return true
+}
+
 // GetIP returns the current instruction pointer.
 func (vm *VirtualMachine) GetIP() int {
 	return vm.ip
@@ -666,9 +1044,44 @@ func (vm *VirtualMachine) pop() object.Object {
 
 func (vm *VirtualMachine) push(obj object.Object) {
 	vm.sp++
+	if vm.sp == len(vm.stack) {
+		vm.growStack()
+	}
 	vm.stack[vm.sp] = obj
 }
 
+// growStack doubles the size of the data stack, up to the maximum size. If the
+// stack is already at the maximum size, the push is undone and it panics with
+// a stackOverflow.
+func (vm *VirtualMachine) growStack() {
+	size := len(vm.stack)
+	if size >= vm.maxStack {
+		vm.sp--
+		panic(stackOverflow{err: fmt.Errorf("%w: maximum stack size of %d exceeded",
+			ErrStackOverflow, vm.maxStack)})
+	}
+	newSize := min(max(size*2, initialStackSize), vm.maxStack)
+	stack := make([]object.Object, newSize)
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+// frame returns the frame at the given frame pointer, allocating frames as
+// needed. An error is returned if this would exceed the maximum frame depth.
+func (vm *VirtualMachine) frame(fp int) (*frame, error) {
+	if fp < len(vm.frames) {
+		return vm.frames[fp], nil
+	}
+	if fp >= vm.maxFrames {
+		return nil, fmt.Errorf("%w: maximum call depth of %d exceeded",
+			ErrStackOverflow, vm.maxFrames)
+	}
+	for len(vm.frames) <= fp {
+		vm.frames = append(vm.frames, &frame{})
+	}
+	return vm.frames[fp], nil
+}
+
 func (vm *VirtualMachine) swap(pos int) {
 	otherIndex := vm.sp - pos
 	tos := vm.stack[vm.sp]
@@ -701,7 +1114,11 @@ func (vm *VirtualMachine) Call(
 		}
 		vm.stop()
 	}()
-	return vm.callFunction(vm.initContext(ctx), fn, args)
+	result, err = vm.callFunction(vm.initContext(ctx), fn, args)
+	if err != nil {
+		return nil, vm.tracedError(err)
+	}
+	return result, nil
 }
 
 // Calls a compiled function with the given arguments. This is used internally
@@ -711,17 +1128,20 @@ func (vm *VirtualMachine) callFunction(
 	fn *object.Function,
 	args []object.Object,
 ) (result object.Object, resultErr error) {
-	// Check that the argument count is appropriate
-	paramsCount := len(fn.Parameters())
+	// Check that the argument count is appropriate. A decorated function is
+	// checked against the signature it presents and then the arguments are
+	// passed on to its wrapper, which checks them against its own.
 	argc := len(args)
-
-	if argc > MaxArgs {
-		return nil, errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
-			MaxArgs, argc)
-	}
 	if err := checkCallArgs(fn, argc); err != nil {
 		return nil, err
 	}
+	if wrapper := callee(fn); wrapper != fn {
+		fn = wrapper
+		if err := checkCallArgs(fn, argc); err != nil {
+			return nil, err
+		}
+	}
+	paramsCount := len(fn.Parameters())
 
 	baseFP := vm.fp
 	baseIP := vm.ip
@@ -730,10 +1150,23 @@ func (vm *VirtualMachine) callFunction(
 	// Restore the previous frame when done
 	defer vm.resumeFrame(baseFP, baseIP, baseSP)
 
+	// Arguments beyond the parameters are collected by the rest parameter
+	var rest *object.List
+	if fn.RestParameter() != "" {
+		extra := []object.Object{}
+		if argc > paramsCount {
+			extra = make([]object.Object, argc-paramsCount)
+			copy(extra, args[paramsCount:])
+			argc = paramsCount
+		}
+		rest = object.NewList(extra)
+	}
+
 	// Assemble frame local variables in vm.tmp. The local variable order is:
 	// 1. Function parameters
-	// 2. Function name (if the function is named)
-	copy(vm.tmp[:argc], args)
+	// 2. Rest parameter (if the function has one)
+	// 3. Function name (if the function is named)
+	copy(vm.tmp[:argc], args[:argc])
 	if argc < paramsCount {
 		defaults := fn.Defaults()
 		for i := argc; i < len(defaults); i++ {
@@ -741,14 +1174,26 @@ func (vm *VirtualMachine) callFunction(
 		}
 		argc = paramsCount
 	}
+	if rest != nil {
+		vm.tmp[argc] = rest
+		argc++
+	}
 	code := fn.Code()
 	if code.IsNamed() {
-		vm.tmp[paramsCount] = fn
+		vm.tmp[argc] = fn
 		argc++
 	}
 
+	// Calling a generator function only creates the generator, which runs
+	// the function as it is iterated over
+	if code.IsGenerator() {
+		return vm.newGenerator(fn, vm.tmp[:argc]), nil
+	}
+
 	// Activate a frame for the function call
-	vm.activateFunction(vm.fp+1, 0, fn, vm.tmp[:argc])
+	if _, err := vm.activateFunction(vm.fp+1, 0, fn, vm.tmp[:argc]); err != nil {
+		return nil, err
+	}
 
 	// Setting StopSignal as the return address will cause the eval function to
 	// stop execution when it reaches the end of the active code.
@@ -757,8 +1202,14 @@ func (vm *VirtualMachine) callFunction(
 	// Set up deferred function calls
 	callFrame := vm.activeFrame
 	defer func() {
+		// If the function returned normally, the caller's frame is active
+		// again but its instruction pointer holds the stop signal. Restore it
+		// so that errors in deferred calls report the caller's location.
+		if vm.ip == StopSignal {
+			vm.ip = baseIP
+		}
 		for _, partial := range callFrame.defers {
-			if err := vm.callObject(ctx, partial.Function(), partial.Args()); err != nil {
+			if err := vm.callObject(ctx, partial, nil, nil); err != nil {
 				result = nil
 				resultErr = err
 			} else {
@@ -771,29 +1222,60 @@ func (vm *VirtualMachine) callFunction(
 
 	// Evaluate the function code then return the result from TOS
 	if err := vm.eval(ctx); err != nil {
+		vm.captureTrace(err)
 		return nil, err
 	}
 	return vm.pop(), nil
 }
 
-// Call a callable object with the given arguments. Returns an error if the
-// object is not callable. If this call succeeds, the result of the call will
-// have been pushed onto the stack.
+// Call a callable object with the given arguments and optional keyword
+// arguments. Returns an error if the object is not callable. If this call
+// succeeds, the result of the call will have been pushed onto the stack.
 func (vm *VirtualMachine) callObject(
 	ctx context.Context,
-	fn object.Object,
+	obj object.Object,
 	args []object.Object,
+	kwargs *object.Map,
 ) error {
-	switch fn := fn.(type) {
+	switch fn := obj.(type) {
 	case *object.Function:
+		if kwargs != nil {
+			var err error
+			if args, err = bindKwargs(fn, args, kwargs); err != nil {
+				return err
+			}
+		}
 		result, err := vm.callFunction(ctx, fn, args)
 		if err != nil {
 			return err
 		}
 		vm.push(result)
 		return nil
+	case *object.BoundMethod:
+		args = fn.Args(args)
+		if kwargs != nil {
+			var err error
+			if args, err = bindKwargs(fn.Method(), args, kwargs); err != nil {
+				return err
+			}
+		}
+		result, err := vm.callFunction(ctx, fn.Method(), args)
+		if err != nil {
+			return err
+		}
+		vm.push(result)
+		return nil
 	case object.Callable:
-		result := fn.Call(ctx, args...)
+		var result object.Object
+		if kwargs != nil && kwargs.Size() > 0 {
+			kwFn, ok := kwargsCallable(obj)
+			if !ok {
+				return errz.ArgsErrorf("args error: %s does not accept keyword arguments", callableName(obj))
+			}
+			result = kwFn.CallWithKwargs(ctx, kwargs.Value(), args...)
+		} else {
+			result = fn.Call(ctx, args...)
+		}
 		if err, ok := result.(*object.Error); ok && err.IsRaised() {
 			return err.Value()
 		}
@@ -802,19 +1284,42 @@ func (vm *VirtualMachine) callObject(
 	case *object.Partial:
 		// Combine the current arguments with the partial's arguments
 		argc := len(args)
-		expandedCount := argc + len(fn.Args())
-		if expandedCount > MaxArgs {
-			return errz.EvalErrorf("eval error: max arguments limit of %d exceeded (got %d)",
-				MaxArgs, expandedCount)
-		}
-		newArgs := make([]object.Object, expandedCount)
+		newArgs := make([]object.Object, argc+len(fn.Args()))
 		copy(newArgs[:argc], args)
 		copy(newArgs[argc:], fn.Args())
+		if partialKwargs := fn.Kwargs(); partialKwargs != nil {
+			merged := partialKwargs.Copy()
+			if kwargs != nil {
+				if err := mergeKwargs(merged, kwargs); err != nil {
+					return err
+				}
+			}
+			kwargs = merged
+		}
 		// Recursive call with the wrapped function and the combined args
-		return vm.callObject(ctx, fn.Function(), newArgs)
+		return vm.callObject(ctx, fn.Function(), newArgs, kwargs)
 	default:
-		return errz.TypeErrorf("type error: object is not callable (got %s)", fn.Type())
+		return errz.TypeErrorf("type error: object is not callable (got %s)", obj.Type())
+	}
+}
+
+// popArgs pops the arguments of a call from the stack, as described by the
+// operand of a Call or Partial instruction. The keyword arguments are nil if
+// the call does not pass any.
+func (vm *VirtualMachine) popArgs(operand uint16) ([]object.Object, *object.Map) {
+	var kwargs *object.Map
+	if operand&op.CallKwargs != 0 {
+		kwargs = vm.pop().(*object.Map)
+	}
+	if operand&op.CallArgsList != 0 {
+		return vm.pop().(*object.List).Value(), kwargs
+	}
+	argc := int(operand & op.CallArgsMask)
+	args := make([]object.Object, argc)
+	for i := argc - 1; i >= 0; i-- {
+		args[i] = vm.pop()
 	}
+	return args, kwargs
 }
 
 // Resume the frame at the given frame pointer, restoring the given IP and SP.
@@ -836,33 +1341,50 @@ func (vm *VirtualMachine) resumeFrame(fp, ip, sp int) *frame {
 	// Activate the resumed frame
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = vm.frames[fp]
 	vm.activeCode = vm.activeFrame.code
 	return vm.activeFrame
 }
 
 // Activate a frame with the given code. This is typically used to begin
-// running the entrypoint for a module or script.
-func (vm *VirtualMachine) activateCode(fp, ip int, code *code) *frame {
+// running the entrypoint for a module or script. If the frame would exceed
+// the maximum frame depth, the VM state is left unchanged and an error is
+// returned.
+func (vm *VirtualMachine) activateCode(fp, ip int, code *code) (*frame, error) {
+	frame, err := vm.frame(fp)
+	if err != nil {
+		return nil, err
+	}
+	callerIP := vm.ip
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = frame
 	vm.activeFrame.ActivateCode(code)
+	vm.activeFrame.callerIP = callerIP
 	vm.activeCode = code
-	return vm.activeFrame
+	return vm.activeFrame, nil
 }
 
 // Activate a frame with the given function, to implement a function call.
-func (vm *VirtualMachine) activateFunction(fp, ip int, fn *object.Function, locals []object.Object) *frame {
+// If the frame would exceed the maximum frame depth, the VM state is left
+// unchanged and an error is returned.
+func (vm *VirtualMachine) activateFunction(fp, ip int, fn *object.Function, locals []object.Object) (*frame, error) {
+	frame, err := vm.frame(fp)
+	if err != nil {
+		return nil, err
+	}
 	code := vm.loadCode(fn.Code())
 	returnAddr := vm.ip
 	returnSp := vm.sp
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = frame
 	vm.activeFrame.ActivateFunction(fn, code, returnAddr, returnSp, locals)
 	vm.activeCode = code
-	return vm.activeFrame
+	if code.calls != nil {
+		atomic.AddUint32(code.calls, 1)
+	}
+	return vm.activeFrame, nil
 }
 
 // Wrap the *compiler.Code in a *vm.code object to make it usable by the VM.
@@ -880,6 +1402,9 @@ func (vm *VirtualMachine) loadCode(cc *compiler.Code) *code {
 	} else {
 		c = loadChildCode(vm.loadedCode[rootCompiled], cc)
 	}
+	if vm.coverage != nil {
+		c.calls, c.hits = vm.coverage.register(cc)
+	}
 	// Store the loaded code but ensure we don't modify the map during a clone
 	vm.cloneMutex.Lock()
 	defer vm.cloneMutex.Unlock()
@@ -917,11 +1442,14 @@ func (vm *VirtualMachine) importModule(ctx context.Context, name string) (*objec
 	baseIP := vm.ip
 	baseSP := vm.sp
 	code := vm.loadCode(module.Code())
-	vm.activateCode(vm.fp+1, 0, code)
+	if _, err := vm.activateCode(vm.fp+1, 0, code); err != nil {
+		return nil, err
+	}
 	// Restore the previous frame when done
 	defer vm.resumeFrame(baseFP, baseIP, baseSP)
 	// Evaluate the module code
 	if err := vm.eval(ctx); err != nil {
+		vm.captureTrace(err)
 		return nil, err
 	}
 	module.UseGlobals(code.Globals)
@@ -978,8 +1506,17 @@ func (vm *VirtualMachine) Clone() (*VirtualMachine, error) {
 		modules:      modules,
 		loadedCode:   loadedCode,
 		concAllowed:  vm.concAllowed,
+		coverage:     vm.coverage,
+		profiler:     vm.profiler,
+		profileTick:  vm.profileTick,
+		maxFrames:    vm.maxFrames,
+		maxStack:     vm.maxStack,
+		noTraces:     vm.noTraces,
+		debugger:     vm.debugger,
+	}
+	if _, err := clone.activateCode(clone.fp, clone.ip, clone.loadCode(clone.main)); err != nil {
+		return nil, err
 	}
-	clone.activateCode(clone.fp, clone.ip, clone.loadCode(clone.main))
 	return clone, nil
 }
 
@@ -1012,6 +1549,7 @@ func (vm *VirtualMachine) cloneCallSync(
 }
 
 func (vm *VirtualMachine) initContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, vmKey{}, vm)
 	ctx = object.WithCallFunc(ctx, vm.callFunction)
 	if vm.concAllowed {
 		ctx = object.WithSpawnFunc(ctx, vm.cloneCallAsync)
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -867,7 +868,7 @@ func TestTryEvalError(t *testing.T) {
 	_, err := run(context.Background(), code)
 	require.NotNil(t, err)
 	require.Equal(t, "oops", err.Error())
-	require.Equal(t, errz.EvalErrorf("oops"), err)
+	require.Equal(t, errz.EvalErrorf("oops"), errors.Unwrap(err))
 }
 
 func TestTryTypeError(t *testing.T) {
@@ -997,7 +998,7 @@ func TestDeferWithError(t *testing.T) {
 	`
 	_, err := run(context.Background(), code)
 	require.Error(t, err)
-	require.Equal(t, fmt.Errorf("AGH"), err)
+	require.Equal(t, fmt.Errorf("AGH"), errors.Unwrap(err))
 }
 
 func TestStringTemplateWithRaisedError(t *testing.T) {
@@ -1014,6 +1015,16 @@ func TestStringTemplateWithNonRaisedError(t *testing.T) {
 	require.Equal(t, object.NewString("the err string is: oops. sad!"), result)
 }
 
+func TestStringTemplateWithBraces(t *testing.T) {
+	tests := []testCase{
+		{`'{ {"a": 1}["a"] }'`, object.NewString("1")},
+		{`m := {"a": {"b": 2}}; '{m["a"]} {{x}}'`, object.NewString(`{"b": 2} {x}`)},
+		{`x := "y"; '{"}" + x}'`, object.NewString("}y")},
+		{`'{ {"a": 1.5}["a"]:.2f}'`, object.NewString("1.50")},
+	}
+	runTests(t, tests)
+}
+
 func TestMultiVarAssignment(t *testing.T) {
 	tests := []testCase{
 		{`a, b := [3, 4]; a`, object.NewInt(3)},
@@ -2231,6 +2242,1460 @@ func TestContextDone(t *testing.T) {
 	require.NotNil(t, d)
 }
 
+func TestStructs(t *testing.T) {
+	tests := []testCase{
+		{`struct Point { x; y }
+		p := Point(1, 2)
+		p.x + p.y`, object.NewInt(3)},
+		{`struct Point { x; y = 10 }
+		Point(1).y`, object.NewInt(10)},
+		{`struct Point { x, y }
+		p := Point(1, 2)
+		p.x = 5
+		p.x`, object.NewInt(5)},
+		{`struct Point {
+			x
+			y
+			func sum() { return self.x + self.y }
+			func scale(n) { return Point(self.x * n, self.y * n) }
+		}
+		Point(2, 3).scale(10).sum()`, object.NewInt(50)},
+		{`struct Point { x; y }
+		Point(1, 2) == Point(1, 2)`, object.True},
+		{`struct Point { x; y }
+		Point(1, 2) == Point(1, 3)`, object.False},
+		{`struct A { x }
+		struct B { x }
+		A(1) == B(1)`, object.False},
+		{`struct Counter {
+			n = 0
+			func incr() { self.n = self.n + 1; return self }
+		}
+		c := Counter()
+		c.incr().incr()
+		c.n`, object.NewInt(2)},
+		{`struct Point { x; y }
+		type(Point(1, 2))`, object.NewString("struct")},
+		{`struct Point { x; y }
+		string(Point(1, "a"))`, object.NewString(`Point(x=1, y="a")`)},
+		{`struct Point { x; y }
+		Point.__name__`, object.NewString("Point")},
+		{`struct Point { x; y = 2
+			func sum() { self.x + self.y }
+		}
+		f := Point(1).sum
+		[1].map(func(_) { f() })[0]`, object.NewInt(3)},
+		{`func build(base) {
+			struct Item {
+				value
+				func total() { return self.value + base }
+			}
+			return Item(5)
+		}
+		build(10).total()`, object.NewInt(15)},
+		{`struct Bag { count = 0; label = "bag" }
+		a := Bag()
+		b := Bag()
+		a.count = 5
+		[a.count, b.count, Bag().count]`, object.NewList([]object.Object{
+			object.NewInt(5), object.NewInt(0), object.NewInt(0),
+		})},
+		{`struct Point { x; y = 2; z = 3 }
+		p := Point(y=5, x=1)
+		[p.x, p.y, p.z]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(5), object.NewInt(3),
+		})},
+		{`struct Point { x; y = 2; z = 3 }
+		p := Point(1, z=4)
+		[p.x, p.y, p.z]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(4),
+		})},
+		{`struct Point { x; y }
+		Point(**{"x": 1, "y": 2}) == Point(1, 2)`, object.True},
+	}
+	runTests(t, tests)
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`struct Point { x; y }
+		Point(1)`, "args error: Point() takes exactly 2 arguments (1 given)"},
+		{`struct Point { x; y = 1 }
+		Point(1, 2, 3)`, "args error: Point() takes between 1 and 2 arguments (3 given)"},
+		{`struct Point { x; y }
+		p := Point(1, 2)
+		p.z = 3`, "type error: struct Point has no field \"z\""},
+		{`struct Point { x; y }
+		Point(1, 2).z`, "type error: attribute \"z\" not found on struct object"},
+		{`struct Point { x = 1; y }`, "compile error: invalid field defaults for struct \"Point\" (line 1)"},
+		{`struct Bag { items = [] }`, "compile error: unsupported default value for field \"items\" of struct \"Bag\" (got [], line 1)"},
+		{`struct Bag { items = {} }`, "compile error: unsupported default value for field \"items\" of struct \"Bag\" (got {}, line 1)"},
+		{`struct Point { x; y }
+		Point(1, z=2)`, "args error: Point() got an unexpected keyword argument \"z\""},
+		{`struct Point { x; y }
+		Point(1, x=2)`, "args error: Point() got multiple values for argument \"x\""},
+		{`struct Point { x; y = 1 }
+		Point(y=2)`, "args error: Point() missing argument \"x\""},
+		{`struct Point { x }
+		Point(1, 2, x=3)`, "args error: Point() takes at most 1 positional arguments (2 given)"},
+		{`struct Point { x }
+		Point = 1`, "compile error: cannot assign to constant \"Point\" (line 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	tests := []testCase{
+		{`x := 0
+		try { error("oops") } catch { x = 1 }
+		x`, object.NewInt(1)},
+		{`msg := ""
+		try { error("oops %d", 42) } catch err { msg = err.message() }
+		msg`, object.NewString("oops 42")},
+		{`x := 0
+		try { x = 1 } catch { x = 2 }
+		x`, object.NewInt(1)},
+		{`l := []
+		try { l.append(1) } catch { l.append(2) } finally { l.append(3) }
+		l`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(3)})},
+		{`l := []
+		try { error("oops"); l.append(1) } catch { l.append(2) } finally { l.append(3) }
+		l`, object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)})},
+		{`func fail() { error("deep") }
+		msg := ""
+		try { [1, 2].map(func(x) { fail() }) } catch err { msg = err.message() }
+		msg`, object.NewString("deep")},
+		{`x := 0
+		try { {}.missing.attr } catch { x = 1 }
+		x`, object.NewInt(1)},
+		{`x := 0
+		func f() {
+			try { return 1 } finally { x = 2 }
+		}
+		[f(), x]`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`func f() {
+			try { error("a") } catch err { return "caught " + err.message() }
+			return "not caught"
+		}
+		f()`, object.NewString("caught a")},
+		{`l := []
+		for i := range 3 {
+			try {
+				if i == 1 { continue }
+				if i == 2 { break }
+				l.append(i)
+			} finally { l.append("f") }
+		}
+		l`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewString("f"), object.NewString("f"), object.NewString("f"),
+		})},
+		{`l := []
+		try {
+			try { error("inner") } finally { l.append("inner finally") }
+		} catch err { l.append(err.message()) }
+		l`, object.NewList([]object.Object{
+			object.NewString("inner finally"), object.NewString("inner"),
+		})},
+		{`l := []
+		try {
+			try { error("a") } catch { error("b") } finally { l.append("f") }
+		} catch err { l.append(err.message()) }
+		l`, object.NewList([]object.Object{object.NewString("f"), object.NewString("b")})},
+		{`x := 0
+		for i := range 3 {
+			try { error("oops") } catch { x += i }
+		}
+		x`, object.NewInt(3)},
+		{`func f() { try { error("oops") } catch err { return err } }
+		f()`, object.NewError(errors.New("oops")).WithRaised(false)},
+		{`s := ""
+		try { error("oops") } catch err { s = '{err}!' }
+		s`, object.NewString("oops!")},
+		{`try(func() { error("oops") }, func(err) { err.message() })`, object.NewString("oops")},
+	}
+	runTests(t, tests)
+}
+
+func TestTryStatementDefers(t *testing.T) {
+	code := `
+	l := []
+	func f() {
+		defer l.append("deferred")
+		l.append("body")
+		error("oops")
+	}
+	try { f() } catch err { l.append(err.message()) }
+	l
+	`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewString("body"),
+		object.NewString("deferred"),
+		object.NewString("oops"),
+	}), result)
+}
+
+func TestTryStatementErrorsAs(t *testing.T) {
+	code := `
+	result := []
+	try { 1 + "a" } catch err {
+		result.append(errors.as(err, errors.type_error("")))
+		result.append(errors.as(err, errors.eval_error("")))
+	}
+	result
+	`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{object.True, object.False}), result)
+}
+
+func TestTryStatementReraise(t *testing.T) {
+	code := `
+	x := 0
+	try { error("oops") } finally { x = 1 }
+	`
+	_, err := run(context.Background(), code)
+	require.NotNil(t, err)
+	require.Equal(t, "oops", err.Error())
+
+	code = `try { error("first") } catch err { error("second: %s", err) }`
+	_, err = run(context.Background(), code)
+	require.NotNil(t, err)
+	require.Equal(t, "second: first", err.Error())
+}
+
+func TestTryStatementFatalError(t *testing.T) {
+	code := `try { len(1, 2) } catch { 1 }`
+	_, err := run(context.Background(), code)
+	require.NotNil(t, err)
+	require.Equal(t, "args error: len() takes exactly 1 argument (2 given)", err.Error())
+}
+
+func TestVariadicFunctions(t *testing.T) {
+	tests := []testCase{
+		{`func log(level, ...parts) { [level, parts] }; log("info", 1, 2)`, object.NewList([]object.Object{
+			object.NewString("info"),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+		})},
+		{`func f(...xs) { xs }; f()`, object.NewList([]object.Object{})},
+		{`func f(a, b=2, ...rest) { [a, b, rest] }; f(1)`, object.NewList([]object.Object{
+			object.NewInt(1),
+			object.NewInt(2),
+			object.NewList([]object.Object{}),
+		})},
+		{`func sum(...xs) {
+			if len(xs) == 1 { return xs[0] }
+			return xs[0] + sum(...xs[1:])
+		}
+		sum(1, 2, 3)`, object.NewInt(6)},
+		{`x := 10; f := func(...xs) { len(xs) + x }; f(1, 2)`, object.NewInt(12)},
+		{`struct P { x; func add(...ys) { self.x + len(ys) } }; P(1).add(5, 6)`, object.NewInt(3)},
+	}
+	runTests(t, tests)
+}
+
+func TestSpreadArguments(t *testing.T) {
+	tests := []testCase{
+		{`func f(a, b, c) { [a, b, c] }; items := [2, 3]; f(1, ...items)`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`func f(a, b, c) { [a, b, c] }; f(...[1], 2, ...[3])`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`func f(...xs) { xs }; f(...{"a": 1}.keys(), ...["b"])`, object.NewList([]object.Object{
+			object.NewString("a"), object.NewString("b"),
+		})},
+		{`len(...["abc"])`, object.NewInt(3)},
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(1, **{"c": 4})`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(4),
+		})},
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(**{"a": 1}, **{"b": 5})`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(5), object.NewInt(3),
+		})},
+		{`struct P { x; func add(a, b=0) { self.x + a + b } }; P(1).add(**{"a": 2, "b": 3})`, object.NewInt(6)},
+		{`func f(a, b) { [a, b] }; 1 | f(**{"b": 2})`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2),
+		})},
+		{`func f(a, b, c) { [a, b, c] }; 1 | f(...[2, 3])`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`c := chan(1); func f(a, b) { x := a + b; c <- x }; go f(...[1, 2]); <-c`, object.NewInt(3)},
+		{`c := chan(1); func f(a, b=0) { x := a + b; c <- x }; go f(1, **{"b": 2}); <-c`, object.NewInt(3)},
+		{`r := []
+		func h(a, b=0) { r.append(a + b) }
+		func g() { defer h(1, **{"b": 2}); r.append(0) }
+		g()
+		r`, object.NewList([]object.Object{object.NewInt(0), object.NewInt(3)})},
+	}
+	runTests(t, tests)
+}
+
+func TestSpreadArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`func f(a, ...b) { a }; f()`, "args error: function \"f\" takes at least 1 argument (0 given)"},
+		{`func f(a, b, ...c) { a }; f(...[1])`, "args error: function \"f\" takes at least 2 arguments (1 given)"},
+		{`func f(a) { a }; f(**{"b": 1})`, "args error: function \"f\" got an unexpected keyword argument \"b\""},
+		{`func f(...a) { a }; f(**{"a": [1]})`, "args error: function \"f\" got an unexpected keyword argument \"a\""},
+		{`func f(a) { a }; f(1, **{"a": 1})`, "args error: function \"f\" got multiple values for argument \"a\""},
+		{`func f(a, b) { a }; f(**{"b": 1})`, "args error: function \"f\" missing required argument \"a\""},
+		{`func f(a) { a }; f(**{"a": 1}, **{"a": 2})`, "args error: got multiple values for keyword argument \"a\""},
+		{`len(**{"x": 1})`, "args error: builtin function \"len\" does not accept keyword arguments"},
+		{`func f(...a) { a }; f(...nil)`, "type error: argument after ... must be iterable (got nil)"},
+		{`func f(a) { a }; f(**[1])`, "type error: argument after ** must be a map (got list)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestKeywordArguments(t *testing.T) {
+	tests := []testCase{
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(1, c=4)`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(4),
+		})},
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(c=6, a=4, b=5)`, object.NewList([]object.Object{
+			object.NewInt(4), object.NewInt(5), object.NewInt(6),
+		})},
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(b=5, **{"a": 1}, c=6)`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(5), object.NewInt(6),
+		})},
+		{`func f(a, ...rest) { [a, rest] }; f(a=1)`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewList([]object.Object{}),
+		})},
+		{`struct P { x; func add(a, b=0) { self.x + a + b } }; P(1).add(2, b=3)`, object.NewInt(6)},
+		{`func f(a, b) { [a, b] }; 1 | f(b=2)`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2),
+		})},
+		{`c := chan(1); func f(a, b=0) { x := a + b; c <- x }; go f(1, b=2); <-c`, object.NewInt(3)},
+		{`strings.contains(substr="b", s="abc")`, object.True},
+		{`strings.repeat("ab", count=2)`, object.NewString("abab")},
+		{`strings.trim_suffix("file.txt", suffix=".txt")`, object.NewString("file")},
+		{`strings.join(sep="-", a=["a", "b"])`, object.NewString("a-b")},
+	}
+	runTests(t, tests)
+}
+
+func TestKeywordArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`func f(a) { a }; f(b=1)`, "args error: function \"f\" got an unexpected keyword argument \"b\""},
+		{`func f(a) { a }; f(1, a=2)`, "args error: function \"f\" got multiple values for argument \"a\""},
+		{`func f(a, b) { a }; f(b=1)`, "args error: function \"f\" missing required argument \"a\""},
+		{`func f(a) { a }; f(a=1, **{"a": 2})`, "args error: got multiple values for keyword argument \"a\""},
+		{`len(x=1)`, "args error: builtin function \"len\" does not accept keyword arguments"},
+		{`strings.contains("abc", sub="b")`, "args error: strings.contains() got an unexpected keyword argument \"sub\""},
+		{`strings.contains("abc", s="b")`, "args error: strings.contains() got multiple values for argument \"s\""},
+		{`strings.contains(substr="b")`, "args error: strings.contains() missing argument \"s\""},
+		{`strings.contains(s="abc")`, "args error: strings.contains() takes exactly 2 arguments (1 given)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestKwargsBuiltin(t *testing.T) {
+	ctx := context.Background()
+	send := object.NewKwargsBuiltin("send", func(ctx context.Context, kwargs map[string]object.Object, args ...object.Object) object.Object {
+		if err := args[0].(*object.Chan).Send(ctx, kwargs["value"]); err != nil {
+			return object.NewError(err)
+		}
+		return object.Nil
+	})
+	options := object.NewKwargsBuiltin("options", func(ctx context.Context, kwargs map[string]object.Object, args ...object.Object) object.Object {
+		return object.NewList([]object.Object{object.NewList(args), object.NewMap(kwargs)})
+	})
+	opts := runOpts{Globals: map[string]any{"send": send, "options": options}}
+
+	result, err := run(ctx, `options(1, timeout=5)`, opts)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewInt(1)}),
+		object.NewMap(map[string]object.Object{"timeout": object.NewInt(5)}),
+	}), result)
+
+	result, err = run(ctx, `options(1)`, opts)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewInt(1)}),
+		object.NewMap(nil),
+	}), result)
+
+	result, err = run(ctx, `c := chan(1); go send(c, value=3); <-c`, opts)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(3), result)
+}
+
+func TestGenerators(t *testing.T) {
+	ints := func(values ...int64) *object.List {
+		var items []object.Object
+		for _, v := range values {
+			items = append(items, object.NewInt(v))
+		}
+		return object.NewList(items)
+	}
+	tests := []testCase{
+		{`func gen() { yield 1; yield 2; yield 3 }; list(gen())`, ints(1, 2, 3)},
+		{`func gen(n) { for i := 0; i < n; i++ { yield i * i } }; list(gen(4))`, ints(0, 1, 4, 9)},
+		{`func gen() { yield 1; yield 2 }
+		  result := []
+		  for i, v := range gen() { result.append([i, v]) }
+		  result`, object.NewList([]object.Object{ints(0, 1), ints(1, 2)})},
+		{`func gen() { yield 1; yield 2 }
+		  result := []
+		  for v := range gen() { result.append(v) }
+		  result`, ints(0, 1)},
+		{`func naturals() { i := 0; for { yield i; i++ } }
+		  result := []
+		  for _, n := range naturals() {
+			if n > 3 { break }
+			result.append(n)
+		  }
+		  result`, ints(0, 1, 2, 3)},
+		{`func gen() { yield 1; yield 2 }; it := iter(gen()); [it.next(), it.next(), it.next()]`,
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2), object.Nil})},
+		{`func gen() { yield 1; yield 2 }; g := gen(); g.next(); g.entry().key`, object.NewInt(0)},
+		{`func gen() { return 1 }; type(gen())`, object.NewString("int")},
+		{`func gen() { yield }; list(gen())`, object.NewList([]object.Object{object.Nil})},
+		{`func gen() { yield 1 }; type(gen())`, object.NewString("generator")},
+		{`func gen() { if false { yield 1 } }; list(gen())`, ints()},
+		{`func gen(a, b=10, ...rest) { yield a; yield b; for _, r := range rest { yield r } }
+		  list(gen(1, 2, 3, 4))`, ints(1, 2, 3, 4)},
+		{`func gen() { total := 0; add := func(n) { total += n }
+		  for _, n := range [1, 2, 3] { add(n); yield total } }
+		  list(gen())`, ints(1, 3, 6)},
+		{`func gen() {
+			for _, n := range [1, 2, 3] {
+				try { yield n; error("fail") } catch e { yield n * 10 }
+			}
+		  }
+		  list(gen())`, ints(1, 10, 2, 20, 3, 30)},
+		{`log := []
+		  func gen() { defer log.append("done"); yield 1; yield 2 }
+		  list(gen()) + log`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2), object.NewString("done")})},
+		{`func outer() { func inner(n) { yield n; yield n + 1 }; for _, v := range inner(1) { yield v }; for _, v := range inner(10) { yield v } }
+		  list(outer())`, ints(1, 2, 10, 11)},
+		{`func gen(xs) { for _, x := range xs { yield x * 2 } }; x := [1, 2, 3] | gen | list; x`, ints(2, 4, 6)},
+		{`func gen() { yield 1; yield 1; yield 2 }; sorted(set(gen()))`, ints(1, 2)},
+		{`func gen() { yield "a"; yield "b" }; keys(gen())`, ints(0, 1)},
+		{`func gen() { yield 0; yield 1 }; [any(gen()), all(gen())]`, object.NewList([]object.Object{object.True, object.False})},
+		{`func gen() { yield 1; yield 2 }; g := gen(); list(g); list(g)`, ints()},
+		{`func add(a, b) { a + b }; func gen() { yield 1; yield 2 }; add(...gen())`, object.NewInt(3)},
+		{`func gen(c) { for _, v := range c { yield v * 10 } }
+		  c := chan(3); c <- 1; c <- 2; c <- 3; close(c)
+		  list(gen(c))`, ints(10, 20, 30)},
+		{`func gen() { yield 1; yield 2; yield 3 }
+		  c := chan()
+		  go func() { for _, v := range gen() { c <- v }; close(c) }()
+		  result := []
+		  for _, v := range c { result.append(v) }
+		  result`, ints(1, 2, 3)},
+		{`func gen() { yield 1; yield 2 }
+		  g := gen()
+		  spawn(func() { list(g) }).wait()`, ints(1, 2)},
+	}
+	runTests(t, tests)
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`func gen() { yield 1; error("kaboom") }; list(gen())`, "kaboom"},
+		{`func gen() { yield 1; error("kaboom") }; for _, v := range gen() {}`, "kaboom"},
+		{`func gen() { error("kaboom"); yield 1 }; g := gen(); g.next()`, "kaboom"},
+		{`func gen() { yield 1; error("kaboom") }; func f(...args) { args }; f(...gen())`, "kaboom"},
+		{`g := nil; func gen() { yield g.next() }; g = gen(); g.next()`, "value error: generator is already running"},
+		{`yield 1`, "compile error: yield statement outside of a function (line 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	describe := `func describe(v) {
+		return match v {
+		case nil: "nil"
+		case 0, 1: "small"
+		case -1: "minus one"
+		case int(n) if n > 100: "big"
+		case int(n): 'int {n}'
+		case string(s): 'string {s}'
+		case []: "empty"
+		case [x]: 'one {x}'
+		case [first, ...rest]: 'first {first} rest {rest}'
+		case {"kind": "pod", "name": n} if n != "": 'pod {n}'
+		case {"kind": kind}: 'kind {kind}'
+		case _: "other"
+		}
+	}
+	`
+	tests := []testCase{
+		{describe + `describe(nil)`, object.NewString("nil")},
+		{describe + `describe(1)`, object.NewString("small")},
+		{describe + `describe(-1)`, object.NewString("minus one")},
+		{describe + `describe(500)`, object.NewString("big")},
+		{describe + `describe(5)`, object.NewString("int 5")},
+		{describe + `describe("x")`, object.NewString("string x")},
+		{describe + `describe([])`, object.NewString("empty")},
+		{describe + `describe([1])`, object.NewString("one 1")},
+		{describe + `describe([1, 2, 3])`, object.NewString("first 1 rest [2, 3]")},
+		{describe + `describe({"kind": "pod", "name": "web"})`, object.NewString("pod web")},
+		{describe + `describe({"kind": "pod", "name": ""})`, object.NewString("kind pod")},
+		{describe + `describe({"name": "web"})`, object.NewString("other")},
+		{describe + `describe(3.5)`, object.NewString("other")},
+		{`match [1, [2, 3]] { case [a, [b, c]]: a + b + c }`, object.NewInt(6)},
+		{`match [1, 2, 3, 4] { case [a, ...mid, z]: [a, mid, z] }`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)}), object.NewInt(4),
+		})},
+		{`match [1, 2] { case [a, b, ...rest]: rest }`, object.NewList([]object.Object{})},
+		{`match [1, 2] { case [..., 2]: "ends with 2" }`, object.NewString("ends with 2")},
+		{`match [1, 2] { case [_, _, _]: 3; case [_]: 1 }`, object.Nil},
+		{`match 5 {}`, object.Nil},
+		{`match 5 { case 1: "one"; default: "default" }`, object.NewString("default")},
+		{`match 5 { default: "default"; case 5: "five" }`, object.NewString("five")},
+		{`match 5 { case 5: }`, object.Nil},
+		{`match 5 { case float(): "float"; case int(): "int" }`, object.NewString("int")},
+		{`match ({"a": 1}) { case map({"a": a}): a }`, object.NewInt(1)},
+		{`match "abc" { case "a", "abc": "yes" }`, object.NewString("yes")},
+		{`x := 3; match x { case n if n > 2: n * 2 }`, object.NewInt(6)},
+		{`func f(v) { match v { case [a, b], {"a": a, "b": b}: return a + b }; return 0 }
+		  [f([1, 2]), f({"a": 3, "b": 4}), f(5)]`, object.NewList([]object.Object{
+			object.NewInt(3), object.NewInt(7), object.NewInt(0),
+		})},
+		{`struct Point { x; y }
+		  func where(p) {
+			match p {
+			case Point(x=0, y=0): "origin"
+			case Point(x=0, y=y): 'y axis {y}'
+			case Point(): "elsewhere"
+			default: "not a point"
+			}
+		  }
+		  [where(Point(0, 0)), where(Point(0, 3)), where(Point(1, 2)), where({"x": 0, "y": 0})]`,
+			object.NewList([]object.Object{
+				object.NewString("origin"), object.NewString("y axis 3"),
+				object.NewString("elsewhere"), object.NewString("not a point"),
+			})},
+		{`match "abc" { case string(len=3): "three" }`, object.Nil},
+		{`match strings { case module(__name__=n): n }`, object.NewString("strings")},
+		{`count := 0
+		  for i := 0; i < 1000; i++ {
+			count += match ({"a": [1, {"b": i}]}) {
+			case {"a": [1, {"b": "x"}]}: 100
+			case {"a": [_, {"b": b}, _]}: 100
+			case {"a": [_, {"b": b}]} if b % 2 == 0: 1
+			case {"a": [...]}: 2
+			}
+		  }
+		  count`, object.NewInt(1500)},
+		{`out := []
+		  for _, v := range [1, 2, 3, 4] {
+			match v {
+			case 2: continue
+			case 4: break
+			}
+			out.append(v)
+		  }
+		  out`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(3)})},
+		{`out := []
+		  for _, v := range [1, 2, 3, 4] {
+			switch v {
+			case 2: continue
+			case 4: break
+			}
+			out.append(v)
+		  }
+		  out`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(3)})},
+		{`func gen(items) { for _, item := range items { match item { case int(n): yield n * 10 } } }
+		  list(gen([1, "a", 2]))`, object.NewList([]object.Object{object.NewInt(10), object.NewInt(20)})},
+		{`match := func(x) { x }; match(4)`, object.NewInt(4)},
+	}
+	runTests(t, tests)
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []testCase{
+		{`[x * 2 for x in [1, 2, 3]]`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(4), object.NewInt(6),
+		})},
+		{`[x for x in [1, 2, 3, 4, 5] if x % 2 == 1 if x > 1]`, object.NewList([]object.Object{
+			object.NewInt(3), object.NewInt(5),
+		})},
+		{`[x for x in []]`, object.NewList([]object.Object{})},
+		{`[i for i, x in ["a", "b", "c"] if x != "b"]`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(2),
+		})},
+		{`[k for k in {"b": 2, "a": 1}]`, object.NewList([]object.Object{
+			object.NewString("a"), object.NewString("b"),
+		})},
+		{`[i * 10 + j for i in [1, 2] for j in [1, 2, 3] if j != i]`, object.NewList([]object.Object{
+			object.NewInt(12), object.NewInt(13), object.NewInt(21), object.NewInt(23),
+		})},
+		{`[[x, y] for x in [1, 2] if x > 1 for y in [x, x + 1]]`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(2)}),
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)}),
+		})},
+		{`[y for x in [[1, 2], [3]] for y in x]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`{k: v * 10 for k, v in {"a": 1, "b": 2}}`, object.NewMap(map[string]object.Object{
+			"a": object.NewInt(10), "b": object.NewInt(20),
+		})},
+		{`{'{x}': x for x in [1, 2] if x > 1}`, object.NewMap(map[string]object.Object{
+			"2": object.NewInt(2),
+		})},
+		{`{x % 3 for x in [1, 2, 3, 4, 5, 6]}`, object.NewSet([]object.Object{
+			object.NewInt(0), object.NewInt(1), object.NewInt(2),
+		})},
+		{`func gen() { yield 1; yield 2 }
+		  [x + 1 for x in gen()]`, object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)})},
+		{`func squares(items) { return [x * x for x in items] }
+		  squares([2, 3])`, object.NewList([]object.Object{object.NewInt(4), object.NewInt(9)})},
+		{`x := "outer"; y := [x for x in [1]]; [x, y]`, object.NewList([]object.Object{
+			object.NewString("outer"), object.NewList([]object.Object{object.NewInt(1)}),
+		})},
+		{`x := [1, 2]; [x for x in x]`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`total := 0
+		  for i := 0; i < 4; i++ {
+			items := [match x { case 2: continue; default: x } for x in [1, 2, 3]]
+			total += len(items)
+		  }
+		  total`, object.NewInt(0)},
+		{`total := 0
+		  for {
+			items := [match x { case 2: break; default: x } for x in [1, 2, 3]]
+			total += len(items)
+		  }
+		  total`, object.NewInt(0)},
+	}
+	runTests(t, tests)
+}
+
+func TestComprehensionErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`[x for x in 1.5]`, "type error: object is not iterable (got float)"},
+		{`{x for x in [[1]]}`, "type error: list object is unhashable"},
+		{`{x: 1 for x in [1]}`, "type error: map key must be a string or enum member (got int)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	resp := `resp := {"data": {"pods": [{"name": "web"}], "count": 0}}; none := nil; `
+	tests := []testCase{
+		{resp + `resp?.data?.pods[0].name`, object.NewString("web")},
+		{resp + `none?.data.pods[0].name`, object.Nil},
+		{resp + `resp?.data?.count`, object.NewInt(0)},
+		{resp + `resp?.["data"]?.["missing"]`, object.Nil},
+		{resp + `resp?.["missing"]?.pods[0]`, object.Nil},
+		{resp + `resp["data"]?.["pods"]?.[0]?.["name"]`, object.NewString("web")},
+		{resp + `none?.[0]`, object.Nil},
+		{resp + `none?.[1:]`, object.Nil},
+		{`[1, 2, 3]?.[1:]`, object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)})},
+		{resp + `none?.keys()`, object.Nil},
+		{resp + `resp?.data.keys()`, object.NewList([]object.Object{
+			object.NewString("count"), object.NewString("pods"),
+		})},
+		{resp + `[none?.a, none?.b.c, 1]`, object.NewList([]object.Object{
+			object.Nil, object.Nil, object.NewInt(1),
+		})},
+		{`calls := 0; func f() { calls++; return 1 }; x := nil; x?.get(f()); calls`, object.NewInt(0)},
+		{`func name(user) { return user?.profile?.name ?? "anonymous" }
+		  [name(nil), name({"profile": nil}), name({"profile": {"name": "ann"}})]`, object.NewList([]object.Object{
+			object.NewString("anonymous"), object.NewString("anonymous"), object.NewString("ann"),
+		})},
+		{`nil ?? 1`, object.NewInt(1)},
+		{`0 ?? 1`, object.NewInt(0)},
+		{`false ?? 1`, object.False},
+		{`"" ?? 1`, object.NewString("")},
+		{`nil ?? nil ?? 3`, object.NewInt(3)},
+		{`calls := 0; func f() { calls++; return 1 }; x := 5 ?? f(); [x, calls]`, object.NewList([]object.Object{
+			object.NewInt(5), object.NewInt(0),
+		})},
+		{`1 + (nil ?? 2) * 3`, object.NewInt(7)},
+	}
+	runTests(t, tests)
+}
+
+func TestOptionalChainingErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`x := {"a": 1}; x?.b`, "type error: attribute \"b\" not found on map object"},
+		{`x := {"a": nil}; x?.a.b`, "type error: attribute \"b\" not found on nil object"},
+		{`x := {"a": 1}; x?.["a"]?.[0]`, "type error: object is not a container (got int)"},
+		{`x := [1]; x?.[3]`, "index error: index out of range: 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	tests := []testCase{
+		{`out := []
+		  outer: for _, i := range [1, 2, 3] {
+		      for _, j := range [1, 2, 3] {
+		          if j == 2 { continue outer }
+		          if i == 3 { break outer }
+		          out.append([i, j])
+		      }
+		  }
+		  out`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(1)}),
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(1)}),
+		})},
+		{`n := 0
+		  rows:
+		  for i := 0; i < 5; i++ {
+		      for { n++; continue rows }
+		  }
+		  n`, object.NewInt(5)},
+		{`n := 0
+		  outer: for {
+		      for i := 0; i < 10; i++ {
+		          n += i
+		          if n > 20 { break outer }
+		      }
+		  }
+		  n`, object.NewInt(21)},
+		{`n := 0
+		  a: for _, x := range [1, 2, 3] {
+		      for _, y := range [10, 20] {
+		          switch y { case 20: continue a }
+		          n += x * y
+		      }
+		  }
+		  n`, object.NewInt(60)},
+		{`func f() {
+		      n := 0
+		      for i := 0; i < 100000; i++ {
+		          for _, x := range [1, 2] { n += x; break }
+		      }
+		      return n
+		  }
+		  f()`, object.NewInt(100000)},
+		{`func f() {
+		      n := 0
+		      outer: for i := 0; i < 1000; i++ {
+		          for _, x := range [1, 2] {
+		              for _, y := range [3, 4] { n++; continue outer }
+		          }
+		      }
+		      return n
+		  }
+		  f()`, object.NewInt(1000)},
+	}
+	runTests(t, tests)
+}
+
+func TestLabeledLoopErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`for { break outer }`, "compile error: invalid break label outer (line 1)"},
+		{`outer: for { func() { continue outer } }`, "compile error: invalid continue label outer (line 1)"},
+		{`a: for { a: for {} }`, "compile error: label a is already defined (line 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []testCase{
+		{`a := chan(1); b := chan(1); b <- 2
+		  select { case v := <-a: v
+		  case v := <-b: v * 10 }`, object.NewInt(20)},
+		{`a := chan(1); a <- "x"; a.close()
+		  [select { case v, ok := <-a: [v, ok] }, select { case v, ok := <-a: [v, ok] }]`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewString("x"), object.True}),
+			object.NewList([]object.Object{object.Nil, object.False}),
+		})},
+		{`a := chan(1); select { case a <- 5: nil }; <-a`, object.NewInt(5)},
+		{`a := chan(); select { case <-a: 1
+		  default: 2 }`, object.NewInt(2)},
+		{`a := chan(); select { case a <- 1: 1
+		  default: }`, object.Nil},
+		{`a := chan(1); a <- 1; select { case <-a: }`, object.Nil},
+		{`results := chan(); done := chan()
+		  spawn(func() {
+		      for i := 0; i < 3; i++ { results <- i }
+		      done <- true
+		  })
+		  total := 0
+		  loop: for {
+		      select {
+		      case v := <-results:
+		          total += v
+		      case <-done:
+		          break loop
+		      }
+		  }
+		  total`, object.NewInt(3)},
+	}
+	runTests(t, tests)
+}
+
+func TestSelectErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`select { case <-1: 1 }`, "type error: object is not a channel (got int)"},
+		{`a := chan(1); a.close(); select { case a <- 1: 1 }`, "exec error: send on closed channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	tests := []testCase{
+		{`func double(fn) { return func(x) { return fn(x) * 2 } }
+		  @double
+		  func inc(x) { return x + 1 }
+		  inc(1)`, object.NewInt(4)},
+		{`calls := []
+		  func logged(fn) {
+		      return func(...args) {
+		          calls.append(args)
+		          return fn(...args)
+		      }
+		  }
+		  @logged
+		  func add(a, b=1) { return a + b }
+		  [add(1), add(2, 3), calls]`, object.NewList([]object.Object{
+			object.NewInt(2),
+			object.NewInt(5),
+			object.NewList([]object.Object{
+				object.NewList([]object.Object{object.NewInt(1)}),
+				object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)}),
+			}),
+		})},
+		{`func double(fn) { return func(x) { return fn(x) * 2 } }
+		  func add(n) { return func(fn) { return func(x) { return fn(x) + n } } }
+		  @add(10)
+		  @double
+		  func inc(x) { return x + 1 }
+		  inc(1)`, object.NewInt(14)},
+		{`func identity(fn) { return fn }
+		  @identity
+		  func f() { return 1 }
+		  f()`, object.NewInt(1)},
+		{`@func(fn) { return 42 }
+		  func f() {}
+		  f`, object.NewInt(42)},
+		{`func memo(fn) {
+		      cache := {}
+		      return func(n) {
+		          key := string(n)
+		          if key in cache { return cache[key] }
+		          cache[key] = fn(n)
+		          return cache[key]
+		      }
+		  }
+		  @memo
+		  func fib(n) { return n < 2 ? n : fib(n - 1) + fib(n - 2) }
+		  fib(60)`, object.NewInt(1548008755920)},
+		{`func count(fn) {
+		      n := 0
+		      return func(x) { n++; fn(x); return n }
+		  }
+		  func run() {
+		      @count
+		      func walk(x) { if x > 0 { walk(x - 1) } }
+		      return walk(4)
+		  }
+		  run()`, object.NewInt(5)},
+		{`func wrap(fn) { return func(...args) { return fn(...args) } }
+		  @wrap
+		  func greet(name="world") { return name }
+		  [greet(), greet("you")]`, object.NewList([]object.Object{
+			object.NewString("world"),
+			object.NewString("you"),
+		})},
+		// Keyword arguments are bound against the decorated signature
+		{`calls := []
+		  func logged(fn) {
+		      return func(...args) {
+		          calls.append(args)
+		          return fn(...args)
+		      }
+		  }
+		  @logged
+		  func add(a, b=1) { return a + b }
+		  [add(b=5, a=1), add(2), calls]`, object.NewList([]object.Object{
+			object.NewInt(6),
+			object.NewInt(3),
+			object.NewList([]object.Object{
+				object.NewList([]object.Object{object.NewInt(1), object.NewInt(5)}),
+				object.NewList([]object.Object{object.NewInt(2)}),
+			}),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestDecoratedInspect(t *testing.T) {
+	result, err := run(context.Background(), `
+	func wrap(fn) { return func(...args) { return fn(...args) } }
+	@wrap
+	func greet(name="world") { return name }
+	greet`)
+	require.Nil(t, err)
+	fn, ok := result.(*object.Function)
+	require.True(t, ok)
+	require.Equal(t, "greet", fn.Name())
+	require.Equal(t, `func greet(name="world") { return name }`, fn.Inspect())
+	require.Equal(t, fn.Wrapped().String(), fn.String())
+	require.Equal(t, []string{"name"}, fn.Parameters())
+	require.Equal(t, "", fn.RestParameter())
+	require.Equal(t, 0, fn.RequiredArgsCount())
+	require.Equal(t, "greet", fn.Wrapped().Name())
+
+	// Calls run the wrapper, which keeps its own name and signature
+	wrapper := fn.Wrapper()
+	require.NotNil(t, wrapper)
+	require.Equal(t, "", wrapper.Name())
+	require.Equal(t, "args", wrapper.RestParameter())
+	require.Nil(t, wrapper.Wrapped())
+}
+
+func TestDecoratedErrors(t *testing.T) {
+	// Arguments that the wrapper itself rejects, and stack traces, refer to
+	// the wrapper that ran rather than the decorated function
+	ctx := context.Background()
+	err := runFile(ctx, `func wrap(fn) { return func() { return fn() } }
+@wrap
+func greet(name="world") { return name }
+greet("you")`, "decorated.risor")
+	require.NotNil(t, err)
+	require.Equal(t, "args error: function takes 0 arguments (1 given)", err.Error())
+
+	// Arguments are checked against the signature of the decorated function
+	err = runFile(ctx, `func wrap(fn) { return func(...args) { return fn(...args) } }
+@wrap
+func greet(name="world") { return name }
+greet("a", "b")`, "decorated.risor")
+	require.NotNil(t, err)
+	require.Equal(t, "args error: function \"greet\" takes 1 argument (2 given)", err.Error())
+
+	err = runFile(ctx, `func wrap(fn) { return func(...args) { return fn(...args) } }
+@wrap
+func greet(name="world") { return name }
+greet(nam="you")`, "decorated.risor")
+	require.NotNil(t, err)
+	require.Equal(t, "args error: function \"greet\" got an unexpected keyword argument \"nam\"", err.Error())
+
+	err = runFile(ctx, `func wrap(fn) {
+  return func(x) { return fn(x).missing }
+}
+@wrap
+func double(x) { return x * 2 }
+double(1)`, "decorated.risor")
+	var traced *errz.TracedError
+	require.True(t, errors.As(err, &traced))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "<anonymous>", File: "decorated.risor", Line: 2, Column: 32},
+		{Function: "__main__", File: "decorated.risor", Line: 6, Column: 7},
+	}, traced.StackTrace())
+}
+
+func TestNestedClosures(t *testing.T) {
+	tests := []testCase{
+		{`func counter() {
+		      n := 0
+		      return func() {
+		          return func() { n++; return n }
+		      }
+		  }
+		  c := counter()
+		  a := c()
+		  b := c()
+		  [a(), b(), a()]`, object.NewList([]object.Object{
+			object.NewInt(1),
+			object.NewInt(2),
+			object.NewInt(3),
+		})},
+		{`func f(x) {
+		      return func(y) {
+		          return func(z) { return x + y + z }
+		      }
+		  }
+		  f(1)(2)(3)`, object.NewInt(6)},
+		// The middle function changes the variable after creating the
+		// innermost closure, which sees the change through the shared cell
+		{`func outer() {
+		      n := 1
+		      return func() {
+		          get := func() { return n }
+		          n = 5
+		          return get
+		      }
+		  }
+		  outer()()()`, object.NewInt(5)},
+		// Closures created by separate calls of the middle function share
+		// the cell of the outermost variable
+		{`func outer() {
+		      n := 0
+		      make := func() { return func() { n += 10; return n } }
+		      return [make(), make(), func() { return n }]
+		  }
+		  fns := outer()
+		  fns[0]()
+		  fns[1]()
+		  fns[2]()`, object.NewInt(20)},
+		{`func a(x) {
+		      return func() {
+		          return func() {
+		              return func() { return x }
+		          }
+		      }
+		  }
+		  a(7)()()()`, object.NewInt(7)},
+	}
+	runTests(t, tests)
+}
+
+func TestEnums(t *testing.T) {
+	status := `enum Status { PENDING, RUNNING = 5, FAILED }
+	`
+	tests := []testCase{
+		{status + `Status.RUNNING.value`, object.NewInt(5)},
+		{status + `Status.FAILED.value`, object.NewInt(6)},
+		{status + `Status.FAILED.name`, object.NewString("FAILED")},
+		{status + `[s.name for s in Status]`, object.NewList([]object.Object{
+			object.NewString("PENDING"),
+			object.NewString("RUNNING"),
+			object.NewString("FAILED"),
+		})},
+		{status + `len(Status)`, object.NewInt(3)},
+		{status + `Status.RUNNING == Status.RUNNING`, object.True},
+		{status + `Status.RUNNING == 5`, object.False},
+		{status + `Status.RUNNING == Status.FAILED`, object.False},
+		{status + `Status(6) == Status.FAILED`, object.True},
+		{status + `Status["PENDING"] == Status.PENDING`, object.True},
+		{status + `Status.PENDING in Status`, object.True},
+		{status + `enum Other { PENDING }
+		  Other.PENDING in Status`, object.False},
+		{status + `s := Status.RUNNING
+		  switch s {
+		  case Status.PENDING:
+		      "pending"
+		  case Status.RUNNING:
+		      "running"
+		  }`, object.NewString("running")},
+		{status + `match Status.FAILED {
+		  case Status.PENDING: "pending"
+		  case Status.FAILED: "failed"
+		  }`, object.NewString("failed")},
+		{status + `len({Status.PENDING, Status.PENDING, Status.FAILED})`, object.NewInt(2)},
+		{status + `enum Other { PENDING }
+		  len({Status.PENDING, Other.PENDING})`, object.NewInt(2)},
+		{status + `m := {}
+		  m[Status.RUNNING] = 1
+		  [string(m), m[Status.RUNNING], Status.RUNNING in m]`, object.NewList([]object.Object{
+			object.NewString("{Status.RUNNING: 1}"),
+			object.NewInt(1),
+			object.True,
+		})},
+		{status + `enum Other { RUNNING }
+		  m := {}
+		  m[Status.RUNNING] = 1
+		  m[Other.RUNNING] = 2
+		  m["RUNNING"] = 3
+		  [len(m), m[Status.RUNNING], m[Other.RUNNING], m["RUNNING"]]`, object.NewList([]object.Object{
+			object.NewInt(3),
+			object.NewInt(1),
+			object.NewInt(2),
+			object.NewInt(3),
+		})},
+		{status + `m := {Status.FAILED: "f", "other": "o", Status.PENDING: "p"}
+		  [string(m), m[Status.FAILED], Status.RUNNING in m]`, object.NewList([]object.Object{
+			object.NewString(`{"other": "o", Status.PENDING: "p", Status.FAILED: "f"}`),
+			object.NewString("f"),
+			object.False,
+		})},
+		// Members don't collide with strings or with members of another enum
+		// with the same name
+		{status + `m := {Status.PENDING: 1, "Status.PENDING": 2, "PENDING": 3}
+		  [len(m), m[Status.PENDING], m["Status.PENDING"], m["PENDING"]]`, object.NewList([]object.Object{
+			object.NewInt(3),
+			object.NewInt(1),
+			object.NewInt(2),
+			object.NewInt(3),
+		})},
+		{status + `m := {"Status.PENDING": 1}
+		  [Status.PENDING in m, m.get(Status.PENDING, 0)]`, object.NewList([]object.Object{
+			object.False,
+			object.NewInt(0),
+		})},
+		{`func newStatus() {
+		      enum Status { OK }
+		      return Status
+		  }
+		  a := newStatus()
+		  b := newStatus()
+		  m := {a.OK: 1, b.OK: 2}
+		  [len(m), m[a.OK], m[b.OK]]`, object.NewList([]object.Object{
+			object.NewInt(2),
+			object.NewInt(1),
+			object.NewInt(2),
+		})},
+		// Keys round-trip as members
+		{status + `m := {Status.FAILED: 1, "a": 2}
+		  k := m.keys()
+		  [k[0], k[1] == Status.FAILED, k[1].value]`, object.NewList([]object.Object{
+			object.NewString("a"),
+			object.True,
+			object.NewInt(6),
+		})},
+		{status + `m := {Status.RUNNING: 1, Status.PENDING: 2}
+		  [k.name for k in m]`, object.NewList([]object.Object{
+			object.NewString("PENDING"),
+			object.NewString("RUNNING"),
+		})},
+		{status + `m := {Status.RUNNING: 1}
+		  [string(m.items())]`, object.NewList([]object.Object{
+			object.NewString("[[Status.RUNNING, 1]]"),
+		})},
+		{status + `m := {Status.RUNNING: 1}
+		  c := m.copy()
+		  c.pop(Status.RUNNING)
+		  [len(m), len(c), m == {Status.RUNNING: 1}]`, object.NewList([]object.Object{
+			object.NewInt(1),
+			object.NewInt(0),
+			object.True,
+		})},
+		{status + `m := {Status.FAILED: 1}
+		  delete(m, Status.FAILED)
+		  len(m)`, object.NewInt(0)},
+		{`enum Level { LOW = "low", HIGH = "high" }
+		  Level.HIGH.value`, object.NewString("high")},
+		{`func f() {
+		      enum Dir { UP, DOWN }
+		      return Dir.DOWN.value
+		  }
+		  f()`, object.NewInt(1)},
+	}
+	runTests(t, tests)
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`enum Color { RED }
+		Color.BLUE`, "type error: attribute \"BLUE\" not found on enum object"},
+		{`enum Color { RED }
+		Color.RED = 1`, "type error: cannot set attribute \"RED\" on enum Color"},
+		{`enum Color { RED }
+		Color.RED.name = "x"`, "type error: cannot set attribute \"name\" on enum member Color.RED"},
+		{`enum Color { RED }
+		Color(3)`, "value error: 3 is not a valid Color"},
+		{`enum Color { RED }
+		{Color.RED.value: 1}`, "type error: map key must be a string or enum member (got int)"},
+		{`enum Color { RED }
+		Color["BLUE"]`, "key error: \"BLUE\""},
+		{`enum Color { RED, GREEN = 0 }`, "compile error: enum members \"RED\" and \"GREEN\" have the same value (line 1)"},
+		{`enum Color { RED = "red", GREEN }`, "compile error: enum member \"GREEN\" requires a value (line 1)"},
+		{`enum Color { RED = 1.5 }`, "compile error: unsupported enum value (got 1.5, line 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestFormatSpecs(t *testing.T) {
+	tests := []testCase{
+		{`price := 3.14159; '{price:.2f}'`, object.NewString("3.14")},
+		{`name := "bob"; '[{name:<6}]'`, object.NewString("[bob   ]")},
+		{`n := 1234567; '{n:,}'`, object.NewString("1,234,567")},
+		{`x := "hi"; '{x!r}'`, object.NewString(`"hi"`)},
+		{`x := "hi"; '{x!s:>4}'`, object.NewString("  hi")},
+		{`rows := [["a", 1.5], ["bc", 10.25]]
+		  ['{r[0]:<3}|{r[1]:>6.2f}' for r in rows]`, object.NewList([]object.Object{
+			object.NewString("a  |  1.50"),
+			object.NewString("bc | 10.25"),
+		})},
+		{`ok := true; '{ok ? "yes" : "no":>4}'`, object.NewString(" yes")},
+		{`'{[1, 2, 3][1:]:>8}'`, object.NewString("  [2, 3]")},
+		{`format(255, "#x") + format(3)`, object.NewString("0xff3")},
+		{`sprintf("%5.1f|%d", 3.14159, 7)`, object.NewString("  3.1|7")},
+	}
+	runTests(t, tests)
+}
+
+func TestFormatSpecErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		expectErr string
+	}{
+		{`x := 1; '{x:.2d}'`, "value error: precision is not allowed for integers"},
+		{`x := "a"; '{x:d}'`, "value error: invalid format type 'd' for string"},
+		{`x := 1; '{x:q}'`, "value error: invalid format spec \"q\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectErr, err.Error())
+		})
+	}
+}
+
+func TestOptimizedCode(t *testing.T) {
+	// Optimized code must give the same results as unoptimized code
+	tests := []string{
+		`x := 1024 * 1024; x + 1`,
+		`"a" + "b" + 'c{1 + 2}'`,
+		`[1 < 2, 2.5 >= 3, "1" == 1, !0, -(2 * 3), 2 ** 0.5, 7 / 2.0]`,
+		`if false { 1 } else if 1 > 2 { 2 } else { 3 }`,
+		`x := 0; for i := 0; i < 10; i++ { if i % 2 == 0 { continue }; if i > 7 { break }; x += i }; x`,
+		`total := 0
+		outer: for _, row := range [[1, 2], [3, 4], [5, 6]] {
+			for _, v := range row {
+				if v == 4 { continue outer }
+				if v == 6 { break outer }
+				total += v
+			}
+		}
+		total`,
+		`for false { 1 }; for true { break }; 2`,
+		`func f(x) { if x { return 1 }; return 2; 3 }; [f(true), f(false)]`,
+		`l := []; try { l.append(1); error("x"); l.append(2) } catch e { l.append(3) } finally { l.append(4) }; l`,
+		`func f() { try { return 1 } finally { 2 } }; f()`,
+		`match [1, 2] { case [a, b] if a < b: a + b case _: 0 }`,
+		`func gen() { for i := 0; i < 3; i++ { if i == 1 { continue }; yield i * 10 } }; [x for x in gen()]`,
+		`func counter() { n := 0; return func() { n += 1; return n } }; c := counter(); c(); c()`,
+		`x := nil; [x?.y, x ?? 5, nil ?? "d"]`,
+		`switch 3 { case 1: "one" case 1 + 2: "three" default: "other" }`,
+	}
+	ctx := context.Background()
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			expected, err := run(ctx, input)
+			require.Nil(t, err)
+			result, err := run(ctx, input, runOpts{Optimize: true})
+			require.Nil(t, err)
+			require.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestSpecializedOps(t *testing.T) {
+	// Operations specialized for integers and local variables must give the
+	// same results as the generic operations for any type
+	tests := []testCase{
+		{`func f(x) { return x + 1 }; [f(1), f(1.5), f(-129), f(1023)]`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewFloat(2.5), object.NewInt(-128), object.NewInt(1024),
+		})},
+		{`func f(x) { return x - 2 }; [f(1), f(2.5)]`, object.NewList([]object.Object{
+			object.NewInt(-1), object.NewFloat(0.5),
+		})},
+		{`func f(x) { return [x * 3, x / 2, x % 4, x ** 2, x << 1] }; f(7)`, object.NewList([]object.Object{
+			object.NewInt(21), object.NewInt(3), object.NewInt(3), object.NewInt(49), object.NewInt(14),
+		})},
+		{`func f(x) { return [x < 2, x <= 2, x == 2, x != 2, x > 2, x >= 2] }; f(2)`, object.NewList([]object.Object{
+			object.False, object.True, object.True, object.False, object.False, object.True,
+		})},
+		{`func f(x) { return [x < 2, x == 2.0, x > 1.5] }; f(2.0)`, object.NewList([]object.Object{
+			object.False, object.True, object.True,
+		})},
+		{`func f(a, b) { return [a + b, a - b, a < b, a == b] }; f(3, 4.5)`, object.NewList([]object.Object{
+			object.NewFloat(7.5), object.NewFloat(-1.5), object.True, object.False,
+		})},
+		{`func f(a, b) { return [a + b, a < b, a == b] }; f("x", "y")`, object.NewList([]object.Object{
+			object.NewString("xy"), object.True, object.False,
+		})},
+		{`func f() { x := 1.5; x++; x -= 1; x *= 4; x /= 2; return x }; f()`, object.NewFloat(3.0)},
+		{`func f() { x := 10; x--; x += 5; return x }; f()`, object.NewInt(14)},
+		{`x := 5; x++; x += 2; x - 1`, object.NewInt(7)},
+		{`func f() { total := 0; for i := 0; i < 100; i++ { total += i }; return total }; f()`, object.NewInt(4950)},
+	}
+	runTests(t, tests)
+}
+
+func TestSpecializedOpErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`func f(x) { return x + 1 }; f("a")`, "type error: unsupported operation for string: + on type int"},
+		{`func f(x) { return x < 1 }; f(nil)`, "type error: unable to compare nil and int"},
+		{`func f(a, b) { return a - b }; f([1], 1)`, "type error: unsupported operation for list: - on type int"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(ctx, tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestAttrCachePolymorphic(t *testing.T) {
+	// A single LoadAttr instruction sees objects of different types
+	opts := runOpts{Globals: map[string]any{
+		"s": &testStruct{A: 3},
+		"m": object.NewBuiltinsModule("m", map[string]object.Object{"A": object.NewInt(4)}),
+	}}
+	result, err := run(context.Background(), `
+	struct P { A }
+	struct Q { B; A }
+	func get(o) { return o.A }
+	[get(P(1)), get(s), get(m), get(Q(0, 2)), get(P(5)), get(s)]
+	`, opts)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewInt(1), object.NewInt(3), object.NewInt(4),
+		object.NewInt(2), object.NewInt(5), object.NewInt(3),
+	}), result)
+
+	result, err = run(context.Background(), `
+	struct P { func to_upper() { return {y: 1} } }
+	func f(x) { return x.to_upper() }
+	[f("a"), f("b"), f(P()).y]
+	`)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewString("A"), object.NewString("B"), object.NewInt(1),
+	}), result)
+}
+
+func TestAttrCacheConcurrent(t *testing.T) {
+	// Goroutines share the inline caches of the code they run
+	opts := runOpts{Globals: map[string]any{"s": &testStruct{A: 2}}}
+	result, err := run(context.Background(), `
+	struct P { A }
+	func get(o) { return o.A }
+	func sum(n) {
+		total := 0
+		for i := 0; i < n; i++ { total += get(i % 2 == 0 ? P(1) : s) }
+		return total
+	}
+	c := chan(4)
+	for _, n := range [10, 20, 30, 40] { go func(n) { c <- (sum(n)) }(n) }
+	total := 0
+	for i := 0; i < 4; i++ { v := <-c; total += v }
+	total
+	`, opts)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(150), result)
+}
+
+func TestAttrCacheModuleOverride(t *testing.T) {
+	ctx := context.Background()
+	m := object.NewBuiltinsModule("m", map[string]object.Object{"value": object.NewInt(1)})
+	vm, err := newVM(ctx, `func get() { return m.value }`, runOpts{
+		Globals: map[string]any{"m": m},
+	})
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	obj, err := vm.Get("get")
+	require.Nil(t, err)
+	fn, ok := obj.(*object.Function)
+	require.True(t, ok)
+
+	result, err := vm.Call(ctx, fn, nil)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(1), result)
+
+	require.Nil(t, m.Override("value", object.NewInt(2)))
+	result, err = vm.Call(ctx, fn, nil)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(2), result)
+
+	require.Nil(t, m.Override("value", nil))
+	_, err = vm.Call(ctx, fn, nil)
+	require.NotNil(t, err)
+	require.Equal(t, `type error: attribute "value" not found on module object`, err.Error())
+}
+
+func TestAttrCacheProxyFields(t *testing.T) {
+	ctx := context.Background()
+	s := &testStruct{A: 1, C: &testData{Count: 2}}
+	vm, err := newVM(ctx, `func get() { return [s.A, s.C.GetCount()] }`, runOpts{
+		Globals: map[string]any{"s": s},
+	})
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	obj, err := vm.Get("get")
+	require.Nil(t, err)
+	fn, ok := obj.(*object.Function)
+	require.True(t, ok)
+
+	result, err := vm.Call(ctx, fn, nil)
+	require.Nil(t, err)
+	require.Equal(t, "[1, 2]", result.Inspect())
+
+	// Field values are read on each access
+	s.A = 5
+	s.C = &testData{Count: 7}
+	result, err = vm.Call(ctx, fn, nil)
+	require.Nil(t, err)
+	require.Equal(t, "[5, 7]", result.Inspect())
+}
+
 type testCase struct {
 	input    string
 	expected object.Object